@@ -0,0 +1,637 @@
+// Package automation runs gate actions for events that match CEL rules read
+// from a YAML or JSON file. An Automation is an event bus subscriber.
+package automation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/adapters/rules"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"gopkg.in/yaml.v3"
+)
+
+// Error constants for automation rules.
+var (
+	ErrVersion          = errors.New("unsupported automation file version")
+	ErrUnknownAction    = errors.New("unknown automation action")
+	ErrActionParam      = errors.New("invalid automation action parameter")
+	ErrInvalidRateLimit = errors.New("invalid rate limit")
+	ErrNoClient         = errors.New("no client is configured")
+)
+
+// FileVersion is the automation file format understood by this build.
+const FileVersion = 1
+
+// Automation actions. Each calls the ports.ClientInterface method of the same name.
+const (
+	ActionAddScrubListEntry    = "add_scrub_list_entry"
+	ActionUpdateAgentStatus    = "update_agent_status"
+	ActionAddAgentCallResponse = "add_agent_call_response"
+	ActionStartCallRecording   = "start_call_recording"
+)
+
+// Audit outcomes recorded in AuditRecord.Outcome.
+const (
+	AuditExecuted    = "executed"
+	AuditDryRun      = "dry_run"
+	AuditRateLimited = "rate_limited"
+	AuditFailed      = "failed"
+)
+
+// actionParams lists the parameters of each action. A default is used when
+// the rule leaves the parameter out; a parameter without one is required.
+var actionParams = map[string]map[string]string{
+	ActionAddScrubListEntry: {
+		"scrub_list_id": "",
+		"content":       "${phone_number}",
+		"notes":         "",
+		"country_code":  "",
+	},
+	ActionUpdateAgentStatus: {
+		"partner_agent_id": "${partner_agent_id}",
+		"state":            "",
+		"reason":           "",
+	},
+	ActionAddAgentCallResponse: {
+		"partner_agent_id": "${partner_agent_id}",
+		"call_sid":         "${call_sid}",
+		"key":              "",
+		"value":            "",
+		"agent_sid":        "0",
+	},
+	ActionStartCallRecording: {
+		"partner_agent_id": "${partner_agent_id}",
+	},
+}
+
+// optionalParams may expand to an empty string.
+var optionalParams = map[string]bool{"notes": true, "country_code": true, "reason": true}
+
+// File is the YAML (or JSON) layout of an automation rules file.
+// Action parameters may reference rule variables as $name or ${name}.
+//
+//	version: 1
+//	rate_limit: {max: 100, per: 1m}
+//	rules:
+//	  - name: dnc-to-scrub-list
+//	    when: kind == "telephony_result" && result in ["DNC", "DO_NOT_CALL"]
+//	    action: add_scrub_list_entry
+//	    params: {scrub_list_id: internal-dnc, notes: "call ${call_sid}"}
+//	  - name: pause-for-callback
+//	    when: kind == "agent_response" && response_key == "callback"
+//	    action: update_agent_status
+//	    params: {state: AGENT_STATE_PAUSED, reason: CALLBACK}
+//	    rate_limit: {max: 1, per: 5m}
+type File struct {
+	Version int `yaml:"version" json:"version"`
+	// RateLimit caps the actions of all rules together.
+	RateLimit *RateLimit `yaml:"rate_limit" json:"rate_limit"`
+	// Rules are evaluated in order; every matching rule runs its action.
+	Rules []Spec `yaml:"rules" json:"rules"`
+}
+
+// Spec is one automation rule as written in a rules file.
+type Spec struct {
+	Name string `yaml:"name" json:"name"`
+	// When is a CEL expression over the variables listed in rules.Variables.
+	When string `yaml:"when" json:"when"`
+	// Action is one of the Action constants.
+	Action string `yaml:"action" json:"action"`
+	// Params are the action's parameters.
+	Params map[string]string `yaml:"params" json:"params"`
+	// RateLimit caps how often this rule's action runs.
+	RateLimit *RateLimit `yaml:"rate_limit" json:"rate_limit"`
+	// Stop skips the remaining rules once this one matches.
+	Stop bool `yaml:"stop" json:"stop"`
+}
+
+// RateLimit allows at most Max actions in any window of length Per.
+type RateLimit struct {
+	Max int           `yaml:"max" json:"max"`
+	Per time.Duration `yaml:"per" json:"per"`
+}
+
+// Rules is a compiled automation rules file.
+type Rules struct {
+	rules     []automationRule
+	rateLimit *RateLimit
+}
+
+type automationRule struct {
+	Spec
+
+	program cel.Program
+}
+
+// Load reads and compiles an automation rules file.
+func Load(path string) (*Rules, error) {
+	//nolint:gosec // Automation path comes from operator configuration
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read automation file: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// Parse compiles a YAML or JSON automation rules document.
+func Parse(data []byte) (*Rules, error) {
+	var file File
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse automation file: %w", err)
+	}
+
+	return Compile(file)
+}
+
+// Compile validates an automation rules file and compiles every
+// expression. Missing parameters are filled in with the action's defaults.
+func Compile(file File) (*Rules, error) {
+	if file.Version != FileVersion {
+		return nil, fmt.Errorf("%w: %d", ErrVersion, file.Version)
+	}
+
+	if err := file.RateLimit.validate(); err != nil {
+		return nil, err
+	}
+
+	env, err := rules.NewEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	ar := &Rules{rateLimit: file.RateLimit}
+	seen := make(map[string]bool, len(file.Rules))
+
+	for _, spec := range file.Rules {
+		switch {
+		case spec.Name == "":
+			return nil, rules.ErrNameRequired
+		case seen[spec.Name]:
+			return nil, fmt.Errorf("%w: %s", rules.ErrDuplicate, spec.Name)
+		}
+
+		seen[spec.Name] = true
+
+		params, err := actionParamsOf(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := spec.RateLimit.validate(); err != nil {
+			return nil, fmt.Errorf("%w in rule %s", err, spec.Name)
+		}
+
+		program, err := rules.CompileCondition(env, spec.Name, spec.When)
+		if err != nil {
+			return nil, err
+		}
+
+		spec.Params = params
+		ar.rules = append(ar.rules, automationRule{Spec: spec, program: program})
+	}
+
+	return ar, nil
+}
+
+// Rules returns the rules in evaluation order, with default parameters filled in.
+func (ar *Rules) Rules() []Spec {
+	specs := make([]Spec, len(ar.rules))
+	for i, rule := range ar.rules {
+		specs[i] = rule.Spec
+	}
+
+	return specs
+}
+
+// actionParamsOf checks a rule's action and parameters and applies defaults.
+func actionParamsOf(spec Spec) (map[string]string, error) {
+	known, ok := actionParams[spec.Action]
+	if !ok {
+		return nil, fmt.Errorf("%w in rule %s: %q", ErrUnknownAction, spec.Name, spec.Action)
+	}
+
+	params := make(map[string]string, len(known))
+
+	for name, value := range spec.Params {
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("%w in rule %s: unknown parameter %q for %s", ErrActionParam, spec.Name, name, spec.Action)
+		}
+
+		params[name] = value
+	}
+
+	for name, def := range known {
+		if _, ok := params[name]; ok {
+			continue
+		}
+
+		if def == "" && !optionalParams[name] {
+			return nil, fmt.Errorf("%w in rule %s: %s requires %q", ErrActionParam, spec.Name, spec.Action, name)
+		}
+
+		params[name] = def
+	}
+
+	for name, value := range params {
+		var unknown []string
+
+		os.Expand(value, func(variable string) string {
+			if _, ok := rules.Variables[variable]; !ok {
+				unknown = append(unknown, variable)
+			}
+
+			return ""
+		})
+
+		if len(unknown) > 0 {
+			return nil, fmt.Errorf("%w in rule %s: %s references unknown variables %v", ErrActionParam, spec.Name, name, unknown)
+		}
+	}
+
+	if state := params["state"]; state != "" && !strings.Contains(state, "$") {
+		if _, ok := ports.ParseAgentState(state); !ok {
+			return nil, fmt.Errorf("%w in rule %s: unknown agent state %q", ErrActionParam, spec.Name, state)
+		}
+	}
+
+	return params, nil
+}
+
+func (rl *RateLimit) validate() error {
+	if rl == nil {
+		return nil
+	}
+
+	if rl.Max <= 0 || rl.Per <= 0 {
+		return fmt.Errorf("%w: max and per must be positive", ErrInvalidRateLimit)
+	}
+
+	return nil
+}
+
+// Options configures an Automation.
+type Options struct {
+	// DryRun evaluates rules and audits the actions they would take without
+	// calling the gate. Rate limits still apply.
+	DryRun bool
+	// AuditPath is a JSON lines file every action is appended to. Empty only logs actions.
+	AuditPath string
+	// Timeout bounds each gate call more tightly than the client's deadline
+	// for its method. Zero leaves it to the client.
+	Timeout time.Duration
+	// QueueSize bounds the events waiting for the rules when they run on the
+	// event bus. Defaults to domain.DefaultSubscriberQueueSize.
+	QueueSize int
+}
+
+// AuditRecord describes one action an automation rule took or tried to take.
+type AuditRecord struct {
+	Time      time.Time         `json:"time"`
+	Rule      string            `json:"rule"`
+	Action    string            `json:"action"`
+	MessageID string            `json:"message_id,omitempty"`
+	EventKey  string            `json:"event_key"`
+	Params    map[string]string `json:"params"`
+	Outcome   string            `json:"outcome"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// Automation runs the actions of matching automation rules through the gate
+// client. It is a bus subscriber; failed actions are audited rather than
+// retried, since most actions are not idempotent.
+type Automation struct {
+	rules  *Rules
+	client func() ports.ClientInterface
+	opts   Options
+	log    *zerolog.Logger
+	now    func() time.Time
+
+	mu       sync.Mutex
+	global   *windowLimiter
+	limiters map[string]*windowLimiter
+	audit    *os.File
+}
+
+// New creates an automation engine. client returns the gate client
+// at the time an action runs, so it may be configured after start.
+func New(
+	compiled *Rules,
+	client func() ports.ClientInterface,
+	opts Options,
+	log *zerolog.Logger,
+) (*Automation, error) {
+	a := &Automation{
+		rules:    compiled,
+		client:   client,
+		opts:     opts,
+		log:      log,
+		now:      time.Now,
+		global:   newWindowLimiter(compiled.rateLimit),
+		limiters: make(map[string]*windowLimiter, len(compiled.rules)),
+	}
+
+	for _, rule := range compiled.rules {
+		a.limiters[rule.Name] = newWindowLimiter(rule.RateLimit)
+	}
+
+	if opts.AuditPath != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.AuditPath), 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create audit directory: %w", err)
+		}
+
+		//nolint:gosec // Audit path comes from operator configuration
+		file, err := os.OpenFile(opts.AuditPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o640)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit log: %w", err)
+		}
+
+		a.audit = file
+	}
+
+	return a, nil
+}
+
+// HandleMessage implements ports.Subscriber. Messages without an event are ignored.
+func (a *Automation) HandleMessage(ctx context.Context, msg ports.Message) error {
+	if msg.Event == nil {
+		return nil
+	}
+
+	a.Process(ctx, msg.ID, *msg.Event)
+
+	return nil
+}
+
+// Process runs the rules against one event and returns the audit record of
+// every action taken or attempted.
+func (a *Automation) Process(ctx context.Context, messageID string, event ports.Event) []AuditRecord {
+	var records []AuditRecord
+
+	vars := rules.Activation(event)
+
+	for _, rule := range a.rules.rules {
+		matched, err := rules.Match(rule.program, vars)
+		if err != nil {
+			a.log.Warn().Err(err).Str("rule", rule.Name).Str("message_id", messageID).Msg("Automation rule failed to evaluate")
+
+			continue
+		}
+
+		if !matched {
+			continue
+		}
+
+		rec := AuditRecord{
+			Time:      a.now().UTC(),
+			Rule:      rule.Name,
+			Action:    rule.Action,
+			MessageID: messageID,
+			EventKey:  event.Key(),
+			Params:    expandParams(rule.Params, vars),
+		}
+
+		switch {
+		case !a.allow(rule.Name):
+			rec.Outcome = AuditRateLimited
+		case a.opts.DryRun:
+			rec.Outcome = AuditDryRun
+		default:
+			if err := a.execute(ctx, rule.Action, rec.Params); err != nil {
+				rec.Outcome = AuditFailed
+				rec.Error = err.Error()
+			} else {
+				rec.Outcome = AuditExecuted
+			}
+		}
+
+		a.record(rec)
+		records = append(records, rec)
+
+		if rule.Stop {
+			break
+		}
+	}
+
+	return records
+}
+
+// Close closes the audit log.
+func (a *Automation) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.audit == nil {
+		return nil
+	}
+
+	err := a.audit.Close()
+	a.audit = nil
+
+	return err
+}
+
+// allow takes a slot from the rule's and the global rate limits, or neither.
+func (a *Automation) allow(rule string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	limiter := a.limiters[rule]
+
+	if !limiter.ok(now) || !a.global.ok(now) {
+		return false
+	}
+
+	limiter.take(now)
+	a.global.take(now)
+
+	return true
+}
+
+// record writes an audit record to the log and the audit file.
+func (a *Automation) record(rec AuditRecord) {
+	event := a.log.Info()
+	if rec.Outcome == AuditFailed {
+		event = a.log.Error().Str("error", rec.Error)
+	}
+
+	event.Str("rule", rec.Rule).
+		Str("action", rec.Action).
+		Str("event_key", rec.EventKey).
+		Str("outcome", rec.Outcome).
+		Msg("Automation action")
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.audit == nil {
+		return
+	}
+
+	line, err := json.Marshal(rec)
+	if err == nil {
+		_, err = a.audit.Write(append(line, '\n'))
+	}
+
+	if err != nil {
+		a.log.Error().Err(err).Str("rule", rec.Rule).Msg("Failed to write automation audit record")
+	}
+}
+
+// execute calls the gate for one action.
+func (a *Automation) execute(ctx context.Context, action string, params map[string]string) error {
+	client := a.client()
+	if client == nil {
+		return ErrNoClient
+	}
+
+	if a.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, a.opts.Timeout)
+		defer cancel()
+	}
+
+	for name, value := range params {
+		if value == "" && !optionalParams[name] {
+			return fmt.Errorf("%w: %s is empty for this event", ErrActionParam, name)
+		}
+	}
+
+	switch action {
+	case ActionAddScrubListEntry:
+		entry := ports.ScrubListEntryInput{Content: params["content"], Notes: optional(params["notes"])}
+		_, err := client.AddScrubListEntries(ctx, ports.AddScrubListEntriesParams{
+			ScrubListID: params["scrub_list_id"],
+			Entries:     []ports.ScrubListEntryInput{entry},
+			CountryCode: optional(params["country_code"]),
+		})
+
+		return err
+	case ActionUpdateAgentStatus:
+		state, ok := ports.ParseAgentState(params["state"])
+		if !ok {
+			return fmt.Errorf("%w: unknown agent state %q", ErrActionParam, params["state"])
+		}
+
+		_, err := client.UpdateAgentStatus(ctx, ports.UpdateAgentStatusParams{
+			PartnerAgentID: params["partner_agent_id"],
+			NewState:       state,
+			Reason:         optional(params["reason"]),
+		})
+
+		return err
+	case ActionAddAgentCallResponse:
+		callSid, err := strconv.ParseInt(params["call_sid"], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: call_sid: %w", ErrActionParam, err)
+		}
+
+		agentSid, err := strconv.ParseInt(params["agent_sid"], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: agent_sid: %w", ErrActionParam, err)
+		}
+
+		_, err = client.AddAgentCallResponse(ctx, ports.AddAgentCallResponseParams{
+			PartnerAgentID: params["partner_agent_id"],
+			CallSid:        callSid,
+			ResponseKey:    params["key"],
+			ResponseValue:  params["value"],
+			AgentSid:       agentSid,
+		})
+
+		return err
+	case ActionStartCallRecording:
+		_, err := client.StartCallRecording(ctx, ports.StartCallRecordingParams{
+			PartnerAgentID: params["partner_agent_id"],
+		})
+
+		return err
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownAction, action)
+	}
+}
+
+// expandParams substitutes rule variables into action parameters.
+func expandParams(params map[string]string, vars map[string]any) map[string]string {
+	expanded := make(map[string]string, len(params))
+
+	for name, value := range params {
+		expanded[name] = os.Expand(value, func(variable string) string {
+			switch v := vars[variable].(type) {
+			case string:
+				return v
+			case int64:
+				return strconv.FormatInt(v, 10)
+			case []string:
+				return strings.Join(v, ",")
+			default:
+				return ""
+			}
+		})
+	}
+
+	return expanded
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
+
+// windowLimiter allows at most max events in any sliding window of length per.
+// A nil limiter allows everything.
+type windowLimiter struct {
+	max   int
+	per   time.Duration
+	times []time.Time
+}
+
+func newWindowLimiter(rl *RateLimit) *windowLimiter {
+	if rl == nil {
+		return nil
+	}
+
+	return &windowLimiter{max: rl.Max, per: rl.Per}
+}
+
+func (l *windowLimiter) ok(now time.Time) bool {
+	if l == nil {
+		return true
+	}
+
+	cutoff := now.Add(-l.per)
+	l.times = slices.DeleteFunc(l.times, func(t time.Time) bool { return !t.After(cutoff) })
+
+	return len(l.times) < l.max
+}
+
+func (l *windowLimiter) take(now time.Time) {
+	if l != nil {
+		l.times = append(l.times, now)
+	}
+}
+
+// Ensure Automation implements the ports.EventSink interface.
+var _ ports.EventSink = (*Automation)(nil)
@@ -0,0 +1,258 @@
+package automation
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/adapters/rules"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+const testAutomationRules = `
+version: 1
+rules:
+  - name: dnc-to-scrub-list
+    when: kind == "telephony_result" && result == "DNC"
+    action: add_scrub_list_entry
+    params: {scrub_list_id: internal-dnc, notes: "call ${call_sid}"}
+  - name: pause-for-callback
+    when: kind == "agent_response" && response_key == "callback"
+    action: update_agent_status
+    params: {state: AGENT_STATE_PAUSED, reason: CALLBACK}
+    rate_limit: {max: 1, per: 1m}
+`
+
+// actionClient records the actions an Automation runs. Other client methods
+// are not implemented.
+type actionClient struct {
+	ports.ClientInterface
+
+	mu       sync.Mutex
+	scrubbed []ports.AddScrubListEntriesParams
+	statuses []ports.UpdateAgentStatusParams
+	err      error
+}
+
+func (c *actionClient) AddScrubListEntries(_ context.Context, params ports.AddScrubListEntriesParams) (ports.AddScrubListEntriesResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.scrubbed = append(c.scrubbed, params)
+
+	return ports.AddScrubListEntriesResult{}, c.err
+}
+
+func (c *actionClient) UpdateAgentStatus(_ context.Context, params ports.UpdateAgentStatusParams) (ports.UpdateAgentStatusResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.statuses = append(c.statuses, params)
+
+	return ports.UpdateAgentStatusResult{}, c.err
+}
+
+func (c *actionClient) calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.scrubbed) + len(c.statuses)
+}
+
+func newTestAutomation(t *testing.T, rules string, opts Options) (*Automation, *actionClient) {
+	t.Helper()
+
+	compiled, err := Parse([]byte(rules))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	client := &actionClient{}
+	logger := zerolog.Nop()
+
+	automation, err := New(compiled, func() ports.ClientInterface { return client }, opts, &logger)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	t.Cleanup(func() { _ = automation.Close() })
+
+	return automation, client
+}
+
+func dncEvent(sid int64) ports.Event {
+	return ports.Event{Telephony: &ports.ExileTelephonyResult{CallSid: sid, CallType: "outbound", Result: "DNC", PhoneNumber: "5551234567"}}
+}
+
+func callbackEvent(sid int64) ports.Event {
+	return ports.Event{AgentResponse: &ports.ExileAgentResponse{
+		AgentCallResponseSid: sid,
+		PartnerAgentID:       "agent-1",
+		ResponseKey:          "callback",
+	}}
+}
+
+func TestAutomation_RunsActions(t *testing.T) {
+	automation, client := newTestAutomation(t, testAutomationRules, Options{})
+
+	records := automation.Process(context.Background(), "m-1", dncEvent(42))
+	if len(records) != 1 || records[0].Outcome != AuditExecuted {
+		t.Fatalf("Expected one executed action, got %+v", records)
+	}
+
+	if len(client.scrubbed) != 1 {
+		t.Fatalf("Expected one scrub list call, got %d", len(client.scrubbed))
+	}
+
+	scrub := client.scrubbed[0]
+	if scrub.ScrubListID != "internal-dnc" || scrub.Entries[0].Content != "5551234567" || *scrub.Entries[0].Notes != "call 42" {
+		t.Errorf("Unexpected scrub list entry: %+v", scrub)
+	}
+
+	if scrub.CountryCode != nil {
+		t.Errorf("Expected no country code, got %q", *scrub.CountryCode)
+	}
+
+	_ = automation.HandleMessage(context.Background(), ports.Message{Event: &[]ports.Event{callbackEvent(1)}[0]})
+
+	if len(client.statuses) != 1 {
+		t.Fatalf("Expected one status update, got %d", len(client.statuses))
+	}
+
+	status := client.statuses[0]
+	paused, _ := ports.ParseAgentState("AGENT_STATE_PAUSED")
+	if status.PartnerAgentID != "agent-1" || status.NewState != paused || *status.Reason != "CALLBACK" {
+		t.Errorf("Unexpected status update: %+v", status)
+	}
+}
+
+func TestAutomation_DryRunAndRateLimit(t *testing.T) {
+	automation, client := newTestAutomation(t, testAutomationRules, Options{DryRun: true})
+
+	now := time.Now()
+	automation.now = func() time.Time { return now }
+
+	outcomes := make([]string, 0, 3)
+
+	for sid := range int64(2) {
+		for _, rec := range automation.Process(context.Background(), "", callbackEvent(sid)) {
+			outcomes = append(outcomes, rec.Outcome)
+		}
+	}
+
+	now = now.Add(time.Minute)
+
+	for _, rec := range automation.Process(context.Background(), "", callbackEvent(3)) {
+		outcomes = append(outcomes, rec.Outcome)
+	}
+
+	want := []string{AuditDryRun, AuditRateLimited, AuditDryRun}
+	if len(outcomes) != len(want) {
+		t.Fatalf("Expected outcomes %v, got %v", want, outcomes)
+	}
+
+	for i := range want {
+		if outcomes[i] != want[i] {
+			t.Fatalf("Expected outcomes %v, got %v", want, outcomes)
+		}
+	}
+
+	if len(client.statuses) != 0 {
+		t.Errorf("Expected no gate calls in dry-run mode, got %d", len(client.statuses))
+	}
+}
+
+func TestAutomation_AuditsFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "automation.jsonl")
+	automation, client := newTestAutomation(t, testAutomationRules, Options{AuditPath: path})
+	client.err = errors.New("permission denied")
+
+	automation.Process(context.Background(), "m-1", dncEvent(1))
+	automation.Process(context.Background(), "m-2", callbackEvent(1))
+
+	if err := automation.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	defer file.Close()
+
+	var records []AuditRecord
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var rec AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("Failed to parse audit record: %v", err)
+		}
+
+		records = append(records, rec)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 audit records, got %d", len(records))
+	}
+
+	if records[0].Rule != "dnc-to-scrub-list" || records[0].Outcome != AuditFailed || records[0].Error != "permission denied" {
+		t.Errorf("Unexpected audit record: %+v", records[0])
+	}
+
+	if records[1].MessageID != "m-2" || records[1].Params["partner_agent_id"] != "agent-1" {
+		t.Errorf("Unexpected audit record: %+v", records[1])
+	}
+}
+
+func TestAutomation_NoClient(t *testing.T) {
+	compiled, err := Parse([]byte(testAutomationRules))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	logger := zerolog.Nop()
+
+	automation, err := New(compiled, func() ports.ClientInterface { return nil }, Options{}, &logger)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	records := automation.Process(context.Background(), "", dncEvent(1))
+	if len(records) != 1 || records[0].Error != ErrNoClient.Error() {
+		t.Errorf("Expected the action to fail without a client, got %+v", records)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want error
+	}{
+		{"version", "version: 2", ErrVersion},
+		{"name", "version: 1\nrules: [{when: 'true', action: start_call_recording}]", rules.ErrNameRequired},
+		{"unknown action", "version: 1\nrules: [{name: a, when: 'true', action: hang_up}]", ErrUnknownAction},
+		{"missing param", "version: 1\nrules: [{name: a, when: 'true', action: add_scrub_list_entry}]", ErrActionParam},
+		{"unknown param", "version: 1\nrules: [{name: a, when: 'true', action: start_call_recording, params: {call: x}}]", ErrActionParam},
+		{"unknown variable", "version: 1\nrules: [{name: a, when: 'true', action: start_call_recording, params: {partner_agent_id: $agent}}]", ErrActionParam},
+		{"unknown state", "version: 1\nrules: [{name: a, when: 'true', action: update_agent_status, params: {state: NAPPING}}]", ErrActionParam},
+		{"rate limit", "version: 1\nrate_limit: {max: 0, per: 1m}", ErrInvalidRateLimit},
+		{"expression", "version: 1\nrules: [{name: a, when: 'call_sid', action: start_call_recording}]", rules.ErrExpression},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.doc)); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
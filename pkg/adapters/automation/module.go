@@ -0,0 +1,92 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//
+// Copyright 2024 TCN Inc
+
+package automation
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/domain"
+	"go.uber.org/fx"
+)
+
+// SubscriberName is the event bus subscriber name used by the automation engine.
+const SubscriberName = "automation"
+
+type automationParams struct {
+	fx.In
+
+	Rules   *Rules
+	Options *Options `optional:"true"`
+	Domain  *domain.Domain
+	Log     *zerolog.Logger
+}
+
+// newFromParams creates the Automation with the domain's gate client.
+func newFromParams(p automationParams) (*Automation, error) {
+	var opts Options
+	if p.Options != nil {
+		opts = *p.Options
+	}
+
+	return New(p.Rules, p.Domain.Client, opts, p.Log)
+}
+
+// Module provides the automation module for dependency injection. It creates
+// an Automation from the supplied rules, runs its actions through the domain's
+// gate client and closes its audit log when the app stops.
+//
+// Actions make gate calls, which can be slow. Events wait in a bounded queue
+// (Options.QueueSize); a full queue drops its oldest event, counted in the
+// subscriber's Dropped stat, rather than hold up polling for every other
+// subscriber. Automation is not durable: an action is a side effect that
+// should not be repeated after a restart.
+//
+// Usage example:
+//
+//	compiled, err := automation.Load("automation.yaml")
+//	...
+//	app := fx.New(
+//	  domain.Module,
+//	  automation.Module,
+//	  fx.Supply(compiled),
+//	  fx.Supply(&automation.Options{DryRun: true, AuditPath: "audit.jsonl"}),
+//	)
+var Module = fx.Module("automation",
+	// Provide the Automation
+	fx.Provide(newFromParams),
+
+	// Contribute the Automation to the domain event bus
+	fx.Provide(fx.Annotate(
+		func(a *Automation) domain.SubscriberRegistration {
+			return domain.SubscriberRegistration{
+				Name:       SubscriberName,
+				Subscriber: a,
+				Options:    domain.SubscriptionOptions{QueueSize: a.opts.QueueSize, Policy: domain.BackpressureDropOldest},
+			}
+		},
+		fx.ResultTags(`group:"event_subscribers"`),
+	)),
+
+	// Close the audit log on shutdown
+	fx.Invoke(func(lc fx.Lifecycle, a *Automation) {
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return a.Close()
+			},
+		})
+	}),
+)
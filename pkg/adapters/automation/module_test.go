@@ -0,0 +1,67 @@
+package automation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/domain"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"go.uber.org/fx"
+)
+
+func TestModule(t *testing.T) {
+	compiled, err := Parse([]byte(testAutomationRules))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	var d *domain.Domain
+
+	app := fx.New(
+		domain.Module,
+		Module,
+		fx.Provide(func() *zerolog.Logger {
+			logger := zerolog.Nop()
+			return &logger
+		}),
+		fx.Supply(compiled),
+		fx.Supply(&Options{QueueSize: 8}),
+		fx.Populate(&d),
+	)
+
+	if err := app.Err(); err != nil {
+		t.Fatalf("Module failed to initialize: %v", err)
+	}
+
+	for _, stats := range d.EventBus().Stats() {
+		if stats.Name == SubscriberName && (stats.Policy != domain.BackpressureDropOldest || stats.Capacity != 8) {
+			t.Errorf("Expected a bounded drop-oldest queue of 8 for automation, got %+v", stats)
+		}
+	}
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Failed to start app: %v", err)
+	}
+
+	defer func() {
+		if err := app.Stop(ctx); err != nil {
+			t.Errorf("Failed to stop app: %v", err)
+		}
+	}()
+
+	client := &actionClient{}
+	d.SetClient(client)
+	d.EventBus().DispatchEvents([]ports.Event{dncEvent(7)})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for client.calls() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the automation subscriber to add a scrub list entry")
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
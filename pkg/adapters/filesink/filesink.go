@@ -0,0 +1,451 @@
+// Package filesink provides an event sink that appends polled events to rotating JSON lines files.
+package filesink
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"github.com/tcncloud/sati-go/pkg/schema"
+)
+
+// Error constants for file sink operations.
+var (
+	ErrDirRequired     = errors.New("sink directory is required")
+	ErrSinkClosed      = errors.New("file sink is closed")
+	ErrUnknownSyncMode = errors.New("unknown sync mode")
+)
+
+const (
+	// activeExt marks the file currently being written. Readers should ignore it.
+	activeExt = ".jsonl.part"
+	// FileExt is the extension of a completed, uncompressed journal.
+	FileExt = ".jsonl"
+	// GzipExt is the extension of a completed, compressed journal.
+	GzipExt = ".jsonl.gz"
+
+	defaultPrefix = "events"
+	timeLayout    = "20060102T150405.000000000Z"
+)
+
+// SyncMode controls when the sink calls fsync.
+type SyncMode int
+
+const (
+	// SyncOnRotate fsyncs a file once, when it is completed.
+	SyncOnRotate SyncMode = iota
+	// SyncAlways fsyncs after every record.
+	SyncAlways
+	// SyncNever leaves flushing to the operating system.
+	SyncNever
+)
+
+// String returns the configuration name of the sync mode.
+func (m SyncMode) String() string {
+	switch m {
+	case SyncOnRotate:
+		return "rotate"
+	case SyncAlways:
+		return "always"
+	case SyncNever:
+		return "never"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseSyncMode converts a configuration name into a SyncMode.
+func ParseSyncMode(name string) (SyncMode, error) {
+	switch name {
+	case "", "rotate":
+		return SyncOnRotate, nil
+	case "always":
+		return SyncAlways, nil
+	case "never":
+		return SyncNever, nil
+	default:
+		return SyncOnRotate, fmt.Errorf("%w: %s", ErrUnknownSyncMode, name)
+	}
+}
+
+// Options configures the file sink.
+type Options struct {
+	// Dir is where journal files are written.
+	Dir string
+	// Prefix starts every file name. Defaults to "events".
+	Prefix string
+	// MaxBytes rotates the active file once it reaches this size. Zero disables size rotation.
+	MaxBytes int64
+	// MaxAge rotates the active file once it has been open this long. Zero disables time rotation.
+	MaxAge time.Duration
+	// Compress gzips completed files.
+	Compress bool
+	// Sync controls fsync behaviour.
+	Sync SyncMode
+}
+
+// Record is one line of a journal file. SchemaVersion is the schema.Version
+// the event was written with; it is empty in journals written before schemas
+// were published.
+type Record struct {
+	MessageID     string      `json:"message_id,omitempty"`
+	Kind          string      `json:"kind"`
+	SchemaVersion string      `json:"schema_version,omitempty"`
+	ReceivedAt    time.Time   `json:"received_at"`
+	Event         ports.Event `json:"event"`
+}
+
+// NewRecord wraps an event in a journal record.
+func NewRecord(messageID string, event ports.Event, receivedAt time.Time) Record {
+	return Record{
+		MessageID:     messageID,
+		Kind:          event.Kind(),
+		SchemaVersion: schema.Version,
+		ReceivedAt:    receivedAt.UTC(),
+		Event:         event,
+	}
+}
+
+// Sink writes one JSON line per event. The active file carries a ".part" suffix;
+// when it is rotated it is fsynced and atomically renamed, so any file without the
+// suffix is complete.
+type Sink struct {
+	opts Options
+	log  *zerolog.Logger
+	now  func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	path     string
+	size     int64
+	openedAt time.Time
+	closed   bool
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New creates a file sink and completes any files left active by a previous run.
+func New(opts Options, log *zerolog.Logger) (*Sink, error) {
+	if opts.Dir == "" {
+		return nil, ErrDirRequired
+	}
+
+	if opts.Prefix == "" {
+		opts.Prefix = defaultPrefix
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create sink directory: %w", err)
+	}
+
+	s := &Sink{
+		opts: opts,
+		log:  log,
+		now:  time.Now,
+		done: make(chan struct{}),
+	}
+
+	if err := s.recover(); err != nil {
+		return nil, err
+	}
+
+	if opts.MaxAge > 0 {
+		s.wg.Add(1)
+
+		go s.rotateLoop()
+	}
+
+	return s, nil
+}
+
+// HandleMessage implements ports.Subscriber. Jobs are ignored.
+func (s *Sink) HandleMessage(_ context.Context, msg ports.Message) error {
+	if msg.Event == nil {
+		return nil
+	}
+
+	return s.Write(NewRecord(msg.ID, *msg.Event, s.now()))
+}
+
+// WriteEvents appends a batch of events, as returned by PollEvents.
+func (s *Sink) WriteEvents(events []ports.Event) error {
+	receivedAt := s.now()
+
+	for _, event := range events {
+		if err := s.Write(NewRecord("", event, receivedAt)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Write appends a single record. Each record is written with one system call so
+// a crash can only ever truncate the final line.
+func (s *Sink) Write(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSinkClosed
+	}
+
+	if s.file != nil && s.shouldRotate(int64(len(line))) {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+
+	if err != nil {
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+
+	if s.opts.Sync == SyncAlways {
+		if err := s.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync record: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Rotate completes the active file, if any.
+func (s *Sink) Rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	return s.rotate()
+}
+
+// Close completes the active file and stops time-based rotation.
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+
+		return nil
+	}
+
+	s.closed = true
+	close(s.done)
+
+	var err error
+	if s.file != nil {
+		err = s.rotate()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	return err
+}
+
+func (s *Sink) shouldRotate(next int64) bool {
+	if s.opts.MaxBytes > 0 && s.size > 0 && s.size+next > s.opts.MaxBytes {
+		return true
+	}
+
+	return s.opts.MaxAge > 0 && s.now().Sub(s.openedAt) >= s.opts.MaxAge
+}
+
+func (s *Sink) open() error {
+	s.openedAt = s.now()
+	name := fmt.Sprintf("%s-%s", s.opts.Prefix, s.openedAt.UTC().Format(timeLayout))
+	s.path = filepath.Join(s.opts.Dir, name+activeExt)
+
+	//nolint:gosec // Sink path is built from operator configuration, not user input
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open journal file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return fmt.Errorf("failed to stat journal file: %w", err)
+	}
+
+	s.file = file
+	s.size = info.Size()
+
+	return nil
+}
+
+// rotate closes the active file and hands it to finish. The caller holds s.mu.
+func (s *Sink) rotate() error {
+	file, path := s.file, s.path
+	s.file, s.path, s.size = nil, "", 0
+
+	if s.opts.Sync != SyncNever {
+		if err := file.Sync(); err != nil {
+			_ = file.Close()
+
+			return fmt.Errorf("failed to sync journal file: %w", err)
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close journal file: %w", err)
+	}
+
+	return s.finish(path)
+}
+
+// finish atomically publishes a completed file, compressing it if configured.
+func (s *Sink) finish(activePath string) error {
+	base := strings.TrimSuffix(activePath, activeExt)
+
+	if !s.opts.Compress {
+		if err := os.Rename(activePath, base+FileExt); err != nil {
+			return fmt.Errorf("failed to complete journal file: %w", err)
+		}
+
+		return s.syncDir()
+	}
+
+	if err := s.compress(activePath, base+GzipExt); err != nil {
+		return err
+	}
+
+	if err := os.Remove(activePath); err != nil {
+		return fmt.Errorf("failed to remove uncompressed journal: %w", err)
+	}
+
+	return s.syncDir()
+}
+
+func (s *Sink) compress(src, dst string) error {
+	//nolint:gosec // Sink path is built from operator configuration, not user input
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open journal for compression: %w", err)
+	}
+	defer in.Close()
+
+	tmp := dst + ".tmp"
+
+	//nolint:gosec // Sink path is built from operator configuration, not user input
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to create compressed journal: %w", err)
+	}
+
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil && s.opts.Sync != SyncNever {
+		err = out.Sync()
+	}
+
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to compress journal: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Sink) syncDir() error {
+	if s.opts.Sync == SyncNever {
+		return nil
+	}
+
+	dir, err := os.Open(s.opts.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to open sink directory: %w", err)
+	}
+	defer dir.Close()
+
+	if err := dir.Sync(); err != nil {
+		return fmt.Errorf("failed to sync sink directory: %w", err)
+	}
+
+	return nil
+}
+
+// recover completes files that were still active when a previous process exited.
+func (s *Sink) recover() error {
+	paths, err := filepath.Glob(filepath.Join(s.opts.Dir, s.opts.Prefix+"-*"+activeExt))
+	if err != nil {
+		return fmt.Errorf("failed to list journal files: %w", err)
+	}
+
+	for _, path := range paths {
+		if err := s.finish(path); err != nil {
+			return err
+		}
+
+		s.log.Info().Str("path", path).Msg("Completed journal file left by previous run")
+	}
+
+	return nil
+}
+
+func (s *Sink) rotateLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(min(s.opts.MaxAge, time.Minute))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.file != nil && s.now().Sub(s.openedAt) >= s.opts.MaxAge {
+				if err := s.rotate(); err != nil {
+					s.log.Error().Err(err).Msg("Failed to rotate journal file")
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Ensure Sink implements the ports.EventSink interface.
+var _ ports.EventSink = (*Sink)(nil)
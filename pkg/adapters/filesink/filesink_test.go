@@ -0,0 +1,228 @@
+package filesink
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"github.com/tcncloud/sati-go/pkg/schema"
+)
+
+func newTestSink(t *testing.T, opts Options) *Sink {
+	t.Helper()
+
+	logger := zerolog.Nop()
+
+	if opts.Dir == "" {
+		opts.Dir = t.TempDir()
+	}
+
+	sink, err := New(opts, &logger)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	t.Cleanup(func() { _ = sink.Close() })
+
+	return sink
+}
+
+func listFiles(t *testing.T, dir, pattern string) []string {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+func readAll(t *testing.T, paths []string) []Record {
+	t.Helper()
+
+	var records []Record
+
+	for _, path := range paths {
+		err := ReadFile(path, func(r Record) error {
+			records = append(records, r)
+
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+	}
+
+	return records
+}
+
+func telephonyEvent(callSid int64) ports.Event {
+	return ports.Event{
+		Type:      ports.EventTypeTelephonyResult,
+		Telephony: &ports.ExileTelephonyResult{CallSid: callSid, CallType: "outbound"},
+	}
+}
+
+func TestNew_RequiresDir(t *testing.T) {
+	logger := zerolog.Nop()
+
+	if _, err := New(Options{}, &logger); !errors.Is(err, ErrDirRequired) {
+		t.Errorf("Expected ErrDirRequired, got %v", err)
+	}
+}
+
+func TestSink_WritesOneLinePerEvent(t *testing.T) {
+	dir := t.TempDir()
+	sink := newTestSink(t, Options{Dir: dir})
+
+	err := sink.HandleMessage(context.Background(), ports.Message{ID: "m-1", Event: &ports.Event{
+		AgentCall: &ports.ExileAgentCall{AgentCallSid: 7, PartnerAgentID: "agent-1"},
+	}})
+	if err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+
+	if err := sink.HandleMessage(context.Background(), ports.Message{Job: &ports.Job{JobID: "job"}}); err != nil {
+		t.Fatalf("HandleMessage failed for job: %v", err)
+	}
+
+	if len(listFiles(t, dir, "*"+activeExt)) != 1 {
+		t.Fatal("Expected an active journal file")
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if len(listFiles(t, dir, "*"+activeExt)) != 0 {
+		t.Error("Expected no active journal file after Close")
+	}
+
+	records := readAll(t, listFiles(t, dir, "*"+FileExt))
+	if len(records) != 1 {
+		t.Fatalf("Expected one record, got %d", len(records))
+	}
+
+	record := records[0]
+	if record.MessageID != "m-1" || record.Kind != ports.EventTypeAgentCall || record.Event.AgentCall.PartnerAgentID != "agent-1" {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+
+	if record.SchemaVersion != schema.Version {
+		t.Errorf("Expected schema version %s, got %q", schema.Version, record.SchemaVersion)
+	}
+
+	if err := sink.Write(record); !errors.Is(err, ErrSinkClosed) {
+		t.Errorf("Expected ErrSinkClosed, got %v", err)
+	}
+}
+
+func TestSink_RotatesBySizeAndCompresses(t *testing.T) {
+	dir := t.TempDir()
+	sink := newTestSink(t, Options{Dir: dir, MaxBytes: 200, Compress: true, Sync: SyncAlways})
+
+	for i := range 5 {
+		if err := sink.WriteEvents([]ports.Event{telephonyEvent(int64(i))}); err != nil {
+			t.Fatalf("WriteEvents failed: %v", err)
+		}
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	files := listFiles(t, dir, "*"+GzipExt)
+	if len(files) < 2 {
+		t.Fatalf("Expected size rotation to produce several files, got %v", files)
+	}
+
+	if plain := listFiles(t, dir, "*"+FileExt); len(plain) != 0 {
+		t.Errorf("Expected only compressed files, got %v", plain)
+	}
+
+	records := readAll(t, files)
+	if len(records) != 5 {
+		t.Fatalf("Expected 5 records, got %d", len(records))
+	}
+
+	for i, record := range records {
+		if record.Event.Telephony.CallSid != int64(i) {
+			t.Errorf("Record %d out of order: %+v", i, record.Event.Telephony)
+		}
+	}
+}
+
+func TestSink_RotatesByAge(t *testing.T) {
+	dir := t.TempDir()
+	sink := newTestSink(t, Options{Dir: dir, MaxAge: time.Hour})
+
+	current := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	sink.now = func() time.Time { return current }
+
+	if err := sink.WriteEvents([]ports.Event{telephonyEvent(1)}); err != nil {
+		t.Fatalf("WriteEvents failed: %v", err)
+	}
+
+	current = current.Add(2 * time.Hour)
+
+	if err := sink.WriteEvents([]ports.Event{telephonyEvent(2)}); err != nil {
+		t.Fatalf("WriteEvents failed: %v", err)
+	}
+
+	if completed := listFiles(t, dir, "*"+FileExt); len(completed) != 1 {
+		t.Errorf("Expected the first file to be completed, got %v", completed)
+	}
+}
+
+func TestNew_CompletesLeftoverActiveFiles(t *testing.T) {
+	dir := t.TempDir()
+	leftover := filepath.Join(dir, "events-20261018T120000.000000000Z"+activeExt)
+
+	line := `{"kind":"agent_call","received_at":"2026-10-18T12:00:00Z","event":{"Type":"agent_call"}}` + "\n"
+	if err := os.WriteFile(leftover, []byte(line), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	newTestSink(t, Options{Dir: dir})
+
+	completed := listFiles(t, dir, "*"+FileExt)
+	if len(completed) != 1 || !strings.HasSuffix(completed[0], "120000.000000000Z"+FileExt) {
+		t.Fatalf("Expected leftover file to be completed, got %v", completed)
+	}
+
+	if records := readAll(t, completed); len(records) != 1 || records[0].Kind != ports.EventTypeAgentCall {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+}
+
+func TestParseSyncMode(t *testing.T) {
+	for name, want := range map[string]SyncMode{"": SyncOnRotate, "rotate": SyncOnRotate, "always": SyncAlways, "never": SyncNever} {
+		got, err := ParseSyncMode(name)
+		if err != nil || got != want {
+			t.Errorf("ParseSyncMode(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+
+	if _, err := ParseSyncMode("sometimes"); !errors.Is(err, ErrUnknownSyncMode) {
+		t.Errorf("Expected ErrUnknownSyncMode, got %v", err)
+	}
+}
+
+func TestReadRecords_ReportsLine(t *testing.T) {
+	input := `{"kind":"agent_call","event":{}}` + "\n\nnot json\n"
+
+	err := ReadRecords(strings.NewReader(input), func(Record) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Expected error on line 3, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//
+// Copyright 2024 TCN Inc
+
+package filesink
+
+import (
+	"context"
+
+	"github.com/tcncloud/sati-go/pkg/domain"
+	"go.uber.org/fx"
+)
+
+// SubscriberName is the event bus subscriber name used by the file sink.
+const SubscriberName = "filesink"
+
+// Module provides the file sink module for dependency injection.
+// It creates the Sink from the provided Options, subscribes it to the domain
+// event bus as a durable subscriber and closes it when the app stops.
+//
+// Usage example:
+//
+//	app := fx.New(
+//	  domain.Module,
+//	  filesink.Module,
+//	  fx.Supply(filesink.Options{Dir: "/var/lib/sati/events", MaxBytes: 64 << 20, Compress: true}),
+//	)
+var Module = fx.Module("filesink",
+	// Provide the Sink
+	fx.Provide(New),
+
+	// Contribute the Sink to the domain event bus
+	fx.Provide(fx.Annotate(
+		func(sink *Sink) domain.SubscriberRegistration {
+			return domain.SubscriberRegistration{
+				Name:       SubscriberName,
+				Subscriber: sink,
+				Options:    domain.SubscriptionOptions{Durable: true},
+			}
+		},
+		fx.ResultTags(`group:"event_subscribers"`),
+	)),
+
+	// Complete the active file on shutdown
+	fx.Invoke(func(lc fx.Lifecycle, sink *Sink) {
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return sink.Close()
+			},
+		})
+	}),
+)
@@ -0,0 +1,68 @@
+package filesink
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxLineSize bounds a single journal line when reading.
+const maxLineSize = 16 * 1024 * 1024
+
+// ReadRecords decodes journal records from r and calls fn for each one, stopping at the first error.
+func ReadRecords(r io.Reader, fn func(Record) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// ReadFile decodes a journal file, transparently decompressing ".gz" files.
+func ReadFile(path string, fn func(Record) error) error {
+	//nolint:gosec // Journal path is supplied by the operator
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("failed to open gzip journal: %w", err)
+		}
+		defer zr.Close()
+
+		r = zr
+	}
+
+	if err := ReadRecords(r, fn); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,300 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// Namespace prefixes every metric name.
+const Namespace = "sati"
+
+// DefaultPath is where the metrics are served when Options.Path is empty.
+const DefaultPath = "/metrics"
+
+// unknownLabel replaces empty label values such as a job without a task type.
+const unknownLabel = "unknown"
+
+// readHeaderTimeout bounds how long a scrape may take to send its headers.
+const readHeaderTimeout = 5 * time.Second
+
+// ErrAddrRequired is returned when no listen address is configured.
+var ErrAddrRequired = errors.New("metrics listen address is required")
+
+// Options configures the metrics endpoint.
+type Options struct {
+	// Addr is the host:port the HTTP server listens on, e.g. ":9090".
+	Addr string
+	// Path is the URL path metrics are served on. Defaults to DefaultPath.
+	Path string
+}
+
+// Collector records the gate client's and domain processes' measurements as
+// Prometheus metrics in its own registry. It implements ports.Metrics.
+type Collector struct {
+	registry *prometheus.Registry
+
+	rpcs              *prometheus.CounterVec
+	rpcDuration       *prometheus.HistogramVec
+	endpointRPCs      *prometheus.CounterVec
+	eventsPolled      *prometheus.CounterVec
+	eventsDuplicate   *prometheus.CounterVec
+	jobsReceived      *prometheus.CounterVec
+	jobDuration       *prometheus.HistogramVec
+	jobErrors         *prometheus.CounterVec
+	submitFailures    prometheus.Counter
+	streamReconnects  *prometheus.CounterVec
+	configReloads     prometheus.Counter
+	certificateExpiry prometheus.Gauge
+	rotations         *prometheus.CounterVec
+}
+
+// NewCollector creates a Collector whose registry also holds the Go runtime and
+// process collectors.
+func NewCollector() *Collector {
+	c := &Collector{
+		registry: prometheus.NewRegistry(),
+		rpcs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "gate_rpcs_total",
+			Help:      "GateService calls by method and gRPC status code.",
+		}, []string{"method", "code"}),
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "gate_rpc_duration_seconds",
+			Help:      "GateService call latency by method. Streaming calls are measured until the stream ends.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		endpointRPCs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "gate_endpoint_rpcs_total",
+			Help:      "GateService call attempts by the gate address that answered them, method and gRPC status code.",
+		}, []string{"endpoint", "method", "code"}),
+		eventsPolled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "events_polled_total",
+			Help:      "Events returned by PollEvents by kind.",
+		}, []string{"kind"}),
+		eventsDuplicate: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "events_deduplicated_total",
+			Help:      "Polled events dropped as duplicates by kind.",
+		}, []string{"kind"}),
+		jobsReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "jobs_received_total",
+			Help:      "Jobs read from the StreamJobs stream by task type.",
+		}, []string{"type"}),
+		jobDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "job_handler_duration_seconds",
+			Help:      "Time event bus subscribers took to handle a job, by subscriber and task type.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"subscriber", "type"}),
+		jobErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "job_handler_errors_total",
+			Help:      "Jobs an event bus subscriber failed to handle, by subscriber and task type.",
+		}, []string{"subscriber", "type"}),
+		submitFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "job_result_submit_failures_total",
+			Help:      "SubmitJobResults calls that returned an error.",
+		}),
+		streamReconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "stream_reconnects_total",
+			Help:      "Streams opened again after they ended or failed.",
+		}, []string{"stream"}),
+		configReloads: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "config_reloads_total",
+			Help:      "Times the domain processes were restarted for a changed client configuration.",
+		}),
+		certificateExpiry: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "client_certificate_expiry_timestamp_seconds",
+			Help:      "Unix time at which the client certificate expires.",
+		}),
+		rotations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "certificate_rotations_total",
+			Help:      "Client certificate rotation attempts by outcome.",
+		}, []string{"outcome"}),
+	}
+
+	c.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		c.rpcs,
+		c.rpcDuration,
+		c.endpointRPCs,
+		c.eventsPolled,
+		c.eventsDuplicate,
+		c.jobsReceived,
+		c.jobDuration,
+		c.jobErrors,
+		c.submitFailures,
+		c.streamReconnects,
+		c.configReloads,
+		c.certificateExpiry,
+		c.rotations,
+	)
+
+	return c
+}
+
+// Registry returns the registry the metrics are registered in, so callers can
+// add their own collectors.
+func (c *Collector) Registry() *prometheus.Registry {
+	return c.registry
+}
+
+// Handler returns an http.Handler that serves the metrics in the Prometheus
+// exposition format.
+func (c *Collector) Handler() http.Handler {
+	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{})
+}
+
+// RPCCompleted implements ports.Metrics.
+func (c *Collector) RPCCompleted(method, code string, duration time.Duration) {
+	c.rpcs.WithLabelValues(method, code).Inc()
+	c.rpcDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+// RPCServed implements ports.Metrics.
+func (c *Collector) RPCServed(method, endpoint, code string) {
+	c.endpointRPCs.WithLabelValues(endpoint, method, code).Inc()
+}
+
+// EventPolled implements ports.Metrics.
+func (c *Collector) EventPolled(kind string) {
+	c.eventsPolled.WithLabelValues(label(kind)).Inc()
+}
+
+// EventDeduplicated implements ports.Metrics.
+func (c *Collector) EventDeduplicated(kind string) {
+	c.eventsDuplicate.WithLabelValues(label(kind)).Inc()
+}
+
+// JobReceived implements ports.Metrics.
+func (c *Collector) JobReceived(jobType string) {
+	c.jobsReceived.WithLabelValues(label(jobType)).Inc()
+}
+
+// JobHandled implements ports.Metrics.
+func (c *Collector) JobHandled(subscriber, jobType string, duration time.Duration, err error) {
+	jobType = label(jobType)
+
+	c.jobDuration.WithLabelValues(subscriber, jobType).Observe(duration.Seconds())
+
+	if err != nil {
+		c.jobErrors.WithLabelValues(subscriber, jobType).Inc()
+	}
+}
+
+// JobResultSubmitFailed implements ports.Metrics.
+func (c *Collector) JobResultSubmitFailed() {
+	c.submitFailures.Inc()
+}
+
+// StreamReconnected implements ports.Metrics.
+func (c *Collector) StreamReconnected(stream string) {
+	c.streamReconnects.WithLabelValues(stream).Inc()
+}
+
+// ConfigReloaded implements ports.Metrics.
+func (c *Collector) ConfigReloaded() {
+	c.configReloads.Inc()
+}
+
+// CertificateExpiry implements ports.Metrics.
+func (c *Collector) CertificateExpiry(notAfter time.Time) {
+	c.certificateExpiry.Set(float64(notAfter.Unix()))
+}
+
+// CertificateRotated implements ports.Metrics.
+func (c *Collector) CertificateRotated(outcome string) {
+	c.rotations.WithLabelValues(outcome).Inc()
+}
+
+func label(value string) string {
+	if value == "" {
+		return unknownLabel
+	}
+
+	return value
+}
+
+// Server serves a Collector's metrics over HTTP.
+type Server struct {
+	opts     Options
+	server   *http.Server
+	listener net.Listener
+	log      *zerolog.Logger
+}
+
+// NewServer creates a Server for collector. It does not listen until Start.
+func NewServer(opts Options, collector *Collector, log *zerolog.Logger) (*Server, error) {
+	if opts.Addr == "" {
+		return nil, ErrAddrRequired
+	}
+
+	if opts.Path == "" {
+		opts.Path = DefaultPath
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(opts.Path, collector.Handler())
+
+	return &Server{
+		opts:   opts,
+		server: &http.Server{Handler: mux, ReadHeaderTimeout: readHeaderTimeout},
+		log:    log,
+	}, nil
+}
+
+// Start listens on the configured address and serves in the background.
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.opts.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for metrics: %w", err)
+	}
+
+	s.listener = listener
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.log.Error().Err(err).Msg("Metrics server failed")
+		}
+	}()
+
+	s.log.Info().Str("addr", listener.Addr().String()).Str("path", s.opts.Path).Msg("Serving metrics")
+
+	return nil
+}
+
+// Addr returns the address the server listens on, or "" before Start.
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return ""
+	}
+
+	return s.listener.Addr().String()
+}
+
+// Close stops the server, waiting for in-flight scrapes until ctx is done.
+func (s *Server) Close(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+var _ ports.Metrics = (*Collector)(nil)
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func scrape(t *testing.T, handler http.Handler) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DefaultPath, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	return rec.Body.String()
+}
+
+func TestCollector_Exposition(t *testing.T) {
+	c := NewCollector()
+
+	c.RPCCompleted("PollEvents", "OK", 20*time.Millisecond)
+	c.RPCCompleted("PollEvents", "Unavailable", time.Second)
+	c.RPCServed("PollEvents", "10.0.0.7:443", "OK")
+	c.EventPolled("telephony_result")
+	c.EventDeduplicated("agent_call")
+	c.JobReceived("")
+	c.JobHandled("hostplugin", "lookup", 10*time.Millisecond, nil)
+	c.JobHandled("hostplugin", "lookup", 10*time.Millisecond, errors.New("failed"))
+	c.JobResultSubmitFailed()
+	c.StreamReconnected("StreamJobs")
+	c.ConfigReloaded()
+	c.CertificateExpiry(time.Unix(1800000000, 0))
+	c.CertificateRotated("success")
+
+	body := scrape(t, c.Handler())
+
+	for _, want := range []string{
+		`sati_gate_rpcs_total{code="OK",method="PollEvents"} 1`,
+		`sati_gate_rpcs_total{code="Unavailable",method="PollEvents"} 1`,
+		`sati_gate_rpc_duration_seconds_count{method="PollEvents"} 2`,
+		`sati_gate_endpoint_rpcs_total{code="OK",endpoint="10.0.0.7:443",method="PollEvents"} 1`,
+		`sati_events_polled_total{kind="telephony_result"} 1`,
+		`sati_events_deduplicated_total{kind="agent_call"} 1`,
+		`sati_jobs_received_total{type="unknown"} 1`,
+		`sati_job_handler_duration_seconds_count{subscriber="hostplugin",type="lookup"} 2`,
+		`sati_job_handler_errors_total{subscriber="hostplugin",type="lookup"} 1`,
+		`sati_job_result_submit_failures_total 1`,
+		`sati_stream_reconnects_total{stream="StreamJobs"} 1`,
+		`sati_config_reloads_total 1`,
+		`sati_client_certificate_expiry_timestamp_seconds 1.8e+09`,
+		`sati_certificate_rotations_total{outcome="success"} 1`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected exposition to contain %q", want)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	logger := zerolog.Nop()
+
+	if _, err := NewServer(Options{}, NewCollector(), &logger); !errors.Is(err, ErrAddrRequired) {
+		t.Fatalf("Expected ErrAddrRequired, got %v", err)
+	}
+
+	server, err := NewServer(Options{Addr: "127.0.0.1:0"}, NewCollector(), &logger)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	resp, err := http.Get("http://" + server.Addr() + DefaultPath)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if !strings.Contains(string(body), "sati_config_reloads_total 0") {
+		t.Errorf("Unexpected metrics body: %s", body)
+	}
+
+	if err := server.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//
+// Copyright 2024 TCN Inc
+
+package metrics
+
+import (
+	"context"
+
+	"github.com/tcncloud/sati-go/pkg/domain"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"go.uber.org/fx"
+)
+
+// Module provides the Prometheus metrics module for dependency injection.
+// It creates a Collector, reports the domain processes to it and serves it
+// over HTTP from the provided Options while the app runs. The Collector is
+// also provided as ports.Metrics so the gate client can be instrumented with
+// saticlient.WithMetrics.
+//
+// Usage example:
+//
+//	app := fx.New(
+//	  domain.Module,
+//	  metrics.Module,
+//	  fx.Supply(metrics.Options{Addr: ":9090"}, cfg),
+//	  fx.Invoke(func(cfg *saticonfig.Config, m ports.Metrics, set func(ports.ClientInterface)) error {
+//	    client, err := saticlient.NewClient(cfg, saticlient.WithMetrics(m))
+//	    if err != nil {
+//	      return err
+//	    }
+//	    set(client)
+//	    return nil
+//	  }),
+//	)
+var Module = fx.Module("metrics",
+	// Provide the Collector
+	fx.Provide(NewCollector),
+
+	fx.Provide(func(collector *Collector) ports.Metrics {
+		return collector
+	}),
+
+	// Provide the HTTP server
+	fx.Provide(NewServer),
+
+	// Report the domain processes and serve the endpoint while the app runs
+	fx.Invoke(func(lc fx.Lifecycle, d *domain.Domain, collector *Collector, server *Server) {
+		d.SetMetrics(collector)
+
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				return server.Start()
+			},
+			OnStop: func(ctx context.Context) error {
+				return server.Close(ctx)
+			},
+		})
+	}),
+)
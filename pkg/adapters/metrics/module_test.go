@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/domain"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"go.uber.org/fx"
+)
+
+func TestModule(t *testing.T) {
+	var (
+		server *Server
+		bus    *domain.EventBus
+	)
+
+	app := fx.New(
+		domain.Module,
+		Module,
+		fx.Provide(func() *zerolog.Logger {
+			logger := zerolog.Nop()
+			return &logger
+		}),
+		fx.Supply(Options{Addr: "127.0.0.1:0"}),
+		fx.Populate(&server, &bus),
+	)
+
+	if err := app.Err(); err != nil {
+		t.Fatalf("Module failed to initialize: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Failed to start app: %v", err)
+	}
+
+	defer func() {
+		if err := app.Stop(ctx); err != nil {
+			t.Errorf("Failed to stop app: %v", err)
+		}
+	}()
+
+	// The host plugin subscriber handles every job, so its handling is reported
+	bus.DispatchJob(&ports.Job{JobID: "job1", Type: "lookup"})
+
+	want := `sati_job_handler_duration_seconds_count{subscriber="hostplugin",type="lookup"} 1`
+	deadline := time.Now().Add(2 * time.Second)
+
+	for {
+		resp, err := http.Get("http://" + server.Addr() + DefaultPath)
+		if err != nil {
+			t.Fatalf("Scrape failed: %v", err)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if strings.Contains(string(body), want) {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the domain to report job handling, got %s", body)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,64 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//
+// Copyright 2024 TCN Inc
+
+package natssink
+
+import (
+	"context"
+
+	"github.com/tcncloud/sati-go/pkg/domain"
+	"go.uber.org/fx"
+)
+
+// SubscriberName is the event bus subscriber name used by the NATS publisher.
+const SubscriberName = "nats"
+
+// Module provides the NATS JetStream publisher module for dependency injection.
+// It connects the Publisher from the provided Options, subscribes it to the
+// domain event bus as a durable, batching subscriber and drains the connection
+// when the app stops.
+//
+// Usage example:
+//
+//	app := fx.New(
+//	  domain.Module,
+//	  natssink.Module,
+//	  fx.Supply(natssink.Options{URL: "nats://nats.internal:4222", CreateStream: true}),
+//	)
+var Module = fx.Module("natssink",
+	// Provide the Publisher
+	fx.Provide(New),
+
+	// Contribute the Publisher to the domain event bus
+	fx.Provide(fx.Annotate(
+		func(publisher *Publisher) domain.SubscriberRegistration {
+			return domain.SubscriberRegistration{
+				Name:       SubscriberName,
+				Subscriber: publisher,
+				Options:    domain.SubscriptionOptions{Durable: true},
+			}
+		},
+		fx.ResultTags(`group:"event_subscribers"`),
+	)),
+
+	// Drain the connection on shutdown
+	fx.Invoke(func(lc fx.Lifecycle, publisher *Publisher) {
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return publisher.Close()
+			},
+		})
+	}),
+)
@@ -0,0 +1,45 @@
+package natssink
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/domain"
+	"go.uber.org/fx"
+)
+
+func TestModule(t *testing.T) {
+	srv := runServer(t)
+
+	var bus *domain.EventBus
+
+	app := fx.New(
+		domain.Module,
+		Module,
+		fx.Provide(func() *zerolog.Logger {
+			logger := zerolog.Nop()
+			return &logger
+		}),
+		fx.Supply(Options{URL: srv.ClientURL(), CreateStream: true}),
+		fx.Populate(&bus),
+	)
+
+	if err := app.Err(); err != nil {
+		t.Fatalf("Module failed to initialize: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Failed to start app: %v", err)
+	}
+
+	if durable := bus.DurableSubscribers(); !slices.Contains(durable, SubscriberName) {
+		t.Errorf("Expected NATS publisher to be a durable subscriber, got %v", durable)
+	}
+
+	if err := app.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop app: %v", err)
+	}
+}
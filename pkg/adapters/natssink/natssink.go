@@ -0,0 +1,285 @@
+// Package natssink publishes polled events to NATS JetStream.
+package natssink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// Error constants for NATS sink operations.
+var (
+	ErrURLRequired  = errors.New("NATS URL is required")
+	ErrPublishAck   = errors.New("JetStream did not acknowledge message")
+	ErrInvalidToken = errors.New("subject prefix must not contain wildcards or spaces")
+)
+
+// Defaults applied by New.
+const (
+	DefaultSubjectPrefix   = "sati.events"
+	DefaultStream          = "SATI_EVENTS"
+	DefaultDuplicateWindow = 2 * time.Minute
+	DefaultPublishTimeout  = 10 * time.Second
+
+	unknownToken = "unknown"
+)
+
+// subjectKinds maps event kinds to their subject token.
+var subjectKinds = map[string]string{
+	ports.EventTypeTelephonyResult:  "telephony",
+	ports.EventTypeAgentCall:        "agent_call",
+	ports.EventTypeAgentResponse:    "agent_response",
+	ports.EventTypeTransferInstance: "transfer",
+}
+
+// Options configures the NATS sink.
+type Options struct {
+	// URL is the NATS server URL, e.g. nats://localhost:4222.
+	URL string
+	// CredsFile is an optional NATS credentials file.
+	CredsFile string
+	// SubjectPrefix starts every subject. Defaults to "sati.events".
+	SubjectPrefix string
+	// Stream is the JetStream stream name. Defaults to "SATI_EVENTS".
+	Stream string
+	// CreateStream creates or updates the stream to capture "<SubjectPrefix>.>".
+	// Leave false when the platform team manages streams.
+	CreateStream bool
+	// DuplicateWindow is the stream's deduplication window when CreateStream is set.
+	DuplicateWindow time.Duration
+	// PublishTimeout bounds waiting for JetStream acknowledgements.
+	PublishTimeout time.Duration
+}
+
+// Stats counts publisher outcomes since start.
+type Stats struct {
+	Published  uint64
+	Duplicates uint64
+}
+
+// Publisher publishes each event to "<prefix>.<kind>.<org>.<call type>" with a
+// Nats-Msg-Id built from the event SIDs, so JetStream drops redelivered events
+// inside its duplicate window.
+type Publisher struct {
+	opts Options
+	conn *nats.Conn
+	js   jetstream.JetStream
+	log  *zerolog.Logger
+
+	published  atomic.Uint64
+	duplicates atomic.Uint64
+}
+
+// New connects to NATS and, if configured, provisions the stream.
+func New(opts Options, log *zerolog.Logger) (*Publisher, error) {
+	if opts.URL == "" {
+		return nil, ErrURLRequired
+	}
+
+	if opts.SubjectPrefix == "" {
+		opts.SubjectPrefix = DefaultSubjectPrefix
+	}
+
+	if strings.ContainsAny(opts.SubjectPrefix, "*> \t") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidToken, opts.SubjectPrefix)
+	}
+
+	if opts.Stream == "" {
+		opts.Stream = DefaultStream
+	}
+
+	if opts.DuplicateWindow <= 0 {
+		opts.DuplicateWindow = DefaultDuplicateWindow
+	}
+
+	if opts.PublishTimeout <= 0 {
+		opts.PublishTimeout = DefaultPublishTimeout
+	}
+
+	connectOpts := []nats.Option{
+		nats.Name("sati-go"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Warn().Err(err).Msg("Disconnected from NATS")
+			}
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			log.Info().Str("url", nc.ConnectedUrl()).Msg("Reconnected to NATS")
+		}),
+	}
+
+	if opts.CredsFile != "" {
+		connectOpts = append(connectOpts, nats.UserCredentials(opts.CredsFile))
+	}
+
+	conn, err := nats.Connect(opts.URL, connectOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	p := &Publisher{opts: opts, conn: conn, js: js, log: log}
+
+	if opts.CreateStream {
+		if err := p.ensureStream(); err != nil {
+			conn.Close()
+
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (p *Publisher) ensureStream() error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.opts.PublishTimeout)
+	defer cancel()
+
+	_, err := p.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       p.opts.Stream,
+		Subjects:   []string{p.opts.SubjectPrefix + ".>"},
+		Duplicates: p.opts.DuplicateWindow,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create stream %s: %w", p.opts.Stream, err)
+	}
+
+	return nil
+}
+
+// Stats returns publish counters.
+func (p *Publisher) Stats() Stats {
+	return Stats{
+		Published:  p.published.Load(),
+		Duplicates: p.duplicates.Load(),
+	}
+}
+
+// Subject returns the subject an event is published to.
+func (p *Publisher) Subject(event ports.Event) string {
+	return Subject(p.opts.SubjectPrefix, event)
+}
+
+// Subject builds "<prefix>.<kind>.<org>.<call type>" for an event. Missing or
+// unsafe tokens are replaced so the subject is always valid.
+func Subject(prefix string, event ports.Event) string {
+	kind, ok := subjectKinds[event.Kind()]
+	if !ok {
+		kind = token(event.Kind())
+	}
+
+	return strings.Join([]string{prefix, kind, token(event.OrgID()), token(strings.ToLower(event.CallType()))}, ".")
+}
+
+// token makes a value safe to use as a single subject token.
+func token(value string) string {
+	if value == "" {
+		return unknownToken
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '\r', '\n':
+			return '_'
+		default:
+			return r
+		}
+	}, value)
+}
+
+// HandleMessage implements ports.Subscriber. Jobs are ignored.
+func (p *Publisher) HandleMessage(ctx context.Context, msg ports.Message) error {
+	return p.HandleBatch(ctx, []ports.Message{msg})
+}
+
+// HandleBatch implements ports.BatchSubscriber.
+func (p *Publisher) HandleBatch(ctx context.Context, msgs []ports.Message) error {
+	events := make([]ports.Event, 0, len(msgs))
+
+	for _, msg := range msgs {
+		if msg.Event != nil {
+			events = append(events, *msg.Event)
+		}
+	}
+
+	return p.PublishEvents(ctx, events)
+}
+
+// PublishEvents publishes events asynchronously and waits until JetStream has
+// acknowledged every one of them.
+func (p *Publisher) PublishEvents(ctx context.Context, events []ports.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.opts.PublishTimeout)
+	defer cancel()
+
+	futures := make([]jetstream.PubAckFuture, 0, len(events))
+
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+
+		msg := nats.NewMsg(p.Subject(event))
+		msg.Data = data
+		msg.Header.Set("Sati-Event-Kind", event.Kind())
+
+		future, err := p.js.PublishMsgAsync(msg, jetstream.WithMsgID(event.Key()))
+		if err != nil {
+			return fmt.Errorf("failed to publish %s: %w", msg.Subject, err)
+		}
+
+		futures = append(futures, future)
+	}
+
+	for _, future := range futures {
+		select {
+		case ack := <-future.Ok():
+			p.published.Add(1)
+
+			if ack.Duplicate {
+				p.duplicates.Add(1)
+			}
+		case err := <-future.Err():
+			return fmt.Errorf("%w: %s: %w", ErrPublishAck, future.Msg().Subject, err)
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrPublishAck, ctx.Err())
+		}
+	}
+
+	return nil
+}
+
+// Close drains the connection, flushing pending publishes.
+func (p *Publisher) Close() error {
+	if err := p.conn.Drain(); err != nil && !errors.Is(err, nats.ErrConnectionClosed) {
+		return fmt.Errorf("failed to drain NATS connection: %w", err)
+	}
+
+	return nil
+}
+
+// Ensure Publisher implements the ports.EventSink and ports.BatchSubscriber interfaces.
+var (
+	_ ports.EventSink       = (*Publisher)(nil)
+	_ ports.BatchSubscriber = (*Publisher)(nil)
+)
@@ -0,0 +1,156 @@
+package natssink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// runServer starts an embedded JetStream-enabled NATS server on a random port.
+func runServer(t *testing.T) *server.Server {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create NATS server: %v", err)
+	}
+
+	go srv.Start()
+
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server did not start")
+	}
+
+	t.Cleanup(srv.Shutdown)
+
+	return srv
+}
+
+func newTestPublisher(t *testing.T, srv *server.Server) *Publisher {
+	t.Helper()
+
+	logger := zerolog.Nop()
+
+	p, err := New(Options{URL: srv.ClientURL(), CreateStream: true}, &logger)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	t.Cleanup(func() { _ = p.Close() })
+
+	return p
+}
+
+func TestNew_Validation(t *testing.T) {
+	logger := zerolog.Nop()
+
+	if _, err := New(Options{}, &logger); !errors.Is(err, ErrURLRequired) {
+		t.Errorf("Expected ErrURLRequired, got %v", err)
+	}
+
+	if _, err := New(Options{URL: "nats://127.0.0.1:1", SubjectPrefix: "sati.*"}, &logger); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestSubject(t *testing.T) {
+	tests := []struct {
+		event ports.Event
+		want  string
+	}{
+		{ports.Event{Telephony: &ports.ExileTelephonyResult{OrgID: "org-1", CallType: "OUTBOUND"}}, "sati.events.telephony.org-1.outbound"},
+		{ports.Event{AgentCall: &ports.ExileAgentCall{OrgID: "acme.corp", CallType: "inbound"}}, "sati.events.agent_call.acme_corp.inbound"},
+		{ports.Event{AgentResponse: &ports.ExileAgentResponse{}}, "sati.events.agent_response.unknown.unknown"},
+		{ports.Event{TransferInstance: &ports.ExileTransferInstance{OrgID: "org-1", SourceCallType: "manual"}}, "sati.events.transfer.org-1.manual"},
+	}
+
+	for _, tt := range tests {
+		if got := Subject(DefaultSubjectPrefix, tt.event); got != tt.want {
+			t.Errorf("Subject() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestPublisher_PublishesWithDeduplication(t *testing.T) {
+	srv := runServer(t)
+	p := newTestPublisher(t, srv)
+	ctx := context.Background()
+
+	event := ports.Event{Type: ports.EventTypeTelephonyResult, Telephony: &ports.ExileTelephonyResult{CallSid: 42, CallType: "outbound", OrgID: "org-1"}}
+	msgs := []ports.Message{
+		{Event: &event},
+		{Event: &ports.Event{AgentCall: &ports.ExileAgentCall{AgentCallSid: 7, OrgID: "org-1", CallType: "outbound"}}},
+		{Job: &ports.Job{JobID: "job"}},
+	}
+
+	if err := p.HandleBatch(ctx, msgs); err != nil {
+		t.Fatalf("HandleBatch failed: %v", err)
+	}
+
+	// A redelivered event inside the duplicate window is dropped by JetStream.
+	if err := p.HandleMessage(ctx, ports.Message{Event: &event}); err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+
+	stream, err := p.js.Stream(ctx, DefaultStream)
+	if err != nil {
+		t.Fatalf("Stream lookup failed: %v", err)
+	}
+
+	info, err := stream.Info(ctx)
+	if err != nil {
+		t.Fatalf("Stream info failed: %v", err)
+	}
+
+	if info.State.Msgs != 2 {
+		t.Errorf("Expected 2 stored messages, got %d", info.State.Msgs)
+	}
+
+	if stats := p.Stats(); stats.Published != 3 || stats.Duplicates != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	stored, err := stream.GetLastMsgForSubject(ctx, "sati.events.telephony.org-1.outbound")
+	if err != nil {
+		t.Fatalf("GetLastMsgForSubject failed: %v", err)
+	}
+
+	if stored.Header.Get(jetstream.MsgIDHeader) != "telephony_result/outbound/42" {
+		t.Errorf("Unexpected message ID %q", stored.Header.Get(jetstream.MsgIDHeader))
+	}
+
+	var decoded ports.Event
+	if err := json.Unmarshal(stored.Data, &decoded); err != nil || decoded.Telephony == nil || decoded.Telephony.CallSid != 42 {
+		t.Errorf("Unexpected stored event: %+v (%v)", decoded, err)
+	}
+}
+
+func TestPublisher_FailsWithoutStream(t *testing.T) {
+	srv := runServer(t)
+	logger := zerolog.Nop()
+
+	p, err := New(Options{URL: srv.ClientURL(), PublishTimeout: time.Second}, &logger)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer p.Close()
+
+	err = p.PublishEvents(context.Background(), []ports.Event{{AgentCall: &ports.ExileAgentCall{AgentCallSid: 1}}})
+	if !errors.Is(err, ErrPublishAck) {
+		t.Errorf("Expected ErrPublishAck, got %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package parquetsink
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive advisory lock on f without waiting. It returns
+// errFileLocked when another open file holds the lock. The lock is released
+// when f is closed or its process exits.
+func lockFile(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return errFileLocked
+	}
+
+	return err
+}
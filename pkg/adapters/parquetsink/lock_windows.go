@@ -0,0 +1,27 @@
+//go:build windows
+
+package parquetsink
+
+import (
+	"errors"
+	"math"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile takes an exclusive lock on f without waiting. It returns
+// errFileLocked when another open file holds the lock. The lock is released
+// when f is closed or its process exits.
+func lockFile(f *os.File) error {
+	err := windows.LockFileEx(
+		windows.Handle(f.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY,
+		0, math.MaxUint32, math.MaxUint32, new(windows.Overlapped),
+	)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return errFileLocked
+	}
+
+	return err
+}
@@ -0,0 +1,69 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//
+// Copyright 2024 TCN Inc
+
+package parquetsink
+
+import (
+	"context"
+
+	"github.com/tcncloud/sati-go/pkg/domain"
+	"go.uber.org/fx"
+)
+
+// SubscriberName is the event bus subscriber name used by the Parquet sink.
+const SubscriberName = "parquet"
+
+// Module provides the Parquet sink module for dependency injection.
+// It creates the Sink from the provided Options, subscribes it to the domain
+// event bus and completes all open files when the app stops.
+//
+// Files are completed every DefaultMaxRows rows and DefaultMaxAge unless
+// Options says otherwise.
+//
+// The sink is not a durable subscriber: rows still buffered in an open file
+// are lost if the process crashes. Where every event must reach the dataset,
+// run the file sink and convert its journal with "sati parquet convert".
+//
+// Usage example:
+//
+//	app := fx.New(
+//	  domain.Module,
+//	  parquetsink.Module,
+//	  fx.Supply(parquetsink.Options{Dir: "/var/lib/sati/parquet", MaxRows: 100000, MaxAge: 15 * time.Minute}),
+//	)
+var Module = fx.Module("parquetsink",
+	// Provide the Sink
+	fx.Provide(New),
+
+	// Contribute the Sink to the domain event bus
+	fx.Provide(fx.Annotate(
+		func(sink *Sink) domain.SubscriberRegistration {
+			return domain.SubscriberRegistration{
+				Name:       SubscriberName,
+				Subscriber: sink,
+			}
+		},
+		fx.ResultTags(`group:"event_subscribers"`),
+	)),
+
+	// Complete open files on shutdown
+	fx.Invoke(func(lc fx.Lifecycle, sink *Sink) {
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return sink.Close()
+			},
+		})
+	}),
+)
@@ -0,0 +1,471 @@
+// Package parquetsink exports polled events to Parquet files partitioned by
+// organization, event kind and date.
+package parquetsink
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// Error constants for Parquet sink operations.
+var (
+	ErrDirRequired = errors.New("parquet directory is required")
+	ErrSinkClosed  = errors.New("parquet sink is closed")
+
+	errFileLocked = errors.New("file is locked by another writer")
+)
+
+const (
+	// activeExt marks a file that is still being written. It has no footer yet
+	// and cannot be read.
+	activeExt = ".parquet.part"
+	// FileExt is the extension of a completed Parquet file.
+	FileExt = ".parquet"
+
+	// DefaultMaxRows is the row count at which a file is completed when
+	// Options.MaxRows is zero.
+	DefaultMaxRows = 100_000
+	// DefaultMaxAge is how long a file stays open when Options.MaxAge is zero.
+	DefaultMaxAge = 15 * time.Minute
+
+	dateLayout       = "2006-01-02"
+	timeLayout       = "20060102T150405.000000000Z"
+	unknownPartition = "unknown"
+)
+
+// processToken keeps the file names of processes that share a dataset apart.
+var processToken = newProcessToken()
+
+func newProcessToken() string {
+	token := make([]byte, 4)
+	_, _ = rand.Read(token)
+
+	return hex.EncodeToString(token)
+}
+
+// Options configures the Parquet sink.
+type Options struct {
+	// Dir is the root of the partitioned dataset.
+	Dir string
+	// MaxRows completes a file once it holds this many rows. Defaults to
+	// DefaultMaxRows; a negative value disables row rotation.
+	MaxRows int64
+	// MaxAge completes a file once it has been open this long. Defaults to
+	// DefaultMaxAge; a negative value disables time rotation. Rows are only
+	// readable once their file is completed, so a sink that rotates neither way
+	// holds every row in an unreadable file until Close.
+	MaxAge time.Duration
+}
+
+// partition is one org_id/event_kind/date directory.
+type partition struct {
+	orgID string
+	kind  string
+	date  string
+}
+
+// path returns the Hive-style directory of the partition below root.
+func (p partition) path(root string) string {
+	return filepath.Join(root, "org_id="+pathToken(p.orgID), "event_kind="+p.kind, "date="+p.date)
+}
+
+// rowWriter writes events of one kind as typed rows.
+type rowWriter interface {
+	write(event ports.Event) error
+	close() error
+}
+
+type typedWriter[T any] struct {
+	writer  *parquet.GenericWriter[T]
+	convert func(ports.Event) T
+}
+
+func (w *typedWriter[T]) write(event ports.Event) error {
+	_, err := w.writer.Write([]T{w.convert(event)})
+
+	return err
+}
+
+func (w *typedWriter[T]) close() error {
+	return w.writer.Close()
+}
+
+func newTypedWriter[T any](out io.Writer, convert func(ports.Event) T) rowWriter {
+	return &typedWriter[T]{
+		writer:  parquet.NewGenericWriter[T](out, parquet.Compression(&parquet.Snappy)),
+		convert: convert,
+	}
+}
+
+// newRowWriter returns the writer for an event kind, or nil for unknown kinds.
+func newRowWriter(kind string, out io.Writer) rowWriter {
+	switch kind {
+	case ports.EventTypeTelephonyResult:
+		return newTypedWriter(out, NewTelephonyRow)
+	case ports.EventTypeAgentCall:
+		return newTypedWriter(out, NewAgentCallRow)
+	case ports.EventTypeAgentResponse:
+		return newTypedWriter(out, NewAgentResponseRow)
+	case ports.EventTypeTransferInstance:
+		return newTypedWriter(out, NewTransferRow)
+	default:
+		return nil
+	}
+}
+
+// partFile is a Parquet file being written for one partition.
+type partFile struct {
+	path     string
+	file     *os.File
+	writer   rowWriter
+	rows     int64
+	openedAt time.Time
+}
+
+// Sink writes each event as a typed row to
+// "<Dir>/org_id=<org>/event_kind=<kind>/date=<YYYY-MM-DD>/part-<time>-<token>-<seq>.parquet",
+// partitioned by the event's create time in UTC. A file only becomes visible,
+// without its ".part" suffix, once it is completed by rotation or Close: Parquet
+// files cannot be read before their footer is written. The token is random per
+// process, and the writer holds an exclusive lock on each file while it is
+// open, so several processes can share one dataset.
+type Sink struct {
+	opts Options
+	log  *zerolog.Logger
+	now  func() time.Time
+
+	mu     sync.Mutex
+	files  map[partition]*partFile
+	seq    uint64
+	closed bool
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New creates a Parquet sink, removing incomplete files left by processes
+// that are no longer running.
+func New(opts Options, log *zerolog.Logger) (*Sink, error) {
+	if opts.Dir == "" {
+		return nil, ErrDirRequired
+	}
+
+	if opts.MaxRows == 0 {
+		opts.MaxRows = DefaultMaxRows
+	}
+
+	if opts.MaxAge == 0 {
+		opts.MaxAge = DefaultMaxAge
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create parquet directory: %w", err)
+	}
+
+	s := &Sink{
+		opts:  opts,
+		log:   log,
+		now:   time.Now,
+		files: make(map[partition]*partFile),
+		done:  make(chan struct{}),
+	}
+
+	if err := s.recover(); err != nil {
+		return nil, err
+	}
+
+	if opts.MaxAge > 0 {
+		s.wg.Add(1)
+
+		go s.rotateLoop()
+	}
+
+	return s, nil
+}
+
+// HandleMessage implements ports.Subscriber. Jobs are ignored.
+func (s *Sink) HandleMessage(_ context.Context, msg ports.Message) error {
+	if msg.Event == nil {
+		return nil
+	}
+
+	return s.Write(*msg.Event)
+}
+
+// WriteEvents writes a batch of events, as returned by PollEvents.
+func (s *Sink) WriteEvents(events []ports.Event) error {
+	for _, event := range events {
+		if err := s.Write(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Write appends one event to the file of its partition. Events without an
+// entity are skipped.
+func (s *Sink) Write(event ports.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSinkClosed
+	}
+
+	kind := event.Kind()
+	if kind == "" {
+		return nil
+	}
+
+	orgID := event.OrgID()
+	if orgID == "" {
+		orgID = unknownPartition
+	}
+
+	p := partition{orgID: orgID, kind: kind, date: eventTime(event, s.now()).Format(dateLayout)}
+
+	pf, ok := s.files[p]
+	if !ok {
+		var err error
+
+		pf, err = s.open(p)
+		if err != nil {
+			return err
+		}
+
+		if pf == nil {
+			return nil
+		}
+	}
+
+	if err := pf.writer.write(event); err != nil {
+		return fmt.Errorf("failed to write parquet row: %w", err)
+	}
+
+	pf.rows++
+
+	if s.opts.MaxRows > 0 && pf.rows >= s.opts.MaxRows {
+		return s.complete(p)
+	}
+
+	return nil
+}
+
+// Rotate completes every open file.
+func (s *Sink) Rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.completeAll()
+}
+
+// Close completes every open file and stops time-based rotation.
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+
+		return nil
+	}
+
+	s.closed = true
+	close(s.done)
+
+	err := s.completeAll()
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	return err
+}
+
+// open starts a file for a partition. It returns nil for kinds without a
+// schema. The caller holds s.mu.
+func (s *Sink) open(p partition) (*partFile, error) {
+	dir := p.path(s.opts.Dir)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create partition directory: %w", err)
+	}
+
+	now := s.now()
+	s.seq++
+
+	name := fmt.Sprintf("part-%s-%s-%06d%s", now.UTC().Format(timeLayout), processToken, s.seq, activeExt)
+	path := filepath.Join(dir, name)
+
+	//nolint:gosec // Parquet path is built from operator configuration and sanitized event fields
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parquet file: %w", err)
+	}
+
+	// The lock marks the file as in use until it is completed or this process exits.
+	if err := lockFile(file); err != nil {
+		_ = file.Close()
+		_ = os.Remove(path)
+
+		return nil, fmt.Errorf("failed to lock parquet file: %w", err)
+	}
+
+	writer := newRowWriter(p.kind, file)
+	if writer == nil {
+		_ = file.Close()
+		_ = os.Remove(path)
+
+		return nil, nil
+	}
+
+	pf := &partFile{path: path, file: file, writer: writer, openedAt: now}
+	s.files[p] = pf
+
+	return pf, nil
+}
+
+// complete writes the footer of a partition's file and publishes it. The caller holds s.mu.
+func (s *Sink) complete(p partition) error {
+	pf := s.files[p]
+	delete(s.files, p)
+
+	err := pf.writer.close()
+	if err == nil {
+		err = pf.file.Sync()
+	}
+
+	if closeErr := pf.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(pf.path, strings.TrimSuffix(pf.path, activeExt)+FileExt)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to complete parquet file: %w", err)
+	}
+
+	s.log.Debug().Str("path", pf.path).Int64("rows", pf.rows).Msg("Completed parquet file")
+
+	return nil
+}
+
+// completeAll completes every open file, returning the first error. The caller holds s.mu.
+func (s *Sink) completeAll() error {
+	var firstErr error
+
+	for p := range s.files {
+		if err := s.complete(p); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// recover removes files that were still being written when their process
+// exited. Without a footer they cannot be read or repaired. Files that are
+// still locked by a running writer, such as a daemon writing the dataset that
+// a "sati parquet convert" run adds to, are left alone.
+func (s *Sink) recover() error {
+	return filepath.WalkDir(s.opts.Dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to scan parquet directory: %w", err)
+		}
+
+		if entry.IsDir() || !strings.HasSuffix(path, activeExt) {
+			return nil
+		}
+
+		if locked, err := writerRunning(path); err != nil || locked {
+			return err
+		}
+
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove incomplete parquet file: %w", err)
+		}
+
+		s.log.Warn().Str("path", path).Msg("Removed incomplete parquet file left by previous run")
+
+		return nil
+	})
+}
+
+func (s *Sink) rotateLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(min(s.opts.MaxAge, time.Minute))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			for p, pf := range s.files {
+				if s.now().Sub(pf.openedAt) < s.opts.MaxAge {
+					continue
+				}
+
+				if err := s.complete(p); err != nil {
+					s.log.Error().Err(err).Msg("Failed to rotate parquet file")
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// writerRunning reports whether an active file is still locked by the process
+// writing it. The file is unlocked again before this returns.
+func writerRunning(path string) (bool, error) {
+	//nolint:gosec // Parquet path comes from scanning the operator's dataset
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Completed or removed by its writer in the meantime.
+		return true, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to open incomplete parquet file: %w", err)
+	}
+	defer file.Close()
+
+	err = lockFile(file)
+	if errors.Is(err, errFileLocked) {
+		return true, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to lock incomplete parquet file: %w", err)
+	}
+
+	return false, nil
+}
+
+// pathToken keeps a partition value safe to use as one path element.
+func pathToken(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', '=', ':', 0:
+			return '_'
+		default:
+			return r
+		}
+	}, strings.Trim(value, "."))
+}
+
+// Ensure Sink implements the ports.EventSink interface.
+var _ ports.EventSink = (*Sink)(nil)
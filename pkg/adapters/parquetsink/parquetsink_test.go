@@ -0,0 +1,296 @@
+package parquetsink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+func newTestSink(t *testing.T, opts Options) *Sink {
+	t.Helper()
+
+	logger := zerolog.Nop()
+
+	if opts.Dir == "" {
+		opts.Dir = t.TempDir()
+	}
+
+	sink, err := New(opts, &logger)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	t.Cleanup(func() { _ = sink.Close() })
+
+	return sink
+}
+
+func listFiles(t *testing.T, dir, pattern string) []string {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+func telephonyEvent(orgID string, sid int64, created string) ports.Event {
+	return ports.Event{
+		Type: ports.EventTypeTelephonyResult,
+		Telephony: &ports.ExileTelephonyResult{
+			OrgID:      orgID,
+			CallSid:    sid,
+			CallType:   "outbound",
+			Status:     "completed",
+			CreateTime: created,
+			EndTime:    "1970-01-01T00:00:00Z",
+		},
+	}
+}
+
+func TestNew_RequiresDir(t *testing.T) {
+	logger := zerolog.Nop()
+
+	if _, err := New(Options{}, &logger); !errors.Is(err, ErrDirRequired) {
+		t.Errorf("Expected ErrDirRequired, got %v", err)
+	}
+}
+
+func TestSink_PartitionsByOrgKindAndDate(t *testing.T) {
+	dir := t.TempDir()
+	sink := newTestSink(t, Options{Dir: dir})
+
+	err := sink.WriteEvents([]ports.Event{
+		telephonyEvent("org-1", 1, "2026-03-01T10:00:00Z"),
+		telephonyEvent("org-1", 2, "2026-03-01T23:59:59Z"),
+		telephonyEvent("org-1", 3, "2026-03-02T00:00:00Z"),
+		telephonyEvent("org-2", 4, "2026-03-01T10:00:00Z"),
+		{
+			Type: ports.EventTypeTransferInstance,
+			TransferInstance: &ports.ExileTransferInstance{
+				OrgID:             "org-1",
+				SourceCallSid:     1,
+				DestinationSkills: []string{"english", "sales"},
+				CreateTime:        "2026-03-01T10:05:00Z",
+			},
+		},
+		{Type: "unknown"},
+	})
+	if err != nil {
+		t.Fatalf("WriteEvents failed: %v", err)
+	}
+
+	if active := listFiles(t, dir, "*/*/*/*"+activeExt); len(active) != 4 {
+		t.Fatalf("Expected 4 active files before Close, got %v", active)
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if err := sink.Write(telephonyEvent("org-1", 5, "")); !errors.Is(err, ErrSinkClosed) {
+		t.Errorf("Expected ErrSinkClosed, got %v", err)
+	}
+
+	day1 := listFiles(t, dir, "org_id=org-1/event_kind=telephony_result/date=2026-03-01/*"+FileExt)
+	if len(day1) != 1 {
+		t.Fatalf("Expected 1 file for org-1 on 2026-03-01, got %v", day1)
+	}
+
+	rows, err := parquet.ReadFile[TelephonyRow](day1[0])
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+
+	if len(rows) != 2 || rows[0].CallSid != 1 || rows[1].CallSid != 2 {
+		t.Fatalf("Expected call SIDs 1 and 2, got %+v", rows)
+	}
+
+	if rows[0].CreateTime == nil || !rows[0].CreateTime.Equal(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected create time 2026-03-01T10:00:00Z, got %v", rows[0].CreateTime)
+	}
+
+	if rows[0].EndTime != nil {
+		t.Errorf("Expected epoch end time to be null, got %v", rows[0].EndTime)
+	}
+
+	if rows[0].EventKey != "telephony_result/outbound/1" {
+		t.Errorf("Expected event key telephony_result/outbound/1, got %s", rows[0].EventKey)
+	}
+
+	for _, pattern := range []string{
+		"org_id=org-1/event_kind=telephony_result/date=2026-03-02/*" + FileExt,
+		"org_id=org-2/event_kind=telephony_result/date=2026-03-01/*" + FileExt,
+	} {
+		if files := listFiles(t, dir, pattern); len(files) != 1 {
+			t.Errorf("Expected 1 file matching %s, got %v", pattern, files)
+		}
+	}
+
+	transfers := listFiles(t, dir, "org_id=org-1/event_kind=transfer_instance/date=2026-03-01/*"+FileExt)
+	if len(transfers) != 1 {
+		t.Fatalf("Expected 1 transfer file, got %v", transfers)
+	}
+
+	transferRows, err := parquet.ReadFile[TransferRow](transfers[0])
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+
+	if len(transferRows) != 1 || len(transferRows[0].DestinationSkills) != 2 {
+		t.Errorf("Expected 1 transfer with 2 skills, got %+v", transferRows)
+	}
+}
+
+func TestSink_RotatesByRows(t *testing.T) {
+	dir := t.TempDir()
+	sink := newTestSink(t, Options{Dir: dir, MaxRows: 2})
+
+	for sid := range int64(5) {
+		if err := sink.Write(telephonyEvent("org", sid, "2026-03-01T10:00:00Z")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	if completed := listFiles(t, dir, "*/*/*/*"+FileExt); len(completed) != 2 {
+		t.Fatalf("Expected 2 completed files, got %v", completed)
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	total := 0
+
+	for _, path := range listFiles(t, dir, "*/*/*/*"+FileExt) {
+		rows, err := parquet.ReadFile[TelephonyRow](path)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+
+		total += len(rows)
+	}
+
+	if total != 5 {
+		t.Errorf("Expected 5 rows across all files, got %d", total)
+	}
+}
+
+func TestSink_RotatesByAge(t *testing.T) {
+	dir := t.TempDir()
+	sink := newTestSink(t, Options{Dir: dir, MaxAge: 20 * time.Millisecond})
+
+	if err := sink.HandleMessage(context.Background(), ports.Message{
+		Event: &ports.Event{AgentCall: &ports.ExileAgentCall{OrgID: "org", AgentCallSid: 1}},
+	}); err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(listFiles(t, dir, "org_id=org/event_kind=agent_call/*/*"+FileExt)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the agent call file to be completed by age")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNew_RemovesLeftoverActiveFiles(t *testing.T) {
+	dir := t.TempDir()
+	leftover := filepath.Join(dir, "org_id=org", "event_kind=agent_call", "date=2026-03-01", "part-x"+activeExt)
+
+	if err := os.MkdirAll(filepath.Dir(leftover), 0o750); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	if err := os.WriteFile(leftover, []byte("PAR1"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	newTestSink(t, Options{Dir: dir})
+
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Errorf("Expected leftover active file to be removed, got %v", err)
+	}
+}
+
+func TestNew_KeepsActiveFilesOfRunningWriters(t *testing.T) {
+	dir := t.TempDir()
+	partition := filepath.Join(dir, "org_id=org", "event_kind=agent_call", "date=2026-03-01")
+
+	if err := os.MkdirAll(partition, 0o750); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	activeFile := func(token string) string {
+		path := filepath.Join(partition, fmt.Sprintf("part-20260301T100000.000000000Z-%s-000001%s", token, activeExt))
+		if err := os.WriteFile(path, []byte("PAR1"), 0o600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		return path
+	}
+
+	locked := activeFile("00000000")
+	crashed := activeFile("ffffffff")
+
+	// Hold the lock the way a running writer does.
+	file, err := os.Open(locked)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer file.Close()
+
+	if err := lockFile(file); err != nil {
+		t.Fatalf("lockFile failed: %v", err)
+	}
+
+	// A sink writing the same dataset keeps its file open and locked too.
+	writer := newTestSink(t, Options{Dir: dir})
+	if err := writer.Write(telephonyEvent("org", 1, "2026-03-01T10:00:00Z")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	writing := listFiles(t, dir, "org_id=org/event_kind=telephony_result/*/*"+activeExt)
+	if len(writing) != 1 {
+		t.Fatalf("Expected the writer to hold one active file, got %v", writing)
+	}
+
+	newTestSink(t, Options{Dir: dir})
+
+	for _, path := range append([]string{locked}, writing...) {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected the active file of a running writer to be kept, got %v", err)
+		}
+	}
+
+	if _, err := os.Stat(crashed); !os.IsNotExist(err) {
+		t.Errorf("Expected the unlocked active file of a finished writer to be removed, got %v", err)
+	}
+}
+
+func TestNew_DefaultsRotation(t *testing.T) {
+	sink := newTestSink(t, Options{Dir: t.TempDir()})
+	if sink.opts.MaxRows != DefaultMaxRows || sink.opts.MaxAge != DefaultMaxAge {
+		t.Errorf("Expected default rotation, got %d rows and %v", sink.opts.MaxRows, sink.opts.MaxAge)
+	}
+
+	unbounded := newTestSink(t, Options{Dir: t.TempDir(), MaxRows: -1, MaxAge: -1})
+	if unbounded.opts.MaxRows > 0 || unbounded.opts.MaxAge > 0 {
+		t.Errorf("Expected negative limits to disable rotation, got %d rows and %v", unbounded.opts.MaxRows, unbounded.opts.MaxAge)
+	}
+}
@@ -0,0 +1,266 @@
+package parquetsink
+
+import (
+	"time"
+
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// The row types below are the column schema of the exported files. Columns
+// are only ever appended, never renamed or retyped, so files written by older
+// builds stay readable alongside new ones. Timestamps are UTC nanoseconds and
+// null when the gate left them unset.
+
+// TelephonyRow is one telephony_result event.
+type TelephonyRow struct {
+	EventKey       string     `parquet:"event_key"`
+	OrgID          string     `parquet:"org_id"`
+	CallSid        int64      `parquet:"call_sid"`
+	CallType       string     `parquet:"call_type"`
+	Status         string     `parquet:"status"`
+	Result         string     `parquet:"result"`
+	CallerID       string     `parquet:"caller_id"`
+	PhoneNumber    string     `parquet:"phone_number"`
+	PoolID         string     `parquet:"pool_id"`
+	RecordID       string     `parquet:"record_id"`
+	ClientSid      int64      `parquet:"client_sid"`
+	DeliveryLength int64      `parquet:"delivery_length"`
+	LinkbackLength int64      `parquet:"linkback_length"`
+	CreateTime     *time.Time `parquet:"create_time"`
+	UpdateTime     *time.Time `parquet:"update_time"`
+	StartTime      *time.Time `parquet:"start_time"`
+	EndTime        *time.Time `parquet:"end_time"`
+}
+
+// AgentCallRow is one agent_call event. Durations are in seconds.
+type AgentCallRow struct {
+	EventKey                 string     `parquet:"event_key"`
+	OrgID                    string     `parquet:"org_id"`
+	AgentCallSid             int64      `parquet:"agent_call_sid"`
+	CallSid                  int64      `parquet:"call_sid"`
+	CallType                 string     `parquet:"call_type"`
+	PartnerAgentID           string     `parquet:"partner_agent_id"`
+	UserID                   string     `parquet:"user_id"`
+	TalkDuration             int64      `parquet:"talk_duration"`
+	CallWaitDuration         int64      `parquet:"call_wait_duration"`
+	WrapUpDuration           int64      `parquet:"wrap_up_duration"`
+	PauseDuration            int64      `parquet:"pause_duration"`
+	TransferDuration         int64      `parquet:"transfer_duration"`
+	ManualDuration           int64      `parquet:"manual_duration"`
+	PreviewDuration          int64      `parquet:"preview_duration"`
+	HoldDuration             int64      `parquet:"hold_duration"`
+	AgentWaitDuration        int64      `parquet:"agent_wait_duration"`
+	SuspendedDuration        int64      `parquet:"suspended_duration"`
+	ExternalTransferDuration int64      `parquet:"external_transfer_duration"`
+	CreateTime               *time.Time `parquet:"create_time"`
+	UpdateTime               *time.Time `parquet:"update_time"`
+}
+
+// AgentResponseRow is one agent_response event.
+type AgentResponseRow struct {
+	EventKey             string     `parquet:"event_key"`
+	OrgID                string     `parquet:"org_id"`
+	AgentCallResponseSid int64      `parquet:"agent_call_response_sid"`
+	CallSid              int64      `parquet:"call_sid"`
+	CallType             string     `parquet:"call_type"`
+	PartnerAgentID       string     `parquet:"partner_agent_id"`
+	UserID               string     `parquet:"user_id"`
+	AgentSid             int64      `parquet:"agent_sid"`
+	ClientSid            int64      `parquet:"client_sid"`
+	ResponseKey          string     `parquet:"response_key"`
+	ResponseValue        string     `parquet:"response_value"`
+	CreateTime           *time.Time `parquet:"create_time"`
+	UpdateTime           *time.Time `parquet:"update_time"`
+}
+
+// TransferRow is one transfer_instance event. Durations are in microseconds.
+type TransferRow struct {
+	EventKey                     string     `parquet:"event_key"`
+	OrgID                        string     `parquet:"org_id"`
+	TransferInstanceID           string     `parquet:"transfer_instance_id"`
+	ClientSid                    int64      `parquet:"client_sid"`
+	SourceCallSid                int64      `parquet:"source_call_sid"`
+	SourceCallType               string     `parquet:"source_call_type"`
+	SourcePartnerAgentID         string     `parquet:"source_partner_agent_id"`
+	SourceUserID                 string     `parquet:"source_user_id"`
+	SourceConversationID         int64      `parquet:"source_conversation_id"`
+	SourceSessionSid             int64      `parquet:"source_session_sid"`
+	SourceAgentCallSid           int64      `parquet:"source_agent_call_sid"`
+	DestinationType              string     `parquet:"destination_type"`
+	DestinationCallSid           int64      `parquet:"destination_call_sid"`
+	DestinationCallType          string     `parquet:"destination_call_type"`
+	DestinationConversationID    int64      `parquet:"destination_conversation_id"`
+	DestinationSessionSid        int64      `parquet:"destination_session_sid"`
+	DestinationPartnerAgentID    string     `parquet:"destination_partner_agent_id"`
+	DestinationUserID            string     `parquet:"destination_user_id"`
+	DestinationPhoneNumber       string     `parquet:"destination_phone_number"`
+	DestinationSkills            []string   `parquet:"destination_skills,list"`
+	TransferType                 string     `parquet:"transfer_type"`
+	TransferResult               string     `parquet:"transfer_result"`
+	StartAsPending               bool       `parquet:"start_as_pending"`
+	StartedAsConference          bool       `parquet:"started_as_conference"`
+	DurationMicroseconds         int64      `parquet:"duration_us"`
+	ExternalDurationMicroseconds int64      `parquet:"external_duration_us"`
+	PendingDurationMicroseconds  int64      `parquet:"pending_duration_us"`
+	CreateTime                   *time.Time `parquet:"create_time"`
+	UpdateTime                   *time.Time `parquet:"update_time"`
+	TransferPendingStartTime     *time.Time `parquet:"transfer_pending_start_time"`
+	TransferStartTime            *time.Time `parquet:"transfer_start_time"`
+	TransferEndTime              *time.Time `parquet:"transfer_end_time"`
+	TransferExternalEndTime      *time.Time `parquet:"transfer_external_end_time"`
+}
+
+// NewTelephonyRow converts a telephony result.
+func NewTelephonyRow(event ports.Event) TelephonyRow {
+	t := event.Telephony
+
+	return TelephonyRow{
+		EventKey:       event.Key(),
+		OrgID:          t.OrgID,
+		CallSid:        t.CallSid,
+		CallType:       t.CallType,
+		Status:         t.Status,
+		Result:         t.Result,
+		CallerID:       t.CallerID,
+		PhoneNumber:    t.PhoneNumber,
+		PoolID:         t.PoolID,
+		RecordID:       t.RecordID,
+		ClientSid:      t.ClientSid,
+		DeliveryLength: t.DeliveryLength,
+		LinkbackLength: t.LinkbackLength,
+		CreateTime:     parseTime(t.CreateTime),
+		UpdateTime:     parseTime(t.UpdateTime),
+		StartTime:      parseTime(t.StartTime),
+		EndTime:        parseTime(t.EndTime),
+	}
+}
+
+// NewAgentCallRow converts an agent call.
+func NewAgentCallRow(event ports.Event) AgentCallRow {
+	c := event.AgentCall
+
+	return AgentCallRow{
+		EventKey:                 event.Key(),
+		OrgID:                    c.OrgID,
+		AgentCallSid:             c.AgentCallSid,
+		CallSid:                  c.CallSid,
+		CallType:                 c.CallType,
+		PartnerAgentID:           c.PartnerAgentID,
+		UserID:                   c.UserID,
+		TalkDuration:             c.TalkDuration,
+		CallWaitDuration:         c.CallWaitDuration,
+		WrapUpDuration:           c.WrapUpDuration,
+		PauseDuration:            c.PauseDuration,
+		TransferDuration:         c.TransferDuration,
+		ManualDuration:           c.ManualDuration,
+		PreviewDuration:          c.PreviewDuration,
+		HoldDuration:             c.HoldDuration,
+		AgentWaitDuration:        c.AgentWaitDuration,
+		SuspendedDuration:        c.SuspendedDuration,
+		ExternalTransferDuration: c.ExternalTransferDuration,
+		CreateTime:               parseTime(c.CreateTime),
+		UpdateTime:               parseTime(c.UpdateTime),
+	}
+}
+
+// NewAgentResponseRow converts an agent response.
+func NewAgentResponseRow(event ports.Event) AgentResponseRow {
+	r := event.AgentResponse
+
+	return AgentResponseRow{
+		EventKey:             event.Key(),
+		OrgID:                r.OrgID,
+		AgentCallResponseSid: r.AgentCallResponseSid,
+		CallSid:              r.CallSid,
+		CallType:             r.CallType,
+		PartnerAgentID:       r.PartnerAgentID,
+		UserID:               r.UserID,
+		AgentSid:             r.AgentSid,
+		ClientSid:            r.ClientSid,
+		ResponseKey:          r.ResponseKey,
+		ResponseValue:        r.ResponseValue,
+		CreateTime:           parseTime(r.CreateTime),
+		UpdateTime:           parseTime(r.UpdateTime),
+	}
+}
+
+// NewTransferRow converts a transfer instance.
+func NewTransferRow(event ports.Event) TransferRow {
+	ti := event.TransferInstance
+
+	return TransferRow{
+		EventKey:                     event.Key(),
+		OrgID:                        ti.OrgID,
+		TransferInstanceID:           ti.TransferInstanceID,
+		ClientSid:                    ti.ClientSid,
+		SourceCallSid:                ti.SourceCallSid,
+		SourceCallType:               ti.SourceCallType,
+		SourcePartnerAgentID:         ti.SourcePartnerAgentID,
+		SourceUserID:                 ti.SourceUserID,
+		SourceConversationID:         ti.SourceConversationID,
+		SourceSessionSid:             ti.SourceSessionSid,
+		SourceAgentCallSid:           ti.SourceAgentCallSid,
+		DestinationType:              ti.DestinationType,
+		DestinationCallSid:           ti.DestinationCallSid,
+		DestinationCallType:          ti.DestinationCallType,
+		DestinationConversationID:    ti.DestinationConversationID,
+		DestinationSessionSid:        ti.DestinationSessionSid,
+		DestinationPartnerAgentID:    ti.DestinationPartnerAgentID,
+		DestinationUserID:            ti.DestinationUserID,
+		DestinationPhoneNumber:       ti.DestinationPhoneNumber,
+		DestinationSkills:            ti.DestinationSkills,
+		TransferType:                 ti.TransferType,
+		TransferResult:               ti.TransferResult,
+		StartAsPending:               ti.StartAsPending,
+		StartedAsConference:          ti.StartedAsConference,
+		DurationMicroseconds:         ti.DurationMicroseconds,
+		ExternalDurationMicroseconds: ti.ExternalDurationMicroseconds,
+		PendingDurationMicroseconds:  ti.PendingDurationMicroseconds,
+		CreateTime:                   parseTime(ti.CreateTime),
+		UpdateTime:                   parseTime(ti.UpdateTime),
+		TransferPendingStartTime:     parseTime(ti.TransferPendingStartTime),
+		TransferStartTime:            parseTime(ti.TransferStartTime),
+		TransferEndTime:              parseTime(ti.TransferEndTime),
+		TransferExternalEndTime:      parseTime(ti.TransferExternalEndTime),
+	}
+}
+
+// parseTime reads an RFC 3339 timestamp. Empty, malformed and Unix epoch
+// values, which the client produces for unset timestamps, become null.
+func parseTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil || t.Unix() == 0 {
+		return nil
+	}
+
+	t = t.UTC()
+
+	return &t
+}
+
+// eventTime is the time an event is partitioned by: its create time, or
+// fallback when that is unset.
+func eventTime(event ports.Event, fallback time.Time) time.Time {
+	var created string
+
+	switch {
+	case event.Telephony != nil:
+		created = event.Telephony.CreateTime
+	case event.AgentCall != nil:
+		created = event.AgentCall.CreateTime
+	case event.AgentResponse != nil:
+		created = event.AgentResponse.CreateTime
+	case event.TransferInstance != nil:
+		created = event.TransferInstance.CreateTime
+	}
+
+	if t := parseTime(created); t != nil {
+		return *t
+	}
+
+	return fallback.UTC()
+}
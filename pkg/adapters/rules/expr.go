@@ -0,0 +1,163 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// Variables documents the variables available to rule expressions. String
+// fields are "" and numeric fields 0 when the event kind does not carry them.
+var Variables = map[string]*cel.Type{
+	"kind":                       cel.StringType,
+	"org_id":                     cel.StringType,
+	"call_sid":                   cel.IntType,
+	"call_type":                  cel.StringType,
+	"status":                     cel.StringType,
+	"result":                     cel.StringType,
+	"partner_agent_id":           cel.StringType,
+	"user_id":                    cel.StringType,
+	"pool_id":                    cel.StringType,
+	"record_id":                  cel.StringType,
+	"caller_id":                  cel.StringType,
+	"phone_number":               cel.StringType,
+	"response_key":               cel.StringType,
+	"response_value":             cel.StringType,
+	"transfer_type":              cel.StringType,
+	"transfer_result":            cel.StringType,
+	"destination_type":           cel.StringType,
+	"destination_skills":         cel.ListType(cel.StringType),
+	"delivery_length":            cel.IntType,
+	"linkback_length":            cel.IntType,
+	"talk_duration":              cel.IntType,
+	"call_wait_duration":         cel.IntType,
+	"wrap_up_duration":           cel.IntType,
+	"pause_duration":             cel.IntType,
+	"transfer_duration":          cel.IntType,
+	"manual_duration":            cel.IntType,
+	"preview_duration":           cel.IntType,
+	"hold_duration":              cel.IntType,
+	"agent_wait_duration":        cel.IntType,
+	"suspended_duration":         cel.IntType,
+	"external_transfer_duration": cel.IntType,
+	"transfer_duration_us":       cel.IntType,
+}
+
+// NewEnv creates the CEL environment declaring Variables.
+func NewEnv() (*cel.Env, error) {
+	opts := make([]cel.EnvOption, 0, len(Variables))
+	for name, typ := range Variables {
+		opts = append(opts, cel.Variable(name, typ))
+	}
+
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rules environment: %w", err)
+	}
+
+	return env, nil
+}
+
+// CompileCondition compiles the boolean expression of the named rule.
+func CompileCondition(env *cel.Env, name, when string) (cel.Program, error) {
+	ast, issues := env.Compile(when)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("%w in rule %s: %w", ErrExpression, name, issues.Err())
+	}
+
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("%w in rule %s: expression must return bool, got %s", ErrExpression, name, ast.OutputType())
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("%w in rule %s: %w", ErrExpression, name, err)
+	}
+
+	return program, nil
+}
+
+// Match runs a compiled condition against the variables of one event.
+func Match(program cel.Program, vars map[string]any) (bool, error) {
+	out, _, err := program.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+
+	matched, ok := out.Value().(bool)
+
+	return ok && matched, nil
+}
+
+// Activation flattens an event into the variables listed in Variables.
+func Activation(event ports.Event) map[string]any {
+	vars := make(map[string]any, len(Variables))
+
+	for name, typ := range Variables {
+		switch typ {
+		case cel.IntType:
+			vars[name] = int64(0)
+		case cel.StringType:
+			vars[name] = ""
+		default:
+			vars[name] = []string{}
+		}
+	}
+
+	vars["kind"] = event.Kind()
+	vars["org_id"] = event.OrgID()
+	vars["call_type"] = event.CallType()
+
+	switch {
+	case event.Telephony != nil:
+		t := event.Telephony
+		vars["call_sid"] = t.CallSid
+		vars["status"] = t.Status
+		vars["result"] = t.Result
+		vars["pool_id"] = t.PoolID
+		vars["record_id"] = t.RecordID
+		vars["caller_id"] = t.CallerID
+		vars["phone_number"] = t.PhoneNumber
+		vars["delivery_length"] = t.DeliveryLength
+		vars["linkback_length"] = t.LinkbackLength
+	case event.AgentCall != nil:
+		c := event.AgentCall
+		vars["call_sid"] = c.CallSid
+		vars["partner_agent_id"] = c.PartnerAgentID
+		vars["user_id"] = c.UserID
+		vars["talk_duration"] = c.TalkDuration
+		vars["call_wait_duration"] = c.CallWaitDuration
+		vars["wrap_up_duration"] = c.WrapUpDuration
+		vars["pause_duration"] = c.PauseDuration
+		vars["transfer_duration"] = c.TransferDuration
+		vars["manual_duration"] = c.ManualDuration
+		vars["preview_duration"] = c.PreviewDuration
+		vars["hold_duration"] = c.HoldDuration
+		vars["agent_wait_duration"] = c.AgentWaitDuration
+		vars["suspended_duration"] = c.SuspendedDuration
+		vars["external_transfer_duration"] = c.ExternalTransferDuration
+	case event.AgentResponse != nil:
+		r := event.AgentResponse
+		vars["call_sid"] = r.CallSid
+		vars["partner_agent_id"] = r.PartnerAgentID
+		vars["user_id"] = r.UserID
+		vars["response_key"] = r.ResponseKey
+		vars["response_value"] = r.ResponseValue
+	case event.TransferInstance != nil:
+		ti := event.TransferInstance
+		vars["call_sid"] = ti.SourceCallSid
+		vars["partner_agent_id"] = ti.SourcePartnerAgentID
+		vars["user_id"] = ti.SourceUserID
+		vars["transfer_type"] = ti.TransferType
+		vars["transfer_result"] = ti.TransferResult
+		vars["destination_type"] = ti.DestinationType
+		vars["transfer_duration_us"] = ti.DurationMicroseconds
+
+		if ti.DestinationSkills != nil {
+			vars["destination_skills"] = ti.DestinationSkills
+		}
+	}
+
+	return vars
+}
@@ -0,0 +1,39 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//
+// Copyright 2024 TCN Inc
+
+package rules
+
+import (
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"go.uber.org/fx"
+)
+
+// Module hands a supplied RuleSet to the domain as its ports.Router, so the
+// event bus routes events with the rules. Supply the rules after loading them
+// with Load.
+//
+// Usage example:
+//
+//	ruleSet, err := rules.Load("rules.yaml", &logger)
+//	...
+//	app := fx.New(
+//	  domain.Module,
+//	  rules.Module,
+//	  fx.Supply(ruleSet),
+//	)
+var Module = fx.Module("rules",
+	// Provide the RuleSet as the domain's router
+	fx.Provide(func(rs *RuleSet) ports.Router { return rs }),
+)
@@ -0,0 +1,77 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/domain"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"go.uber.org/fx"
+)
+
+// typeSubscriber forwards the type of every event it receives.
+type typeSubscriber chan string
+
+func (s typeSubscriber) HandleMessage(_ context.Context, msg ports.Message) error {
+	s <- msg.Event.Type
+
+	return nil
+}
+
+func TestModule(t *testing.T) {
+	var bus *domain.EventBus
+
+	ruleSet := parseTestRules(t, "version: 1\nrules: [{name: outbound, when: 'call_type == \"OUTBOUND\"', route: [nats]}]")
+	nats := make(typeSubscriber, 4)
+
+	app := fx.New(
+		domain.Module,
+		Module,
+		fx.Provide(func() *zerolog.Logger {
+			logger := zerolog.Nop()
+			return &logger
+		}),
+		fx.Supply(ruleSet),
+		fx.Provide(fx.Annotate(
+			func() domain.SubscriberRegistration {
+				return domain.SubscriberRegistration{Name: "nats", Subscriber: nats}
+			},
+			fx.ResultTags(`group:"event_subscribers"`),
+		)),
+		fx.Populate(&bus),
+	)
+
+	if err := app.Err(); err != nil {
+		t.Fatalf("Module failed to initialize: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Failed to start app: %v", err)
+	}
+
+	defer func() {
+		if err := app.Stop(ctx); err != nil {
+			t.Fatalf("Failed to stop app: %v", err)
+		}
+	}()
+
+	err := bus.Publish(ctx,
+		ports.Message{ID: "in", Event: &ports.Event{Type: "inbound", AgentCall: &ports.ExileAgentCall{CallType: "INBOUND"}}},
+		ports.Message{ID: "out", Event: &ports.Event{Type: "outbound", AgentCall: &ports.ExileAgentCall{CallType: "OUTBOUND"}}},
+	)
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	select {
+	case got := <-nats:
+		if got != "outbound" {
+			t.Errorf("Expected only the outbound event to be routed to nats, got %s", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the routed event")
+	}
+}
@@ -0,0 +1,244 @@
+// Package rules routes event bus messages to subscribers with CEL rules read
+// from a YAML or JSON file. A RuleSet implements ports.Router.
+package rules
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/google/cel-go/cel"
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"gopkg.in/yaml.v3"
+)
+
+// Error constants for routing rules.
+var (
+	ErrVersion      = errors.New("unsupported rules file version")
+	ErrNameRequired = errors.New("rule name is required")
+	ErrDuplicate    = errors.New("duplicate rule name")
+	ErrExpression   = errors.New("invalid rule expression")
+	ErrNoRoute      = errors.New("rule must route to at least one subscriber")
+)
+
+// FileVersion is the rules file format understood by this build.
+const FileVersion = 1
+
+// File is the YAML (or JSON) layout of a routing rules file.
+//
+//	version: 1
+//	default: [filesink]
+//	rules:
+//	  - name: failed-outbound
+//	    when: kind == "telephony_result" && call_type == "OUTBOUND" && result != "ANSWERED"
+//	    route: [webhook, nats]
+//	    stop: true
+//	  - name: long-calls
+//	    when: kind == "agent_call" && talk_duration > 600
+//	    route: [sqlstore]
+type File struct {
+	Version int `yaml:"version" json:"version"`
+	// Default receives events that no rule matched. Empty drops them.
+	Default []string `yaml:"default" json:"default"`
+	// Rules are evaluated in order.
+	Rules []Spec `yaml:"rules" json:"rules"`
+}
+
+// Spec is one rule as written in a rules file.
+type Spec struct {
+	Name string `yaml:"name" json:"name"`
+	// When is a CEL expression over the variables listed in Variables.
+	When string `yaml:"when" json:"when"`
+	// Route names the sinks or plugins (event bus subscribers) that receive matches.
+	Route []string `yaml:"route" json:"route"`
+	// Stop skips the remaining rules once this one matches.
+	Stop bool `yaml:"stop" json:"stop"`
+}
+
+// Rule is a compiled routing rule.
+type Rule struct {
+	Spec
+
+	program cel.Program
+}
+
+// RuleSet routes events to subscribers according to compiled rules. It
+// implements ports.Router: subscribers never named in the rules file keep
+// receiving every event.
+type RuleSet struct {
+	rules   []Rule
+	def     []string
+	managed map[string]bool
+	log     *zerolog.Logger
+}
+
+// Decision explains how a RuleSet routed one event.
+type Decision struct {
+	// Matched lists the names of the rules that matched, in order.
+	Matched []string
+	// Subscribers lists the managed subscribers that receive the event.
+	Subscribers []string
+	// Errors holds rules that failed to evaluate, keyed by rule name.
+	Errors map[string]string
+}
+
+// Load reads and compiles a rules file.
+func Load(path string, log *zerolog.Logger) (*RuleSet, error) {
+	//nolint:gosec // Rules path comes from operator configuration
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	return Parse(data, log)
+}
+
+// Parse compiles a YAML or JSON rules document.
+func Parse(data []byte, log *zerolog.Logger) (*RuleSet, error) {
+	var file File
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse rules file: %w", err)
+	}
+
+	return Compile(file, log)
+}
+
+// Compile validates a rules file and compiles every expression.
+func Compile(file File, log *zerolog.Logger) (*RuleSet, error) {
+	if file.Version != FileVersion {
+		return nil, fmt.Errorf("%w: %d", ErrVersion, file.Version)
+	}
+
+	env, err := NewEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	rs := &RuleSet{
+		def:     file.Default,
+		managed: make(map[string]bool),
+		log:     log,
+	}
+
+	for _, name := range file.Default {
+		rs.managed[name] = true
+	}
+
+	seen := make(map[string]bool, len(file.Rules))
+
+	for _, spec := range file.Rules {
+		switch {
+		case spec.Name == "":
+			return nil, ErrNameRequired
+		case seen[spec.Name]:
+			return nil, fmt.Errorf("%w: %s", ErrDuplicate, spec.Name)
+		case len(spec.Route) == 0:
+			return nil, fmt.Errorf("%w: %s", ErrNoRoute, spec.Name)
+		}
+
+		seen[spec.Name] = true
+
+		program, err := CompileCondition(env, spec.Name, spec.When)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range spec.Route {
+			rs.managed[name] = true
+		}
+
+		rs.rules = append(rs.rules, Rule{Spec: spec, program: program})
+	}
+
+	return rs, nil
+}
+
+// Rules returns the compiled rules in evaluation order.
+func (rs *RuleSet) Rules() []Rule {
+	return rs.rules
+}
+
+// Managed returns every subscriber named by the rules file.
+func (rs *RuleSet) Managed() []string {
+	names := make([]string, 0, len(rs.managed))
+	for name := range rs.managed {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// Manages implements ports.Router.
+func (rs *RuleSet) Manages(subscriber string) bool {
+	return rs.managed[subscriber]
+}
+
+// Route implements ports.Router.
+func (rs *RuleSet) Route(msg ports.Message) []string {
+	if msg.Event == nil {
+		return nil
+	}
+
+	decision := rs.Evaluate(*msg.Event)
+
+	for rule, err := range decision.Errors {
+		rs.log.Warn().Str("rule", rule).Str("message_id", msg.ID).Str("error", err).Msg("Routing rule failed to evaluate")
+	}
+
+	return decision.Subscribers
+}
+
+// Evaluate runs the rules against an event. A rule that fails to evaluate is
+// treated as not matching.
+func (rs *RuleSet) Evaluate(event ports.Event) Decision {
+	var decision Decision
+
+	vars := Activation(event)
+
+	for _, rule := range rs.rules {
+		matched, err := Match(rule.program, vars)
+		if err != nil {
+			if decision.Errors == nil {
+				decision.Errors = make(map[string]string)
+			}
+
+			decision.Errors[rule.Name] = err.Error()
+
+			continue
+		}
+
+		if !matched {
+			continue
+		}
+
+		decision.Matched = append(decision.Matched, rule.Name)
+
+		for _, name := range rule.Route {
+			if !slices.Contains(decision.Subscribers, name) {
+				decision.Subscribers = append(decision.Subscribers, name)
+			}
+		}
+
+		if rule.Stop {
+			break
+		}
+	}
+
+	if len(decision.Matched) == 0 {
+		decision.Subscribers = append(decision.Subscribers, rs.def...)
+	}
+
+	return decision
+}
+
+// Ensure RuleSet implements the ports.Router interface.
+var _ ports.Router = (*RuleSet)(nil)
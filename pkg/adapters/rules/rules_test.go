@@ -0,0 +1,131 @@
+package rules
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+const testRules = `
+version: 1
+default: [filesink]
+rules:
+  - name: failed-outbound
+    when: kind == "telephony_result" && call_type == "OUTBOUND" && result != "ANSWERED"
+    route: [webhook, nats]
+    stop: true
+  - name: outbound
+    when: call_type == "OUTBOUND"
+    route: [nats]
+  - name: long-calls
+    when: kind == "agent_call" && talk_duration > 600 && partner_agent_id.startsWith("team-a")
+    route: [sqlstore]
+  - name: spanish-transfers
+    when: '"spanish" in destination_skills'
+    route: [webhook]
+`
+
+func parseTestRules(t *testing.T, doc string) *RuleSet {
+	t.Helper()
+
+	logger := zerolog.Nop()
+
+	rules, err := Parse([]byte(doc), &logger)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	return rules
+}
+
+func TestRuleSet_Evaluate(t *testing.T) {
+	rules := parseTestRules(t, testRules)
+
+	tests := []struct {
+		name    string
+		event   ports.Event
+		matched []string
+		subs    []string
+	}{
+		{
+			"stop after first match",
+			ports.Event{Telephony: &ports.ExileTelephonyResult{CallType: "OUTBOUND", Result: "NO_ANSWER"}},
+			[]string{"failed-outbound"},
+			[]string{"webhook", "nats"},
+		},
+		{
+			"later rule",
+			ports.Event{Telephony: &ports.ExileTelephonyResult{CallType: "OUTBOUND", Result: "ANSWERED"}},
+			[]string{"outbound"},
+			[]string{"nats"},
+		},
+		{
+			"duration threshold",
+			ports.Event{AgentCall: &ports.ExileAgentCall{CallType: "INBOUND", TalkDuration: 900, PartnerAgentID: "team-a-7"}},
+			[]string{"long-calls"},
+			[]string{"sqlstore"},
+		},
+		{
+			"list membership",
+			ports.Event{TransferInstance: &ports.ExileTransferInstance{DestinationSkills: []string{"spanish"}}},
+			[]string{"spanish-transfers"},
+			[]string{"webhook"},
+		},
+		{
+			"default route",
+			ports.Event{AgentResponse: &ports.ExileAgentResponse{CallType: "INBOUND"}},
+			nil,
+			[]string{"filesink"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := rules.Evaluate(tt.event)
+
+			if !slices.Equal(decision.Matched, tt.matched) {
+				t.Errorf("Expected matched %v, got %v", tt.matched, decision.Matched)
+			}
+
+			if !slices.Equal(decision.Subscribers, tt.subs) {
+				t.Errorf("Expected subscribers %v, got %v", tt.subs, decision.Subscribers)
+			}
+		})
+	}
+
+	if managed := rules.Managed(); !slices.Equal(managed, []string{"filesink", "nats", "sqlstore", "webhook"}) {
+		t.Errorf("Unexpected managed subscribers: %v", managed)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	logger := zerolog.Nop()
+
+	tests := []struct {
+		name string
+		doc  string
+		want error
+	}{
+		{"version", "version: 2", ErrVersion},
+		{"name", "version: 1\nrules: [{when: 'true', route: [a]}]", ErrNameRequired},
+		{"duplicate", "version: 1\nrules: [{name: a, when: 'true', route: [a]}, {name: a, when: 'true', route: [a]}]", ErrDuplicate},
+		{"no route", "version: 1\nrules: [{name: a, when: 'true'}]", ErrNoRoute},
+		{"unknown variable", "version: 1\nrules: [{name: a, when: 'agent == \"x\"', route: [a]}]", ErrExpression},
+		{"not bool", "version: 1\nrules: [{name: a, when: 'call_sid + 1', route: [a]}]", ErrExpression},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.doc), &logger); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if _, err := Parse([]byte("version: 1\nrulez: []"), &logger); err == nil {
+		t.Error("Expected unknown field to be rejected")
+	}
+}
@@ -0,0 +1,87 @@
+package sqlstore
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	// Register the database/sql drivers used by the supported dialects.
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+// ErrUnknownDialect is returned for unsupported SQL dialects.
+var ErrUnknownDialect = errors.New("unknown SQL dialect")
+
+// Dialect identifies the SQL flavour of the target database.
+type Dialect string
+
+const (
+	// DialectSQLite targets SQLite through the pure Go modernc.org/sqlite driver.
+	DialectSQLite Dialect = "sqlite"
+	// DialectPostgres targets PostgreSQL through the pgx driver.
+	DialectPostgres Dialect = "postgres"
+)
+
+// ParseDialect converts a configuration name into a Dialect.
+func ParseDialect(name string) (Dialect, error) {
+	switch strings.ToLower(name) {
+	case "sqlite", "sqlite3":
+		return DialectSQLite, nil
+	case "postgres", "postgresql", "pgx":
+		return DialectPostgres, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownDialect, name)
+	}
+}
+
+// driverName returns the database/sql driver registered for the dialect.
+func (d Dialect) driverName() string {
+	if d == DialectPostgres {
+		return "pgx"
+	}
+
+	return "sqlite"
+}
+
+// timestampType is the column type used for event timestamps.
+func (d Dialect) timestampType() string {
+	if d == DialectPostgres {
+		return "TIMESTAMPTZ"
+	}
+
+	return "TIMESTAMP"
+}
+
+// placeholder returns the n-th (1-based) bind parameter.
+func (d Dialect) placeholder(n int) string {
+	if d == DialectPostgres {
+		return "$" + strconv.Itoa(n)
+	}
+
+	return "?"
+}
+
+// rebind rewrites "?" placeholders into the dialect's bind parameter syntax.
+func (d Dialect) rebind(query string) string {
+	if d != DialectPostgres {
+		return query
+	}
+
+	var b strings.Builder
+
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString(d.placeholder(n))
+
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,205 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// migration is one versioned schema change. Statements may use the {{timestamp}}
+// token, which is replaced with the dialect's timestamp column type.
+type migration struct {
+	version     int
+	description string
+	statements  []string
+}
+
+// migrations lists every schema change in order. Never edit a released
+// migration; append a new one instead.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create event tables",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS telephony_results (
+				call_sid        BIGINT NOT NULL,
+				call_type       TEXT NOT NULL,
+				org_id          TEXT NOT NULL,
+				status          TEXT NOT NULL,
+				result          TEXT NOT NULL,
+				caller_id       TEXT NOT NULL,
+				phone_number    TEXT NOT NULL,
+				pool_id         TEXT NOT NULL,
+				record_id       TEXT NOT NULL,
+				client_sid      BIGINT NOT NULL,
+				internal_key    TEXT NOT NULL,
+				delivery_length BIGINT NOT NULL,
+				linkback_length BIGINT NOT NULL,
+				create_time     {{timestamp}},
+				update_time     {{timestamp}},
+				start_time      {{timestamp}},
+				end_time        {{timestamp}},
+				PRIMARY KEY (call_sid, call_type)
+			)`,
+			`CREATE TABLE IF NOT EXISTS agent_calls (
+				agent_call_sid             BIGINT PRIMARY KEY,
+				call_sid                   BIGINT NOT NULL,
+				call_type                  TEXT NOT NULL,
+				org_id                     TEXT NOT NULL,
+				user_id                    TEXT NOT NULL,
+				partner_agent_id           TEXT NOT NULL,
+				internal_key               TEXT NOT NULL,
+				talk_duration              BIGINT NOT NULL,
+				call_wait_duration         BIGINT NOT NULL,
+				wrap_up_duration           BIGINT NOT NULL,
+				pause_duration             BIGINT NOT NULL,
+				transfer_duration          BIGINT NOT NULL,
+				manual_duration            BIGINT NOT NULL,
+				preview_duration           BIGINT NOT NULL,
+				hold_duration              BIGINT NOT NULL,
+				agent_wait_duration        BIGINT NOT NULL,
+				suspended_duration         BIGINT NOT NULL,
+				external_transfer_duration BIGINT NOT NULL,
+				create_time                {{timestamp}},
+				update_time                {{timestamp}}
+			)`,
+			`CREATE TABLE IF NOT EXISTS agent_responses (
+				agent_call_response_sid BIGINT PRIMARY KEY,
+				call_sid                BIGINT NOT NULL,
+				call_type               TEXT NOT NULL,
+				org_id                  TEXT NOT NULL,
+				user_id                 TEXT NOT NULL,
+				partner_agent_id        TEXT NOT NULL,
+				agent_sid               BIGINT NOT NULL,
+				client_sid              BIGINT NOT NULL,
+				internal_key            TEXT NOT NULL,
+				response_key            TEXT NOT NULL,
+				response_value          TEXT NOT NULL,
+				create_time             {{timestamp}},
+				update_time             {{timestamp}}
+			)`,
+			`CREATE TABLE IF NOT EXISTS transfer_instances (
+				transfer_instance_id           TEXT PRIMARY KEY,
+				org_id                         TEXT NOT NULL,
+				client_sid                     BIGINT NOT NULL,
+				source_call_sid                BIGINT NOT NULL,
+				source_call_type               TEXT NOT NULL,
+				source_partner_agent_id        TEXT NOT NULL,
+				source_user_id                 TEXT NOT NULL,
+				source_conversation_id         BIGINT NOT NULL,
+				source_session_sid             BIGINT NOT NULL,
+				source_agent_call_sid          BIGINT NOT NULL,
+				destination_type               TEXT NOT NULL,
+				destination_call_sid           BIGINT NOT NULL,
+				destination_call_type          TEXT NOT NULL,
+				destination_conversation_id    BIGINT NOT NULL,
+				destination_session_sid        BIGINT NOT NULL,
+				destination_partner_agent_id   TEXT NOT NULL,
+				destination_user_id            TEXT NOT NULL,
+				destination_phone_number       TEXT NOT NULL,
+				transfer_type                  TEXT NOT NULL,
+				transfer_result                TEXT NOT NULL,
+				start_as_pending               BOOLEAN NOT NULL,
+				started_as_conference          BOOLEAN NOT NULL,
+				duration_microseconds          BIGINT NOT NULL,
+				external_duration_microseconds BIGINT NOT NULL,
+				pending_duration_microseconds  BIGINT NOT NULL,
+				create_time                    {{timestamp}},
+				update_time                    {{timestamp}},
+				transfer_pending_start_time    {{timestamp}},
+				transfer_start_time            {{timestamp}},
+				transfer_end_time              {{timestamp}},
+				transfer_external_end_time     {{timestamp}}
+			)`,
+			`CREATE TABLE IF NOT EXISTS transfer_destination_skills (
+				transfer_instance_id TEXT NOT NULL REFERENCES transfer_instances (transfer_instance_id) ON DELETE CASCADE,
+				skill                TEXT NOT NULL,
+				PRIMARY KEY (transfer_instance_id, skill)
+			)`,
+		},
+	},
+	{
+		version:     2,
+		description: "index event lookups",
+		statements: []string{
+			`CREATE INDEX IF NOT EXISTS telephony_results_create_time_idx ON telephony_results (create_time)`,
+			`CREATE INDEX IF NOT EXISTS agent_calls_call_sid_idx ON agent_calls (call_sid)`,
+			`CREATE INDEX IF NOT EXISTS agent_calls_partner_agent_id_idx ON agent_calls (partner_agent_id, create_time)`,
+			`CREATE INDEX IF NOT EXISTS agent_responses_call_sid_idx ON agent_responses (call_sid)`,
+			`CREATE INDEX IF NOT EXISTS agent_responses_partner_agent_id_idx ON agent_responses (partner_agent_id, create_time)`,
+			`CREATE INDEX IF NOT EXISTS transfer_instances_source_call_sid_idx ON transfer_instances (source_call_sid)`,
+		},
+	},
+}
+
+// LatestSchemaVersion is the schema version after all migrations are applied.
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// Migrate applies every pending migration, each in its own transaction.
+func (s *Store) Migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version     INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at  %s NOT NULL
+	)`, s.dialect.timestampType())); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	current, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		if err := s.apply(ctx, m); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.description, err)
+		}
+
+		s.log.Info().Int("version", m.version).Str("description", m.description).Msg("Applied event store migration")
+	}
+
+	return nil
+}
+
+// SchemaVersion returns the highest applied migration version, or 0 for an empty database.
+func (s *Store) SchemaVersion(ctx context.Context) (int, error) {
+	var version sql.NullInt64
+
+	if err := s.db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	return int(version.Int64), nil
+}
+
+func (s *Store) apply(ctx context.Context, m migration) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, stmt := range m.statements {
+		stmt = strings.ReplaceAll(stmt, "{{timestamp}}", s.dialect.timestampType())
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		s.dialect.rebind(`INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)`),
+		m.version, m.description, time.Now().UTC(),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,67 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//
+// Copyright 2024 TCN Inc
+
+package sqlstore
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/domain"
+	"go.uber.org/fx"
+)
+
+// SubscriberName is the event bus subscriber name used by the SQL event store.
+const SubscriberName = "sqlstore"
+
+// Module provides the SQL event store module for dependency injection.
+// It opens the Store from the provided Options, applies pending migrations,
+// subscribes it to the domain event bus as a durable subscriber and closes the
+// connection pool when the app stops.
+//
+// Usage example:
+//
+//	app := fx.New(
+//	  domain.Module,
+//	  sqlstore.Module,
+//	  fx.Supply(sqlstore.Options{Dialect: sqlstore.DialectPostgres, DSN: "postgres://sati@db/events"}),
+//	)
+var Module = fx.Module("sqlstore",
+	// Provide the Store
+	fx.Provide(func(opts Options, log *zerolog.Logger) (*Store, error) {
+		return Open(context.Background(), opts, log)
+	}),
+
+	// Contribute the Store to the domain event bus
+	fx.Provide(fx.Annotate(
+		func(store *Store) domain.SubscriberRegistration {
+			return domain.SubscriberRegistration{
+				Name:       SubscriberName,
+				Subscriber: store,
+				Options:    domain.SubscriptionOptions{Durable: true},
+			}
+		},
+		fx.ResultTags(`group:"event_subscribers"`),
+	)),
+
+	// Close the connection pool on shutdown
+	fx.Invoke(func(lc fx.Lifecycle, store *Store) {
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return store.Close()
+			},
+		})
+	}),
+)
@@ -0,0 +1,331 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// Filter narrows a Query. Zero values match everything.
+type Filter struct {
+	// CallSid matches the call on any event; for transfers, either leg.
+	CallSid int64
+	// PartnerAgentID matches agent calls, agent responses and either side of a
+	// transfer. Telephony results carry no agent and are excluded when it is set.
+	PartnerAgentID string
+	// CallType matches the call type, e.g. "outbound". Transfers match on the source leg.
+	CallType string
+	// Since and Until bound the event create time (inclusive, exclusive).
+	Since time.Time
+	Until time.Time
+	// Kinds restricts results to the given event types (ports.EventType*).
+	Kinds []string
+	// Limit caps the number of events returned. Zero means no limit.
+	Limit int
+}
+
+func (f Filter) wants(kind string) bool {
+	return len(f.Kinds) == 0 || slices.Contains(f.Kinds, kind)
+}
+
+// where accumulates conditions and their arguments.
+type where struct {
+	conds []string
+	args  []any
+}
+
+func (w *where) add(cond string, args ...any) {
+	w.conds = append(w.conds, cond)
+	w.args = append(w.args, args...)
+}
+
+func (w *where) String() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(w.conds, " AND ")
+}
+
+// common applies the create time range shared by every table.
+func (f Filter) common(w *where) {
+	if !f.Since.IsZero() {
+		w.add("create_time >= ?", f.Since.UTC())
+	}
+
+	if !f.Until.IsZero() {
+		w.add("create_time < ?", f.Until.UTC())
+	}
+}
+
+// queryEvent pairs a decoded event with its create time for ordering.
+type queryEvent struct {
+	createTime sql.NullTime
+	event      ports.Event
+}
+
+// Query returns stored events matching filter, oldest first.
+func (s *Store) Query(ctx context.Context, filter Filter) ([]ports.Event, error) {
+	var results []queryEvent
+
+	queries := []struct {
+		kind string
+		run  func(context.Context, Filter) ([]queryEvent, error)
+	}{
+		{ports.EventTypeTelephonyResult, s.queryTelephony},
+		{ports.EventTypeAgentCall, s.queryAgentCalls},
+		{ports.EventTypeAgentResponse, s.queryAgentResponses},
+		{ports.EventTypeTransferInstance, s.queryTransferInstances},
+	}
+
+	for _, q := range queries {
+		if !filter.wants(q.kind) {
+			continue
+		}
+
+		rows, err := q.run(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, rows...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].createTime.Time.Before(results[j].createTime.Time)
+	})
+
+	if filter.Limit > 0 && len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+
+	events := make([]ports.Event, len(results))
+	for i, r := range results {
+		events[i] = r.event
+	}
+
+	return events, nil
+}
+
+func (s *Store) selectRows(ctx context.Context, table, cols string, w *where, limit int, scan func(*sql.Rows) (queryEvent, error)) ([]queryEvent, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY create_time", cols, table, w)
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(query), w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var results []queryEvent
+
+	for rows.Next() {
+		r, err := scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+
+		results = append(results, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+
+	return results, nil
+}
+
+func (s *Store) queryTelephony(ctx context.Context, f Filter) ([]queryEvent, error) {
+	if f.PartnerAgentID != "" {
+		return nil, nil
+	}
+
+	w := &where{}
+	f.common(w)
+
+	if f.CallSid != 0 {
+		w.add("call_sid = ?", f.CallSid)
+	}
+
+	if f.CallType != "" {
+		w.add("call_type = ?", f.CallType)
+	}
+
+	cols := `call_sid, call_type, org_id, status, result, caller_id, phone_number, pool_id, record_id,
+		client_sid, internal_key, delivery_length, linkback_length, create_time, update_time, start_time, end_time`
+
+	return s.selectRows(ctx, "telephony_results", cols, w, f.Limit, func(rows *sql.Rows) (queryEvent, error) {
+		var (
+			t                          ports.ExileTelephonyResult
+			create, update, start, end sql.NullTime
+		)
+
+		err := rows.Scan(&t.CallSid, &t.CallType, &t.OrgID, &t.Status, &t.Result, &t.CallerID, &t.PhoneNumber,
+			&t.PoolID, &t.RecordID, &t.ClientSid, &t.InternalKey, &t.DeliveryLength, &t.LinkbackLength,
+			&create, &update, &start, &end)
+
+		t.CreateTime, t.UpdateTime = formatTime(create), formatTime(update)
+		t.StartTime, t.EndTime = formatTime(start), formatTime(end)
+
+		return queryEvent{create, ports.Event{Type: ports.EventTypeTelephonyResult, Telephony: &t}}, err
+	})
+}
+
+func (s *Store) queryAgentCalls(ctx context.Context, f Filter) ([]queryEvent, error) {
+	w := &where{}
+	f.common(w)
+
+	if f.CallSid != 0 {
+		w.add("call_sid = ?", f.CallSid)
+	}
+
+	if f.PartnerAgentID != "" {
+		w.add("partner_agent_id = ?", f.PartnerAgentID)
+	}
+
+	if f.CallType != "" {
+		w.add("call_type = ?", f.CallType)
+	}
+
+	cols := `agent_call_sid, call_sid, call_type, org_id, user_id, partner_agent_id, internal_key,
+		talk_duration, call_wait_duration, wrap_up_duration, pause_duration, transfer_duration, manual_duration,
+		preview_duration, hold_duration, agent_wait_duration, suspended_duration, external_transfer_duration,
+		create_time, update_time`
+
+	return s.selectRows(ctx, "agent_calls", cols, w, f.Limit, func(rows *sql.Rows) (queryEvent, error) {
+		var (
+			c              ports.ExileAgentCall
+			create, update sql.NullTime
+		)
+
+		err := rows.Scan(&c.AgentCallSid, &c.CallSid, &c.CallType, &c.OrgID, &c.UserID, &c.PartnerAgentID, &c.InternalKey,
+			&c.TalkDuration, &c.CallWaitDuration, &c.WrapUpDuration, &c.PauseDuration, &c.TransferDuration, &c.ManualDuration,
+			&c.PreviewDuration, &c.HoldDuration, &c.AgentWaitDuration, &c.SuspendedDuration, &c.ExternalTransferDuration,
+			&create, &update)
+
+		c.CreateTime, c.UpdateTime = formatTime(create), formatTime(update)
+
+		return queryEvent{create, ports.Event{Type: ports.EventTypeAgentCall, AgentCall: &c}}, err
+	})
+}
+
+func (s *Store) queryAgentResponses(ctx context.Context, f Filter) ([]queryEvent, error) {
+	w := &where{}
+	f.common(w)
+
+	if f.CallSid != 0 {
+		w.add("call_sid = ?", f.CallSid)
+	}
+
+	if f.PartnerAgentID != "" {
+		w.add("partner_agent_id = ?", f.PartnerAgentID)
+	}
+
+	if f.CallType != "" {
+		w.add("call_type = ?", f.CallType)
+	}
+
+	cols := `agent_call_response_sid, call_sid, call_type, org_id, user_id, partner_agent_id, agent_sid, client_sid,
+		internal_key, response_key, response_value, create_time, update_time`
+
+	return s.selectRows(ctx, "agent_responses", cols, w, f.Limit, func(rows *sql.Rows) (queryEvent, error) {
+		var (
+			r              ports.ExileAgentResponse
+			create, update sql.NullTime
+		)
+
+		err := rows.Scan(&r.AgentCallResponseSid, &r.CallSid, &r.CallType, &r.OrgID, &r.UserID, &r.PartnerAgentID,
+			&r.AgentSid, &r.ClientSid, &r.InternalKey, &r.ResponseKey, &r.ResponseValue, &create, &update)
+
+		r.CreateTime, r.UpdateTime = formatTime(create), formatTime(update)
+
+		return queryEvent{create, ports.Event{Type: ports.EventTypeAgentResponse, AgentResponse: &r}}, err
+	})
+}
+
+func (s *Store) queryTransferInstances(ctx context.Context, f Filter) ([]queryEvent, error) {
+	w := &where{}
+	f.common(w)
+
+	if f.CallSid != 0 {
+		w.add("(source_call_sid = ? OR destination_call_sid = ?)", f.CallSid, f.CallSid)
+	}
+
+	if f.PartnerAgentID != "" {
+		w.add("(source_partner_agent_id = ? OR destination_partner_agent_id = ?)", f.PartnerAgentID, f.PartnerAgentID)
+	}
+
+	if f.CallType != "" {
+		w.add("source_call_type = ?", f.CallType)
+	}
+
+	cols := `transfer_instance_id, org_id, client_sid, source_call_sid, source_call_type, source_partner_agent_id,
+		source_user_id, source_conversation_id, source_session_sid, source_agent_call_sid, destination_type,
+		destination_call_sid, destination_call_type, destination_conversation_id, destination_session_sid,
+		destination_partner_agent_id, destination_user_id, destination_phone_number, transfer_type, transfer_result,
+		start_as_pending, started_as_conference, duration_microseconds, external_duration_microseconds,
+		pending_duration_microseconds, create_time, update_time, transfer_pending_start_time, transfer_start_time,
+		transfer_end_time, transfer_external_end_time`
+
+	results, err := s.selectRows(ctx, "transfer_instances", cols, w, f.Limit, func(rows *sql.Rows) (queryEvent, error) {
+		var (
+			ti                                          ports.ExileTransferInstance
+			create, update, pending, start, end, extEnd sql.NullTime
+		)
+
+		err := rows.Scan(&ti.TransferInstanceID, &ti.OrgID, &ti.ClientSid, &ti.SourceCallSid, &ti.SourceCallType,
+			&ti.SourcePartnerAgentID, &ti.SourceUserID, &ti.SourceConversationID, &ti.SourceSessionSid,
+			&ti.SourceAgentCallSid, &ti.DestinationType, &ti.DestinationCallSid, &ti.DestinationCallType,
+			&ti.DestinationConversationID, &ti.DestinationSessionSid, &ti.DestinationPartnerAgentID,
+			&ti.DestinationUserID, &ti.DestinationPhoneNumber, &ti.TransferType, &ti.TransferResult,
+			&ti.StartAsPending, &ti.StartedAsConference, &ti.DurationMicroseconds, &ti.ExternalDurationMicroseconds,
+			&ti.PendingDurationMicroseconds, &create, &update, &pending, &start, &end, &extEnd)
+
+		ti.CreateTime, ti.UpdateTime = formatTime(create), formatTime(update)
+		ti.TransferPendingStartTime, ti.TransferStartTime = formatTime(pending), formatTime(start)
+		ti.TransferEndTime, ti.TransferExternalEndTime = formatTime(end), formatTime(extEnd)
+
+		return queryEvent{create, ports.Event{Type: ports.EventTypeTransferInstance, TransferInstance: &ti}}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range results {
+		if err := s.loadSkills(ctx, r.event.TransferInstance); err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+func (s *Store) loadSkills(ctx context.Context, ti *ports.ExileTransferInstance) error {
+	rows, err := s.db.QueryContext(ctx,
+		s.dialect.rebind(`SELECT skill FROM transfer_destination_skills WHERE transfer_instance_id = ? ORDER BY skill`),
+		ti.TransferInstanceID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query transfer skills: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var skill string
+		if err := rows.Scan(&skill); err != nil {
+			return fmt.Errorf("failed to scan transfer skill: %w", err)
+		}
+
+		ti.DestinationSkills = append(ti.DestinationSkills, skill)
+	}
+
+	return rows.Err()
+}
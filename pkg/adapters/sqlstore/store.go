@@ -0,0 +1,369 @@
+// Package sqlstore persists polled events into normalized SQL tables.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// Error constants for event store operations.
+var (
+	ErrDSNRequired   = errors.New("database DSN is required")
+	ErrSchemaTooNew  = errors.New("database schema is newer than this binary")
+	ErrUnknownEvent  = errors.New("event has no payload")
+	ErrMissingNatKey = errors.New("event is missing its natural key")
+)
+
+// Options configures the event store.
+type Options struct {
+	// Dialect selects the SQL flavour. Defaults to SQLite.
+	Dialect Dialect
+	// DSN is the driver data source name, e.g. a file path for SQLite or a
+	// postgres:// URL for PostgreSQL.
+	DSN string
+}
+
+// Store writes events into one table per event kind. Every write is an upsert on
+// the entity's natural key, so redelivered events update rows instead of duplicating them.
+type Store struct {
+	db      *sql.DB
+	dialect Dialect
+	log     *zerolog.Logger
+}
+
+// Open connects to the database described by opts and applies pending migrations.
+func Open(ctx context.Context, opts Options, log *zerolog.Logger) (*Store, error) {
+	if opts.DSN == "" {
+		return nil, ErrDSNRequired
+	}
+
+	if opts.Dialect == "" {
+		opts.Dialect = DialectSQLite
+	}
+
+	db, err := sql.Open(opts.Dialect.driverName(), opts.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event store: %w", err)
+	}
+
+	if opts.Dialect == DialectSQLite {
+		// SQLite serialises writers; a single connection avoids SQLITE_BUSY and
+		// keeps per-connection pragmas such as foreign_keys in effect.
+		db.SetMaxOpenConns(1)
+
+		if _, err := db.ExecContext(ctx, `PRAGMA foreign_keys = ON`); err != nil {
+			_ = db.Close()
+
+			return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
+		}
+	}
+
+	store, err := New(ctx, db, opts.Dialect, log)
+	if err != nil {
+		_ = db.Close()
+
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// New wraps an existing connection pool and applies pending migrations.
+func New(ctx context.Context, db *sql.DB, dialect Dialect, log *zerolog.Logger) (*Store, error) {
+	s := &Store{db: db, dialect: dialect, log: log}
+
+	if err := s.Migrate(ctx); err != nil {
+		return nil, err
+	}
+
+	version, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if version > LatestSchemaVersion() {
+		return nil, fmt.Errorf("%w: database is at version %d, binary supports %d", ErrSchemaTooNew, version, LatestSchemaVersion())
+	}
+
+	return s, nil
+}
+
+// DB returns the underlying connection pool.
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// HandleMessage implements ports.Subscriber. Jobs are ignored. An event that
+// can never be stored, because it has no payload or no natural key, is logged
+// and skipped; only database errors are returned for the bus to retry.
+func (s *Store) HandleMessage(ctx context.Context, msg ports.Message) error {
+	if msg.Event == nil {
+		return nil
+	}
+
+	err := s.SaveEvents(ctx, []ports.Event{*msg.Event})
+	if errors.Is(err, ErrUnknownEvent) || errors.Is(err, ErrMissingNatKey) {
+		s.log.Error().Err(err).Str("message_id", msg.ID).Str("event_type", msg.Event.Type).Msg("Skipping event the store cannot save")
+
+		return nil
+	}
+
+	return err
+}
+
+// SaveEvents upserts a batch of events in a single transaction.
+func (s *Store) SaveEvents(ctx context.Context, events []ports.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, event := range events {
+		if err := s.save(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit events: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the connection pool.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) save(ctx context.Context, tx *sql.Tx, event ports.Event) error {
+	switch {
+	case event.Telephony != nil:
+		return s.saveTelephony(ctx, tx, event.Telephony)
+	case event.AgentCall != nil:
+		return s.saveAgentCall(ctx, tx, event.AgentCall)
+	case event.AgentResponse != nil:
+		return s.saveAgentResponse(ctx, tx, event.AgentResponse)
+	case event.TransferInstance != nil:
+		return s.saveTransferInstance(ctx, tx, event.TransferInstance)
+	default:
+		return ErrUnknownEvent
+	}
+}
+
+func (s *Store) saveTelephony(ctx context.Context, tx *sql.Tx, t *ports.ExileTelephonyResult) error {
+	if t.CallSid == 0 {
+		return fmt.Errorf("%w: telephony result call_sid", ErrMissingNatKey)
+	}
+
+	return s.upsert(ctx, tx, "telephony_results", []string{"call_sid", "call_type"}, []column{
+		{"call_sid", t.CallSid},
+		{"call_type", t.CallType},
+		{"org_id", t.OrgID},
+		{"status", t.Status},
+		{"result", t.Result},
+		{"caller_id", t.CallerID},
+		{"phone_number", t.PhoneNumber},
+		{"pool_id", t.PoolID},
+		{"record_id", t.RecordID},
+		{"client_sid", t.ClientSid},
+		{"internal_key", t.InternalKey},
+		{"delivery_length", t.DeliveryLength},
+		{"linkback_length", t.LinkbackLength},
+		{"create_time", parseTime(t.CreateTime)},
+		{"update_time", parseTime(t.UpdateTime)},
+		{"start_time", parseTime(t.StartTime)},
+		{"end_time", parseTime(t.EndTime)},
+	})
+}
+
+func (s *Store) saveAgentCall(ctx context.Context, tx *sql.Tx, c *ports.ExileAgentCall) error {
+	if c.AgentCallSid == 0 {
+		return fmt.Errorf("%w: agent_call_sid", ErrMissingNatKey)
+	}
+
+	return s.upsert(ctx, tx, "agent_calls", []string{"agent_call_sid"}, []column{
+		{"agent_call_sid", c.AgentCallSid},
+		{"call_sid", c.CallSid},
+		{"call_type", c.CallType},
+		{"org_id", c.OrgID},
+		{"user_id", c.UserID},
+		{"partner_agent_id", c.PartnerAgentID},
+		{"internal_key", c.InternalKey},
+		{"talk_duration", c.TalkDuration},
+		{"call_wait_duration", c.CallWaitDuration},
+		{"wrap_up_duration", c.WrapUpDuration},
+		{"pause_duration", c.PauseDuration},
+		{"transfer_duration", c.TransferDuration},
+		{"manual_duration", c.ManualDuration},
+		{"preview_duration", c.PreviewDuration},
+		{"hold_duration", c.HoldDuration},
+		{"agent_wait_duration", c.AgentWaitDuration},
+		{"suspended_duration", c.SuspendedDuration},
+		{"external_transfer_duration", c.ExternalTransferDuration},
+		{"create_time", parseTime(c.CreateTime)},
+		{"update_time", parseTime(c.UpdateTime)},
+	})
+}
+
+func (s *Store) saveAgentResponse(ctx context.Context, tx *sql.Tx, r *ports.ExileAgentResponse) error {
+	if r.AgentCallResponseSid == 0 {
+		return fmt.Errorf("%w: agent_call_response_sid", ErrMissingNatKey)
+	}
+
+	return s.upsert(ctx, tx, "agent_responses", []string{"agent_call_response_sid"}, []column{
+		{"agent_call_response_sid", r.AgentCallResponseSid},
+		{"call_sid", r.CallSid},
+		{"call_type", r.CallType},
+		{"org_id", r.OrgID},
+		{"user_id", r.UserID},
+		{"partner_agent_id", r.PartnerAgentID},
+		{"agent_sid", r.AgentSid},
+		{"client_sid", r.ClientSid},
+		{"internal_key", r.InternalKey},
+		{"response_key", r.ResponseKey},
+		{"response_value", r.ResponseValue},
+		{"create_time", parseTime(r.CreateTime)},
+		{"update_time", parseTime(r.UpdateTime)},
+	})
+}
+
+func (s *Store) saveTransferInstance(ctx context.Context, tx *sql.Tx, ti *ports.ExileTransferInstance) error {
+	if ti.TransferInstanceID == "" {
+		return fmt.Errorf("%w: transfer_instance_id", ErrMissingNatKey)
+	}
+
+	err := s.upsert(ctx, tx, "transfer_instances", []string{"transfer_instance_id"}, []column{
+		{"transfer_instance_id", ti.TransferInstanceID},
+		{"org_id", ti.OrgID},
+		{"client_sid", ti.ClientSid},
+		{"source_call_sid", ti.SourceCallSid},
+		{"source_call_type", ti.SourceCallType},
+		{"source_partner_agent_id", ti.SourcePartnerAgentID},
+		{"source_user_id", ti.SourceUserID},
+		{"source_conversation_id", ti.SourceConversationID},
+		{"source_session_sid", ti.SourceSessionSid},
+		{"source_agent_call_sid", ti.SourceAgentCallSid},
+		{"destination_type", ti.DestinationType},
+		{"destination_call_sid", ti.DestinationCallSid},
+		{"destination_call_type", ti.DestinationCallType},
+		{"destination_conversation_id", ti.DestinationConversationID},
+		{"destination_session_sid", ti.DestinationSessionSid},
+		{"destination_partner_agent_id", ti.DestinationPartnerAgentID},
+		{"destination_user_id", ti.DestinationUserID},
+		{"destination_phone_number", ti.DestinationPhoneNumber},
+		{"transfer_type", ti.TransferType},
+		{"transfer_result", ti.TransferResult},
+		{"start_as_pending", ti.StartAsPending},
+		{"started_as_conference", ti.StartedAsConference},
+		{"duration_microseconds", ti.DurationMicroseconds},
+		{"external_duration_microseconds", ti.ExternalDurationMicroseconds},
+		{"pending_duration_microseconds", ti.PendingDurationMicroseconds},
+		{"create_time", parseTime(ti.CreateTime)},
+		{"update_time", parseTime(ti.UpdateTime)},
+		{"transfer_pending_start_time", parseTime(ti.TransferPendingStartTime)},
+		{"transfer_start_time", parseTime(ti.TransferStartTime)},
+		{"transfer_end_time", parseTime(ti.TransferEndTime)},
+		{"transfer_external_end_time", parseTime(ti.TransferExternalEndTime)},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Skills are replaced wholesale so the table always mirrors the latest update.
+	if _, err := tx.ExecContext(ctx,
+		s.dialect.rebind(`DELETE FROM transfer_destination_skills WHERE transfer_instance_id = ?`),
+		ti.TransferInstanceID,
+	); err != nil {
+		return fmt.Errorf("failed to clear transfer skills: %w", err)
+	}
+
+	for _, skill := range ti.DestinationSkills {
+		if _, err := tx.ExecContext(ctx,
+			s.dialect.rebind(`INSERT INTO transfer_destination_skills (transfer_instance_id, skill) VALUES (?, ?) ON CONFLICT DO NOTHING`),
+			ti.TransferInstanceID, skill,
+		); err != nil {
+			return fmt.Errorf("failed to save transfer skill: %w", err)
+		}
+	}
+
+	return nil
+}
+
+type column struct {
+	name  string
+	value any
+}
+
+// upsert inserts a row or, when the natural key already exists, overwrites every
+// other column. Both SQLite (3.24+) and PostgreSQL accept this ON CONFLICT form.
+func (s *Store) upsert(ctx context.Context, tx *sql.Tx, table string, keys []string, cols []column) error {
+	names := make([]string, len(cols))
+	binds := make([]string, len(cols))
+	args := make([]any, len(cols))
+	updates := make([]string, 0, len(cols))
+
+	for i, c := range cols {
+		names[i] = c.name
+		binds[i] = s.dialect.placeholder(i + 1)
+		args[i] = c.value
+
+		if !slices.Contains(keys, c.name) {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", c.name, c.name))
+		}
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s",
+		table,
+		strings.Join(names, ", "),
+		strings.Join(binds, ", "),
+		strings.Join(keys, ", "),
+		strings.Join(updates, ", "),
+	)
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to upsert %s: %w", table, err)
+	}
+
+	return nil
+}
+
+// parseTime converts an RFC 3339 event timestamp into a nullable UTC time. Empty
+// strings and the Unix epoch, which the API reports for unset timestamps, become NULL.
+func parseTime(value string) sql.NullTime {
+	if value == "" {
+		return sql.NullTime{}
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil || t.Unix() == 0 {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// formatTime is the inverse of parseTime.
+func formatTime(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+
+	return t.Time.UTC().Format(time.RFC3339)
+}
+
+// Ensure Store implements the ports.EventSink interface.
+var _ ports.EventSink = (*Store)(nil)
@@ -0,0 +1,215 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+func newTestStore(t *testing.T, dsn string) *Store {
+	t.Helper()
+
+	logger := zerolog.Nop()
+
+	if dsn == "" {
+		dsn = filepath.Join(t.TempDir(), "events.db")
+	}
+
+	store, err := Open(context.Background(), Options{DSN: dsn}, &logger)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	t.Cleanup(func() { _ = store.Close() })
+
+	return store
+}
+
+func sampleEvents() []ports.Event {
+	return []ports.Event{
+		{Type: ports.EventTypeTelephonyResult, Telephony: &ports.ExileTelephonyResult{
+			CallSid: 100, CallType: "outbound", Status: "COMPLETE", CreateTime: "2026-10-18T12:00:00Z", StartTime: "1970-01-01T00:00:00Z",
+		}},
+		{Type: ports.EventTypeAgentCall, AgentCall: &ports.ExileAgentCall{
+			AgentCallSid: 1, CallSid: 100, CallType: "outbound", PartnerAgentID: "agent-1", TalkDuration: 30, CreateTime: "2026-10-18T12:01:00Z",
+		}},
+		{Type: ports.EventTypeAgentResponse, AgentResponse: &ports.ExileAgentResponse{
+			AgentCallResponseSid: 2, CallSid: 100, CallType: "outbound", PartnerAgentID: "agent-1", ResponseKey: "disposition", ResponseValue: "sale", CreateTime: "2026-10-18T12:02:00Z",
+		}},
+		{Type: ports.EventTypeTransferInstance, TransferInstance: &ports.ExileTransferInstance{
+			TransferInstanceID: "ti-1", SourceCallSid: 100, SourceCallType: "outbound", SourcePartnerAgentID: "agent-1",
+			DestinationType: "skills", DestinationSkills: []string{"spanish", "billing"}, StartAsPending: true, CreateTime: "2026-10-18T12:03:00Z",
+		}},
+		{Type: ports.EventTypeAgentCall, AgentCall: &ports.ExileAgentCall{
+			AgentCallSid: 3, CallSid: 200, CallType: "inbound", PartnerAgentID: "agent-2", CreateTime: "2026-10-18T13:00:00Z",
+		}},
+	}
+}
+
+func TestOpen_MigratesToLatest(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "events.db")
+	store := newTestStore(t, dsn)
+
+	version, err := store.SchemaVersion(context.Background())
+	if err != nil {
+		t.Fatalf("SchemaVersion failed: %v", err)
+	}
+
+	if version != LatestSchemaVersion() {
+		t.Errorf("Expected schema version %d, got %d", LatestSchemaVersion(), version)
+	}
+
+	// Reopening must be a no-op.
+	if err := store.Migrate(context.Background()); err != nil {
+		t.Errorf("Second Migrate failed: %v", err)
+	}
+}
+
+func TestOpen_RequiresDSN(t *testing.T) {
+	logger := zerolog.Nop()
+
+	if _, err := Open(context.Background(), Options{}, &logger); !errors.Is(err, ErrDSNRequired) {
+		t.Errorf("Expected ErrDSNRequired, got %v", err)
+	}
+}
+
+func TestStore_UpsertsOnNaturalKey(t *testing.T) {
+	store := newTestStore(t, "")
+	ctx := context.Background()
+
+	if err := store.SaveEvents(ctx, sampleEvents()); err != nil {
+		t.Fatalf("SaveEvents failed: %v", err)
+	}
+
+	updated := ports.Event{AgentCall: &ports.ExileAgentCall{
+		AgentCallSid: 1, CallSid: 100, CallType: "outbound", PartnerAgentID: "agent-1", TalkDuration: 45, CreateTime: "2026-10-18T12:01:00Z",
+	}}
+	if err := store.HandleMessage(ctx, ports.Message{ID: "m-1", Event: &updated}); err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+
+	transfer := ports.Event{TransferInstance: &ports.ExileTransferInstance{
+		TransferInstanceID: "ti-1", SourceCallSid: 100, SourceCallType: "outbound", DestinationSkills: []string{"billing"}, CreateTime: "2026-10-18T12:03:00Z",
+	}}
+	if err := store.SaveEvents(ctx, []ports.Event{transfer}); err != nil {
+		t.Fatalf("SaveEvents failed: %v", err)
+	}
+
+	events, err := store.Query(ctx, Filter{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if len(events) != 5 {
+		t.Fatalf("Expected 5 events after upserts, got %d", len(events))
+	}
+
+	if events[1].AgentCall == nil || events[1].AgentCall.TalkDuration != 45 {
+		t.Errorf("Expected agent call to be updated, got %+v", events[1])
+	}
+
+	ti := events[3].TransferInstance
+	if ti == nil || len(ti.DestinationSkills) != 1 || ti.DestinationSkills[0] != "billing" {
+		t.Errorf("Expected transfer skills to be replaced, got %+v", ti)
+	}
+
+	if tel := events[0].Telephony; tel.StartTime != "" || tel.CreateTime != "2026-10-18T12:00:00Z" {
+		t.Errorf("Unexpected telephony timestamps: %+v", tel)
+	}
+}
+
+func TestStore_RejectsMissingKey(t *testing.T) {
+	store := newTestStore(t, "")
+
+	err := store.SaveEvents(context.Background(), []ports.Event{{AgentCall: &ports.ExileAgentCall{CallSid: 1}}})
+	if !errors.Is(err, ErrMissingNatKey) {
+		t.Errorf("Expected ErrMissingNatKey, got %v", err)
+	}
+
+	if err := store.SaveEvents(context.Background(), []ports.Event{{}}); !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("Expected ErrUnknownEvent, got %v", err)
+	}
+}
+
+func TestStore_HandleMessageSkipsUnstorableEvents(t *testing.T) {
+	store := newTestStore(t, "")
+
+	for _, event := range []ports.Event{{}, {AgentCall: &ports.ExileAgentCall{CallSid: 1}}} {
+		if err := store.HandleMessage(context.Background(), ports.Message{Event: &event}); err != nil {
+			t.Errorf("Expected %+v to be skipped, got %v", event, err)
+		}
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	err := store.HandleMessage(context.Background(), ports.Message{Event: &sampleEvents()[0]})
+	if err == nil {
+		t.Error("Expected a database error to be returned for a retry")
+	}
+}
+
+func TestStore_QueryFilters(t *testing.T) {
+	store := newTestStore(t, "")
+	ctx := context.Background()
+
+	if err := store.SaveEvents(ctx, sampleEvents()); err != nil {
+		t.Fatalf("SaveEvents failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"call sid", Filter{CallSid: 100}, 4},
+		{"agent", Filter{PartnerAgentID: "agent-1"}, 3},
+		{"call type", Filter{CallType: "inbound"}, 1},
+		{"since", Filter{Since: time.Date(2026, 10, 18, 12, 2, 0, 0, time.UTC)}, 3},
+		{"until", Filter{Until: time.Date(2026, 10, 18, 12, 2, 0, 0, time.UTC)}, 2},
+		{"kind", Filter{Kinds: []string{ports.EventTypeAgentCall}}, 2},
+		{"limit", Filter{Limit: 2}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := store.Query(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+
+			if len(events) != tt.want {
+				t.Errorf("Expected %d events, got %d", tt.want, len(events))
+			}
+		})
+	}
+}
+
+func TestParseDialect(t *testing.T) {
+	for name, want := range map[string]Dialect{"sqlite": DialectSQLite, "postgres": DialectPostgres, "PostgreSQL": DialectPostgres} {
+		got, err := ParseDialect(name)
+		if err != nil || got != want {
+			t.Errorf("ParseDialect(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+
+	if _, err := ParseDialect("oracle"); !errors.Is(err, ErrUnknownDialect) {
+		t.Errorf("Expected ErrUnknownDialect, got %v", err)
+	}
+}
+
+func TestDialect_Rebind(t *testing.T) {
+	if got := DialectPostgres.rebind("a = ? AND b = ?"); got != "a = $1 AND b = $2" {
+		t.Errorf("Unexpected postgres rebind: %s", got)
+	}
+
+	if got := DialectSQLite.rebind("a = ?"); got != "a = ?" {
+		t.Errorf("Unexpected sqlite rebind: %s", got)
+	}
+}
@@ -0,0 +1,66 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//
+// Copyright 2024 TCN Inc
+
+package tracing
+
+import (
+	"context"
+
+	"github.com/tcncloud/sati-go/pkg/domain"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+)
+
+// Module provides the OpenTelemetry tracing module for dependency injection.
+// It creates a tracer provider from the provided Options, traces the domain
+// processes with it and flushes it when the app stops. The provider is also
+// provided as trace.TracerProvider so the gate client can be traced with
+// saticlient.WithTracerProvider.
+//
+// Usage example:
+//
+//	app := fx.New(
+//	  domain.Module,
+//	  tracing.Module,
+//	  fx.Supply(tracing.Options{Exporter: tracing.ExporterOTLP, Endpoint: "otel-collector:4317", Insecure: true}, cfg),
+//	  fx.Invoke(func(cfg *saticonfig.Config, tp trace.TracerProvider, set func(ports.ClientInterface)) error {
+//	    client, err := saticlient.NewClient(cfg, saticlient.WithTracerProvider(tp))
+//	    if err != nil {
+//	      return err
+//	    }
+//	    set(client)
+//	    return nil
+//	  }),
+//	)
+var Module = fx.Module("tracing",
+	// Provide the tracer provider
+	fx.Provide(NewTracerProvider),
+
+	fx.Provide(func(tp *sdktrace.TracerProvider) trace.TracerProvider {
+		return tp
+	}),
+
+	// Trace the domain processes and flush the spans when the app stops
+	fx.Invoke(func(lc fx.Lifecycle, d *domain.Domain, tp *sdktrace.TracerProvider) {
+		d.SetTracer(NewTracer(tp))
+
+		lc.Append(fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				return tp.Shutdown(ctx)
+			},
+		})
+	}),
+)
@@ -0,0 +1,105 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/domain"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"github.com/tcncloud/sati-go/pkg/sati/hostplugin"
+	"go.uber.org/fx"
+)
+
+// syncBuffer lets the exporter's goroutine write while the test reads.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestModule(t *testing.T) {
+	var (
+		out syncBuffer
+		bus *domain.EventBus
+	)
+
+	app := fx.New(
+		domain.Module,
+		Module,
+		fx.Provide(func() *zerolog.Logger {
+			logger := zerolog.Nop()
+			return &logger
+		}),
+		fx.Supply(Options{Exporter: ExporterStdout, Writer: &out}),
+		fx.Invoke(func(d *domain.Domain, log *zerolog.Logger) {
+			d.SetHostPluginProcess(hostplugin.NewHostPluginProcess(log))
+		}),
+		fx.Populate(&bus),
+	)
+
+	if err := app.Err(); err != nil {
+		t.Fatalf("Module failed to initialize: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Failed to start app: %v", err)
+	}
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	// The host plugin subscriber handles every job, so its handling is traced
+	bus.DispatchJob(&ports.Job{JobID: "job1", Type: "lookup", Trace: &ports.TraceContext{
+		TraceParent:   "00-" + traceID + "-00f067aa0ba902b7-01",
+		CorrelationID: "job1",
+	}})
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for !delivered(bus, domain.HostPluginSubscriberName) {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the host plugin to handle the job")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Stopping the app flushes the spans
+	if err := app.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop app: %v", err)
+	}
+
+	for _, want := range []string{`"Name":"sati.handle_job"`, `"TraceID":"` + traceID + `"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected the exported spans to contain %s, got %s", want, out.String())
+		}
+	}
+}
+
+func delivered(bus *domain.EventBus, name string) bool {
+	for _, stats := range bus.Stats() {
+		if stats.Name == name && stats.Delivered > 0 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation scope of the domain processes' spans.
+const TracerName = "github.com/tcncloud/sati-go/pkg/domain"
+
+// tracer starts the domain processes' spans with OpenTelemetry.
+type tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer returns a ports.Tracer that starts its spans with tp.
+func NewTracer(tp trace.TracerProvider) ports.Tracer {
+	return &tracer{tracer: tp.Tracer(TracerName)}
+}
+
+func (t *tracer) Start(ctx context.Context, name string, opts ports.SpanOptions) (context.Context, ports.Span) {
+	if opts.Parent != nil {
+		ctx = contextWithRemote(ctx, opts.Parent)
+	}
+
+	startOpts := []trace.SpanStartOption{trace.WithAttributes(attributes(opts.Attributes)...)}
+
+	if opts.Consumer {
+		startOpts = append(startOpts, trace.WithSpanKind(trace.SpanKindConsumer))
+	}
+
+	for _, link := range opts.Links {
+		startOpts = append(startOpts, trace.WithLinks(trace.LinkFromContext(contextWithRemote(context.Background(), link))))
+	}
+
+	ctx, otelSpan := t.tracer.Start(ctx, name, startOpts...)
+
+	return ctx, span{otelSpan}
+}
+
+// contextWithRemote returns ctx carrying tc as a remote span context.
+func contextWithRemote(ctx context.Context, tc *ports.TraceContext) context.Context {
+	return propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{
+		"traceparent": tc.TraceParent,
+		"tracestate":  tc.TraceState,
+	})
+}
+
+// span adapts an OpenTelemetry span to ports.Span.
+type span struct {
+	span trace.Span
+}
+
+func (s span) SetAttribute(key string, value any) {
+	s.span.SetAttributes(attributeOf(key, value))
+}
+
+func (s span) TraceParent() string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(trace.ContextWithSpan(context.Background(), s.span), carrier)
+
+	return carrier.Get("traceparent")
+}
+
+func (s span) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+
+	s.span.End()
+}
+
+func attributes(values map[string]any) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(values))
+	for key, value := range values {
+		attrs = append(attrs, attributeOf(key, value))
+	}
+
+	return attrs
+}
+
+// attributeOf converts one of the value types ports.SpanOptions allows.
+// Other types are recorded as their fmt representation.
+func attributeOf(key string, value any) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
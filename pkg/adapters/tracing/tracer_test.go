@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+func TestTracer_ContinuesParentTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	const otherTraceID = "0af7651916cd43dd8448eb211c80319c"
+
+	ctx, span := tracer.Start(context.Background(), "sati.handle_job", ports.SpanOptions{
+		Parent:     &ports.TraceContext{TraceParent: "00-" + testTraceID + "-00f067aa0ba902b7-01"},
+		Links:      []*ports.TraceContext{{TraceParent: "00-" + otherTraceID + "-b7ad6b7169203331-01"}},
+		Consumer:   true,
+		Attributes: map[string]any{"sati.job.id": "job1", "sati.jobs": 2},
+	})
+
+	if got := trace.SpanContextFromContext(ctx).TraceID().String(); got != testTraceID {
+		t.Errorf("Expected ctx to carry the parent's trace, got %s", got)
+	}
+
+	if got := span.TraceParent(); !strings.HasPrefix(got, "00-"+testTraceID+"-") || strings.Contains(got, "00f067aa0ba902b7") {
+		t.Errorf("Expected the span's own traceparent in the parent's trace, got %q", got)
+	}
+
+	span.SetAttribute("sati.events.polled", 3)
+	span.End(errors.New("plugin failed"))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	got := spans[0]
+	if got.Parent().SpanID().String() != "00f067aa0ba902b7" || got.SpanKind() != trace.SpanKindConsumer {
+		t.Errorf("Expected a consumer span under the parent, got %s under %s", got.SpanKind(), got.Parent().SpanID())
+	}
+
+	if len(got.Links()) != 1 || got.Links()[0].SpanContext.TraceID().String() != otherTraceID {
+		t.Errorf("Expected a link to the other trace, got %v", got.Links())
+	}
+
+	if got.Status().Code != codes.Error || got.Status().Description != "plugin failed" {
+		t.Errorf("Expected the error on the span, got %v", got.Status())
+	}
+
+	attrs := map[string]string{}
+	for _, attr := range got.Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+
+	for key, want := range map[string]string{"sati.job.id": "job1", "sati.jobs": "2", "sati.events.polled": "3"} {
+		if attrs[key] != want {
+			t.Errorf("Expected %s=%s, got %q", key, want, attrs[key])
+		}
+	}
+}
+
+func TestTracer_ChildOfContextSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx, parent := tracer.Start(context.Background(), "sati.poll_events", ports.SpanOptions{})
+	_, child := tracer.Start(ctx, "child", ports.SpanOptions{})
+
+	child.End(nil)
+	parent.End(nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Fatalf("Expected the second span to be a child of the first, got %v", spans)
+	}
+
+	if spans[1].Status().Code != codes.Unset {
+		t.Errorf("Expected no error status, got %v", spans[1].Status())
+	}
+}
@@ -0,0 +1,139 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// Exporters accepted in Options.Exporter.
+const (
+	// ExporterNone records spans without exporting them, so logs still carry
+	// trace IDs.
+	ExporterNone = "none"
+	// ExporterStdout writes spans as JSON to Options.Writer.
+	ExporterStdout = "stdout"
+	// ExporterOTLP sends spans to an OTLP collector over gRPC.
+	ExporterOTLP = "otlp"
+)
+
+// DefaultServiceName is the service.name resource attribute when
+// Options.ServiceName is empty.
+const DefaultServiceName = "sati"
+
+// Error constants for tracer provider setup.
+var (
+	ErrUnknownExporter    = errors.New("unknown trace exporter")
+	ErrInvalidSampleRatio = errors.New("trace sample ratio must be between 0 and 1")
+)
+
+// Options configures the tracer provider.
+type Options struct {
+	// Exporter is ExporterOTLP, ExporterStdout or ExporterNone, the default.
+	Exporter string
+	// Endpoint is the OTLP collector's host:port. When empty the exporter
+	// reads OTEL_EXPORTER_OTLP_ENDPOINT, falling back to localhost:4317.
+	Endpoint string
+	// Insecure sends spans to the OTLP collector without TLS.
+	Insecure bool
+	// Headers are sent with every OTLP export, e.g. an API key.
+	Headers map[string]string
+	// ServiceName defaults to DefaultServiceName.
+	ServiceName string
+	// SampleRatio is the fraction of new traces that are sampled. Zero samples
+	// every trace. Traces continued from a sampled parent are always sampled.
+	SampleRatio float64
+	// Writer receives the stdout exporter's output. Defaults to os.Stdout.
+	Writer io.Writer
+}
+
+// NewTracerProvider returns a tracer provider that exports with the exporter
+// selected by opts. Shut it down to flush the spans still buffered.
+func NewTracerProvider(opts Options) (*sdktrace.TracerProvider, error) {
+	if opts.SampleRatio < 0 || opts.SampleRatio > 1 {
+		return nil, fmt.Errorf("%w: %g", ErrInvalidSampleRatio, opts.SampleRatio)
+	}
+
+	serviceName := opts.ServiceName
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if opts.SampleRatio > 0 && opts.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(opts.SampleRatio)
+	}
+
+	providerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+	}
+
+	exporter, err := newExporter(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if exporter != nil {
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter))
+	}
+
+	return sdktrace.NewTracerProvider(providerOpts...), nil
+}
+
+// newExporter returns the exporter selected by opts, or nil for ExporterNone.
+func newExporter(opts Options) (sdktrace.SpanExporter, error) {
+	switch opts.Exporter {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterStdout:
+		writer := opts.Writer
+		if writer == nil {
+			writer = os.Stdout
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(writer))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout trace exporter: %w", err)
+		}
+
+		return exporter, nil
+	case ExporterOTLP:
+		var clientOpts []otlptracegrpc.Option
+
+		if opts.Endpoint != "" {
+			clientOpts = append(clientOpts, otlptracegrpc.WithEndpoint(opts.Endpoint))
+		}
+
+		if opts.Insecure {
+			clientOpts = append(clientOpts, otlptracegrpc.WithInsecure())
+		}
+
+		if len(opts.Headers) > 0 {
+			clientOpts = append(clientOpts, otlptracegrpc.WithHeaders(opts.Headers))
+		}
+
+		// The gRPC connection is made lazily, so this does not block.
+		exporter, err := otlptracegrpc.New(context.Background(), clientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+
+		return exporter, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownExporter, opts.Exporter)
+	}
+}
@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewTracerProvider_Stdout(t *testing.T) {
+	var buf bytes.Buffer
+
+	tp, err := NewTracerProvider(Options{Exporter: ExporterStdout, Writer: &buf, ServiceName: "connector"})
+	if err != nil {
+		t.Fatalf("NewTracerProvider failed: %v", err)
+	}
+
+	_, span := tp.Tracer("test").Start(context.Background(), "sati.poll_events")
+	span.End()
+
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	for _, want := range []string{`"Name":"sati.poll_events"`, `"Value":"connector"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected the exported span to contain %s, got %s", want, buf.String())
+		}
+	}
+}
+
+func TestNewTracerProvider_None(t *testing.T) {
+	tp, err := NewTracerProvider(Options{})
+	if err != nil {
+		t.Fatalf("NewTracerProvider failed: %v", err)
+	}
+
+	defer tp.Shutdown(context.Background()) //nolint:errcheck // nothing is exported
+
+	_, span := tp.Tracer("test").Start(context.Background(), "sati.job")
+	defer span.End()
+
+	if !span.SpanContext().IsValid() {
+		t.Error("Expected spans to get trace IDs without an exporter")
+	}
+}
+
+func TestNewTracerProvider_OTLP(t *testing.T) {
+	tp, err := NewTracerProvider(Options{Exporter: ExporterOTLP, Endpoint: "127.0.0.1:4317", Insecure: true})
+	if err != nil {
+		t.Fatalf("NewTracerProvider failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	_ = tp.Shutdown(ctx)
+}
+
+func TestNewTracerProvider_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want error
+	}{
+		{"UnknownExporter", Options{Exporter: "jaeger"}, ErrUnknownExporter},
+		{"NegativeRatio", Options{SampleRatio: -0.5}, ErrInvalidSampleRatio},
+		{"RatioAboveOne", Options{SampleRatio: 2}, ErrInvalidSampleRatio},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTracerProvider(tt.opts); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+const (
+	deadLetterExt = ".json"
+	keyPrefixLen  = 16
+)
+
+// DeadLetter is a batch that could not be delivered to one target.
+type DeadLetter struct {
+	Target         string        `json:"target"`
+	URL            string        `json:"url"`
+	IdempotencyKey string        `json:"idempotency_key"`
+	FailedAt       time.Time     `json:"failed_at"`
+	Attempts       int           `json:"attempts"`
+	LastError      string        `json:"last_error"`
+	Events         []ports.Event `json:"events"`
+}
+
+// DeadLetterQueue stores undeliverable batches as one JSON file each.
+// Secrets are never written; re-driving needs the target configuration.
+type DeadLetterQueue struct {
+	dir string
+}
+
+// OpenDeadLetterQueue creates dir if needed and returns a queue backed by it.
+func OpenDeadLetterQueue(dir string) (*DeadLetterQueue, error) {
+	if dir == "" {
+		return nil, ErrDeadLetterDirRequired
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create dead-letter directory: %w", err)
+	}
+
+	return &DeadLetterQueue{dir: dir}, nil
+}
+
+// Dir returns the queue directory.
+func (q *DeadLetterQueue) Dir() string {
+	return q.dir
+}
+
+// Write atomically stores a dead letter and returns its path.
+func (q *DeadLetterQueue) Write(dl DeadLetter) (string, error) {
+	key := dl.IdempotencyKey
+	if len(key) > keyPrefixLen {
+		key = key[:keyPrefixLen]
+	}
+
+	name := fmt.Sprintf("%020d-%s-%s%s", dl.FailedAt.UnixNano(), sanitize(dl.Target), key, deadLetterExt)
+	path := filepath.Join(q.dir, name)
+
+	return path, q.write(path, dl)
+}
+
+// List returns the paths of stored dead letters, oldest first.
+func (q *DeadLetterQueue) List() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(q.dir, "*"+deadLetterExt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// Read loads a dead letter from path.
+func (q *DeadLetterQueue) Read(path string) (DeadLetter, error) {
+	var dl DeadLetter
+
+	//nolint:gosec // Dead-letter paths come from List or operator input
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return dl, fmt.Errorf("failed to read dead letter: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &dl); err != nil {
+		return dl, fmt.Errorf("failed to decode dead letter %s: %w", filepath.Base(path), err)
+	}
+
+	return dl, nil
+}
+
+// Remove deletes a dead letter.
+func (q *DeadLetterQueue) Remove(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove dead letter: %w", err)
+	}
+
+	return nil
+}
+
+func (q *DeadLetterQueue) write(path string, dl DeadLetter) error {
+	data, err := json.MarshalIndent(dl, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter: %w", err)
+	}
+
+	tmp := path + ".tmp"
+
+	//nolint:gosec // Dead-letter path is built from operator configuration
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to create dead letter: %w", err)
+	}
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to write dead letter: %w", err)
+	}
+
+	return nil
+}
+
+// sanitize keeps target names safe for use in file names.
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// RedriveResult summarises a Redrive run.
+type RedriveResult struct {
+	Delivered int
+	Failed    int
+	Skipped   int
+}
+
+// Redrive re-sends every dead letter to its original target. Delivered batches
+// are removed; failed ones stay in the queue with their attempt count and last
+// error updated. Dead letters for targets the forwarder does not know are skipped.
+func (f *Forwarder) Redrive(ctx context.Context) (RedriveResult, error) {
+	var result RedriveResult
+
+	paths, err := f.dlq.List()
+	if err != nil {
+		return result, err
+	}
+
+	targets := make(map[string]Target, len(f.opts.Targets))
+	for _, target := range f.opts.Targets {
+		targets[target.Name] = target
+	}
+
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		dl, err := f.dlq.Read(path)
+		if err != nil {
+			f.log.Error().Err(err).Str("path", path).Msg("Skipping unreadable dead letter")
+			result.Skipped++
+
+			continue
+		}
+
+		target, ok := targets[dl.Target]
+		if !ok {
+			f.log.Warn().Str("target", dl.Target).Str("path", path).Msg("Skipping dead letter for unknown target")
+			result.Skipped++
+
+			continue
+		}
+
+		attempts, err := f.send(ctx, target, NewPayload(dl.Events, f.now()))
+		if err == nil {
+			if err := f.dlq.Remove(path); err != nil {
+				return result, err
+			}
+
+			f.delivered.Add(uint64(len(dl.Events)))
+			result.Delivered++
+
+			continue
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return result, ctxErr
+		}
+
+		dl.Attempts += attempts
+		dl.LastError = err.Error()
+		dl.FailedAt = f.now().UTC()
+
+		if err := f.dlq.write(path, dl); err != nil {
+			return result, err
+		}
+
+		result.Failed++
+	}
+
+	return result, nil
+}
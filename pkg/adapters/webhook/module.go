@@ -0,0 +1,55 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//
+// Copyright 2024 TCN Inc
+
+package webhook
+
+import (
+	"github.com/tcncloud/sati-go/pkg/domain"
+	"go.uber.org/fx"
+)
+
+// SubscriberName is the event bus subscriber name used by the webhook forwarder.
+const SubscriberName = "webhook"
+
+// Module provides the webhook forwarder module for dependency injection.
+// It creates the Forwarder from the provided Options and subscribes it to the
+// domain event bus as a durable, batching subscriber.
+//
+// Usage example:
+//
+//	app := fx.New(
+//	  domain.Module,
+//	  webhook.Module,
+//	  fx.Supply(webhook.Options{
+//	    Targets:       []webhook.Target{{Name: "crm", URL: "https://crm.internal/hooks/sati", Secret: secret}},
+//	    DeadLetterDir: "/var/lib/sati/webhook-dlq",
+//	  }),
+//	)
+var Module = fx.Module("webhook",
+	// Provide the Forwarder
+	fx.Provide(New),
+
+	// Contribute the Forwarder to the domain event bus
+	fx.Provide(fx.Annotate(
+		func(forwarder *Forwarder) domain.SubscriberRegistration {
+			return domain.SubscriberRegistration{
+				Name:       SubscriberName,
+				Subscriber: forwarder,
+				Options:    domain.SubscriptionOptions{Durable: true},
+			}
+		},
+		fx.ResultTags(`group:"event_subscribers"`),
+	)),
+)
@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/domain"
+	"go.uber.org/fx"
+)
+
+func TestModule(t *testing.T) {
+	var bus *domain.EventBus
+
+	app := fx.New(
+		domain.Module,
+		Module,
+		fx.Provide(func() *zerolog.Logger {
+			logger := zerolog.Nop()
+			return &logger
+		}),
+		fx.Supply(Options{Targets: []Target{{Name: "crm", URL: "http://127.0.0.1:0"}}, DeadLetterDir: t.TempDir()}),
+		fx.Populate(&bus),
+	)
+
+	if err := app.Err(); err != nil {
+		t.Fatalf("Module failed to initialize: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Failed to start app: %v", err)
+	}
+
+	if durable := bus.DurableSubscribers(); !slices.Contains(durable, SubscriberName) {
+		t.Errorf("Expected webhook forwarder to be a durable subscriber, got %v", durable)
+	}
+
+	if err := app.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop app: %v", err)
+	}
+}
@@ -0,0 +1,454 @@
+// Package webhook forwards polled events to HTTP endpoints as signed JSON batches.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"github.com/tcncloud/sati-go/pkg/schema"
+)
+
+// Error constants for webhook operations.
+var (
+	ErrNoTargets             = errors.New("at least one webhook target is required")
+	ErrTargetURLRequired     = errors.New("webhook target URL is required")
+	ErrTargetNameRequired    = errors.New("webhook target name is required")
+	ErrDuplicateTarget       = errors.New("duplicate webhook target name")
+	ErrDeadLetterDirRequired = errors.New("dead-letter directory is required")
+	ErrUnexpectedStatus      = errors.New("unexpected webhook response status")
+)
+
+// Request headers set on every delivery.
+const (
+	HeaderSignature      = "X-Sati-Signature"
+	HeaderTimestamp      = "X-Sati-Timestamp"
+	HeaderIdempotencyKey = "Idempotency-Key"
+	HeaderSchemaVersion  = "X-Sati-Schema-Version"
+
+	signaturePrefix = "sha256="
+)
+
+// Defaults applied by New.
+const (
+	DefaultTimeout        = 10 * time.Second
+	DefaultMaxAttempts    = 5
+	DefaultInitialBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff     = 30 * time.Second
+)
+
+// Target is one webhook endpoint.
+type Target struct {
+	// Name identifies the target in logs and dead letters.
+	Name string
+	// URL receives a POST per batch.
+	URL string
+	// Secret signs request bodies with HMAC-SHA256. Empty disables signing.
+	Secret string
+	// Headers are added to every request.
+	Headers map[string]string
+}
+
+// Options configures the forwarder.
+type Options struct {
+	Targets []Target
+	// DeadLetterDir receives batches that could not be delivered.
+	DeadLetterDir string
+	// Timeout bounds each HTTP request. Defaults to DefaultTimeout.
+	Timeout time.Duration
+	// MaxAttempts is the retry budget per batch and target, including the first try.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry; it doubles on every retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between retries.
+	MaxBackoff time.Duration
+	// HTTPClient overrides the client used for requests.
+	HTTPClient *http.Client
+}
+
+// Payload is the JSON body of a webhook request.
+type Payload struct {
+	IdempotencyKey string          `json:"idempotency_key"`
+	SentAt         time.Time       `json:"sent_at"`
+	Events         []PayloadRecord `json:"events"`
+}
+
+// PayloadRecord is one event within a Payload. Event follows the published
+// schema named by SchemaVersion.
+type PayloadRecord struct {
+	IdempotencyKey string      `json:"idempotency_key"`
+	Kind           string      `json:"kind"`
+	SchemaVersion  string      `json:"schema_version"`
+	Event          ports.Event `json:"event"`
+}
+
+// Stats counts forwarder outcomes since start.
+type Stats struct {
+	Delivered    uint64
+	Retried      uint64
+	DeadLettered uint64
+}
+
+// Forwarder POSTs event batches to every configured target. Batches that exhaust
+// their retry budget are written to the dead-letter directory and treated as
+// handled, so one broken endpoint never stalls the event bus.
+type Forwarder struct {
+	opts   Options
+	client *http.Client
+	dlq    *DeadLetterQueue
+	log    *zerolog.Logger
+	now    func() time.Time
+	sleep  func(context.Context, time.Duration) error
+
+	delivered    atomic.Uint64
+	retried      atomic.Uint64
+	deadLettered atomic.Uint64
+}
+
+// New validates opts and creates a Forwarder.
+func New(opts Options, log *zerolog.Logger) (*Forwarder, error) {
+	if len(opts.Targets) == 0 {
+		return nil, ErrNoTargets
+	}
+
+	seen := make(map[string]bool, len(opts.Targets))
+
+	for _, target := range opts.Targets {
+		switch {
+		case target.Name == "":
+			return nil, ErrTargetNameRequired
+		case target.URL == "":
+			return nil, fmt.Errorf("%w: %s", ErrTargetURLRequired, target.Name)
+		case seen[target.Name]:
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateTarget, target.Name)
+		}
+
+		seen[target.Name] = true
+	}
+
+	dlq, err := OpenDeadLetterQueue(opts.DeadLetterDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = DefaultInitialBackoff
+	}
+
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: opts.Timeout}
+	}
+
+	return &Forwarder{
+		opts:   opts,
+		client: client,
+		dlq:    dlq,
+		log:    log,
+		now:    time.Now,
+		sleep:  sleepContext,
+	}, nil
+}
+
+// DeadLetters returns the forwarder's dead-letter queue.
+func (f *Forwarder) DeadLetters() *DeadLetterQueue {
+	return f.dlq
+}
+
+// Stats returns delivery counters.
+func (f *Forwarder) Stats() Stats {
+	return Stats{
+		Delivered:    f.delivered.Load(),
+		Retried:      f.retried.Load(),
+		DeadLettered: f.deadLettered.Load(),
+	}
+}
+
+// HandleMessage implements ports.Subscriber. Jobs are ignored.
+func (f *Forwarder) HandleMessage(ctx context.Context, msg ports.Message) error {
+	return f.HandleBatch(ctx, []ports.Message{msg})
+}
+
+// HandleBatch implements ports.BatchSubscriber.
+func (f *Forwarder) HandleBatch(ctx context.Context, msgs []ports.Message) error {
+	events := make([]ports.Event, 0, len(msgs))
+
+	for _, msg := range msgs {
+		if msg.Event != nil {
+			events = append(events, *msg.Event)
+		}
+	}
+
+	return f.Forward(ctx, events)
+}
+
+// Forward sends events to every target as one batch. It only returns an error if
+// the context is cancelled or a failed batch cannot be dead-lettered.
+func (f *Forwarder) Forward(ctx context.Context, events []ports.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	payload := NewPayload(events, f.now())
+
+	for _, target := range f.opts.Targets {
+		attempts, err := f.send(ctx, target, payload)
+		if err == nil {
+			f.delivered.Add(uint64(len(events)))
+
+			continue
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		path, dlqErr := f.dlq.Write(DeadLetter{
+			Target:         target.Name,
+			URL:            target.URL,
+			IdempotencyKey: payload.IdempotencyKey,
+			FailedAt:       f.now().UTC(),
+			Attempts:       attempts,
+			LastError:      err.Error(),
+			Events:         events,
+		})
+		if dlqErr != nil {
+			return fmt.Errorf("failed to dead-letter batch for %s: %w", target.Name, dlqErr)
+		}
+
+		f.deadLettered.Add(uint64(len(events)))
+		f.log.Error().
+			Err(err).
+			Str("target", target.Name).
+			Str("idempotency_key", payload.IdempotencyKey).
+			Int("attempts", attempts).
+			Str("path", path).
+			Msg("Webhook delivery failed, batch dead-lettered")
+	}
+
+	return nil
+}
+
+// Close implements ports.EventSink. The forwarder holds no open resources.
+func (f *Forwarder) Close() error {
+	return nil
+}
+
+// send POSTs payload to target, retrying transient failures with exponential
+// backoff. It returns the number of attempts made.
+func (f *Forwarder) send(ctx context.Context, target Target, payload Payload) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	var lastErr error
+
+	for attempt := 1; attempt <= f.opts.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			f.retried.Add(1)
+
+			if err := f.sleep(ctx, f.backoff(attempt-1, lastErr)); err != nil {
+				return attempt - 1, err
+			}
+		}
+
+		retry, err := f.post(ctx, target, payload.IdempotencyKey, body)
+		if err == nil {
+			return attempt, nil
+		}
+
+		lastErr = err
+
+		if !retry || ctx.Err() != nil || attempt == f.opts.MaxAttempts {
+			return attempt, err
+		}
+
+		f.log.Warn().Err(err).Str("target", target.Name).Int("attempt", attempt).Msg("Webhook delivery failed, retrying")
+	}
+
+	return f.opts.MaxAttempts, lastErr
+}
+
+// post performs one request and reports whether a failure is worth retrying.
+func (f *Forwarder) post(ctx context.Context, target Target, key string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(f.now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderIdempotencyKey, key)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSchemaVersion, schema.Version)
+
+	if target.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(target.Secret, timestamp, body))
+	}
+
+	for name, value := range target.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	statusErr := &StatusError{StatusCode: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+
+	return retryableStatus(resp.StatusCode), statusErr
+}
+
+// backoff returns the wait before retry n (1-based): InitialBackoff doubled per
+// retry with up to 20% jitter, capped at MaxBackoff. A server Retry-After wins
+// if it is longer.
+func (f *Forwarder) backoff(n int, lastErr error) time.Duration {
+	wait := f.opts.InitialBackoff << min(n-1, 30)
+	if wait <= 0 || wait > f.opts.MaxBackoff {
+		wait = f.opts.MaxBackoff
+	}
+
+	//nolint:gosec // Jitter does not need a cryptographic source
+	wait -= time.Duration(rand.Int64N(int64(wait)/5 + 1))
+
+	var statusErr *StatusError
+	if errors.As(lastErr, &statusErr) && statusErr.RetryAfter > wait {
+		wait = min(statusErr.RetryAfter, f.opts.MaxBackoff)
+	}
+
+	return wait
+}
+
+// StatusError reports a non-2xx webhook response.
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %d %s", ErrUnexpectedStatus, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Unwrap lets errors.Is match ErrUnexpectedStatus.
+func (e *StatusError) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+// retryableStatus reports whether a response status is transient. Other 4xx
+// responses mean the request itself is wrong and will never succeed.
+func retryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+
+	return 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// NewPayload builds the request body for events. The batch idempotency key is
+// derived from the event keys, so the same events always produce the same key,
+// including when a dead-lettered batch is re-driven.
+func NewPayload(events []ports.Event, sentAt time.Time) Payload {
+	records := make([]PayloadRecord, len(events))
+	keys := make([]string, len(events))
+
+	for i, event := range events {
+		keys[i] = event.Key()
+		records[i] = PayloadRecord{
+			IdempotencyKey: keys[i],
+			Kind:           event.Kind(),
+			SchemaVersion:  schema.Version,
+			Event:          event,
+		}
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(keys, "\n")))
+
+	return Payload{
+		IdempotencyKey: hex.EncodeToString(sum[:]),
+		SentAt:         sentAt.UTC(),
+		Events:         records,
+	}
+}
+
+// Sign returns the signature header value for a request: the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with secret, prefixed with "sha256=".
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header produced by Sign in constant time.
+// Receivers should also reject stale timestamps to prevent replays.
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Ensure Forwarder implements the ports.EventSink and ports.BatchSubscriber interfaces.
+var (
+	_ ports.EventSink       = (*Forwarder)(nil)
+	_ ports.BatchSubscriber = (*Forwarder)(nil)
+)
@@ -0,0 +1,320 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"github.com/tcncloud/sati-go/pkg/schema"
+)
+
+// testServer answers with the queued status codes, then 200, and records requests.
+type testServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newTestServer(t *testing.T, statuses ...int) *testServer {
+	t.Helper()
+
+	s := &testServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, body)
+
+		status := http.StatusOK
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		s.mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *testServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.requests)
+}
+
+func newTestForwarder(t *testing.T, opts Options) (*Forwarder, *[]time.Duration) {
+	t.Helper()
+
+	logger := zerolog.Nop()
+
+	if opts.DeadLetterDir == "" {
+		opts.DeadLetterDir = t.TempDir()
+	}
+
+	f, err := New(opts, &logger)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	var waits []time.Duration
+
+	f.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+
+		return nil
+	}
+
+	return f, &waits
+}
+
+func sampleEvents() []ports.Event {
+	return []ports.Event{
+		{Type: ports.EventTypeAgentCall, AgentCall: &ports.ExileAgentCall{AgentCallSid: 7, CallSid: 100}},
+		{Type: ports.EventTypeTelephonyResult, Telephony: &ports.ExileTelephonyResult{CallSid: 100, CallType: "outbound"}},
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	logger := zerolog.Nop()
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+		opts Options
+		want error
+	}{
+		{"no targets", Options{DeadLetterDir: dir}, ErrNoTargets},
+		{"no name", Options{Targets: []Target{{URL: "http://x"}}, DeadLetterDir: dir}, ErrTargetNameRequired},
+		{"no url", Options{Targets: []Target{{Name: "a"}}, DeadLetterDir: dir}, ErrTargetURLRequired},
+		{"duplicate", Options{Targets: []Target{{Name: "a", URL: "http://x"}, {Name: "a", URL: "http://y"}}, DeadLetterDir: dir}, ErrDuplicateTarget},
+		{"no dead-letter dir", Options{Targets: []Target{{Name: "a", URL: "http://x"}}}, ErrDeadLetterDirRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.opts, &logger); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestForwarder_SignsBatches(t *testing.T) {
+	server := newTestServer(t)
+	f, _ := newTestForwarder(t, Options{Targets: []Target{{Name: "crm", URL: server.URL, Secret: "s3cret", Headers: map[string]string{"X-Team": "ops"}}}})
+
+	msgs := []ports.Message{{Event: &sampleEvents()[0]}, {Event: &sampleEvents()[1]}, {Job: &ports.Job{JobID: "job"}}}
+	if err := f.HandleBatch(context.Background(), msgs); err != nil {
+		t.Fatalf("HandleBatch failed: %v", err)
+	}
+
+	if server.count() != 1 {
+		t.Fatalf("Expected one request, got %d", server.count())
+	}
+
+	req, body := server.requests[0], server.bodies[0]
+
+	if !Verify("s3cret", req.Header.Get(HeaderTimestamp), body, req.Header.Get(HeaderSignature)) {
+		t.Error("Expected a valid signature")
+	}
+
+	if req.Header.Get("X-Team") != "ops" {
+		t.Errorf("Expected custom header, got %q", req.Header.Get("X-Team"))
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+
+	if len(payload.Events) != 2 || payload.Events[0].IdempotencyKey != "agent_call/7" || payload.Events[1].IdempotencyKey != "telephony_result/outbound/100" {
+		t.Errorf("Unexpected payload events: %+v", payload.Events)
+	}
+
+	if req.Header.Get(HeaderSchemaVersion) != schema.Version || len(payload.Events) == 0 || payload.Events[0].SchemaVersion != schema.Version {
+		t.Errorf("Expected schema version %s on the request and every record, got header %q",
+			schema.Version, req.Header.Get(HeaderSchemaVersion))
+	}
+
+	if req.Header.Get(HeaderIdempotencyKey) != payload.IdempotencyKey || payload.IdempotencyKey != NewPayload(sampleEvents(), time.Now()).IdempotencyKey {
+		t.Errorf("Expected a stable batch idempotency key, got %q", payload.IdempotencyKey)
+	}
+
+	if stats := f.Stats(); stats.Delivered != 2 {
+		t.Errorf("Expected 2 delivered events, got %+v", stats)
+	}
+}
+
+func TestForwarder_RetriesWithBackoff(t *testing.T) {
+	server := newTestServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	f, waits := newTestForwarder(t, Options{
+		Targets:        []Target{{Name: "crm", URL: server.URL}},
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	})
+
+	if err := f.Forward(context.Background(), sampleEvents()); err != nil {
+		t.Fatalf("Forward failed: %v", err)
+	}
+
+	if server.count() != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", server.count())
+	}
+
+	if len(*waits) != 2 || (*waits)[0] > 100*time.Millisecond || (*waits)[1] <= 100*time.Millisecond || (*waits)[1] > 200*time.Millisecond {
+		t.Errorf("Expected exponential backoff, got %v", *waits)
+	}
+
+	if paths, _ := f.DeadLetters().List(); len(paths) != 0 {
+		t.Errorf("Expected no dead letters, got %v", paths)
+	}
+}
+
+func TestForwarder_DeadLettersAndRedrives(t *testing.T) {
+	server := newTestServer(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadRequest)
+	f, _ := newTestForwarder(t, Options{Targets: []Target{{Name: "crm", URL: server.URL, Secret: "s3cret"}}, MaxAttempts: 2})
+
+	// Two 502s exhaust the budget.
+	if err := f.Forward(context.Background(), sampleEvents()); err != nil {
+		t.Fatalf("Forward failed: %v", err)
+	}
+
+	// A 400 is permanent and dead-letters without retrying.
+	if err := f.Forward(context.Background(), sampleEvents()[:1]); err != nil {
+		t.Fatalf("Forward failed: %v", err)
+	}
+
+	if server.count() != 3 {
+		t.Errorf("Expected 3 requests, got %d", server.count())
+	}
+
+	paths, err := f.DeadLetters().List()
+	if err != nil || len(paths) != 2 {
+		t.Fatalf("Expected 2 dead letters, got %v (%v)", paths, err)
+	}
+
+	dl, err := f.DeadLetters().Read(paths[0])
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if dl.Target != "crm" || dl.Attempts != 2 || len(dl.Events) != 2 || dl.LastError == "" {
+		t.Errorf("Unexpected dead letter: %+v", dl)
+	}
+
+	if stats := f.Stats(); stats.DeadLettered != 3 || stats.Retried != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	result, err := f.Redrive(context.Background())
+	if err != nil {
+		t.Fatalf("Redrive failed: %v", err)
+	}
+
+	if result.Delivered != 2 || result.Failed != 0 {
+		t.Errorf("Unexpected redrive result: %+v", result)
+	}
+
+	if paths, _ := f.DeadLetters().List(); len(paths) != 0 {
+		t.Errorf("Expected dead letters to be removed, got %v", paths)
+	}
+}
+
+func TestForwarder_RedriveKeepsFailures(t *testing.T) {
+	server := newTestServer(t, http.StatusBadRequest, http.StatusInternalServerError)
+	dir := t.TempDir()
+	f, _ := newTestForwarder(t, Options{Targets: []Target{{Name: "crm", URL: server.URL}}, DeadLetterDir: dir, MaxAttempts: 1})
+
+	if err := f.Forward(context.Background(), sampleEvents()); err != nil {
+		t.Fatalf("Forward failed: %v", err)
+	}
+
+	if _, err := f.DeadLetters().Write(DeadLetter{Target: "gone", FailedAt: time.Now(), Events: sampleEvents()}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	result, err := f.Redrive(context.Background())
+	if err != nil {
+		t.Fatalf("Redrive failed: %v", err)
+	}
+
+	if result.Failed != 1 || result.Skipped != 1 || result.Delivered != 0 {
+		t.Errorf("Unexpected redrive result: %+v", result)
+	}
+
+	paths, _ := f.DeadLetters().List()
+	if len(paths) != 2 {
+		t.Fatalf("Expected both dead letters to remain, got %v", paths)
+	}
+
+	dl, err := f.DeadLetters().Read(paths[0])
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if dl.Attempts != 2 {
+		t.Errorf("Expected attempts to accumulate, got %d", dl.Attempts)
+	}
+}
+
+func TestForwarder_StopsOnCancel(t *testing.T) {
+	server := newTestServer(t, http.StatusServiceUnavailable)
+	f, _ := newTestForwarder(t, Options{Targets: []Target{{Name: "crm", URL: server.URL}}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.sleep = func(ctx context.Context, _ time.Duration) error {
+		cancel()
+
+		return ctx.Err()
+	}
+
+	if err := f.Forward(ctx, sampleEvents()); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	if paths, _ := f.DeadLetters().List(); len(paths) != 0 {
+		t.Errorf("Expected cancelled batch not to be dead-lettered, got %v", paths)
+	}
+}
+
+func TestNewPayload_KeysFromSIDs(t *testing.T) {
+	events := []ports.Event{
+		{AgentResponse: &ports.ExileAgentResponse{AgentCallResponseSid: 9}},
+		{TransferInstance: &ports.ExileTransferInstance{TransferInstanceID: "ti-1"}},
+	}
+
+	payload := NewPayload(events, time.Now())
+
+	for i, want := range []string{"agent_response/9", "transfer_instance/ti-1"} {
+		if got := payload.Events[i].IdempotencyKey; got != want {
+			t.Errorf("Event %d key = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestVerify_RejectsTampering(t *testing.T) {
+	signature := Sign("secret", "1700000000", []byte(`{"a":1}`))
+
+	if Verify("secret", "1700000000", []byte(`{"a":2}`), signature) {
+		t.Error("Expected modified body to fail verification")
+	}
+
+	if Verify("other", "1700000000", []byte(`{"a":1}`), signature) {
+		t.Error("Expected wrong secret to fail verification")
+	}
+}
@@ -47,13 +47,13 @@ func AddAgentCallResponseCmd(configPath *string) *cobra.Command {
 			if err != nil {
 				return err
 			}
-			client, err := saticlient.NewClient(cfg)
+			client, err := saticlient.NewClient(cfg, clientOptions(cfg)...)
 			if err != nil {
 				return err
 			}
 			defer handleClientClose(client)
 
-			ctx, cancel := createContext(DefaultTimeout)
+			ctx, cancel := createContext()
 			defer cancel()
 
 			// Convert callSid from string to int64
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tcncloud/sati-go/pkg/domain"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// AgentsCmd groups reports about agents built from captured events.
+func AgentsCmd(configPath *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "agents",
+		Short: "Report on agents from captured events",
+	}
+
+	makeConfigOptional(cmd, configPath)
+
+	cmd.AddCommand(AgentsKPICmd())
+
+	return cmd
+}
+
+// kpiColumns is the CSV header of the KPI report.
+var kpiColumns = []string{
+	"partner_agent_id", "bucket", "start", "calls",
+	"talk_total", "talk_avg", "call_wait_avg", "wrap_up_avg", "pause_avg", "hold_avg",
+	"transfer_avg", "preview_avg", "manual_avg",
+	"handle_total", "handle_avg", "handle_p50", "handle_p90", "handle_p95", "handle_p99",
+}
+
+// AgentsKPICmd rolls agent call durations up per agent and time bucket.
+func AgentsKPICmd() *cobra.Command {
+	var (
+		eventsPath string
+		bucket     string
+		agent      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "kpi",
+		Short: "Per-agent talk, wait, wrap-up and handle-time KPIs per time bucket",
+		Long: `Roll the durations of captured agent call events up per partner agent and time
+bucket. Handle time is talk + hold + wrap-up; durations are in seconds. The events
+file may be a poll-events --sink journal (.jsonl or .jsonl.gz) or the JSON printed
+by poll-events -o json.`,
+		Example: `  sati agents kpi --events ./events/events-20261018T120000.000000000Z.jsonl --bucket 1h
+  sati agents kpi --events events.json --bucket 1d --agent AGENT_ID -o csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if eventsPath == "" {
+				return ErrEventsFileRequired
+			}
+
+			width, err := domain.ParseKPIBucket(bucket)
+			if err != nil {
+				return err
+			}
+
+			_, events, err := readCapturedEvents(eventsPath)
+			if err != nil {
+				return err
+			}
+
+			kpis, err := domain.AggregateKPIs(events, width)
+			if err != nil {
+				return err
+			}
+
+			if agent != "" {
+				filtered := kpis[:0]
+				for _, kpi := range kpis {
+					if kpi.PartnerAgentID == agent {
+						filtered = append(filtered, kpi)
+					}
+				}
+
+				kpis = filtered
+			}
+
+			switch OutputFormat {
+			case OutputFormatJSON:
+				return outputJSON(kpis)
+			case OutputFormatCSV:
+				return writeKPIsCSV(kpis)
+			}
+
+			if len(kpis) == 0 {
+				fmt.Println("No agent calls found")
+
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "AGENT\tSTART\tCALLS\tTALK AVG\tWAIT AVG\tWRAP AVG\tHOLD AVG\tAHT\tP50\tP90\tP99")
+
+			for _, kpi := range kpis {
+				fmt.Fprintf(w, "%s\t%s\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%.0f\t%.0f\t%.0f\n",
+					kpi.PartnerAgentID, kpi.Start.Format(time.RFC3339), kpi.Calls,
+					kpi.Averages.Talk, kpi.Averages.CallWait, kpi.Averages.WrapUp, kpi.Averages.Hold,
+					kpi.Averages.HandleTime, kpi.HandleTimeP50, kpi.HandleTimeP90, kpi.HandleTimeP99)
+			}
+
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&eventsPath, "events", "", "Captured events file (required)")
+	cmd.Flags().StringVar(&bucket, "bucket", "1h", "Bucket width: 15m, 1h or 1d")
+	cmd.Flags().StringVar(&agent, "agent", "", "Only report this partner agent ID")
+	markFlagRequired(cmd, "events")
+
+	return cmd
+}
+
+// writeKPIsCSV prints KPIs as CSV with a header row.
+func writeKPIsCSV(kpis []ports.AgentKPI) error {
+	w := csv.NewWriter(os.Stdout)
+
+	if err := w.Write(kpiColumns); err != nil {
+		return err
+	}
+
+	num := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	for _, kpi := range kpis {
+		row := []string{
+			kpi.PartnerAgentID, kpi.Bucket, kpi.Start.Format(time.RFC3339), strconv.Itoa(kpi.Calls),
+			num(kpi.Totals.Talk), num(kpi.Averages.Talk), num(kpi.Averages.CallWait), num(kpi.Averages.WrapUp),
+			num(kpi.Averages.Pause), num(kpi.Averages.Hold), num(kpi.Averages.Transfer), num(kpi.Averages.Preview),
+			num(kpi.Averages.Manual), num(kpi.Totals.HandleTime), num(kpi.Averages.HandleTime),
+			num(kpi.HandleTimeP50), num(kpi.HandleTimeP90), num(kpi.HandleTimeP95), num(kpi.HandleTimeP99),
+		}
+
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/tcncloud/sati-go/pkg/adapters/automation"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// AutomationCmd groups commands that work with automation rules files.
+func AutomationCmd(configPath *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "automation",
+		Short: "Validate and dry-run event-driven automation rules",
+	}
+
+	makeConfigOptional(cmd, configPath)
+
+	cmd.AddCommand(AutomationTestCmd())
+
+	return cmd
+}
+
+// AutomationTestCmd dry-runs an automation rules file against captured events.
+func AutomationTestCmd() *cobra.Command {
+	var (
+		rulesPath  string
+		eventsPath string
+		auditPath  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Report the gate actions automation rules would take for captured events",
+		Long: `Compile an automation rules file and dry-run it against captured events: rules
+and rate limits are evaluated as in the daemon, but no gate method is called.
+The events file may be a poll-events --sink journal (.jsonl or .jsonl.gz) or the
+JSON printed by poll-events -o json. Rate limits are applied as if every event
+arrived at once.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if rulesPath == "" {
+				return ErrRulesFileRequired
+			}
+
+			if eventsPath == "" {
+				return ErrEventsFileRequired
+			}
+
+			rules, err := automation.Load(rulesPath)
+			if err != nil {
+				return err
+			}
+
+			ids, events, err := readCapturedEvents(eventsPath)
+			if err != nil {
+				return err
+			}
+
+			logger := zerolog.Nop()
+
+			engine, err := automation.New(
+				rules,
+				func() ports.ClientInterface { return nil },
+				automation.Options{DryRun: true, AuditPath: auditPath},
+				&logger,
+			)
+			if err != nil {
+				return err
+			}
+			defer engine.Close()
+
+			records := []automation.AuditRecord{}
+			for i, event := range events {
+				records = append(records, engine.Process(context.Background(), ids[i], event)...)
+			}
+
+			if OutputFormat == OutputFormatJSON {
+				return outputJSON(records)
+			}
+
+			counts := make(map[string]int)
+
+			for _, rec := range records {
+				counts[rec.Rule+" "+rec.Outcome]++
+
+				fmt.Printf("%-12s %-24s %-24s %-40s %s\n", rec.Outcome, rec.Rule, rec.Action, rec.EventKey, formatParams(rec.Params))
+			}
+
+			names := make([]string, 0, len(counts))
+			for name := range counts {
+				names = append(names, name)
+			}
+
+			sort.Strings(names)
+
+			fmt.Printf("\nTotals (%d events, %d actions):\n", len(events), len(records))
+
+			for _, name := range names {
+				fmt.Printf("  %-40s %d\n", name, counts[name])
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&rulesPath, "rules", "", "Automation rules file (YAML or JSON) (required)")
+	cmd.Flags().StringVar(&eventsPath, "events", "", "Captured events file (required)")
+	cmd.Flags().StringVar(&auditPath, "audit", "", "Append the dry-run audit records to this JSON lines file")
+	markFlagRequired(cmd, "rules")
+	markFlagRequired(cmd, "events")
+
+	return cmd
+}
+
+// formatParams renders action parameters as sorted key=value pairs, omitting empty ones.
+func formatParams(params map[string]string) string {
+	names := make([]string, 0, len(params))
+	for name, value := range params {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + params[name]
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tcncloud/sati-go/pkg/domain"
+)
+
+// CallsCmd groups commands that work with correlated call records.
+func CallsCmd(configPath *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "calls",
+		Short: "Join captured events into one record per call",
+	}
+
+	makeConfigOptional(cmd, configPath)
+
+	cmd.AddCommand(CallsCorrelateCmd())
+
+	return cmd
+}
+
+// CallsCorrelateCmd joins captured events into call records.
+func CallsCorrelateCmd() *cobra.Command {
+	var (
+		eventsPath string
+		callSid    int64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "correlate",
+		Short: "Print one record per call with its timeline, agents, dispositions and transfers",
+		Long: `Join the telephony results, agent calls, agent responses and transfer instances
+in a captured events file by call SID. The events file may be a poll-events --sink
+journal (.jsonl or .jsonl.gz) or the JSON printed by poll-events -o json.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if eventsPath == "" {
+				return ErrEventsFileRequired
+			}
+
+			_, events, err := readCapturedEvents(eventsPath)
+			if err != nil {
+				return err
+			}
+
+			records := domain.CorrelateEvents(events)
+
+			if callSid != 0 {
+				filtered := records[:0]
+				for _, rec := range records {
+					if rec.CallSid == callSid {
+						filtered = append(filtered, rec)
+					}
+				}
+
+				records = filtered
+			}
+
+			if OutputFormat == OutputFormatJSON {
+				return outputJSON(records)
+			}
+
+			if len(records) == 0 {
+				fmt.Println("No calls found")
+
+				return nil
+			}
+
+			for _, rec := range records {
+				fmt.Printf("Call %d (%s) org=%s events=%d\n", rec.CallSid, rec.CallType, rec.OrgID, rec.Events)
+
+				if t := rec.Telephony; t != nil {
+					fmt.Printf("  Result: %s (%s)\n", t.Result, t.Status)
+				}
+
+				if len(rec.Agents) > 0 {
+					fmt.Printf("  Agents: %s\n", strings.Join(rec.Agents, ", "))
+				}
+
+				for _, d := range rec.Dispositions {
+					fmt.Printf("  Disposition: %s=%s (agent %s)\n", d.Key, d.Value, d.PartnerAgentID)
+				}
+
+				if len(rec.Timeline) > 0 {
+					fmt.Println("  Timeline:")
+
+					for _, entry := range rec.Timeline {
+						fmt.Printf("    %s  %-17s %s\n", entry.Time, entry.Kind, entry.Description)
+					}
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&eventsPath, "events", "", "Captured events file (required)")
+	cmd.Flags().Int64Var(&callSid, "call-sid", 0, "Only print this call")
+	markFlagRequired(cmd, "events")
+
+	return cmd
+}
@@ -53,8 +53,10 @@ var (
 // Common constants.
 const (
 	OutputFormatJSON = "json"
-	DefaultTimeout   = 10 * time.Second
-	LongTimeout      = 30 * time.Second
+	OutputFormatCSV  = "csv"
+	// StoreTimeout bounds event store queries. Gate calls are bounded by the
+	// client, with the deadlines of the config's transport section.
+	StoreTimeout = 30 * time.Second
 )
 
 // createClient creates a new client with proper error handling.
@@ -64,7 +66,7 @@ func createClient(configPath *string) (ports.ClientInterface, error) {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	client, err := saticlient.NewClient(cfg)
+	client, err := saticlient.NewClient(cfg, clientOptions(cfg)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client: %w", err)
 	}
@@ -72,9 +74,10 @@ func createClient(configPath *string) (ports.ClientInterface, error) {
 	return client, nil
 }
 
-// createContext creates a context with timeout.
-func createContext(timeout time.Duration) (context.Context, context.CancelFunc) {
-	return context.WithTimeout(context.Background(), timeout)
+// createContext creates the context of a gate call. The client sets the
+// call's deadline.
+func createContext() (context.Context, context.CancelFunc) {
+	return context.WithCancel(context.Background())
 }
 
 // handleClientClose handles client.Close() with error checking.
@@ -99,6 +102,12 @@ func markPersistentFlagRequired(cmd *cobra.Command, flagName string) {
 	}
 }
 
+// makeConfigOptional shadows the root --config flag with an optional one for
+// commands that only work on local files and never contact the gate.
+func makeConfigOptional(cmd *cobra.Command, configPath *string) {
+	cmd.PersistentFlags().StringVarP(configPath, "config", "c", "", "Path to base64-encoded JSON config file (unused)")
+}
+
 // outputJSON outputs data in JSON format.
 func outputJSON(data interface{}) error {
 	jsonData, err := json.MarshalIndent(data, "", "  ")
@@ -130,7 +139,7 @@ func createSkillCommand(use, short string, configPath *string, partnerAgentID, s
 			}
 			defer handleClientClose(client)
 
-			ctx, cancel := createContext(DefaultTimeout)
+			ctx, cancel := createContext()
 			defer cancel()
 
 			params := ports.AssignAgentSkillParams{
@@ -25,13 +25,13 @@ func DialCmd(configPath *string) *cobra.Command {
 			}
 
 			// Use the new client constructor
-			client, err := saticlient.NewClient(cfg)
+			client, err := saticlient.NewClient(cfg, clientOptions(cfg)...)
 			if err != nil {
 				return err
 			}
 			defer handleClientClose(client)
 
-			ctx, cancel := createContext(DefaultTimeout)
+			ctx, cancel := createContext()
 			defer cancel()
 
 			// Build the custom Params struct
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/tcncloud/sati-go/pkg/adapters/sqlstore"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// ErrDSNRequired is returned when no event store is given.
+var ErrDSNRequired = errors.New("--dsn is required")
+
+// EventsCmd groups commands that work on locally stored events.
+func EventsCmd(configPath *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Work with events persisted by the SQL event store",
+	}
+
+	makeConfigOptional(cmd, configPath)
+
+	cmd.AddCommand(EventsQueryCmd())
+
+	return cmd
+}
+
+// EventsQueryCmd searches the SQL event store.
+func EventsQueryCmd() *cobra.Command {
+	var (
+		dialect        string
+		dsn            string
+		callSid        int64
+		partnerAgentID string
+		callType       string
+		since          string
+		until          string
+		kinds          []string
+		limit          int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "query",
+		Short: "Query events stored by the SQL event store",
+		Example: `  sati events query --dsn events.db --call-sid 12345
+  sati events query --dialect postgres --dsn postgres://sati@db/events --agent agent-1 --since 24h`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dsn == "" {
+				return ErrDSNRequired
+			}
+
+			filter := sqlstore.Filter{
+				CallSid:        callSid,
+				PartnerAgentID: partnerAgentID,
+				CallType:       callType,
+				Kinds:          kinds,
+				Limit:          limit,
+			}
+
+			var err error
+			if filter.Since, err = parseTimeFlag("since", since); err != nil {
+				return err
+			}
+
+			if filter.Until, err = parseTimeFlag("until", until); err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), StoreTimeout)
+			defer cancel()
+
+			store, err := openEventStore(ctx, dialect, dsn)
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			events, err := store.Query(ctx, filter)
+			if err != nil {
+				return err
+			}
+
+			if OutputFormat == OutputFormatJSON {
+				return outputJSON(events)
+			}
+
+			printEvents(events)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dialect, "dialect", string(sqlstore.DialectSQLite), "Database dialect: sqlite or postgres")
+	cmd.Flags().StringVar(&dsn, "dsn", "", "Database DSN, e.g. a SQLite file path or postgres:// URL (required)")
+	cmd.Flags().Int64Var(&callSid, "call-sid", 0, "Only events for this call SID")
+	cmd.Flags().StringVar(&partnerAgentID, "agent", "", "Only events for this partner agent ID")
+	cmd.Flags().StringVar(&callType, "call-type", "", "Only events with this call type")
+	cmd.Flags().StringVar(&since, "since", "", "Only events created at or after this RFC 3339 time or duration ago (e.g. 24h)")
+	cmd.Flags().StringVar(&until, "until", "", "Only events created before this RFC 3339 time or duration ago")
+	cmd.Flags().StringSliceVar(&kinds, "kind", nil, "Only these event kinds: telephony_result, agent_call, agent_response, transfer_instance")
+	cmd.Flags().IntVar(&limit, "limit", 100, "Maximum number of events to print (0 for no limit)")
+	markFlagRequired(cmd, "dsn")
+
+	return cmd
+}
+
+// parseTimeFlag accepts an RFC 3339 timestamp or a duration relative to now.
+func parseTimeFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s %q: expected RFC 3339 time or duration", name, value)
+	}
+
+	return time.Now().Add(-d), nil
+}
+
+func printEvents(events []ports.Event) {
+	if len(events) == 0 {
+		fmt.Println("No events found")
+
+		return
+	}
+
+	for _, event := range events {
+		timestamp, details := eventSummary(event)
+		if details == "" {
+			continue
+		}
+
+		fmt.Printf("%s  %-17s %s\n", timestamp, event.Kind(), details)
+	}
+}
+
+// eventSummary returns an event's create time and a one-line description of it.
+func eventSummary(event ports.Event) (string, string) {
+	switch {
+	case event.Telephony != nil:
+		t := event.Telephony
+
+		return t.CreateTime, fmt.Sprintf("call=%d type=%s status=%s result=%s", t.CallSid, t.CallType, t.Status, t.Result)
+	case event.AgentCall != nil:
+		c := event.AgentCall
+
+		return c.CreateTime, fmt.Sprintf("call=%d type=%s agent=%s agent_call=%d talk=%ds",
+			c.CallSid, c.CallType, c.PartnerAgentID, c.AgentCallSid, c.TalkDuration)
+	case event.AgentResponse != nil:
+		r := event.AgentResponse
+
+		return r.CreateTime, fmt.Sprintf("call=%d type=%s agent=%s %s=%s",
+			r.CallSid, r.CallType, r.PartnerAgentID, r.ResponseKey, r.ResponseValue)
+	case event.TransferInstance != nil:
+		ti := event.TransferInstance
+
+		return ti.CreateTime, fmt.Sprintf("call=%d type=%s agent=%s id=%s destination=%s result=%s",
+			ti.SourceCallSid, ti.SourceCallType, ti.SourcePartnerAgentID, ti.TransferInstanceID, ti.DestinationType, ti.TransferResult)
+	default:
+		return "", ""
+	}
+}
+
+// openEventStore opens the SQL event store, applying any pending migrations.
+func openEventStore(ctx context.Context, dialect, dsn string) (*sqlstore.Store, error) {
+	parsedDialect, err := sqlstore.ParseDialect(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := zerolog.New(os.Stderr).With().Timestamp().Logger().Level(zerolog.WarnLevel)
+
+	return sqlstore.Open(ctx, sqlstore.Options{Dialect: parsedDialect, DSN: dsn}, &logger)
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tcncloud/sati-go/pkg/sati/gateerr"
+)
+
+// Exit codes. Gate errors exit with the code of their gateerr kind, every
+// other error with ExitFailure.
+const (
+	ExitFailure                    = 1
+	ExitInvalidArgument            = 2
+	ExitNotFound                   = 3
+	ExitAlreadyExists              = 4
+	ExitPermissionDenied           = 5
+	ExitUnauthenticated            = 6
+	ExitCertificateRejected        = 7
+	ExitUnavailable                = 8
+	ExitDeadlineExceeded           = 9
+	ExitResourceExhausted          = 10
+	ExitFailedPrecondition         = 11
+	ExitGateError                  = 12
+	ExitServerPinMismatch          = 13
+	ExitServerCertificateUntrusted = 14
+	ExitCanceled                   = 130
+)
+
+// errorClass describes how the CLI reports one gateerr kind.
+type errorClass struct {
+	kind    error
+	code    int
+	summary string
+	hint    string
+}
+
+// errorClasses is checked in order, so ErrCertificateRejected comes before
+// the ErrUnauthenticated it wraps.
+var errorClasses = []errorClass{
+	{gateerr.ErrInvalidArgument, ExitInvalidArgument, "Invalid request", "Check the command's flags."},
+	{gateerr.ErrNotFound, ExitNotFound, "Not found", ""},
+	{gateerr.ErrAlreadyExists, ExitAlreadyExists, "Already exists", ""},
+	{gateerr.ErrPermissionDenied, ExitPermissionDenied, "Permission denied", "The organization of this configuration's certificate may not do this."},
+	{gateerr.ErrCertificateRejected, ExitCertificateRejected, "Certificate rejected", "The client certificate may have expired or been revoked. Generate a new configuration on operator.tcn.com or run rotate-certificate."},
+	{gateerr.ErrUnauthenticated, ExitUnauthenticated, "Not authenticated", "Check that --config points at a current configuration file."},
+	{gateerr.ErrServerPinMismatch, ExitServerPinMismatch, "Gate certificate not pinned", "The gate presented a key that matches none of server_pins. A proxy may be intercepting TLS, or the gate's certificate changed; update server_pins only if TCN announced the change."},
+	{gateerr.ErrServerCertificateUntrusted, ExitServerCertificateUntrusted, "Gate certificate not trusted", "The gate's certificate is not signed by the configuration's CA, has expired, or does not name the API endpoint. Check server_name, or whether a proxy is intercepting TLS."},
+	{gateerr.ErrUnavailable, ExitUnavailable, "Gate unavailable", "Check network access to the API endpoint and try again."},
+	{gateerr.ErrDeadlineExceeded, ExitDeadlineExceeded, "Timed out", "The gate did not answer in time. Try again later."},
+	{gateerr.ErrResourceExhausted, ExitResourceExhausted, "Rate limited", "Try again later, or lower the call rate with --rate-limit."},
+	{gateerr.ErrFailedPrecondition, ExitFailedPrecondition, "Request refused", "The gate cannot do this in the current state, e.g. the agent is not logged in."},
+	{context.Canceled, ExitCanceled, "Canceled", ""},
+	{gateerr.ErrInternal, ExitGateError, "Gate error", ""},
+}
+
+// describeError returns the message the CLI prints for err and the code it
+// exits with.
+func describeError(err error) (string, int) {
+	var gateErr *gateerr.Error
+	if !errors.As(err, &gateErr) {
+		return "Error: " + err.Error(), ExitFailure
+	}
+
+	for _, class := range errorClasses {
+		if !errors.Is(gateErr.Kind, class.kind) {
+			continue
+		}
+
+		var b strings.Builder
+
+		b.WriteString(class.summary)
+
+		if gateErr.Method != "" {
+			fmt.Fprintf(&b, " (%s)", gateErr.Method)
+		}
+
+		if gateErr.Message != "" {
+			fmt.Fprintf(&b, ": %s", gateErr.Message)
+		}
+
+		for _, v := range gateErr.Violations {
+			fmt.Fprintf(&b, "\n  %s: %s", v.Field, v.Description)
+		}
+
+		if class.hint != "" {
+			fmt.Fprintf(&b, "\n%s", class.hint)
+		}
+
+		return b.String(), class.code
+	}
+
+	return "Error: " + err.Error(), ExitFailure
+}
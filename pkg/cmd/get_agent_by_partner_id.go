@@ -41,13 +41,13 @@ func GetAgentByPartnerIDCmd(configPath *string) *cobra.Command {
 			}
 
 			// Use the new client constructor
-			client, err := saticlient.NewClient(cfg)
+			client, err := saticlient.NewClient(cfg, clientOptions(cfg)...)
 			if err != nil {
 				return err
 			}
 			defer handleClientClose(client) // Ensure connection is closed
 
-			ctx, cancel := createContext(DefaultTimeout)
+			ctx, cancel := createContext()
 			defer cancel()
 
 			// Build the params struct
@@ -21,13 +21,13 @@ func GetClientConfigCmd(configPath *string) *cobra.Command {
 			}
 
 			// Use the new client constructor
-			client, err := saticlient.NewClient(cfg)
+			client, err := saticlient.NewClient(cfg, clientOptions(cfg)...)
 			if err != nil {
 				return err
 			}
 			defer handleClientClose(client) // Ensure connection is closed
 
-			ctx, cancel := createContext(DefaultTimeout)
+			ctx, cancel := createContext()
 			defer cancel()
 
 			// Build the custom Params struct (empty in this case)
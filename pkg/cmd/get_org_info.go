@@ -36,7 +36,7 @@ func GetOrgInfoCmd(configPath *string) *cobra.Command {
 			}
 
 			// Use the new client constructor
-			client, err := saticlient.NewClient(cfg)
+			client, err := saticlient.NewClient(cfg, clientOptions(cfg)...)
 			if err != nil {
 				fmt.Printf("Error setting up client: %v\n", err)
 
@@ -44,7 +44,7 @@ func GetOrgInfoCmd(configPath *string) *cobra.Command {
 			}
 			defer handleClientClose(client) // Ensure connection is closed
 
-			ctx, cancel := createContext(DefaultTimeout)
+			ctx, cancel := createContext()
 			defer cancel()
 
 			// Build the params struct
@@ -36,13 +36,13 @@ func ListAgentsCmd(configPath *string) *cobra.Command {
 			}
 
 			// Use the new client constructor
-			client, err := saticlient.NewClient(cfg)
+			client, err := saticlient.NewClient(cfg, clientOptions(cfg)...)
 			if err != nil {
 				return err
 			}
 			defer handleClientClose(client) // Ensure connection is closed
 
-			ctx, cancel := createContext(LongTimeout)
+			ctx, cancel := createContext()
 			defer cancel()
 
 			// Build the custom Params struct (empty)
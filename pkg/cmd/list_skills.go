@@ -32,7 +32,7 @@ func ListSkillsCmd(configPath *string) *cobra.Command {
 			}
 			defer handleClientClose(client)
 
-			ctx, cancel := createContext(DefaultTimeout)
+			ctx, cancel := createContext()
 			defer cancel()
 
 			params := ports.ListSkillsParams{}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/tcncloud/sati-go/pkg/adapters/parquetsink"
+)
+
+// ParquetCmd groups commands that work with the Parquet event dataset.
+func ParquetCmd(configPath *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "parquet",
+		Short: "Export captured events to partitioned Parquet files",
+	}
+
+	makeConfigOptional(cmd, configPath)
+
+	cmd.AddCommand(ParquetConvertCmd())
+
+	return cmd
+}
+
+// ParquetConvertCmd converts captured event journals to Parquet.
+func ParquetConvertCmd() *cobra.Command {
+	var (
+		outDir  string
+		maxRows int64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "convert JOURNAL...",
+		Short: "Convert event journals to Parquet files partitioned by org, event kind and date",
+		Long: `Read poll-events --sink journals (.jsonl or .jsonl.gz) or the JSON printed by
+poll-events -o json and write every event as a typed row below --out, in
+org_id=<org>/event_kind=<kind>/date=<YYYY-MM-DD> directories. Each run adds new
+files and never rewrites existing ones, so convert each journal only once.`,
+		Example: `  sati parquet convert --out ./dataset /var/lib/sati/events/*.jsonl.gz`,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger := zerolog.Nop()
+
+			// A conversion completes its files on exit rather than by age.
+			opts := parquetsink.Options{Dir: outDir, MaxRows: maxRows, MaxAge: -1}
+			if maxRows == 0 {
+				opts.MaxRows = -1
+			}
+
+			sink, err := parquetsink.New(opts, &logger)
+			if err != nil {
+				return err
+			}
+
+			total := 0
+
+			for _, path := range args {
+				_, events, err := readCapturedEvents(path)
+				if err != nil {
+					_ = sink.Close()
+
+					return fmt.Errorf("%s: %w", path, err)
+				}
+
+				if err := sink.WriteEvents(events); err != nil {
+					_ = sink.Close()
+
+					return err
+				}
+
+				total += len(events)
+			}
+
+			if err := sink.Close(); err != nil {
+				return err
+			}
+
+			if OutputFormat == OutputFormatJSON {
+				return outputJSON(map[string]any{"events": total, "journals": len(args), "out": outDir})
+			}
+
+			fmt.Printf("Converted %d events from %d journals into %s\n", total, len(args), outDir)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&outDir, "out", "", "Root directory of the Parquet dataset (required)")
+	cmd.Flags().Int64Var(&maxRows, "max-rows", 1_000_000, "Start a new file after this many rows (0 disables)")
+	markFlagRequired(cmd, "out")
+
+	return cmd
+}
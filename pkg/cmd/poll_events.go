@@ -1,33 +1,151 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
+	"github.com/tcncloud/sati-go/pkg/adapters/filesink"
+	"github.com/tcncloud/sati-go/pkg/adapters/natssink"
+	"github.com/tcncloud/sati-go/pkg/adapters/sqlstore"
+	"github.com/tcncloud/sati-go/pkg/adapters/webhook"
+	"github.com/tcncloud/sati-go/pkg/domain"
 	"github.com/tcncloud/sati-go/pkg/ports"
 	saticlient "github.com/tcncloud/sati-go/pkg/sati/client"
 	saticonfig "github.com/tcncloud/sati-go/pkg/sati/config"
 )
 
 func PollEventsCmd(configPath *string) *cobra.Command {
-	return &cobra.Command{
+	var (
+		sinkDir      string
+		sinkMaxBytes int64
+		sinkMaxAge   time.Duration
+		sinkGzip     bool
+		sinkFsync    string
+		storeDSN     string
+		storeDialect string
+		webhookURL   string
+		webhookDLQ   string
+		secretFile   string
+		natsURL      string
+		natsPrefix   string
+		natsStream   string
+		natsCreate   bool
+		dedupState   string
+		dedupWindow  time.Duration
+	)
+
+	cmd := &cobra.Command{
 		Use:   "poll-events",
 		Short: "Call GateService.PollEvents",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			var sink *filesink.Sink
+
+			if sinkDir != "" {
+				syncMode, err := filesink.ParseSyncMode(sinkFsync)
+				if err != nil {
+					return err
+				}
+
+				logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+				sink, err = filesink.New(filesink.Options{
+					Dir:      sinkDir,
+					MaxBytes: sinkMaxBytes,
+					MaxAge:   sinkMaxAge,
+					Compress: sinkGzip,
+					Sync:     syncMode,
+				}, &logger)
+				if err != nil {
+					return err
+				}
+				defer sink.Close()
+			}
+
+			var store *sqlstore.Store
+
+			if storeDSN != "" {
+				ctx, cancel := context.WithTimeout(context.Background(), StoreTimeout)
+				defer cancel()
+
+				var err error
+
+				store, err = openEventStore(ctx, storeDialect, storeDSN)
+				if err != nil {
+					return err
+				}
+				defer store.Close()
+			}
+
+			var forwarder *webhook.Forwarder
+
+			if webhookURL != "" {
+				secret, err := readWebhookSecret(secretFile)
+				if err != nil {
+					return err
+				}
+
+				logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+				forwarder, err = webhook.New(webhook.Options{
+					Targets:       []webhook.Target{{Name: "default", URL: webhookURL, Secret: secret}},
+					DeadLetterDir: webhookDLQ,
+				}, &logger)
+				if err != nil {
+					return err
+				}
+			}
+
+			var publisher *natssink.Publisher
+
+			if natsURL != "" {
+				logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+				var err error
+
+				publisher, err = natssink.New(natssink.Options{
+					URL:           natsURL,
+					SubjectPrefix: natsPrefix,
+					Stream:        natsStream,
+					CreateStream:  natsCreate,
+				}, &logger)
+				if err != nil {
+					return err
+				}
+				defer publisher.Close()
+			}
+
+			var dedup *domain.Deduplicator
+
+			if dedupState != "" {
+				logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+				var err error
+
+				dedup, err = domain.OpenDeduplicator(domain.DedupOptions{Path: dedupState, Window: dedupWindow}, &logger)
+				if err != nil {
+					return err
+				}
+				defer dedup.Close()
+			}
+
 			cfg, err := saticonfig.LoadConfig(*configPath)
 			if err != nil {
 				return err
 			}
 
 			// Use the new client constructor
-			client, err := saticlient.NewClient(cfg)
+			client, err := saticlient.NewClient(cfg, clientOptions(cfg)...)
 			if err != nil {
 				return err
 			}
 			defer handleClientClose(client) // Ensure connection is closed
 
-			ctx, cancel := createContext(DefaultTimeout)
+			ctx, cancel := createContext()
 			defer cancel()
 
 			// Build the params struct
@@ -38,6 +156,46 @@ func PollEventsCmd(configPath *string) *cobra.Command {
 			if err != nil {
 				return err
 			}
+
+			if dedup != nil {
+				resp.Events, err = dedup.Check(resp.Events)
+				if err != nil {
+					return err
+				}
+			}
+
+			if sink != nil {
+				if err := sink.WriteEvents(resp.Events); err != nil {
+					return fmt.Errorf("failed to write events to sink: %w", err)
+				}
+			}
+
+			if store != nil {
+				if err := store.SaveEvents(ctx, resp.Events); err != nil {
+					return fmt.Errorf("failed to save events to store: %w", err)
+				}
+			}
+
+			if forwarder != nil {
+				if err := forwarder.Forward(context.Background(), resp.Events); err != nil {
+					return fmt.Errorf("failed to forward events: %w", err)
+				}
+			}
+
+			if publisher != nil {
+				if err := publisher.PublishEvents(context.Background(), resp.Events); err != nil {
+					return fmt.Errorf("failed to publish events to NATS: %w", err)
+				}
+			}
+
+			// Remember the events only once every sink has them, so a failed
+			// write is retried rather than dropped as a duplicate next time.
+			if dedup != nil {
+				if err := dedup.Commit(resp.Events); err != nil {
+					return fmt.Errorf("failed to persist deduplicator state: %w", err)
+				}
+			}
+
 			if OutputFormat == OutputFormatJSON {
 				data, err := json.MarshalIndent(resp, "", "  ")
 				if err != nil {
@@ -45,6 +203,10 @@ func PollEventsCmd(configPath *string) *cobra.Command {
 				}
 				fmt.Println(string(data))
 			} else {
+				if dedup != nil {
+					fmt.Printf("Duplicates dropped: %d\n", dedup.Stats().Dropped)
+				}
+
 				fmt.Printf("Events:\n")
 				if len(resp.Events) == 0 {
 					fmt.Println("  No events found")
@@ -61,6 +223,9 @@ func PollEventsCmd(configPath *string) *cobra.Command {
 						if event.AgentResponse != nil {
 							fmt.Printf("    Agent Response: %+v\n", event.AgentResponse)
 						}
+						if event.TransferInstance != nil {
+							fmt.Printf("    Transfer Instance: %+v\n", event.TransferInstance)
+						}
 					}
 				}
 			}
@@ -68,4 +233,22 @@ func PollEventsCmd(configPath *string) *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().StringVar(&sinkDir, "sink", "", "Directory to append polled events to as JSON lines")
+	cmd.Flags().Int64Var(&sinkMaxBytes, "sink-max-bytes", 64<<20, "Rotate the sink file after this many bytes (0 disables)")
+	cmd.Flags().DurationVar(&sinkMaxAge, "sink-max-age", time.Hour, "Rotate the sink file after this long (0 disables)")
+	cmd.Flags().BoolVar(&sinkGzip, "sink-gzip", false, "Gzip completed sink files")
+	cmd.Flags().StringVar(&sinkFsync, "sink-fsync", "rotate", "When to fsync sink files: always, rotate or never")
+	cmd.Flags().StringVar(&storeDSN, "store", "", "Database DSN to save polled events to, e.g. a SQLite file path or postgres:// URL")
+	cmd.Flags().StringVar(&storeDialect, "store-dialect", "sqlite", "Event store dialect: sqlite or postgres")
+	cmd.Flags().StringVar(&webhookURL, "webhook", "", "URL to POST polled events to as a signed batch")
+	cmd.Flags().StringVar(&webhookDLQ, "webhook-dead-letter-dir", "webhook-dead-letters", "Directory for batches the webhook could not accept")
+	cmd.Flags().StringVar(&natsURL, "nats", "", "NATS URL to publish polled events to via JetStream")
+	cmd.Flags().StringVar(&natsPrefix, "nats-subject-prefix", natssink.DefaultSubjectPrefix, "Subject prefix for published events")
+	cmd.Flags().StringVar(&natsStream, "nats-stream", natssink.DefaultStream, "JetStream stream capturing the subjects")
+	cmd.Flags().BoolVar(&natsCreate, "nats-create-stream", false, "Create or update the stream to capture <prefix>.>")
+	cmd.Flags().StringVar(&dedupState, "dedup-state", "", "File remembering polled event SIDs so repeats across polls are dropped")
+	cmd.Flags().DurationVar(&dedupWindow, "dedup-window", domain.DefaultDedupWindow, "How long event SIDs are remembered")
+	cmd.Flags().StringVar(&secretFile, "webhook-secret-file", "", "File containing the webhook HMAC secret (default $"+WebhookSecretEnv+")")
+
+	return cmd
 }
@@ -22,13 +22,13 @@ func PutCallOnSimpleHoldCmd(configPath *string) *cobra.Command {
 				return err
 			}
 
-			client, err := saticlient.NewClient(cfg)
+			client, err := saticlient.NewClient(cfg, clientOptions(cfg)...)
 			if err != nil {
 				return err
 			}
 			defer handleClientClose(client)
 
-			ctx, cancel := createContext(DefaultTimeout)
+			ctx, cancel := createContext()
 			defer cancel()
 
 			params := ports.PutCallOnSimpleHoldParams{
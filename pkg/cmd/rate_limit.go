@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	saticlient "github.com/tcncloud/sati-go/pkg/sati/client"
+	saticonfig "github.com/tcncloud/sati-go/pkg/sati/config"
+)
+
+// ErrInvalidMethodRateLimit is returned for a --method-rate-limit that is not METHOD=RPS[:BURST].
+var ErrInvalidMethodRateLimit = errors.New("method rate limit must be METHOD=RPS[:BURST]")
+
+// Rate limit flags shared by every command that calls the gate.
+var (
+	rateLimitRPS     float64
+	rateLimitBurst   int
+	methodRateLimits = methodRateLimitsValue{}
+)
+
+// methodRateLimitsValue collects repeated --method-rate-limit flags.
+type methodRateLimitsValue map[string]saticonfig.RateLimit
+
+func (v methodRateLimitsValue) Set(raw string) error {
+	for _, entry := range strings.Split(raw, ",") {
+		method, spec, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || method == "" {
+			return fmt.Errorf("%w: %q", ErrInvalidMethodRateLimit, entry)
+		}
+
+		rps, burst, hasBurst := strings.Cut(spec, ":")
+
+		var (
+			limit saticonfig.RateLimit
+			err   error
+		)
+
+		if limit.RPS, err = strconv.ParseFloat(rps, 64); err != nil {
+			return fmt.Errorf("%w: %q", ErrInvalidMethodRateLimit, entry)
+		}
+
+		if hasBurst {
+			if limit.Burst, err = strconv.Atoi(burst); err != nil {
+				return fmt.Errorf("%w: %q", ErrInvalidMethodRateLimit, entry)
+			}
+		}
+
+		if err := limit.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", method, err)
+		}
+
+		v[method] = limit
+	}
+
+	return nil
+}
+
+func (v methodRateLimitsValue) String() string {
+	methods := make([]string, 0, len(v))
+	for method := range v {
+		methods = append(methods, method)
+	}
+
+	sort.Strings(methods)
+
+	parts := make([]string, len(methods))
+	for i, method := range methods {
+		parts[i] = fmt.Sprintf("%s=%g:%d", method, v[method].RPS, v[method].Burst)
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func (v methodRateLimitsValue) Type() string {
+	return "METHOD=RPS[:BURST]"
+}
+
+// addRateLimitFlags registers the rate limit flags on the root command.
+func addRateLimitFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Float64Var(&rateLimitRPS, "rate-limit", 0, "Limit calls to the gate to this many per second across all methods (overrides the config's rate_limits.global)")
+	cmd.PersistentFlags().IntVar(&rateLimitBurst, "rate-limit-burst", 0, "Calls allowed in a burst under --rate-limit (default: one second's worth)")
+	cmd.PersistentFlags().Var(methodRateLimits, "method-rate-limit", "Limit one gate method, e.g. UpsertAgent=5:10 (repeatable, overrides the config's rate_limits.methods)")
+}
+
+// validateRateLimitFlags checks the flags that cannot validate themselves.
+func validateRateLimitFlags() error {
+	if rateLimitRPS == 0 && rateLimitBurst == 0 {
+		return nil
+	}
+
+	return saticonfig.RateLimit{RPS: rateLimitRPS, Burst: rateLimitBurst}.Validate()
+}
+
+// clientOptions returns the gate client options selected by the global flags.
+// Rate limit flags are layered over the config's rate_limits section.
+func clientOptions(cfg *saticonfig.Config) []saticlient.Option {
+	if rateLimitRPS == 0 && len(methodRateLimits) == 0 {
+		return nil
+	}
+
+	limits := saticonfig.RateLimits{Methods: make(map[string]saticonfig.RateLimit)}
+
+	if cfg.RateLimits != nil {
+		limits.Global = cfg.RateLimits.Global
+
+		for method, limit := range cfg.RateLimits.Methods {
+			limits.Methods[method] = limit
+		}
+	}
+
+	if rateLimitRPS > 0 {
+		limits.Global = &saticonfig.RateLimit{RPS: rateLimitRPS, Burst: rateLimitBurst}
+	}
+
+	for method, limit := range methodRateLimits {
+		limits.Methods[method] = limit
+	}
+
+	return []saticlient.Option{saticlient.WithRateLimits(limits)}
+}
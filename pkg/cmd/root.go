@@ -11,25 +11,52 @@ var OutputFormat string
 var rootCmd = &cobra.Command{
 	Use:   "sati-client",
 	Short: "Sati Client - CLI for exile gateway that exposes the API",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := cmd.ValidateRequiredFlags(); err != nil {
+			return err
+		}
+
+		if err := validateRateLimitFlags(); err != nil {
+			return err
+		}
+
+		// The flags are valid, so later errors are not usage errors.
+		cmd.SilenceUsage = true
+
+		return nil
+	},
+	SilenceErrors: true,
 }
 
+// Execute runs the CLI and exits with a code that tells gate errors apart.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		message, code := describeError(err)
+		fmt.Fprintln(os.Stderr, message)
+		os.Exit(code)
 	}
 }
 
 func init() {
 	var configPath string
 	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "Path to base64-encoded JSON config file")
-	rootCmd.PersistentFlags().StringVarP(&OutputFormat, "output", "o", "text", "Output format: json or text")
+	rootCmd.PersistentFlags().StringVarP(&OutputFormat, "output", "o", "text", "Output format: json or text (csv for reports)")
+	addRateLimitFlags(rootCmd)
 
 	rootCmd.AddCommand(
 		GetClientConfigCmd(&configPath),
 		GetOrgInfoCmd(&configPath),
 		RotateCertificateCmd(&configPath),
 		PollEventsCmd(&configPath),
+		WatchEventsCmd(&configPath),
+		EventsCmd(&configPath),
+		WebhookCmd(&configPath),
+		RulesCmd(&configPath),
+		AutomationCmd(&configPath),
+		CallsCmd(&configPath),
+		AgentsCmd(&configPath),
+		ParquetCmd(&configPath),
+		SchemaCmd(&configPath),
 		StreamJobsCmd(&configPath),
 		SubmitJobResultsCmd(&configPath),
 		GetAgentStatusCmd(&configPath),
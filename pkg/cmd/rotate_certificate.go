@@ -3,35 +3,66 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 
+	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
 	"github.com/tcncloud/sati-go/pkg/ports"
 	saticlient "github.com/tcncloud/sati-go/pkg/sati/client"
 	saticonfig "github.com/tcncloud/sati-go/pkg/sati/config"
+	"github.com/tcncloud/sati-go/pkg/sati/rotation"
 )
 
 func RotateCertificateCmd(configPath *string) *cobra.Command {
-	var certificateHash string
+	var (
+		certificateHash string
+		write           bool
+	)
 
 	cmd := &cobra.Command{
 		Use:   "rotate-certificate",
 		Short: "Call GateService.RotateCertificate",
+		Long: "Call GateService.RotateCertificate and print the new certificate. With --write the certificate is checked " +
+			"against the private key and CA certificate, and the config file is rewritten, keeping the previous one with a .bak suffix.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg, err := saticonfig.LoadConfig(*configPath)
 			if err != nil {
 				return err
 			}
 
-			client, err := saticlient.NewClient(cfg)
+			client, err := saticlient.NewClient(cfg, clientOptions(cfg)...)
 			if err != nil {
 				return err
 			}
 			defer handleClientClose(client)
 
-			ctx, cancel := createContext(DefaultTimeout)
+			ctx, cancel := createContext()
 			defer cancel()
 
-			params := ports.RotateCertificateParams{}
+			if write {
+				logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+				manager, err := rotation.NewManager(client, rotation.Options{ConfigPath: *configPath}, nil, &logger)
+				if err != nil {
+					return err
+				}
+
+				if _, err := manager.Rotate(ctx); err != nil {
+					return err
+				}
+
+				fmt.Printf("Certificate rotated, %s rewritten (previous configuration in %s%s)\n", *configPath, *configPath, rotation.BackupSuffix)
+
+				return nil
+			}
+
+			if certificateHash == "" {
+				if certificateHash, err = rotation.CertificateHash(cfg); err != nil {
+					return err
+				}
+			}
+
+			params := ports.RotateCertificateParams{CertificateHash: certificateHash}
 			resp, err := client.RotateCertificate(ctx, params)
 			if err != nil {
 				return err
@@ -58,8 +89,9 @@ func RotateCertificateCmd(configPath *string) *cobra.Command {
 			return nil
 		},
 	}
-	cmd.Flags().StringVar(&certificateHash, "certificate-hash", "", "Certificate hash (required)")
-	markFlagRequired(cmd, "certificate-hash")
+	cmd.Flags().StringVar(&certificateHash, "certificate-hash", "", "Certificate hash (default: the config's fingerprint_sha256)")
+	cmd.Flags().BoolVar(&write, "write", false, "Check the new certificate and rewrite the config file with it")
+	cmd.MarkFlagsMutuallyExclusive("certificate-hash", "write")
 
 	return cmd
 }
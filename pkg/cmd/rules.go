@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/tcncloud/sati-go/pkg/adapters/filesink"
+	"github.com/tcncloud/sati-go/pkg/adapters/rules"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// Rules command errors.
+var (
+	ErrRulesFileRequired  = errors.New("--rules is required")
+	ErrEventsFileRequired = errors.New("--events is required")
+)
+
+// RulesCmd groups commands that work with routing rules files.
+func RulesCmd(configPath *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Validate and test event routing rules",
+	}
+
+	makeConfigOptional(cmd, configPath)
+
+	cmd.AddCommand(RulesTestCmd())
+
+	return cmd
+}
+
+// ruleTestResult is the routing outcome for one captured event.
+type ruleTestResult struct {
+	Index       int               `json:"index"`
+	MessageID   string            `json:"message_id,omitempty"`
+	Kind        string            `json:"kind"`
+	Key         string            `json:"key"`
+	Matched     []string          `json:"matched"`
+	Subscribers []string          `json:"subscribers"`
+	Errors      map[string]string `json:"errors,omitempty"`
+}
+
+// RulesTestCmd runs a rules file against captured events.
+func RulesTestCmd() *cobra.Command {
+	var (
+		rulesPath  string
+		eventsPath string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Report which sinks each captured event would reach",
+		Long: `Compile a rules file and evaluate it against captured events. The events file
+may be a poll-events --sink journal (.jsonl or .jsonl.gz) or the JSON printed by
+poll-events -o json. Subscribers the rules file never names receive every event
+and are not listed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if rulesPath == "" {
+				return ErrRulesFileRequired
+			}
+
+			if eventsPath == "" {
+				return ErrEventsFileRequired
+			}
+
+			logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+			ruleSet, err := rules.Load(rulesPath, &logger)
+			if err != nil {
+				return err
+			}
+
+			ids, events, err := readCapturedEvents(eventsPath)
+			if err != nil {
+				return err
+			}
+
+			results := make([]ruleTestResult, len(events))
+			counts := make(map[string]int)
+
+			for i, event := range events {
+				decision := ruleSet.Evaluate(event)
+				results[i] = ruleTestResult{
+					Index:       i + 1,
+					MessageID:   ids[i],
+					Kind:        event.Kind(),
+					Key:         event.Key(),
+					Matched:     decision.Matched,
+					Subscribers: decision.Subscribers,
+					Errors:      decision.Errors,
+				}
+
+				if len(decision.Subscribers) == 0 {
+					counts["(dropped)"]++
+				}
+
+				for _, name := range decision.Subscribers {
+					counts[name]++
+				}
+			}
+
+			if OutputFormat == OutputFormatJSON {
+				return outputJSON(map[string]any{"events": results, "totals": counts})
+			}
+
+			for _, r := range results {
+				sinks := strings.Join(r.Subscribers, ", ")
+				if sinks == "" {
+					sinks = "(dropped)"
+				}
+
+				matched := strings.Join(r.Matched, ", ")
+				if matched == "" {
+					matched = "default"
+				}
+
+				fmt.Printf("%4d  %-17s %-40s -> %s  [%s]\n", r.Index, r.Kind, r.Key, sinks, matched)
+
+				for rule, msg := range r.Errors {
+					fmt.Printf("      rule %s failed: %s\n", rule, msg)
+				}
+			}
+
+			names := make([]string, 0, len(counts))
+			for name := range counts {
+				names = append(names, name)
+			}
+
+			sort.Strings(names)
+
+			fmt.Printf("\nTotals (%d events):\n", len(events))
+
+			for _, name := range names {
+				fmt.Printf("  %-20s %d\n", name, counts[name])
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&rulesPath, "rules", "", "Rules file (YAML or JSON) (required)")
+	cmd.Flags().StringVar(&eventsPath, "events", "", "Captured events file (required)")
+	markFlagRequired(cmd, "rules")
+	markFlagRequired(cmd, "events")
+
+	return cmd
+}
+
+// readCapturedEvents loads events from a sink journal or poll-events JSON output.
+// It returns journal message IDs alongside the events; IDs are empty for JSON input.
+func readCapturedEvents(path string) ([]string, []ports.Event, error) {
+	var (
+		ids    []string
+		events []ports.Event
+	)
+
+	if strings.HasSuffix(path, ".json") {
+		//nolint:gosec // Events path is operator input
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read events file: %w", err)
+		}
+
+		var result ports.PollEventsResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse events file: %w", err)
+		}
+
+		return make([]string, len(result.Events)), result.Events, nil
+	}
+
+	err := filesink.ReadFile(path, func(r filesink.Record) error {
+		ids = append(ids, r.MessageID)
+		events = append(events, r.Event)
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ids, events, nil
+}
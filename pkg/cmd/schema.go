@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tcncloud/sati-go/pkg/schema"
+)
+
+// ErrSchemaOutRequired is returned when several schemas would be printed to stdout.
+var ErrSchemaOutRequired = errors.New("--out is required to export more than one schema")
+
+// formatAll selects every schema format in schema export.
+const formatAll = "all"
+
+// SchemaCmd groups commands that publish the event and job schemas.
+func SchemaCmd(configPath *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Publish the event and job schemas for downstream consumers",
+	}
+
+	makeConfigOptional(cmd, configPath)
+
+	cmd.AddCommand(SchemaExportCmd())
+
+	return cmd
+}
+
+// SchemaExportCmd writes JSON Schema and Avro schemas for the published types.
+func SchemaExportCmd() *cobra.Command {
+	var (
+		format string
+		outDir string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export [TYPE...]",
+		Short: "Export JSON Schema and Avro schemas for events and jobs",
+		Long: fmt.Sprintf(`Export the schemas of the event and job types written by the file and webhook
+sinks, which stamp every record with schema_version %s. TYPE is one of
+event, call_record, agent_kpi, job and job_result; all types are exported when
+none is given. With --out each schema is written to <type>.schema.json or
+<type>.avsc, otherwise the single selected schema is printed.`, schema.Version),
+		Example: `  sati schema export --out ./schemas
+  sati schema export event --format avro`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			formats := schema.Formats
+			if format != formatAll {
+				formats = []string{format}
+			}
+
+			names := args
+			if len(names) == 0 {
+				for _, t := range schema.Types() {
+					names = append(names, t.Name)
+				}
+			}
+
+			if outDir == "" && len(names)*len(formats) > 1 {
+				return ErrSchemaOutRequired
+			}
+
+			if outDir != "" {
+				if err := os.MkdirAll(outDir, 0o750); err != nil {
+					return fmt.Errorf("failed to create output directory: %w", err)
+				}
+			}
+
+			for _, name := range names {
+				for _, f := range formats {
+					data, err := schema.Generate(name, f)
+					if err != nil {
+						return err
+					}
+
+					if outDir == "" {
+						_, err = os.Stdout.Write(data)
+
+						return err
+					}
+
+					file, err := schema.FileName(name, f)
+					if err != nil {
+						return err
+					}
+
+					path := filepath.Join(outDir, file)
+
+					//nolint:gosec // Schemas are meant to be shared
+					if err := os.WriteFile(path, data, 0o644); err != nil {
+						return fmt.Errorf("failed to write schema: %w", err)
+					}
+
+					fmt.Println(path)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", formatAll, "Schema format: jsonschema, avro or all")
+	cmd.Flags().StringVar(&outDir, "out", "", "Directory to write schema files to")
+
+	return cmd
+}
@@ -106,13 +106,13 @@ func SearchVoiceRecordingsCmd(configPath *string) *cobra.Command {
 				return err
 			}
 
-			client, err := saticlient.NewClient(cfg)
+			client, err := saticlient.NewClient(cfg, clientOptions(cfg)...)
 			if err != nil {
 				return err
 			}
 			defer handleClientClose(client)
 
-			ctx, cancel := createContext(LongTimeout)
+			ctx, cancel := createContext()
 			defer cancel()
 
 			params := buildSearchParams(startDate, endDate, agentID, callSid, recordingSid, searchQuery, pageToken, pageSize, searchFields)
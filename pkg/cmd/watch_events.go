@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// ErrUnknownEventKind is returned for a --kind that is not an event type.
+var ErrUnknownEventKind = errors.New("unknown event kind")
+
+// ANSI colors for event kinds in watch-events output.
+const (
+	ansiReset   = "\033[0m"
+	ansiDim     = "\033[2m"
+	ansiCyan    = "\033[36m"
+	ansiGreen   = "\033[32m"
+	ansiYellow  = "\033[33m"
+	ansiMagenta = "\033[35m"
+)
+
+var kindColors = map[string]string{
+	ports.EventTypeTelephonyResult:  ansiCyan,
+	ports.EventTypeAgentCall:        ansiGreen,
+	ports.EventTypeAgentResponse:    ansiYellow,
+	ports.EventTypeTransferInstance: ansiMagenta,
+}
+
+// eventFilter selects events by kind, agent, call SID and call type. Empty
+// fields match everything.
+type eventFilter struct {
+	kinds    []string
+	agent    string
+	callSid  int64
+	callType string
+}
+
+func (f eventFilter) match(event ports.Event) bool {
+	if len(f.kinds) > 0 && !slices.Contains(f.kinds, event.Kind()) {
+		return false
+	}
+
+	if f.callType != "" && !strings.EqualFold(f.callType, event.CallType()) {
+		return false
+	}
+
+	var (
+		sids   []int64
+		agents []string
+	)
+
+	switch {
+	case event.Telephony != nil:
+		sids = []int64{event.Telephony.CallSid}
+	case event.AgentCall != nil:
+		sids = []int64{event.AgentCall.CallSid}
+		agents = []string{event.AgentCall.PartnerAgentID}
+	case event.AgentResponse != nil:
+		sids = []int64{event.AgentResponse.CallSid}
+		agents = []string{event.AgentResponse.PartnerAgentID}
+	case event.TransferInstance != nil:
+		ti := event.TransferInstance
+		sids = []int64{ti.SourceCallSid, ti.DestinationCallSid}
+		agents = []string{ti.SourcePartnerAgentID, ti.DestinationPartnerAgentID}
+	}
+
+	if f.callSid != 0 && !slices.Contains(sids, f.callSid) {
+		return false
+	}
+
+	if f.agent != "" && !slices.Contains(agents, f.agent) {
+		return false
+	}
+
+	return true
+}
+
+// WatchEventsCmd polls events continuously and prints those matching the filters.
+func WatchEventsCmd(configPath *string) *cobra.Command {
+	var (
+		filter   eventFilter
+		interval time.Duration
+		counts   bool
+		noColor  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "watch-events",
+		Short: "Poll events continuously and print them until interrupted",
+		Long: `Call GateService.PollEvents in a loop until Ctrl-C, printing a one-line summary
+of each event that matches the filters. With -o json every event is printed as
+one JSON object per line (NDJSON).`,
+		Example: `  sati watch-events --config sati.cfg --kind telephony_result --call-type outbound
+  sati watch-events --config sati.cfg --agent AGENT_ID --counts
+  sati watch-events --config sati.cfg -o json | jq .Telephony`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, kind := range filter.kinds {
+				if _, ok := kindColors[kind]; !ok {
+					return fmt.Errorf("%w: %s", ErrUnknownEventKind, kind)
+				}
+			}
+
+			client, err := createClient(configPath)
+			if err != nil {
+				return err
+			}
+			defer handleClientClose(client)
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			color := !noColor && useColor(os.Stdout)
+			totals := make(map[string]int)
+			encoder := json.NewEncoder(os.Stdout)
+
+			for ctx.Err() == nil {
+				resp, err := client.PollEvents(ctx, ports.PollEventsParams{})
+
+				if ctx.Err() != nil {
+					break
+				}
+
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "poll failed: %v\n", err)
+					sleepContext(ctx, interval)
+
+					continue
+				}
+
+				matched := 0
+
+				for _, event := range resp.Events {
+					if !filter.match(event) {
+						continue
+					}
+
+					matched++
+					totals[event.Kind()]++
+
+					if OutputFormat == OutputFormatJSON {
+						if err := encoder.Encode(event); err != nil {
+							return err
+						}
+
+						continue
+					}
+
+					printWatchedEvent(os.Stdout, event, color)
+				}
+
+				if counts && matched > 0 {
+					fmt.Fprintln(os.Stderr, formatCounts(totals))
+				}
+
+				if len(resp.Events) == 0 {
+					sleepContext(ctx, interval)
+				}
+			}
+
+			if counts {
+				fmt.Fprintf(os.Stderr, "total: %s\n", formatCounts(totals))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&filter.kinds, "kind", nil, "Only these event kinds: telephony_result, agent_call, agent_response, transfer_instance")
+	cmd.Flags().StringVar(&filter.agent, "agent", "", "Only events involving this partner agent ID")
+	cmd.Flags().Int64Var(&filter.callSid, "call-sid", 0, "Only events for this call SID")
+	cmd.Flags().StringVar(&filter.callType, "call-type", "", "Only events of this call type, e.g. outbound")
+	cmd.Flags().DurationVar(&interval, "interval", time.Second, "Wait between polls that return no events")
+	cmd.Flags().BoolVar(&counts, "counts", false, "Print a running count per event kind to stderr")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "Disable colored output")
+
+	return cmd
+}
+
+// printWatchedEvent prints one event as "<time> <kind> <details>".
+func printWatchedEvent(w io.Writer, event ports.Event, color bool) {
+	timestamp, details := eventSummary(event)
+	if timestamp == "" {
+		timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	kind := fmt.Sprintf("%-17s", event.Kind())
+
+	if color {
+		timestamp = ansiDim + timestamp + ansiReset
+		kind = kindColors[event.Kind()] + kind + ansiReset
+	}
+
+	fmt.Fprintf(w, "%s  %s %s\n", timestamp, kind, details)
+}
+
+// formatCounts renders per-kind counts as "kind=n" pairs in a stable order.
+func formatCounts(counts map[string]int) string {
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+
+	sort.Strings(kinds)
+
+	parts := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		parts = append(parts, fmt.Sprintf("%s=%d", kind, counts[kind]))
+	}
+
+	if len(parts) == 0 {
+		return "no events"
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// useColor reports whether f is a terminal and NO_COLOR is unset.
+func useColor(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
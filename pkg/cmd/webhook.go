@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/tcncloud/sati-go/pkg/adapters/webhook"
+)
+
+// Webhook command errors.
+var (
+	ErrDeadLetterDirRequired = errors.New("--dead-letter-dir is required")
+	ErrInvalidWebhookTarget  = errors.New("invalid webhook target, expected NAME=URL")
+)
+
+// WebhookSecretEnv is read when no --secret-file is given.
+const WebhookSecretEnv = "SATI_WEBHOOK_SECRET"
+
+// WebhookCmd groups commands that manage webhook dead letters.
+func WebhookCmd(configPath *string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webhook",
+		Short: "Inspect and re-drive webhook dead letters",
+	}
+
+	makeConfigOptional(cmd, configPath)
+
+	cmd.AddCommand(WebhookListCmd(), WebhookRedriveCmd())
+
+	return cmd
+}
+
+// WebhookListCmd lists dead-lettered batches.
+func WebhookListCmd() *cobra.Command {
+	var deadLetterDir string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List dead-lettered webhook batches",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deadLetterDir == "" {
+				return ErrDeadLetterDirRequired
+			}
+
+			dlq, err := webhook.OpenDeadLetterQueue(deadLetterDir)
+			if err != nil {
+				return err
+			}
+
+			paths, err := dlq.List()
+			if err != nil {
+				return err
+			}
+
+			letters := make([]webhook.DeadLetter, 0, len(paths))
+
+			for _, path := range paths {
+				dl, err := dlq.Read(path)
+				if err != nil {
+					return err
+				}
+
+				letters = append(letters, dl)
+			}
+
+			if OutputFormat == OutputFormatJSON {
+				return outputJSON(letters)
+			}
+
+			if len(letters) == 0 {
+				fmt.Println("No dead letters found")
+
+				return nil
+			}
+
+			for i, dl := range letters {
+				fmt.Printf("%s  target=%s events=%d attempts=%d key=%s\n    error: %s\n",
+					dl.FailedAt.Format("2006-01-02T15:04:05Z07:00"), dl.Target, len(dl.Events), dl.Attempts, dl.IdempotencyKey, dl.LastError)
+				fmt.Printf("    file: %s\n", paths[i])
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&deadLetterDir, "dead-letter-dir", "", "Webhook dead-letter directory (required)")
+	markFlagRequired(cmd, "dead-letter-dir")
+
+	return cmd
+}
+
+// WebhookRedriveCmd re-sends dead-lettered batches.
+func WebhookRedriveCmd() *cobra.Command {
+	var (
+		deadLetterDir string
+		targetFlags   []string
+		secretFile    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "redrive",
+		Short: "Re-send dead-lettered webhook batches to their targets",
+		Long: `Re-send every dead-lettered batch. Batches go to the URL recorded in the dead
+letter unless --target NAME=URL overrides it. The signing secret is read from
+--secret-file or the ` + WebhookSecretEnv + ` environment variable. Delivered batches
+are removed; failed ones stay for the next run.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deadLetterDir == "" {
+				return ErrDeadLetterDirRequired
+			}
+
+			secret, err := readWebhookSecret(secretFile)
+			if err != nil {
+				return err
+			}
+
+			dlq, err := webhook.OpenDeadLetterQueue(deadLetterDir)
+			if err != nil {
+				return err
+			}
+
+			targets, err := redriveTargets(dlq, targetFlags, secret)
+			if err != nil {
+				return err
+			}
+
+			if len(targets) == 0 {
+				fmt.Println("No dead letters found")
+
+				return nil
+			}
+
+			logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+			forwarder, err := webhook.New(webhook.Options{Targets: targets, DeadLetterDir: deadLetterDir}, &logger)
+			if err != nil {
+				return err
+			}
+
+			result, err := forwarder.Redrive(context.Background())
+			if err != nil {
+				return err
+			}
+
+			if OutputFormat == OutputFormatJSON {
+				return outputJSON(result)
+			}
+
+			fmt.Printf("Delivered: %d\nFailed: %d\nSkipped: %d\n", result.Delivered, result.Failed, result.Skipped)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&deadLetterDir, "dead-letter-dir", "", "Webhook dead-letter directory (required)")
+	cmd.Flags().StringArrayVar(&targetFlags, "target", nil, "Override a target URL as NAME=URL (repeatable)")
+	cmd.Flags().StringVar(&secretFile, "secret-file", "", "File containing the HMAC signing secret")
+	markFlagRequired(cmd, "dead-letter-dir")
+
+	return cmd
+}
+
+// redriveTargets builds one target per dead-lettered target name, using the
+// recorded URL unless overridden.
+func redriveTargets(dlq *webhook.DeadLetterQueue, overrides []string, secret string) ([]webhook.Target, error) {
+	urls := make(map[string]string, len(overrides))
+
+	for _, override := range overrides {
+		name, url, ok := strings.Cut(override, "=")
+		if !ok || name == "" || url == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidWebhookTarget, override)
+		}
+
+		urls[name] = url
+	}
+
+	paths, err := dlq.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []webhook.Target
+
+	seen := make(map[string]bool)
+
+	for _, path := range paths {
+		dl, err := dlq.Read(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[dl.Target] {
+			continue
+		}
+
+		seen[dl.Target] = true
+
+		url := dl.URL
+		if override, ok := urls[dl.Target]; ok {
+			url = override
+		}
+
+		targets = append(targets, webhook.Target{Name: dl.Target, URL: url, Secret: secret})
+	}
+
+	return targets, nil
+}
+
+// readWebhookSecret loads the signing secret from a file or the environment.
+func readWebhookSecret(path string) (string, error) {
+	if path == "" {
+		return os.Getenv(WebhookSecretEnv), nil
+	}
+
+	//nolint:gosec // Secret path is operator input
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read webhook secret: %w", err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
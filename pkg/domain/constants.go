@@ -6,8 +6,60 @@ import "time"
 const (
 	// ConfigCheckInterval is the interval for checking client configuration changes.
 	ConfigCheckInterval = 60 * time.Second
-	// DefaultTimeout is the default timeout for API calls.
-	DefaultTimeout = 30 * time.Second
+	// MaxCallTimeout bounds each gate call of the domain processes. It is a
+	// backstop above the client's per-method deadlines, which end calls first.
+	MaxCallTimeout = 2 * time.Minute
+	// MaxStreamDuration bounds one StreamJobs stream, after which it is opened again.
+	MaxStreamDuration = 30 * time.Minute
 	// RetryDelay is the delay between retries for failed operations.
 	RetryDelay = 5 * time.Second
+	// DefaultSubscriberQueueSize is the in-memory queue length for an event bus subscriber.
+	DefaultSubscriberQueueSize = 1024
+	// DefaultSubscriberBatchSize is the largest batch handed to a ports.BatchSubscriber.
+	DefaultSubscriberBatchSize = 100
+	// PollBackoffMin is the initial wait before polling again when every subscriber queue is full.
+	PollBackoffMin = 100 * time.Millisecond
+	// PollBackoffMax caps the wait between saturation checks.
+	PollBackoffMax = 5 * time.Second
+	// DurableRedeliveryDelay is the wait before a durable subscriber retries a failed message.
+	DurableRedeliveryDelay = time.Second
+	// HostPluginSubscriberName is the event bus subscriber that feeds the host plugin process.
+	HostPluginSubscriberName = "hostplugin"
+	// StreamJobsName labels the StreamJobs stream in reconnect metrics.
+	StreamJobsName = "StreamJobs"
+	// maxSpillRecordSize bounds a single spilled message.
+	maxSpillRecordSize = 16 * 1024 * 1024
+)
+
+// Call correlator defaults.
+const (
+	// DefaultCorrelationWindow is how long a call must be quiet before its record is emitted.
+	DefaultCorrelationWindow = 5 * time.Minute
+	// DefaultMaxOpenCalls bounds the calls the correlator holds in memory.
+	DefaultMaxOpenCalls = 10000
+	// DefaultCorrelatorScanInterval is how often the correlator looks for quiet calls.
+	DefaultCorrelatorScanInterval = time.Second
+	// CorrelatorSubscriberName is the event bus subscriber that feeds the call correlator.
+	CorrelatorSubscriberName = "correlator"
+)
+
+// Agent KPI aggregator defaults.
+const (
+	// DefaultKPIGrace is how long a bucket stays open after it ends to take late events.
+	DefaultKPIGrace = 5 * time.Minute
+	// DefaultKPIFlushInterval is how often the aggregator looks for closed buckets.
+	DefaultKPIFlushInterval = 10 * time.Second
+	// KPISubscriberName is the event bus subscriber that feeds the agent KPI aggregator.
+	KPISubscriberName = "agent_kpi"
+)
+
+// DefaultKPIBuckets are the bucket widths the KPI aggregator rolls up by default.
+var DefaultKPIBuckets = []time.Duration{15 * time.Minute, time.Hour, 24 * time.Hour}
+
+// Event deduplicator defaults.
+const (
+	// DefaultDedupWindow is how long the deduplicator remembers an event key.
+	DefaultDedupWindow = 24 * time.Hour
+	// DefaultDedupMaxKeys bounds the keys the deduplicator remembers.
+	DefaultDedupMaxKeys = 1_000_000
 )
@@ -0,0 +1,439 @@
+package domain
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// epochTime is how the client formats an unset protobuf timestamp.
+const epochTime = "1970-01-01T00:00:00Z"
+
+// CorrelatorOptions configures the call correlator.
+type CorrelatorOptions struct {
+	// Window is how long a call must receive no events before its record is
+	// emitted. Defaults to DefaultCorrelationWindow.
+	Window time.Duration
+	// MaxOpenCalls bounds the calls held in memory. When it is exceeded the
+	// least recently updated call is emitted early, from the background scan
+	// rather than from Add. Defaults to DefaultMaxOpenCalls.
+	MaxOpenCalls int
+	// ScanInterval is how often quiet calls are looked for. Defaults to
+	// DefaultCorrelatorScanInterval.
+	ScanInterval time.Duration
+}
+
+// callKey identifies a call. Call SIDs are only unique within a call type.
+type callKey struct {
+	sid      int64
+	callType string
+}
+
+// openCall accumulates the events of a call that has not yet gone quiet.
+type openCall struct {
+	record     ports.CallRecord
+	elem       *list.Element
+	agentCalls map[int64]int
+	responses  map[int64]int
+	transfers  map[string]int
+}
+
+// CallCorrelator joins telephony results, agent calls, agent responses and
+// transfer instances that share a call SID into one ports.CallRecord. A record
+// is emitted once its call has been quiet for the configured window; events
+// that arrive afterwards start a new record for the same call.
+type CallCorrelator struct {
+	opts CorrelatorOptions
+	emit func(ports.CallRecord)
+	log  *zerolog.Logger
+	now  func() time.Time
+
+	mu      sync.Mutex
+	calls   map[callKey]*openCall
+	order   *list.List
+	evicted []ports.CallRecord
+	wake    chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+	closed  bool
+}
+
+// NewCallCorrelator creates a correlator that passes finished records to emit.
+// Call Start to emit records as calls go quiet.
+func NewCallCorrelator(opts CorrelatorOptions, emit func(ports.CallRecord), log *zerolog.Logger) *CallCorrelator {
+	if opts.Window <= 0 {
+		opts.Window = DefaultCorrelationWindow
+	}
+
+	if opts.MaxOpenCalls <= 0 {
+		opts.MaxOpenCalls = DefaultMaxOpenCalls
+	}
+
+	if opts.ScanInterval <= 0 {
+		opts.ScanInterval = DefaultCorrelatorScanInterval
+	}
+
+	return &CallCorrelator{
+		opts:  opts,
+		emit:  emit,
+		log:   log,
+		now:   time.Now,
+		calls: make(map[callKey]*openCall),
+		order: list.New(),
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+}
+
+// Start emits records in the background as calls go quiet or are evicted.
+func (c *CallCorrelator) Start() {
+	c.wg.Add(1)
+
+	go c.scanLoop()
+}
+
+func (c *CallCorrelator) scanLoop() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.opts.ScanInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.Expire()
+		case <-c.wake:
+			c.drain(func(*openCall) bool { return false })
+		}
+	}
+}
+
+// HandleMessage implements ports.Subscriber. Jobs and call records are ignored.
+func (c *CallCorrelator) HandleMessage(_ context.Context, msg ports.Message) error {
+	if msg.Event != nil {
+		c.Add(*msg.Event)
+	}
+
+	return nil
+}
+
+// Add joins an event into its call record. Calls evicted to stay within
+// MaxOpenCalls are emitted by the background scan, so Add never waits on emit.
+func (c *CallCorrelator) Add(event ports.Event) {
+	key, ok := eventCallKey(event)
+	if !ok {
+		return
+	}
+
+	now := c.now()
+
+	c.mu.Lock()
+
+	call, exists := c.calls[key]
+	if !exists {
+		call = &openCall{
+			record: ports.CallRecord{
+				CallSid:   key.sid,
+				CallType:  key.callType,
+				OrgID:     event.OrgID(),
+				FirstSeen: now,
+			},
+			agentCalls: make(map[int64]int),
+			responses:  make(map[int64]int),
+			transfers:  make(map[string]int),
+		}
+		c.calls[key] = call
+		call.elem = c.order.PushBack(key)
+	} else {
+		c.order.MoveToBack(call.elem)
+	}
+
+	call.merge(event)
+	call.record.LastSeen = now
+	call.record.Events++
+
+	evicted := 0
+	for len(c.calls) > c.opts.MaxOpenCalls {
+		rec := c.evictOldest()
+		c.evicted = append(c.evicted, rec)
+		evicted++
+
+		c.log.Warn().Int64("call_sid", rec.CallSid).Int("max_open_calls", c.opts.MaxOpenCalls).Msg("Too many open calls, emitting call record early")
+	}
+
+	c.mu.Unlock()
+
+	if evicted > 0 {
+		signal(c.wake)
+	}
+}
+
+// evictOldest removes and finishes the least recently updated call. Calls are
+// kept in c.order by LastSeen, so it is the front one. Callers hold c.mu.
+func (c *CallCorrelator) evictOldest() ports.CallRecord {
+	key, _ := c.order.Remove(c.order.Front()).(callKey)
+
+	oldest := c.calls[key]
+	delete(c.calls, key)
+
+	return oldest.finish()
+}
+
+// Expire emits the calls evicted since the last scan and every call that has
+// been quiet for at least the window.
+func (c *CallCorrelator) Expire() int {
+	cutoff := c.now().Add(-c.opts.Window)
+
+	return c.drain(func(call *openCall) bool {
+		return !call.record.LastSeen.After(cutoff)
+	})
+}
+
+// Flush emits every evicted and open call regardless of the window.
+func (c *CallCorrelator) Flush() int {
+	return c.drain(func(*openCall) bool { return true })
+}
+
+// OpenCalls returns the number of calls waiting to go quiet.
+func (c *CallCorrelator) OpenCalls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.calls)
+}
+
+// drain emits the evicted calls, oldest first, followed by the open calls
+// that are ready, ordered by their first event.
+func (c *CallCorrelator) drain(ready func(*openCall) bool) int {
+	c.mu.Lock()
+
+	records := c.evicted
+	c.evicted = nil
+
+	var finished []ports.CallRecord
+
+	for key, call := range c.calls {
+		if ready(call) {
+			finished = append(finished, call.finish())
+			c.order.Remove(call.elem)
+			delete(c.calls, key)
+		}
+	}
+
+	c.mu.Unlock()
+
+	sort.SliceStable(finished, func(i, j int) bool {
+		return finished[i].FirstSeen.Before(finished[j].FirstSeen)
+	})
+
+	records = append(records, finished...)
+
+	for _, rec := range records {
+		c.emit(rec)
+	}
+
+	return len(records)
+}
+
+// Close stops the background scan and emits every open call.
+func (c *CallCorrelator) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+
+		return nil
+	}
+
+	c.closed = true
+	close(c.done)
+	c.mu.Unlock()
+
+	c.wg.Wait()
+	c.Flush()
+
+	return nil
+}
+
+// CorrelateEvents joins captured events into call records without waiting for
+// calls to go quiet. Records are ordered by the first event of each call.
+func CorrelateEvents(events []ports.Event) []ports.CallRecord {
+	var records []ports.CallRecord
+
+	logger := zerolog.Nop()
+
+	c := NewCallCorrelator(CorrelatorOptions{MaxOpenCalls: len(events) + 1}, func(rec ports.CallRecord) {
+		records = append(records, rec)
+	}, &logger)
+
+	// Events arrive in one burst, so order calls by event position rather than clock.
+	base := time.Now()
+	for i, event := range events {
+		c.now = func() time.Time { return base.Add(time.Duration(i)) }
+		c.Add(event)
+	}
+
+	c.Flush()
+
+	return records
+}
+
+// eventCallKey returns the call an event belongs to. Transfers belong to their source leg.
+func eventCallKey(event ports.Event) (callKey, bool) {
+	switch {
+	case event.Telephony != nil:
+		return callKey{event.Telephony.CallSid, event.Telephony.CallType}, true
+	case event.AgentCall != nil:
+		return callKey{event.AgentCall.CallSid, event.AgentCall.CallType}, true
+	case event.AgentResponse != nil:
+		return callKey{event.AgentResponse.CallSid, event.AgentResponse.CallType}, true
+	case event.TransferInstance != nil:
+		return callKey{event.TransferInstance.SourceCallSid, event.TransferInstance.SourceCallType}, true
+	default:
+		return callKey{}, false
+	}
+}
+
+// merge stores the latest version of each entity, replacing redelivered copies.
+func (call *openCall) merge(event ports.Event) {
+	rec := &call.record
+
+	if rec.OrgID == "" {
+		rec.OrgID = event.OrgID()
+	}
+
+	switch {
+	case event.Telephony != nil:
+		t := *event.Telephony
+		rec.Telephony = &t
+	case event.AgentCall != nil:
+		if i, ok := call.agentCalls[event.AgentCall.AgentCallSid]; ok {
+			rec.AgentCalls[i] = *event.AgentCall
+		} else {
+			call.agentCalls[event.AgentCall.AgentCallSid] = len(rec.AgentCalls)
+			rec.AgentCalls = append(rec.AgentCalls, *event.AgentCall)
+		}
+
+		addAgent(rec, event.AgentCall.PartnerAgentID)
+	case event.AgentResponse != nil:
+		if i, ok := call.responses[event.AgentResponse.AgentCallResponseSid]; ok {
+			rec.Responses[i] = *event.AgentResponse
+		} else {
+			call.responses[event.AgentResponse.AgentCallResponseSid] = len(rec.Responses)
+			rec.Responses = append(rec.Responses, *event.AgentResponse)
+		}
+
+		addAgent(rec, event.AgentResponse.PartnerAgentID)
+	case event.TransferInstance != nil:
+		ti := event.TransferInstance
+		if i, ok := call.transfers[ti.TransferInstanceID]; ok {
+			rec.Transfers[i] = *ti
+		} else {
+			call.transfers[ti.TransferInstanceID] = len(rec.Transfers)
+			rec.Transfers = append(rec.Transfers, *ti)
+		}
+
+		addAgent(rec, ti.SourcePartnerAgentID)
+		addAgent(rec, ti.DestinationPartnerAgentID)
+	}
+}
+
+// finish builds the dispositions and timeline from the collected entities.
+func (call *openCall) finish() ports.CallRecord {
+	rec := call.record
+
+	rec.Dispositions = make([]ports.CallDisposition, 0, len(rec.Responses))
+	for _, r := range rec.Responses {
+		rec.Dispositions = append(rec.Dispositions, ports.CallDisposition{
+			PartnerAgentID: r.PartnerAgentID,
+			Key:            r.ResponseKey,
+			Value:          r.ResponseValue,
+			Time:           r.CreateTime,
+		})
+	}
+
+	sort.SliceStable(rec.Dispositions, func(i, j int) bool {
+		return rec.Dispositions[i].Time < rec.Dispositions[j].Time
+	})
+
+	rec.Timeline = buildTimeline(rec)
+
+	return rec
+}
+
+// addAgent records an agent involved in the call, once.
+func addAgent(rec *ports.CallRecord, partnerAgentID string) {
+	if partnerAgentID != "" && !slices.Contains(rec.Agents, partnerAgentID) {
+		rec.Agents = append(rec.Agents, partnerAgentID)
+	}
+}
+
+// buildTimeline orders the entities of a call by time. Entries without a
+// timestamp are left out.
+func buildTimeline(rec ports.CallRecord) []ports.TimelineEntry {
+	var timeline []ports.TimelineEntry
+
+	add := func(at, kind, description string) {
+		if at == "" || at == epochTime {
+			return
+		}
+
+		timeline = append(timeline, ports.TimelineEntry{Time: at, Kind: kind, Description: description})
+	}
+
+	if t := rec.Telephony; t != nil {
+		add(t.StartTime, ports.TimelineCallStarted, fmt.Sprintf("%s call to %s", t.CallType, t.PhoneNumber))
+		add(t.EndTime, ports.TimelineCallEnded, fmt.Sprintf("%s (%s)", t.Result, t.Status))
+	}
+
+	for _, ac := range rec.AgentCalls {
+		add(ac.CreateTime, ports.EventTypeAgentCall, fmt.Sprintf("agent %s talked %ds, wrap-up %ds", ac.PartnerAgentID, ac.TalkDuration, ac.WrapUpDuration))
+	}
+
+	for _, r := range rec.Responses {
+		add(r.CreateTime, ports.EventTypeAgentResponse, fmt.Sprintf("agent %s set %s=%s", r.PartnerAgentID, r.ResponseKey, r.ResponseValue))
+	}
+
+	for _, ti := range rec.Transfers {
+		at := ti.TransferStartTime
+		if at == "" || at == epochTime {
+			at = ti.CreateTime
+		}
+
+		add(at, ports.EventTypeTransferInstance, fmt.Sprintf("agent %s %s transfer to %s: %s", ti.SourcePartnerAgentID, strings.ToLower(ti.TransferType), transferDestination(ti), ti.TransferResult))
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].Time < timeline[j].Time
+	})
+
+	return timeline
+}
+
+// transferDestination describes where a transfer leg went.
+func transferDestination(ti ports.ExileTransferInstance) string {
+	switch ti.DestinationType {
+	case "agent":
+		return "agent " + ti.DestinationPartnerAgentID
+	case "phone":
+		return ti.DestinationPhoneNumber
+	case "skills":
+		return "skills " + strings.Join(ti.DestinationSkills, ",")
+	case "call":
+		return fmt.Sprintf("call %d", ti.DestinationCallSid)
+	default:
+		return ti.DestinationType
+	}
+}
+
+// Ensure CallCorrelator implements the ports.Subscriber interface.
+var _ ports.Subscriber = (*CallCorrelator)(nil)
@@ -0,0 +1,326 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"go.uber.org/fx"
+)
+
+// recordCollector gathers emitted call records.
+type recordCollector struct {
+	mu      sync.Mutex
+	records []ports.CallRecord
+}
+
+func (r *recordCollector) emit(rec ports.CallRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records = append(r.records, rec)
+}
+
+func (r *recordCollector) all() []ports.CallRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.records)
+}
+
+// testCallEvents returns the four event kinds for call 42, with a redelivered response.
+func testCallEvents() []ports.Event {
+	return []ports.Event{
+		{AgentCall: &ports.ExileAgentCall{
+			AgentCallSid: 1, CallSid: 42, CallType: "INBOUND", OrgID: "org",
+			PartnerAgentID: "alice", TalkDuration: 30, CreateTime: "2025-01-01T10:01:00Z",
+		}},
+		{AgentResponse: &ports.ExileAgentResponse{
+			AgentCallResponseSid: 7, CallSid: 42, CallType: "INBOUND", OrgID: "org",
+			PartnerAgentID: "alice", ResponseKey: "outcome", ResponseValue: "callback", CreateTime: "2025-01-01T10:02:00Z",
+		}},
+		{TransferInstance: &ports.ExileTransferInstance{
+			TransferInstanceID: "t1", SourceCallSid: 42, SourceCallType: "INBOUND", OrgID: "org",
+			SourcePartnerAgentID: "alice", DestinationType: "agent", DestinationPartnerAgentID: "bob",
+			TransferType: "COLD", TransferResult: "SUCCESS", TransferStartTime: "2025-01-01T10:01:30Z",
+		}},
+		{Telephony: &ports.ExileTelephonyResult{
+			CallSid: 42, CallType: "INBOUND", OrgID: "org", Status: "COMPLETED", Result: "ANSWERED",
+			StartTime: "2025-01-01T10:00:00Z", EndTime: "2025-01-01T10:03:00Z",
+		}},
+		{AgentResponse: &ports.ExileAgentResponse{
+			AgentCallResponseSid: 7, CallSid: 42, CallType: "INBOUND", OrgID: "org",
+			PartnerAgentID: "alice", ResponseKey: "outcome", ResponseValue: "sale", CreateTime: "2025-01-01T10:02:00Z",
+		}},
+		{AgentCall: &ports.ExileAgentCall{AgentCallSid: 2, CallSid: 42, CallType: "OUTBOUND", OrgID: "org", PartnerAgentID: "carol"}},
+	}
+}
+
+func TestCorrelateEvents(t *testing.T) {
+	records := CorrelateEvents(testCallEvents())
+
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 call records, got %d", len(records))
+	}
+
+	rec := records[0]
+	if rec.CallSid != 42 || rec.CallType != "INBOUND" || rec.OrgID != "org" {
+		t.Errorf("Expected INBOUND call 42 for org, got %d %s %s", rec.CallSid, rec.CallType, rec.OrgID)
+	}
+
+	if rec.Events != 5 {
+		t.Errorf("Expected 5 events joined, got %d", rec.Events)
+	}
+
+	if rec.Telephony == nil || rec.Telephony.Result != "ANSWERED" {
+		t.Errorf("Expected telephony result, got %+v", rec.Telephony)
+	}
+
+	if !slices.Equal(rec.Agents, []string{"alice", "bob"}) {
+		t.Errorf("Expected agents [alice bob], got %v", rec.Agents)
+	}
+
+	if len(rec.Responses) != 1 || len(rec.Dispositions) != 1 || rec.Dispositions[0].Value != "sale" {
+		t.Errorf("Expected the redelivered response to replace the first, got %+v", rec.Dispositions)
+	}
+
+	if len(rec.Transfers) != 1 {
+		t.Errorf("Expected 1 transfer leg, got %d", len(rec.Transfers))
+	}
+
+	kinds := make([]string, 0, len(rec.Timeline))
+	for _, entry := range rec.Timeline {
+		kinds = append(kinds, entry.Kind)
+	}
+
+	expected := []string{
+		ports.TimelineCallStarted,
+		ports.EventTypeAgentCall,
+		ports.EventTypeTransferInstance,
+		ports.EventTypeAgentResponse,
+		ports.TimelineCallEnded,
+	}
+	if !slices.Equal(kinds, expected) {
+		t.Errorf("Expected timeline %v, got %v", expected, kinds)
+	}
+
+	if records[1].CallType != "OUTBOUND" || len(records[1].Timeline) != 0 {
+		t.Errorf("Expected an OUTBOUND record without timestamps, got %+v", records[1])
+	}
+}
+
+func TestCallCorrelator_ExpiresQuietCalls(t *testing.T) {
+	logger := zerolog.Nop()
+	collector := &recordCollector{}
+
+	correlator := NewCallCorrelator(CorrelatorOptions{Window: time.Minute}, collector.emit, &logger)
+
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	correlator.now = func() time.Time { return now }
+
+	events := testCallEvents()
+	correlator.Add(events[0])
+
+	now = now.Add(50 * time.Second)
+	correlator.Add(events[1])
+
+	now = now.Add(50 * time.Second)
+	if n := correlator.Expire(); n != 0 {
+		t.Fatalf("Expected no records while the call is active, got %d", n)
+	}
+
+	now = now.Add(10 * time.Second)
+	if n := correlator.Expire(); n != 1 {
+		t.Fatalf("Expected 1 record once the call is quiet, got %d", n)
+	}
+
+	records := collector.all()
+	if len(records) != 1 || records[0].Events != 2 {
+		t.Fatalf("Expected one record with 2 events, got %+v", records)
+	}
+
+	if correlator.OpenCalls() != 0 {
+		t.Errorf("Expected no open calls, got %d", correlator.OpenCalls())
+	}
+}
+
+func TestCallCorrelator_MaxOpenCalls(t *testing.T) {
+	logger := zerolog.Nop()
+	collector := &recordCollector{}
+
+	correlator := NewCallCorrelator(CorrelatorOptions{MaxOpenCalls: 1}, collector.emit, &logger)
+
+	now := time.Now()
+	correlator.now = func() time.Time { return now }
+
+	events := testCallEvents()
+	correlator.Add(events[0])
+
+	now = now.Add(time.Second)
+	correlator.Add(events[5])
+
+	if records := collector.all(); len(records) != 0 {
+		t.Fatalf("Expected Add to leave the evicted call to the background scan, got %+v", records)
+	}
+
+	correlator.Start()
+	waitFor(t, func() bool { return len(collector.all()) == 1 })
+
+	records := collector.all()
+	if records[0].CallType != "INBOUND" {
+		t.Fatalf("Expected the oldest call to be emitted early, got %+v", records)
+	}
+
+	if err := correlator.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if records = collector.all(); len(records) != 2 || records[1].CallType != "OUTBOUND" {
+		t.Errorf("Expected Close to flush the open call, got %+v", records)
+	}
+}
+
+func TestCallCorrelator_EvictsLeastRecentlyUpdated(t *testing.T) {
+	logger := zerolog.Nop()
+	collector := &recordCollector{}
+
+	correlator := NewCallCorrelator(CorrelatorOptions{MaxOpenCalls: 2}, collector.emit, &logger)
+
+	now := time.Now()
+	correlator.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	call := func(sid int64) ports.Event {
+		return ports.Event{AgentCall: &ports.ExileAgentCall{AgentCallSid: sid, CallSid: sid, CallType: "INBOUND"}}
+	}
+
+	correlator.Add(call(1))
+	correlator.Add(call(2))
+	correlator.Add(call(1))
+	correlator.Add(call(3))
+
+	if correlator.Flush() != 3 {
+		t.Fatal("Expected three records")
+	}
+
+	if records := collector.all(); records[0].CallSid != 2 {
+		t.Errorf("Expected call 2 to be evicted first, got %+v", records)
+	}
+}
+
+func TestCallCorrelator_EvictionDoesNotBlockTheBus(t *testing.T) {
+	bus := newTestBus(t)
+	logger := zerolog.Nop()
+
+	correlator := NewCallCorrelator(CorrelatorOptions{MaxOpenCalls: 1}, func(rec ports.CallRecord) {
+		_ = bus.PublishFrom(context.Background(), CorrelatorSubscriberName, ports.Message{Call: &rec})
+	}, &logger)
+	correlator.Start()
+
+	t.Cleanup(func() { _ = correlator.Close() })
+
+	if err := bus.Subscribe(CorrelatorSubscriberName, correlator, SubscriptionOptions{QueueSize: 1}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	sink := newChannelSubscriber(64)
+	if err := bus.Subscribe("sink", sink, SubscriptionOptions{QueueSize: 64}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	published := make(chan error, 1)
+
+	go func() {
+		for sid := range int64(20) {
+			event := ports.Event{AgentCall: &ports.ExileAgentCall{AgentCallSid: sid, CallSid: sid, CallType: "INBOUND"}}
+			if err := bus.Publish(context.Background(), ports.Message{Event: &event}); err != nil {
+				published <- err
+
+				return
+			}
+		}
+
+		published <- nil
+	}()
+
+	select {
+	case err := <-published:
+		if err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Publishing stalled behind the correlator")
+	}
+
+	// The correlator receives the 20 events but none of the records it published.
+	waitFor(t, func() bool { return bus.Stats()[0].Delivered == 20 })
+	waitFor(t, func() bool { return len(sink.received) == 20+19 })
+
+	if stats := bus.Stats()[0]; stats.Delivered != 20 {
+		t.Errorf("Expected the correlator to receive only the 20 events, got %d", stats.Delivered)
+	}
+}
+
+func TestModule_CorrelatorPublishesCallRecords(t *testing.T) {
+	var bus *EventBus
+
+	app := fx.New(
+		Module,
+		fx.Provide(func() *zerolog.Logger {
+			logger := zerolog.Nop()
+			return &logger
+		}),
+		fx.Supply(&CorrelatorOptions{Window: 10 * time.Millisecond, ScanInterval: 5 * time.Millisecond}),
+		fx.Populate(&bus),
+	)
+
+	if err := app.Err(); err != nil {
+		t.Fatalf("Module failed to initialize: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Failed to start app: %v", err)
+	}
+
+	defer func() {
+		if err := app.Stop(ctx); err != nil {
+			t.Errorf("Failed to stop app: %v", err)
+		}
+	}()
+
+	sub := newChannelSubscriber(16)
+	if err := bus.Subscribe("test", sub, SubscriptionOptions{}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	events := testCallEvents()
+	if err := bus.Publish(context.Background(), ports.Message{Event: &events[3]}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+
+	for {
+		select {
+		case msg := <-sub.received:
+			if msg.Call == nil {
+				continue
+			}
+
+			if msg.Call.CallSid != 42 || msg.Call.Telephony == nil {
+				t.Errorf("Expected a record for call 42, got %+v", msg.Call)
+			}
+
+			return
+		case <-deadline:
+			t.Fatal("Timed out waiting for a call record")
+		}
+	}
+}
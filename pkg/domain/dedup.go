@@ -0,0 +1,376 @@
+package domain
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// ErrDeduplicatorClosed is returned when filtering after Close.
+var ErrDeduplicatorClosed = errors.New("deduplicator is closed")
+
+// dedupCompactSlack is how many stale lines the state file may hold before it is compacted.
+const dedupCompactSlack = 1024
+
+// DedupOptions configures the event deduplicator.
+type DedupOptions struct {
+	// Window is how long an event key is remembered. Defaults to DefaultDedupWindow.
+	Window time.Duration
+	// MaxKeys bounds the remembered keys; the oldest are forgotten first.
+	// Defaults to DefaultDedupMaxKeys.
+	MaxKeys int
+	// Path, when set, persists the seen-set so duplicates are also caught
+	// across restarts. New keys are appended as they are seen and the file is
+	// compacted on open and close.
+	Path string
+}
+
+// DedupStats counts deduplicator outcomes since it was opened.
+type DedupStats struct {
+	Passed  uint64
+	Dropped uint64
+	Keys    int
+}
+
+// dedupEntry is one remembered key.
+type dedupEntry struct {
+	key  string
+	seen time.Time
+}
+
+// Deduplicator suppresses events whose ports.Event.Key has already been seen
+// within the window. Keys are remembered in arrival order, so expiry and the
+// size bound both drop the oldest first.
+type Deduplicator struct {
+	opts DedupOptions
+	log  *zerolog.Logger
+	now  func() time.Time
+
+	mu      sync.Mutex
+	seen    map[string]time.Time
+	order   []dedupEntry
+	head    int
+	file    *os.File
+	lines   int
+	passed  uint64
+	dropped uint64
+	closed  bool
+}
+
+// OpenDeduplicator creates a deduplicator, loading the persisted seen-set when
+// opts.Path is set.
+func OpenDeduplicator(opts DedupOptions, log *zerolog.Logger) (*Deduplicator, error) {
+	if opts.Window <= 0 {
+		opts.Window = DefaultDedupWindow
+	}
+
+	if opts.MaxKeys <= 0 {
+		opts.MaxKeys = DefaultDedupMaxKeys
+	}
+
+	d := &Deduplicator{
+		opts: opts,
+		log:  log,
+		now:  time.Now,
+		seen: make(map[string]time.Time),
+	}
+
+	if opts.Path == "" {
+		return d, nil
+	}
+
+	if err := d.load(); err != nil {
+		return nil, err
+	}
+
+	if err := d.compact(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Filter checks events and commits the fresh ones at once. Use Check and
+// Commit instead when the events must be stored before their keys are
+// remembered. The events are still returned when persisting the new keys fails.
+func (d *Deduplicator) Filter(events []ports.Event) ([]ports.Event, error) {
+	fresh, err := d.Check(events)
+	if err != nil {
+		return nil, err
+	}
+
+	return fresh, d.Commit(fresh)
+}
+
+// Check returns the events that have not been seen within the window, in
+// order. Repeats inside events are dropped too. The keys of the returned
+// events are not remembered until they are passed to Commit, so a batch that
+// fails to be stored is let through again when it is redelivered.
+func (d *Deduplicator) Check(events []ports.Event) ([]ports.Event, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return nil, ErrDeduplicatorClosed
+	}
+
+	d.expire(d.now())
+
+	fresh := make([]ports.Event, 0, len(events))
+	batch := make(map[string]bool, len(events))
+
+	for _, event := range events {
+		key := event.Key()
+
+		if _, ok := d.seen[key]; ok || batch[key] {
+			d.dropped++
+
+			continue
+		}
+
+		batch[key] = true
+
+		fresh = append(fresh, event)
+		d.passed++
+	}
+
+	if dropped := len(events) - len(fresh); dropped > 0 {
+		d.log.Debug().Int("dropped", dropped).Int("passed", len(fresh)).Msg("Dropped duplicate events")
+	}
+
+	return fresh, nil
+}
+
+// Commit remembers the keys of events that have been stored, and persists
+// them when a Path is set. Keys already remembered are skipped.
+func (d *Deduplicator) Commit(events []ports.Event) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return ErrDeduplicatorClosed
+	}
+
+	now := d.now()
+
+	var added []dedupEntry
+
+	for _, event := range events {
+		key := event.Key()
+
+		if _, ok := d.seen[key]; ok {
+			continue
+		}
+
+		entry := dedupEntry{key: key, seen: now}
+		d.remember(entry)
+		added = append(added, entry)
+	}
+
+	return d.append(added)
+}
+
+// Stats returns deduplicator counters.
+func (d *Deduplicator) Stats() DedupStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return DedupStats{Passed: d.passed, Dropped: d.dropped, Keys: len(d.seen)}
+}
+
+// Close compacts and closes the persisted seen-set.
+func (d *Deduplicator) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return nil
+	}
+
+	d.closed = true
+
+	if d.opts.Path == "" {
+		return nil
+	}
+
+	d.expire(d.now())
+
+	return d.compact()
+}
+
+// remember adds a key and enforces the size bound. Callers hold d.mu.
+func (d *Deduplicator) remember(entry dedupEntry) {
+	d.seen[entry.key] = entry.seen
+	d.order = append(d.order, entry)
+
+	for len(d.seen) > d.opts.MaxKeys {
+		d.forgetOldest()
+	}
+}
+
+// expire forgets keys older than the window. Callers hold d.mu.
+func (d *Deduplicator) expire(now time.Time) {
+	cutoff := now.Add(-d.opts.Window)
+
+	for d.head < len(d.order) && !d.order[d.head].seen.After(cutoff) {
+		d.forgetOldest()
+	}
+}
+
+// forgetOldest drops the oldest remembered key. Callers hold d.mu.
+func (d *Deduplicator) forgetOldest() {
+	entry := d.order[d.head]
+	d.order[d.head] = dedupEntry{}
+	d.head++
+
+	delete(d.seen, entry.key)
+
+	// Reclaim the consumed prefix once it dominates the slice.
+	if d.head > len(d.order)/2 {
+		d.order = append([]dedupEntry(nil), d.order[d.head:]...)
+		d.head = 0
+	}
+}
+
+// load reads the persisted seen-set. Each line is "<unix nanos> <key>".
+func (d *Deduplicator) load() error {
+	//nolint:gosec // Dedup state path comes from operator configuration
+	file, err := os.Open(d.opts.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to open dedup state: %w", err)
+	}
+	defer file.Close()
+
+	now := d.now()
+	cutoff := now.Add(-d.opts.Window)
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		stamp, key, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+
+		nanos, err := strconv.ParseInt(stamp, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		seen := time.Unix(0, nanos)
+		if !seen.After(cutoff) {
+			continue
+		}
+
+		if _, dup := d.seen[key]; dup {
+			continue
+		}
+
+		d.remember(dedupEntry{key: key, seen: seen})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read dedup state: %w", err)
+	}
+
+	return nil
+}
+
+// compact rewrites the state file with the remembered keys and reopens it for
+// appending. Callers hold d.mu or own d exclusively.
+func (d *Deduplicator) compact() error {
+	if d.file != nil {
+		if err := d.file.Close(); err != nil {
+			return fmt.Errorf("failed to close dedup state: %w", err)
+		}
+
+		d.file = nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(d.opts.Path), 0o750); err != nil {
+		return fmt.Errorf("failed to create dedup state directory: %w", err)
+	}
+
+	tmp := d.opts.Path + ".tmp"
+
+	//nolint:gosec // Dedup state path comes from operator configuration
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create dedup state: %w", err)
+	}
+
+	w := bufio.NewWriter(file)
+	for _, entry := range d.order[d.head:] {
+		fmt.Fprintf(w, "%d %s\n", entry.seen.UnixNano(), entry.key)
+	}
+
+	err = w.Flush()
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp, d.opts.Path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to write dedup state: %w", err)
+	}
+
+	d.lines = len(d.order) - d.head
+
+	if d.closed {
+		return nil
+	}
+
+	//nolint:gosec // Dedup state path comes from operator configuration
+	d.file, err = os.OpenFile(d.opts.Path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open dedup state: %w", err)
+	}
+
+	return nil
+}
+
+// append persists newly seen keys. Callers hold d.mu.
+func (d *Deduplicator) append(entries []dedupEntry) error {
+	if d.file == nil || len(entries) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "%d %s\n", entry.seen.UnixNano(), entry.key)
+	}
+
+	if _, err := d.file.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to persist dedup state: %w", err)
+	}
+
+	d.lines += len(entries)
+
+	// Expired and evicted keys stay in the file until it is rewritten.
+	if d.lines > 2*len(d.seen)+dedupCompactSlack {
+		return d.compact()
+	}
+
+	return nil
+}
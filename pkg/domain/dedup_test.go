@@ -0,0 +1,214 @@
+package domain
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+func agentCallEvent(sid int64) ports.Event {
+	return ports.Event{Type: ports.EventTypeAgentCall, AgentCall: &ports.ExileAgentCall{AgentCallSid: sid}}
+}
+
+func openTestDeduplicator(t *testing.T, opts DedupOptions) *Deduplicator {
+	t.Helper()
+
+	logger := zerolog.Nop()
+
+	dedup, err := OpenDeduplicator(opts, &logger)
+	if err != nil {
+		t.Fatalf("OpenDeduplicator failed: %v", err)
+	}
+
+	return dedup
+}
+
+func TestDeduplicator_DropsRepeats(t *testing.T) {
+	dedup := openTestDeduplicator(t, DedupOptions{})
+
+	fresh, err := dedup.Filter([]ports.Event{agentCallEvent(1), agentCallEvent(2), agentCallEvent(1)})
+	if err != nil {
+		t.Fatalf("Filter failed: %v", err)
+	}
+
+	if len(fresh) != 2 {
+		t.Fatalf("Expected 2 fresh events, got %d", len(fresh))
+	}
+
+	fresh, _ = dedup.Filter([]ports.Event{
+		agentCallEvent(2),
+		{AgentResponse: &ports.ExileAgentResponse{AgentCallResponseSid: 2}},
+	})
+	if len(fresh) != 1 || fresh[0].AgentResponse == nil {
+		t.Fatalf("Expected only the agent response to pass, got %+v", fresh)
+	}
+
+	stats := dedup.Stats()
+	if stats.Passed != 3 || stats.Dropped != 2 || stats.Keys != 3 {
+		t.Errorf("Expected 3 passed, 2 dropped and 3 keys, got %+v", stats)
+	}
+}
+
+func TestDeduplicator_CheckRemembersOnlyCommittedKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup.state")
+	dedup := openTestDeduplicator(t, DedupOptions{Path: path})
+
+	events := []ports.Event{agentCallEvent(1), agentCallEvent(2), agentCallEvent(1)}
+
+	fresh, err := dedup.Check(events)
+	if err != nil || len(fresh) != 2 {
+		t.Fatalf("Expected 2 fresh events, got %d (%v)", len(fresh), err)
+	}
+
+	// The batch was never stored, so a redelivery must pass again.
+	if again, _ := dedup.Check(events); len(again) != 2 {
+		t.Fatalf("Expected unstored events to pass again, got %d", len(again))
+	}
+
+	if err := dedup.Commit(fresh[:1]); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	if again, _ := dedup.Check(events); len(again) != 1 || again[0].Key() != agentCallEvent(2).Key() {
+		t.Errorf("Expected only the uncommitted event to pass, got %+v", again)
+	}
+
+	if err := dedup.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened := openTestDeduplicator(t, DedupOptions{Path: path})
+
+	if again, _ := reopened.Check(events); len(again) != 1 {
+		t.Errorf("Expected only the committed key to be persisted, got %d fresh", len(again))
+	}
+}
+
+func TestDeduplicator_ForgetsAfterWindow(t *testing.T) {
+	dedup := openTestDeduplicator(t, DedupOptions{Window: time.Minute})
+
+	now := time.Now()
+	dedup.now = func() time.Time { return now }
+
+	_, _ = dedup.Filter([]ports.Event{agentCallEvent(1)})
+
+	now = now.Add(2 * time.Minute)
+
+	fresh, _ := dedup.Filter([]ports.Event{agentCallEvent(1)})
+	if len(fresh) != 1 {
+		t.Errorf("Expected the event to pass once the window passed, got %d", len(fresh))
+	}
+}
+
+func TestDeduplicator_MaxKeys(t *testing.T) {
+	dedup := openTestDeduplicator(t, DedupOptions{MaxKeys: 2})
+
+	_, _ = dedup.Filter([]ports.Event{agentCallEvent(1), agentCallEvent(2), agentCallEvent(3)})
+
+	if keys := dedup.Stats().Keys; keys != 2 {
+		t.Fatalf("Expected 2 keys, got %d", keys)
+	}
+
+	fresh, _ := dedup.Filter([]ports.Event{agentCallEvent(1), agentCallEvent(3)})
+	if len(fresh) != 1 || fresh[0].AgentCall.AgentCallSid != 1 {
+		t.Errorf("Expected the oldest key to be forgotten, got %+v", fresh)
+	}
+}
+
+func TestDeduplicator_PersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup", "seen")
+
+	dedup := openTestDeduplicator(t, DedupOptions{Path: path})
+
+	_, _ = dedup.Filter([]ports.Event{agentCallEvent(1)})
+	_, _ = dedup.Filter([]ports.Event{agentCallEvent(2)})
+
+	// Reopen without closing, as after a crash.
+	reopened := openTestDeduplicator(t, DedupOptions{Path: path})
+
+	fresh, _ := reopened.Filter([]ports.Event{agentCallEvent(1), agentCallEvent(2), agentCallEvent(3)})
+	if len(fresh) != 1 {
+		t.Fatalf("Expected only the new event to pass after a restart, got %d", len(fresh))
+	}
+
+	if err := reopened.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if _, err := reopened.Filter(nil); err == nil {
+		t.Error("Expected Filter to fail after Close")
+	}
+
+	reopened = openTestDeduplicator(t, DedupOptions{Path: path})
+	if keys := reopened.Stats().Keys; keys != 3 {
+		t.Errorf("Expected 3 keys after a clean restart, got %d", keys)
+	}
+}
+
+func TestPollEventsProcess_DropsDuplicates(t *testing.T) {
+	domain, _, mockClient := setupTestDomain()
+	sub := newChannelSubscriber(4)
+
+	if err := domain.Subscribe("sink", sub, SubscriptionOptions{}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	domain.SetDeduplicator(openTestDeduplicator(t, DedupOptions{}))
+
+	metrics := &recordingMetrics{}
+	domain.SetMetrics(metrics)
+
+	mockClient.pollEventsResult = ports.PollEventsResult{Events: []ports.Event{agentCallEvent(1)}}
+
+	process := &PollEventsProcess{domain: domain}
+	for range 2 {
+		if err := process.pollEvents(); err != nil {
+			t.Fatalf("pollEvents failed: %v", err)
+		}
+	}
+
+	sub.expect(t, ports.EventTypeAgentCall)
+
+	select {
+	case msg := <-sub.received:
+		t.Errorf("Expected the repeated event to be dropped, got %+v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if dropped := domain.dedup.Stats().Dropped; dropped != 1 {
+		t.Errorf("Expected 1 dropped event, got %d", dropped)
+	}
+
+	if !slices.Equal(metrics.duplicates, []string{ports.EventTypeAgentCall}) {
+		t.Errorf("Expected the dropped event to be reported, got %v", metrics.duplicates)
+	}
+}
+
+func TestPollEventsProcess_PublishesWhenDeduplicatorClosed(t *testing.T) {
+	domain, _, mockClient := setupTestDomain()
+	sub := newChannelSubscriber(4)
+
+	if err := domain.Subscribe("sink", sub, SubscriptionOptions{}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	dedup := openTestDeduplicator(t, DedupOptions{})
+	domain.SetDeduplicator(dedup)
+
+	if err := dedup.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	mockClient.pollEventsResult = ports.PollEventsResult{Events: []ports.Event{agentCallEvent(1)}}
+
+	process := &PollEventsProcess{domain: domain}
+	if err := process.pollEvents(); err != nil {
+		t.Fatalf("pollEvents failed: %v", err)
+	}
+
+	sub.expect(t, ports.EventTypeAgentCall)
+}
@@ -2,6 +2,8 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -9,6 +11,10 @@ import (
 	"github.com/tcncloud/sati-go/pkg/ports"
 )
 
+// ErrNoHostPlugin is returned to the bus for messages that arrive while no host
+// plugin process is set, so the durable host plugin subscriber keeps them.
+var ErrNoHostPlugin = errors.New("no host plugin process is set")
+
 // Domain is the main domain object for the application.
 // It implements the following services:
 // - ConfigWatcherHandler that will be called by the config watcher service when the
@@ -19,13 +25,29 @@ import (
 // verifying if the client configuration has changed and if so, it will restart the PollEventsProcess, StreamJobsProcess and HostPluginProcess.
 //
 // - PollEventsProcess - this will be started by the ExileClientConfiguration process. This process will be responsible for polling
-// events from the exile client using the PollEvents method from the exile client and dispatching them to the HostPluginProcess.
+// events from the exile client using the PollEvents method from the exile client and publishing them on the EventBus.
 //
 // - StreamJobsProcess - this will be started by the ExileClientConfiguration process. This process will be responsible for streaming
-// jobs from the exile client using the StreamJobs method from the exile client and dispatching them to the HostPluginProcess.
+// jobs from the exile client using the StreamJobs method from the exile client and publishing them on the EventBus.
 //
 // - HostPluginProcess - this will be started by the ExileClientConfiguration process. This process will be responsible for hosting the plugin
-// and dispatching the events and jobs to the plugin.
+// and dispatching the events and jobs to the plugin. It receives them as the durable "hostplugin" subscriber of the EventBus,
+// so events the plugin fails to take, or that arrive while no plugin is set, are dispatched again and, with an EventSpool, survive a restart.
+//
+// - EventBus - fans events and jobs out to every subscriber (sinks, metrics, rule engines, plugins), each with its own
+// bounded queue and backpressure policy.
+//
+// - EventSpool - optional. When set, every polled batch is written to disk before it is published and kept until each
+// durable subscriber has acknowledged it; unacknowledged events are redelivered after a restart.
+//
+// - Deduplicator - optional. When set, polled events whose SIDs were already seen within its window are dropped
+// before they are spooled or published.
+//
+// - Metrics - optional. When set, polled events, streamed jobs, stream reconnects, configuration reloads and
+// subscribers' job handling are reported to it.
+//
+// - Tracing - optional. When set, every event poll and every delivery of a job to a subscriber gets a span; job
+// spans are children of the job's trace started by the gate client.
 type Domain struct {
 	log           *zerolog.Logger
 	configWatcher ports.ConfigWatcher
@@ -37,16 +59,95 @@ type Domain struct {
 	pollEventsProcess  *PollEventsProcess
 	streamJobsProcess  *StreamJobsProcess
 	hostPluginProcess  ports.HostPluginProcess
+	bus                *EventBus
+	spool              *EventSpool
+	dedup              *Deduplicator
+	metrics            ports.Metrics
+	tracer             ports.Tracer
 	isRunning          bool
 	shutdownChan       chan struct{}
 }
 
 // NewDomain creates a new Domain instance.
 func NewDomain(log *zerolog.Logger) *Domain {
-	return &Domain{
+	d := &Domain{
 		log:          log,
+		bus:          NewEventBus(log),
+		metrics:      ports.NopMetrics{},
+		tracer:       ports.NopTracer{},
 		shutdownChan: make(chan struct{}),
 	}
+
+	if err := d.bus.Subscribe(HostPluginSubscriberName, hostPluginSubscriber{d}, SubscriptionOptions{Durable: true}); err != nil {
+		log.Error().Err(err).Msg("Failed to subscribe host plugin to event bus")
+	}
+
+	return d
+}
+
+// EventBus returns the bus that polled events and streamed jobs are published on.
+func (d *Domain) EventBus() *EventBus {
+	return d.bus
+}
+
+// Subscribe registers an additional consumer of polled events and streamed jobs.
+func (d *Domain) Subscribe(name string, subscriber ports.Subscriber, opts SubscriptionOptions) error {
+	return d.bus.Subscribe(name, subscriber, opts)
+}
+
+// hostPluginSubscriber forwards bus messages to the currently configured host
+// plugin process. It receives them in batches so that the plugin still gets
+// each polled batch of events in a single DispatchEvents call.
+type hostPluginSubscriber struct {
+	d *Domain
+}
+
+// HandleMessage forwards a single message to the host plugin.
+func (s hostPluginSubscriber) HandleMessage(ctx context.Context, msg ports.Message) error {
+	return s.HandleBatch(ctx, []ports.Message{msg})
+}
+
+// HandleBatch forwards messages to the host plugin in order. Consecutive
+// events are dispatched together; a job ends the run of events before it.
+// The subscription is durable, so a batch the plugin fails is delivered again.
+func (s hostPluginSubscriber) HandleBatch(_ context.Context, msgs []ports.Message) error {
+	s.d.mu.RLock()
+	process := s.d.hostPluginProcess
+	s.d.mu.RUnlock()
+
+	if process == nil {
+		return ErrNoHostPlugin
+	}
+
+	events := make([]ports.Event, 0, len(msgs))
+
+	for _, msg := range msgs {
+		if msg.Event != nil {
+			events = append(events, *msg.Event)
+		}
+
+		if msg.Job != nil {
+			if len(events) > 0 {
+				if err := process.DispatchEvents(events); err != nil {
+					return fmt.Errorf("host plugin failed to take events: %w", err)
+				}
+
+				events = make([]ports.Event, 0, len(msgs))
+			}
+
+			if err := process.DispatchJob(msg.Job); err != nil {
+				return fmt.Errorf("host plugin failed to take job %s: %w", msg.Job.JobID, err)
+			}
+		}
+	}
+
+	if len(events) > 0 {
+		if err := process.DispatchEvents(events); err != nil {
+			return fmt.Errorf("host plugin failed to take events: %w", err)
+		}
+	}
+
+	return nil
 }
 
 // SetConfigWatcher sets the configuration watcher for the domain.
@@ -65,6 +166,14 @@ func (d *Domain) SetClient(client ports.ClientInterface) {
 	d.client = client
 }
 
+// Client returns the client set with SetClient, or nil.
+func (d *Domain) Client() ports.ClientInterface {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.client
+}
+
 // SetHostPluginProcess sets the host plugin process for the domain.
 func (d *Domain) SetHostPluginProcess(process ports.HostPluginProcess) {
 	d.mu.Lock()
@@ -73,6 +182,57 @@ func (d *Domain) SetHostPluginProcess(process ports.HostPluginProcess) {
 	d.hostPluginProcess = process
 }
 
+// SetEventSpool enables at-least-once delivery of polled events to durable subscribers.
+func (d *Domain) SetEventSpool(spool *EventSpool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.spool = spool
+	d.bus.SetAcknowledger(spool)
+}
+
+// SetDeduplicator drops polled events that were already seen.
+func (d *Domain) SetDeduplicator(dedup *Deduplicator) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.dedup = dedup
+}
+
+// SetMetrics reports the domain processes' measurements to metrics.
+func (d *Domain) SetMetrics(metrics ports.Metrics) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.metrics = metrics
+	d.bus.SetMetrics(metrics)
+}
+
+// currentMetrics returns the metrics set with SetMetrics, or a no-op.
+func (d *Domain) currentMetrics() ports.Metrics {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.metrics
+}
+
+// SetTracer traces event polls and subscribers' job handling with tracer.
+func (d *Domain) SetTracer(tracer ports.Tracer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.tracer = tracer
+	d.bus.SetTracer(tracer)
+}
+
+// currentTracer returns the tracer set with SetTracer, or a no-op.
+func (d *Domain) currentTracer() ports.Tracer {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.tracer
+}
+
 // StartConfigWatcher starts the configuration watcher.
 func (d *Domain) StartConfigWatcher(ctx context.Context) error {
 	d.mu.Lock()
@@ -214,6 +374,31 @@ func (d *Domain) StartHostPlugin() error {
 
 // StopAllProcesses stops all running processes.
 func (d *Domain) StopAllProcesses() error {
+	spool, err := d.stopProcesses()
+	if err != nil {
+		return err
+	}
+
+	// The bus is closed without d.mu held: its delivery goroutines take d.mu
+	// to reach the host plugin, and Close waits for them to finish.
+	if err := d.bus.Close(); err != nil {
+		d.log.Error().Err(err).Msg("Failed to close event bus")
+	}
+
+	if spool != nil {
+		if err := spool.Close(); err != nil {
+			d.log.Error().Err(err).Msg("Failed to close event spool")
+		}
+	}
+
+	d.log.Info().Msg("All domain processes stopped")
+
+	return nil
+}
+
+// stopProcesses stops the processes and the config watcher and returns the
+// event spool to close once the bus is closed.
+func (d *Domain) stopProcesses() (*EventSpool, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
@@ -244,16 +429,14 @@ func (d *Domain) StopAllProcesses() error {
 		if err := d.configWatcher.Stop(); err != nil {
 			d.log.Error().Err(err).Msg("Failed to stop config watcher")
 
-			return err
+			return nil, err
 		}
 	}
 
 	d.isRunning = false
 	close(d.shutdownChan)
 
-	d.log.Info().Msg("All domain processes stopped")
-
-	return nil
+	return d.spool, nil
 }
 
 // IsRunning returns true if the domain is currently running.
@@ -270,6 +453,7 @@ func (d *Domain) ClientConfigurationChanged(oldConfig, newConfig *ports.GetClien
 	defer d.mu.Unlock()
 
 	d.log.Info().Msg("Client configuration changed, restarting processes")
+	d.metrics.ConfigReloaded()
 
 	// Stop existing processes
 	if d.hostPluginProcess != nil {
@@ -3,7 +3,9 @@ package domain
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/tcncloud/sati-go/pkg/ports"
@@ -51,14 +53,18 @@ func (m *MockHostPluginProcess) Stop() {
 	m.stopCalled = true
 }
 
-func (m *MockHostPluginProcess) DispatchEvents(events []ports.Event) {
+func (m *MockHostPluginProcess) DispatchEvents(events []ports.Event) error {
 	m.dispatchEventsCalled = true
 	m.eventsDispatched = events
+
+	return nil
 }
 
-func (m *MockHostPluginProcess) DispatchJob(job *ports.Job) {
+func (m *MockHostPluginProcess) DispatchJob(job *ports.Job) error {
 	m.dispatchJobCalled = true
 	m.jobDispatched = job
+
+	return nil
 }
 
 // MockClientInterface is a mock implementation of ports.ClientInterface
@@ -517,6 +523,51 @@ func TestDomain_StopAllProcesses(t *testing.T) {
 	})
 }
 
+// publishingWatcher publishes an event on the bus while it is stopped and
+// waits for the host plugin subscriber to take it off its queue.
+type publishingWatcher struct {
+	MockConfigWatcher
+	bus *EventBus
+}
+
+func (w *publishingWatcher) Stop() error {
+	_ = w.bus.Publish(context.Background(), ports.Message{Event: &ports.Event{}})
+
+	for queued(w.bus, HostPluginSubscriberName) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	return w.MockConfigWatcher.Stop()
+}
+
+func queued(bus *EventBus, name string) int {
+	for _, stats := range bus.Stats() {
+		if stats.Name == name {
+			return stats.Queued
+		}
+	}
+
+	return 0
+}
+
+func TestDomain_StopAllProcessesWithEventInFlight(t *testing.T) {
+	domain, _, _ := setupTestDomain()
+	domain.SetConfigWatcher(&publishingWatcher{bus: domain.EventBus()})
+
+	done := make(chan error, 1)
+
+	go func() { done <- domain.StopAllProcesses() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected StopAllProcesses to return while an event was being delivered")
+	}
+}
+
 func TestDomain_IsRunning(t *testing.T) {
 	domain, _, _ := setupTestDomain()
 
@@ -539,3 +590,111 @@ func TestDomain_ImplementsDomainService(t *testing.T) {
 	// Test that Domain implements DomainService interface
 	var _ ports.DomainService = domain
 }
+
+// batchRecordingPlugin records every DispatchEvents call. Each call waits
+// for a value on gate first.
+type batchRecordingPlugin struct {
+	MockHostPluginProcess
+	gate    chan struct{}
+	batches chan []ports.Event
+}
+
+func (p *batchRecordingPlugin) DispatchEvents(events []ports.Event) error {
+	<-p.gate
+	p.batches <- events
+
+	return nil
+}
+
+func TestDomain_DispatchesBatchesToHostPlugin(t *testing.T) {
+	logger := zerolog.Nop()
+	plugin := &batchRecordingPlugin{gate: make(chan struct{}), batches: make(chan []ports.Event, 4)}
+
+	domain := NewDomain(&logger)
+	domain.SetHostPluginProcess(plugin)
+	t.Cleanup(func() { _ = domain.EventBus().Close() })
+
+	// The first event is parked on the gate while the next batch queues up.
+	domain.EventBus().DispatchEvents([]ports.Event{{Type: "first"}})
+	waitFor(t, func() bool { return domain.EventBus().Stats()[0].Queued == 0 })
+	domain.EventBus().DispatchEvents([]ports.Event{{Type: "a"}, {Type: "b"}, {Type: "c"}})
+	close(plugin.gate)
+
+	for _, want := range []int{1, 3} {
+		select {
+		case events := <-plugin.batches:
+			if len(events) != want {
+				t.Errorf("Expected %d events in one call, got %+v", want, events)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for events")
+		}
+	}
+}
+
+// failingPlugin fails the first DispatchEvents call and records the rest.
+type failingPlugin struct {
+	MockHostPluginProcess
+	failed  atomic.Bool
+	batches chan []ports.Event
+}
+
+func (p *failingPlugin) DispatchEvents(events []ports.Event) error {
+	if p.failed.CompareAndSwap(false, true) {
+		return errors.New("plugin busy")
+	}
+
+	p.batches <- events
+
+	return nil
+}
+
+func TestDomain_RedispatchesEventsTheHostPluginFailed(t *testing.T) {
+	logger := zerolog.Nop()
+	plugin := &failingPlugin{batches: make(chan []ports.Event, 1)}
+
+	domain := NewDomain(&logger)
+	domain.SetHostPluginProcess(plugin)
+	t.Cleanup(func() { _ = domain.EventBus().Close() })
+
+	domain.EventBus().DispatchEvents([]ports.Event{{Type: "a"}})
+
+	select {
+	case events := <-plugin.batches:
+		if len(events) != 1 || events[0].Type != "a" {
+			t.Errorf("Expected event a again, got %+v", events)
+		}
+	case <-time.After(DurableRedeliveryDelay + time.Second):
+		t.Fatal("Timed out waiting for the event to be dispatched again")
+	}
+}
+
+func TestDomain_KeepsEventsUntilAHostPluginIsSet(t *testing.T) {
+	logger := zerolog.Nop()
+	acker := &recordingAcker{}
+
+	domain := NewDomain(&logger)
+	domain.EventBus().SetAcknowledger(acker)
+	t.Cleanup(func() { _ = domain.EventBus().Close() })
+
+	domain.EventBus().DispatchEvents([]ports.Event{{Type: "a"}})
+
+	waitFor(t, func() bool { return domain.EventBus().Stats()[0].Failed > 0 })
+
+	if acked := acker.acked(); len(acked) != 0 {
+		t.Fatalf("Expected no acknowledgement without a host plugin, got %v", acked)
+	}
+
+	plugin := &failingPlugin{batches: make(chan []ports.Event, 1)}
+	plugin.failed.Store(true)
+	domain.SetHostPluginProcess(plugin)
+
+	select {
+	case events := <-plugin.batches:
+		if len(events) != 1 || events[0].Type != "a" {
+			t.Errorf("Expected event a, got %+v", events)
+		}
+	case <-time.After(DurableRedeliveryDelay + time.Second):
+		t.Fatal("Timed out waiting for the event to reach the host plugin")
+	}
+}
@@ -0,0 +1,894 @@
+package domain
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// Error constants for event bus operations.
+var (
+	ErrBusClosed            = errors.New("event bus is closed")
+	ErrSubscriberExists     = errors.New("subscriber already registered")
+	ErrSubscriberNotFound   = errors.New("subscriber not found")
+	ErrSubscriberNameEmpty  = errors.New("subscriber name is required")
+	ErrSubscriberNil        = errors.New("subscriber is required")
+	ErrSpillDirRequired     = errors.New("spill directory is required for the spill backpressure policy")
+	ErrUnknownBackpressure  = errors.New("unknown backpressure policy")
+	ErrSpillRecordMalformed = errors.New("malformed spill record")
+	ErrDurableDropOldest    = errors.New("durable subscribers cannot use the drop-oldest backpressure policy")
+)
+
+// BackpressurePolicy controls what happens when a subscriber's queue is full.
+type BackpressurePolicy int
+
+const (
+	// BackpressureBlock makes publishers wait until the subscriber frees a slot.
+	BackpressureBlock BackpressurePolicy = iota
+	// BackpressureDropOldest discards the oldest queued message to make room.
+	BackpressureDropOldest
+	// BackpressureSpill writes overflow to a file on disk and replays it in order.
+	BackpressureSpill
+)
+
+// String returns the configuration name of the policy.
+func (p BackpressurePolicy) String() string {
+	switch p {
+	case BackpressureBlock:
+		return "block"
+	case BackpressureDropOldest:
+		return "drop-oldest"
+	case BackpressureSpill:
+		return "spill"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseBackpressurePolicy converts a configuration name into a BackpressurePolicy.
+func ParseBackpressurePolicy(name string) (BackpressurePolicy, error) {
+	switch name {
+	case "", "block":
+		return BackpressureBlock, nil
+	case "drop-oldest":
+		return BackpressureDropOldest, nil
+	case "spill":
+		return BackpressureSpill, nil
+	default:
+		return BackpressureBlock, fmt.Errorf("%w: %s", ErrUnknownBackpressure, name)
+	}
+}
+
+// SubscriptionOptions configures a single subscriber on the event bus.
+type SubscriptionOptions struct {
+	// QueueSize is the number of messages held in memory. Defaults to DefaultSubscriberQueueSize.
+	QueueSize int
+	// Policy decides what to do when the queue is full.
+	Policy BackpressurePolicy
+	// SpillDir is the directory used for overflow files when Policy is BackpressureSpill.
+	SpillDir string
+	// Durable subscribers acknowledge every handled message to the bus acknowledger and
+	// retry failed deliveries until they succeed, giving at-least-once semantics. They
+	// cannot use BackpressureDropOldest, which would lose messages.
+	Durable bool
+	// BatchSize caps the messages passed to a ports.BatchSubscriber in one call.
+	// Defaults to DefaultSubscriberBatchSize. Ignored for plain subscribers.
+	BatchSize int
+}
+
+// SubscriptionStats is a point-in-time snapshot of a subscriber's queue.
+type SubscriptionStats struct {
+	Name      string
+	Policy    BackpressurePolicy
+	Capacity  int
+	Queued    int
+	Spilled   int
+	Delivered uint64
+	Failed    uint64
+	Dropped   uint64
+}
+
+// EventBus is an in-process publish/subscribe bus. Every subscriber owns a bounded
+// queue and a delivery goroutine, so a slow consumer only affects itself unless its
+// policy is BackpressureBlock.
+type EventBus struct {
+	log *zerolog.Logger
+
+	mu      sync.RWMutex
+	subs    map[string]*subscription
+	order   []string
+	acker   ports.Acknowledger
+	router  ports.Router
+	metrics ports.Metrics
+	tracer  ports.Tracer
+	closed  bool
+	seq     atomic.Uint64
+}
+
+// NewEventBus creates a new EventBus instance.
+func NewEventBus(log *zerolog.Logger) *EventBus {
+	return &EventBus{
+		log:  log,
+		subs: make(map[string]*subscription),
+	}
+}
+
+// SetAcknowledger sets where durable subscribers report handled messages.
+func (b *EventBus) SetAcknowledger(acker ports.Acknowledger) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.acker = acker
+}
+
+// SetRouter sets the router that narrows which subscribers receive each event.
+// A nil router delivers every message to every subscriber.
+func (b *EventBus) SetRouter(router ports.Router) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.router = router
+}
+
+// SetMetrics sets where subscribers' job handling times and errors are reported.
+func (b *EventBus) SetMetrics(metrics ports.Metrics) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.metrics = metrics
+}
+
+// SetTracer traces subscribers' job handling with tracer.
+func (b *EventBus) SetTracer(tracer ports.Tracer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tracer = tracer
+}
+
+// currentTracer returns the tracer set with SetTracer, or a no-op.
+func (b *EventBus) currentTracer() ports.Tracer {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.tracer == nil {
+		return ports.NopTracer{}
+	}
+
+	return b.tracer
+}
+
+// acknowledger returns the current acknowledger, if any.
+func (b *EventBus) acknowledger() ports.Acknowledger {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.acker
+}
+
+// DurableSubscribers returns the names of subscribers that acknowledge messages.
+func (b *EventBus) DurableSubscribers() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	names := make([]string, 0, len(b.order))
+	for _, name := range b.order {
+		if b.subs[name].opts.Durable {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// Subscribe registers a subscriber and starts its delivery loop.
+func (b *EventBus) Subscribe(name string, subscriber ports.Subscriber, opts SubscriptionOptions) error {
+	if name == "" {
+		return ErrSubscriberNameEmpty
+	}
+
+	if subscriber == nil {
+		return ErrSubscriberNil
+	}
+
+	if opts.Durable && opts.Policy == BackpressureDropOldest {
+		return fmt.Errorf("%w: %s", ErrDurableDropOldest, name)
+	}
+
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultSubscriberQueueSize
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultSubscriberBatchSize
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBusClosed
+	}
+
+	if _, ok := b.subs[name]; ok {
+		return fmt.Errorf("%w: %s", ErrSubscriberExists, name)
+	}
+
+	sub, err := newSubscription(name, subscriber, opts, b.acknowledgeFunc(name), b.observeFunc(name), b.currentTracer, b.log)
+	if err != nil {
+		return err
+	}
+
+	b.subs[name] = sub
+	b.order = append(b.order, name)
+
+	go sub.run()
+
+	b.log.Info().
+		Str("subscriber", name).
+		Int("queue_size", opts.QueueSize).
+		Str("policy", opts.Policy.String()).
+		Msg("Event bus subscriber registered")
+
+	return nil
+}
+
+// Unsubscribe removes a subscriber by name and stops its delivery loop.
+func (b *EventBus) Unsubscribe(name string) error {
+	b.mu.Lock()
+	sub, ok := b.subs[name]
+	if ok {
+		delete(b.subs, name)
+		for i, n := range b.order {
+			if n == name {
+				b.order = append(b.order[:i], b.order[i+1:]...)
+
+				break
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSubscriberNotFound, name)
+	}
+
+	sub.close()
+
+	return nil
+}
+
+// acknowledgeFunc returns the callback a durable subscription uses to report a handled message.
+func (b *EventBus) acknowledgeFunc(name string) func(ports.Message) {
+	return func(msg ports.Message) {
+		acker := b.acknowledger()
+		if acker == nil {
+			return
+		}
+
+		if err := acker.Ack(name, msg.ID); err != nil {
+			b.log.Error().Err(err).Str("subscriber", name).Str("message_id", msg.ID).Msg("Failed to acknowledge message")
+		}
+	}
+}
+
+// observeFunc returns the callback a subscription uses to report how long it
+// took to handle a delivery. Only job messages are measured.
+func (b *EventBus) observeFunc(name string) func([]ports.Message, time.Duration, error) {
+	return func(msgs []ports.Message, elapsed time.Duration, err error) {
+		b.mu.RLock()
+		metrics := b.metrics
+		b.mu.RUnlock()
+
+		if metrics == nil {
+			return
+		}
+
+		for _, msg := range msgs {
+			if msg.Job != nil {
+				metrics.JobHandled(name, msg.Job.Type, elapsed, err)
+			}
+		}
+	}
+}
+
+// PublishTo enqueues messages for a single subscriber. It is used to redeliver
+// messages that a durable subscriber had not acknowledged before a restart.
+func (b *EventBus) PublishTo(ctx context.Context, name string, msgs ...ports.Message) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+
+		return ErrBusClosed
+	}
+
+	sub, ok := b.subs[name]
+	b.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSubscriberNotFound, name)
+	}
+
+	for _, msg := range msgs {
+		if err := sub.enqueue(ctx, msg); err != nil {
+			return fmt.Errorf("failed to enqueue message for %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Publish enqueues messages for every subscriber.
+func (b *EventBus) Publish(ctx context.Context, msgs ...ports.Message) error {
+	return b.PublishFrom(ctx, "", msgs...)
+}
+
+// PublishFrom enqueues messages that the named subscriber derived for every
+// other subscriber. A subscriber that publishes from its own delivery path
+// would otherwise wait on its own full queue.
+func (b *EventBus) PublishFrom(ctx context.Context, source string, msgs ...ports.Message) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+
+		return ErrBusClosed
+	}
+
+	subs := make([]*subscription, 0, len(b.order))
+	for _, name := range b.order {
+		if name != source {
+			subs = append(subs, b.subs[name])
+		}
+	}
+
+	router := b.router
+	b.mu.RUnlock()
+
+	for _, msg := range msgs {
+		if msg.ID == "" {
+			msg.ID = strconv.FormatUint(b.seq.Add(1), 10)
+		}
+
+		var routes []string
+		if router != nil && msg.Event != nil {
+			routes = router.Route(msg)
+		}
+
+		for _, sub := range subs {
+			if router != nil && msg.Event != nil && router.Manages(sub.name) && !slices.Contains(routes, sub.name) {
+				// Acknowledge skipped messages so durable storage does not wait for them.
+				if sub.opts.Durable {
+					sub.ack(msg)
+				}
+
+				continue
+			}
+
+			if err := sub.enqueue(ctx, msg); err != nil {
+				return fmt.Errorf("failed to enqueue message for %s: %w", sub.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// DispatchEvents publishes polled events to every subscriber.
+func (b *EventBus) DispatchEvents(events []ports.Event) {
+	msgs := make([]ports.Message, 0, len(events))
+	for i := range events {
+		msgs = append(msgs, ports.Message{Event: &events[i]})
+	}
+
+	if err := b.Publish(context.Background(), msgs...); err != nil {
+		b.log.Error().Err(err).Int("count", len(events)).Msg("Failed to publish events")
+	}
+}
+
+// DispatchJob publishes a streamed job to every subscriber.
+func (b *EventBus) DispatchJob(job *ports.Job) {
+	if err := b.Publish(context.Background(), ports.Message{Job: job}); err != nil {
+		b.log.Error().Err(err).Msg("Failed to publish job")
+	}
+}
+
+// Saturated returns true if every subscriber's queue is full. Spill
+// subscribers overflow to disk rather than hold up publishers, so they are
+// left out. A bus without other subscribers is never saturated.
+func (b *EventBus) Saturated() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	saturated := false
+
+	for _, sub := range b.subs {
+		if sub.opts.Policy == BackpressureSpill {
+			continue
+		}
+
+		if !sub.full() {
+			return false
+		}
+
+		saturated = true
+	}
+
+	return saturated
+}
+
+// Stats returns a snapshot of every subscriber's queue in subscription order.
+func (b *EventBus) Stats() []SubscriptionStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	stats := make([]SubscriptionStats, 0, len(b.order))
+	for _, name := range b.order {
+		stats = append(stats, b.subs[name].stats())
+	}
+
+	return stats
+}
+
+// Close stops all subscribers. Messages still queued in memory are discarded;
+// spilled messages remain on disk and are replayed by the next subscriber with the same name.
+func (b *EventBus) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+
+		return nil
+	}
+
+	b.closed = true
+	subs := b.subs
+	b.subs = make(map[string]*subscription)
+	b.order = nil
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.close()
+	}
+
+	return nil
+}
+
+// subscription holds the bounded queue and delivery loop for one subscriber.
+type subscription struct {
+	name       string
+	subscriber ports.Subscriber
+	opts       SubscriptionOptions
+	ack        func(ports.Message)
+	observe    func([]ports.Message, time.Duration, error)
+	tracer     func() ports.Tracer
+	log        *zerolog.Logger
+
+	mu       sync.Mutex
+	queue    []ports.Message
+	spill    *spillFile
+	notEmpty chan struct{}
+	notFull  chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	closing  sync.Once
+
+	delivered atomic.Uint64
+	failed    atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+func newSubscription(
+	name string,
+	subscriber ports.Subscriber,
+	opts SubscriptionOptions,
+	ack func(ports.Message),
+	observe func([]ports.Message, time.Duration, error),
+	tracer func() ports.Tracer,
+	log *zerolog.Logger,
+) (*subscription, error) {
+	sub := &subscription{
+		name:       name,
+		subscriber: subscriber,
+		opts:       opts,
+		ack:        ack,
+		observe:    observe,
+		tracer:     tracer,
+		log:        log,
+		queue:      make([]ports.Message, 0, opts.QueueSize),
+		notEmpty:   make(chan struct{}, 1),
+		notFull:    make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+
+	switch opts.Policy {
+	case BackpressureBlock, BackpressureDropOldest:
+	case BackpressureSpill:
+		if opts.SpillDir == "" {
+			return nil, ErrSpillDirRequired
+		}
+
+		spill, err := openSpillFile(filepath.Join(opts.SpillDir, name+".spill"))
+		if err != nil {
+			return nil, err
+		}
+
+		sub.spill = spill
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnknownBackpressure, opts.Policy)
+	}
+
+	sub.ctx, sub.cancel = context.WithCancel(context.Background())
+
+	return sub, nil
+}
+
+func (s *subscription) enqueue(ctx context.Context, msg ports.Message) error {
+	for {
+		s.mu.Lock()
+
+		select {
+		case <-s.done:
+			s.mu.Unlock()
+
+			return ErrBusClosed
+		default:
+		}
+
+		// Once messages have spilled, new ones follow them to disk to keep ordering.
+		if s.spill != nil && s.spill.pending > 0 {
+			err := s.spill.write(msg)
+			s.mu.Unlock()
+
+			return err
+		}
+
+		if len(s.queue) < s.opts.QueueSize {
+			s.queue = append(s.queue, msg)
+			s.mu.Unlock()
+			signal(s.notEmpty)
+
+			return nil
+		}
+
+		switch s.opts.Policy {
+		case BackpressureDropOldest:
+			s.queue = append(s.queue[1:], msg)
+			s.mu.Unlock()
+			s.dropped.Add(1)
+
+			return nil
+		case BackpressureSpill:
+			err := s.spill.write(msg)
+			s.mu.Unlock()
+
+			return err
+		default:
+			s.mu.Unlock()
+		}
+
+		select {
+		case <-s.notFull:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.done:
+			return ErrBusClosed
+		}
+	}
+}
+
+// next blocks until a message is available and returns up to limit queued
+// messages. It returns false once the subscription is closed.
+func (s *subscription) next(limit int) ([]ports.Message, bool) {
+	for {
+		select {
+		case <-s.done:
+			return nil, false
+		default:
+		}
+
+		s.mu.Lock()
+
+		if len(s.queue) == 0 && s.spill != nil && s.spill.pending > 0 {
+			msgs, err := s.spill.read(s.opts.QueueSize)
+			if err != nil {
+				s.log.Error().Err(err).Str("subscriber", s.name).Msg("Failed to read spilled messages")
+			}
+
+			s.queue = append(s.queue, msgs...)
+		}
+
+		if len(s.queue) > 0 {
+			n := min(limit, len(s.queue))
+			batch := make([]ports.Message, n)
+			copy(batch, s.queue)
+			clear(s.queue[:n])
+			s.queue = s.queue[n:]
+			s.mu.Unlock()
+			signal(s.notFull)
+
+			return batch, true
+		}
+
+		s.mu.Unlock()
+
+		select {
+		case <-s.notEmpty:
+		case <-s.done:
+			return nil, false
+		}
+	}
+}
+
+func (s *subscription) run() {
+	defer close(s.stopped)
+
+	batcher, isBatcher := s.subscriber.(ports.BatchSubscriber)
+
+	limit := 1
+	if isBatcher {
+		limit = s.opts.BatchSize
+	}
+
+	for {
+		msgs, ok := s.next(limit)
+		if !ok {
+			return
+		}
+
+		handle := func(ctx context.Context) error { return s.subscriber.HandleMessage(ctx, msgs[0]) }
+		if isBatcher {
+			handle = func(ctx context.Context) error { return batcher.HandleBatch(ctx, msgs) }
+		}
+
+		if !s.deliver(msgs, handle) {
+			return
+		}
+	}
+}
+
+// deliver hands messages to the subscriber. Durable subscriptions retry until the
+// subscriber succeeds and then acknowledge every message. It returns false if the
+// subscription was closed while retrying. Every attempt to deliver jobs is traced.
+func (s *subscription) deliver(msgs []ports.Message, handle func(context.Context) error) bool {
+	for {
+		ctx, span := startJobSpan(s.ctx, s.tracer(), s.name, msgs)
+
+		start := time.Now()
+		err := handle(ctx)
+		s.observe(msgs, time.Since(start), err)
+		endSpan(span, err)
+
+		if err == nil {
+			s.delivered.Add(uint64(len(msgs)))
+
+			if s.opts.Durable {
+				for _, msg := range msgs {
+					s.ack(msg)
+				}
+			}
+
+			return true
+		}
+
+		s.failed.Add(uint64(len(msgs)))
+		LoggerWithTrace(ctx, s.log).Error().
+			Err(err).
+			Str("subscriber", s.name).
+			Str("message_id", msgs[0].ID).
+			Int("messages", len(msgs)).
+			Msg("Subscriber failed to handle message")
+
+		if !s.opts.Durable {
+			return true
+		}
+
+		select {
+		case <-s.done:
+			return false
+		case <-time.After(DurableRedeliveryDelay):
+		}
+	}
+}
+
+func (s *subscription) full() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.queue) >= s.opts.QueueSize
+}
+
+func (s *subscription) stats() SubscriptionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	spilled := 0
+	if s.spill != nil {
+		spilled = s.spill.pending
+	}
+
+	return SubscriptionStats{
+		Name:      s.name,
+		Policy:    s.opts.Policy,
+		Capacity:  s.opts.QueueSize,
+		Queued:    len(s.queue),
+		Spilled:   spilled,
+		Delivered: s.delivered.Load(),
+		Failed:    s.failed.Load(),
+		Dropped:   s.dropped.Load(),
+	}
+}
+
+func (s *subscription) close() {
+	s.closing.Do(func() {
+		close(s.done)
+		s.cancel()
+		<-s.stopped
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.spill != nil {
+			if err := s.spill.compact(); err != nil {
+				s.log.Error().Err(err).Str("subscriber", s.name).Msg("Failed to compact spill file")
+			}
+
+			if err := s.spill.close(); err != nil {
+				s.log.Error().Err(err).Str("subscriber", s.name).Msg("Failed to close spill file")
+			}
+		}
+	})
+}
+
+// signal performs a non-blocking send on a one-slot notification channel.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// spillFile is an append-only JSON lines file used as queue overflow.
+// The read offset lives in memory, so after a restart spilled messages are
+// replayed from the beginning of the file.
+type spillFile struct {
+	file    *os.File
+	offset  int64
+	pending int
+}
+
+func openSpillFile(path string) (*spillFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create spill directory: %w", err)
+	}
+
+	//nolint:gosec // Spill path is built from operator configuration, not user input
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spill file: %w", err)
+	}
+
+	spill := &spillFile{file: file}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSpillRecordSize)
+
+	for scanner.Scan() {
+		spill.pending++
+	}
+
+	if err := scanner.Err(); err != nil {
+		_ = file.Close()
+
+		return nil, fmt.Errorf("failed to scan spill file: %w", err)
+	}
+
+	return spill, nil
+}
+
+func (f *spillFile) write(msg ports.Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode spill record: %w", err)
+	}
+
+	if _, err := f.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write spill record: %w", err)
+	}
+
+	f.pending++
+
+	return nil
+}
+
+func (f *spillFile) read(limit int) ([]ports.Message, error) {
+	reader := bufio.NewReaderSize(io.NewSectionReader(f.file, f.offset, 1<<62), 64*1024)
+	msgs := make([]ports.Message, 0, min(limit, f.pending))
+
+	for len(msgs) < limit && f.pending > 0 {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return msgs, f.reset(fmt.Errorf("%w: %v", ErrSpillRecordMalformed, err))
+		}
+
+		f.offset += int64(len(line))
+		f.pending--
+
+		var msg ports.Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			return msgs, f.reset(fmt.Errorf("%w: %v", ErrSpillRecordMalformed, err))
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	if f.pending == 0 {
+		return msgs, f.reset(nil)
+	}
+
+	return msgs, nil
+}
+
+// reset empties the spill file. A corrupt file is discarded rather than
+// retried forever; cause is returned so the caller can report it.
+func (f *spillFile) reset(cause error) error {
+	f.pending = 0
+	f.offset = 0
+
+	if err := f.file.Truncate(0); err != nil {
+		return errors.Join(cause, fmt.Errorf("failed to truncate spill file: %w", err))
+	}
+
+	return cause
+}
+
+// compact drops already-delivered records from the head of the file so that
+// a restarted subscriber only replays messages it has not seen.
+func (f *spillFile) compact() error {
+	if f.offset == 0 {
+		return nil
+	}
+
+	info, err := f.file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat spill file: %w", err)
+	}
+
+	rest := make([]byte, info.Size()-f.offset)
+	if _, err := f.file.ReadAt(rest, f.offset); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read spill file: %w", err)
+	}
+
+	if err := f.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate spill file: %w", err)
+	}
+
+	f.offset = 0
+
+	if _, err := f.file.Write(rest); err != nil {
+		return fmt.Errorf("failed to rewrite spill file: %w", err)
+	}
+
+	return nil
+}
+
+func (f *spillFile) close() error {
+	return f.file.Close()
+}
+
+// Ensure EventBus implements the ports.EventBus interface.
+var _ ports.EventBus = (*EventBus)(nil)
@@ -0,0 +1,390 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// channelSubscriber forwards delivered messages to a channel. When gate is
+// non-nil, every delivery waits for a value on it first.
+type channelSubscriber struct {
+	received chan ports.Message
+	gate     chan struct{}
+}
+
+func newChannelSubscriber(size int) *channelSubscriber {
+	return &channelSubscriber{received: make(chan ports.Message, size)}
+}
+
+func (s *channelSubscriber) HandleMessage(ctx context.Context, msg ports.Message) error {
+	if s.gate != nil {
+		select {
+		case <-s.gate:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	s.received <- msg
+
+	return nil
+}
+
+func (s *channelSubscriber) expect(t *testing.T, eventType string) {
+	t.Helper()
+
+	select {
+	case msg := <-s.received:
+		if msg.Event == nil || msg.Event.Type != eventType {
+			t.Fatalf("Expected event %q, got %+v", eventType, msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for event %q", eventType)
+	}
+}
+
+func newTestBus(t *testing.T) *EventBus {
+	t.Helper()
+
+	logger := zerolog.Nop()
+	bus := NewEventBus(&logger)
+	t.Cleanup(func() { _ = bus.Close() })
+
+	return bus
+}
+
+func TestEventBus_FanOut(t *testing.T) {
+	bus := newTestBus(t)
+	first := newChannelSubscriber(4)
+	second := newChannelSubscriber(4)
+
+	if err := bus.Subscribe("first", first, SubscriptionOptions{}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	if err := bus.Subscribe("second", second, SubscriptionOptions{}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	bus.DispatchEvents([]ports.Event{{Type: "a"}, {Type: "b"}})
+
+	for _, sub := range []*channelSubscriber{first, second} {
+		sub.expect(t, "a")
+		sub.expect(t, "b")
+	}
+}
+
+// batchSubscriber records the size of every batch it receives.
+type batchSubscriber struct {
+	channelSubscriber
+	batches chan int
+}
+
+func (s *batchSubscriber) HandleBatch(ctx context.Context, msgs []ports.Message) error {
+	s.batches <- len(msgs)
+
+	for _, msg := range msgs {
+		if err := s.HandleMessage(ctx, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestEventBus_BatchSubscriber(t *testing.T) {
+	bus := newTestBus(t)
+	sub := &batchSubscriber{channelSubscriber: *newChannelSubscriber(8), batches: make(chan int, 8)}
+	sub.gate = make(chan struct{})
+
+	if err := bus.Subscribe("batch", sub, SubscriptionOptions{BatchSize: 3}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	bus.DispatchEvents([]ports.Event{{Type: "a"}, {Type: "b"}, {Type: "c"}, {Type: "d"}, {Type: "e"}})
+	close(sub.gate)
+
+	for _, eventType := range []string{"a", "b", "c", "d", "e"} {
+		sub.expect(t, eventType)
+	}
+
+	total, largest := 0, 0
+	for total < 5 {
+		n := <-sub.batches
+		if n > 3 {
+			t.Errorf("Expected batches of at most 3, got %d", n)
+		}
+
+		total += n
+		largest = max(largest, n)
+	}
+
+	if largest < 2 {
+		t.Errorf("Expected queued messages to be batched, largest batch was %d", largest)
+	}
+}
+
+func TestEventBus_SubscribeValidation(t *testing.T) {
+	bus := newTestBus(t)
+	sub := newChannelSubscriber(1)
+
+	if err := bus.Subscribe("", sub, SubscriptionOptions{}); !errors.Is(err, ErrSubscriberNameEmpty) {
+		t.Errorf("Expected ErrSubscriberNameEmpty, got %v", err)
+	}
+
+	if err := bus.Subscribe("nil", nil, SubscriptionOptions{}); !errors.Is(err, ErrSubscriberNil) {
+		t.Errorf("Expected ErrSubscriberNil, got %v", err)
+	}
+
+	if err := bus.Subscribe("spill", sub, SubscriptionOptions{Policy: BackpressureSpill}); !errors.Is(err, ErrSpillDirRequired) {
+		t.Errorf("Expected ErrSpillDirRequired, got %v", err)
+	}
+
+	if err := bus.Subscribe("dup", sub, SubscriptionOptions{}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	if err := bus.Subscribe("dup", sub, SubscriptionOptions{}); !errors.Is(err, ErrSubscriberExists) {
+		t.Errorf("Expected ErrSubscriberExists, got %v", err)
+	}
+
+	if err := bus.Unsubscribe("dup"); err != nil {
+		t.Errorf("Unsubscribe failed: %v", err)
+	}
+
+	if err := bus.Unsubscribe("dup"); !errors.Is(err, ErrSubscriberNotFound) {
+		t.Errorf("Expected ErrSubscriberNotFound, got %v", err)
+	}
+}
+
+func TestEventBus_DropOldest(t *testing.T) {
+	bus := newTestBus(t)
+	sub := newChannelSubscriber(4)
+	sub.gate = make(chan struct{})
+
+	if err := bus.Subscribe("slow", sub, SubscriptionOptions{QueueSize: 2, Policy: BackpressureDropOldest}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	// The first message is taken by the delivery loop and parked on the gate.
+	bus.DispatchEvents([]ports.Event{{Type: "1"}})
+	waitFor(t, func() bool { return bus.Stats()[0].Queued == 0 })
+
+	bus.DispatchEvents([]ports.Event{{Type: "2"}, {Type: "3"}, {Type: "4"}})
+
+	if !bus.Saturated() {
+		t.Error("Expected bus to be saturated")
+	}
+
+	if dropped := bus.Stats()[0].Dropped; dropped != 1 {
+		t.Errorf("Expected 1 dropped message, got %d", dropped)
+	}
+
+	close(sub.gate)
+	sub.expect(t, "1")
+	sub.expect(t, "3")
+	sub.expect(t, "4")
+}
+
+func TestEventBus_RejectsDurableDropOldest(t *testing.T) {
+	bus := newTestBus(t)
+
+	err := bus.Subscribe("slow", newChannelSubscriber(1), SubscriptionOptions{Policy: BackpressureDropOldest, Durable: true})
+	if !errors.Is(err, ErrDurableDropOldest) {
+		t.Errorf("Expected ErrDurableDropOldest, got %v", err)
+	}
+}
+
+func TestEventBus_BlockWaitsForCapacity(t *testing.T) {
+	bus := newTestBus(t)
+	sub := newChannelSubscriber(4)
+	sub.gate = make(chan struct{})
+
+	if err := bus.Subscribe("blocking", sub, SubscriptionOptions{QueueSize: 1}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	bus.DispatchEvents([]ports.Event{{Type: "1"}})
+	waitFor(t, func() bool { return bus.Stats()[0].Queued == 0 })
+	bus.DispatchEvents([]ports.Event{{Type: "2"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := bus.Publish(ctx, ports.Message{Event: &ports.Event{Type: "3"}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected publish to block until deadline, got %v", err)
+	}
+
+	close(sub.gate)
+	sub.expect(t, "1")
+	sub.expect(t, "2")
+}
+
+func TestEventBus_SpillPreservesOrder(t *testing.T) {
+	bus := newTestBus(t)
+	dir := t.TempDir()
+	sub := newChannelSubscriber(8)
+	sub.gate = make(chan struct{})
+
+	if err := bus.Subscribe("spilling", sub, SubscriptionOptions{QueueSize: 1, Policy: BackpressureSpill, SpillDir: dir}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	bus.DispatchEvents([]ports.Event{{Type: "1"}})
+	waitFor(t, func() bool { return bus.Stats()[0].Queued == 0 })
+	bus.DispatchEvents([]ports.Event{{Type: "2"}, {Type: "3"}, {Type: "4"}})
+
+	if spilled := bus.Stats()[0].Spilled; spilled != 2 {
+		t.Errorf("Expected 2 spilled messages, got %d", spilled)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "spilling.spill")); err != nil {
+		t.Errorf("Expected spill file to exist: %v", err)
+	}
+
+	if bus.Saturated() {
+		t.Error("Expected a spill subscriber not to saturate the bus")
+	}
+
+	close(sub.gate)
+
+	for _, eventType := range []string{"1", "2", "3", "4"} {
+		sub.expect(t, eventType)
+	}
+}
+
+func TestEventBus_SpillReplayedAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	logger := zerolog.Nop()
+
+	bus := NewEventBus(&logger)
+	parked := newChannelSubscriber(1)
+	parked.gate = make(chan struct{})
+
+	if err := bus.Subscribe("durable", parked, SubscriptionOptions{QueueSize: 1, Policy: BackpressureSpill, SpillDir: dir}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	bus.DispatchEvents([]ports.Event{{Type: "1"}})
+	waitFor(t, func() bool { return bus.Stats()[0].Queued == 0 })
+	bus.DispatchEvents([]ports.Event{{Type: "2"}, {Type: "3"}})
+
+	if err := bus.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	restarted := newTestBus(t)
+	sub := newChannelSubscriber(4)
+
+	if err := restarted.Subscribe("durable", sub, SubscriptionOptions{QueueSize: 1, Policy: BackpressureSpill, SpillDir: dir}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	sub.expect(t, "3")
+}
+
+func TestEventBus_ClosedBusRejectsPublish(t *testing.T) {
+	logger := zerolog.Nop()
+	bus := NewEventBus(&logger)
+
+	if err := bus.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if err := bus.Publish(context.Background(), ports.Message{}); !errors.Is(err, ErrBusClosed) {
+		t.Errorf("Expected ErrBusClosed, got %v", err)
+	}
+
+	if err := bus.Subscribe("late", newChannelSubscriber(1), SubscriptionOptions{}); !errors.Is(err, ErrBusClosed) {
+		t.Errorf("Expected ErrBusClosed, got %v", err)
+	}
+}
+
+func TestParseBackpressurePolicy(t *testing.T) {
+	for name, want := range map[string]BackpressurePolicy{
+		"":            BackpressureBlock,
+		"block":       BackpressureBlock,
+		"drop-oldest": BackpressureDropOldest,
+		"spill":       BackpressureSpill,
+	} {
+		got, err := ParseBackpressurePolicy(name)
+		if err != nil || got != want {
+			t.Errorf("ParseBackpressurePolicy(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+
+	if _, err := ParseBackpressurePolicy("bogus"); !errors.Is(err, ErrUnknownBackpressure) {
+		t.Errorf("Expected ErrUnknownBackpressure, got %v", err)
+	}
+}
+
+func TestPollEventsProcess_PublishesToSubscribers(t *testing.T) {
+	domain, _, mockClient := setupTestDomain()
+	sub := newChannelSubscriber(2)
+
+	if err := domain.Subscribe("sink", sub, SubscriptionOptions{}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	mockClient.pollEventsResult = ports.PollEventsResult{Events: []ports.Event{{Type: "polled"}}}
+
+	process := &PollEventsProcess{domain: domain}
+	if err := process.pollEvents(); err != nil {
+		t.Fatalf("pollEvents failed: %v", err)
+	}
+
+	sub.expect(t, "polled")
+}
+
+func TestPollEventsProcess_waitForCapacity(t *testing.T) {
+	domain, _, _ := setupTestDomain()
+	_ = domain.EventBus().Unsubscribe(HostPluginSubscriberName)
+
+	sub := newChannelSubscriber(1)
+	sub.gate = make(chan struct{})
+
+	if err := domain.Subscribe("slow", sub, SubscriptionOptions{QueueSize: 1, Policy: BackpressureDropOldest}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	domain.EventBus().DispatchEvents([]ports.Event{{Type: "1"}})
+	waitFor(t, func() bool { return domain.EventBus().Stats()[0].Queued == 0 })
+	domain.EventBus().DispatchEvents([]ports.Event{{Type: "2"}})
+
+	process := &PollEventsProcess{domain: domain}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if process.waitForCapacity(ctx) {
+		t.Error("Expected waitForCapacity to wait while the bus is saturated")
+	}
+
+	close(sub.gate)
+
+	if !process.waitForCapacity(context.Background()) {
+		t.Error("Expected waitForCapacity to return once capacity is available")
+	}
+}
+
+// waitFor polls cond until it holds or a second passes.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,391 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// ErrInvalidKPIBucket is returned for a bucket width that is not a positive whole number of minutes.
+var ErrInvalidKPIBucket = errors.New("invalid KPI bucket")
+
+// KPIOptions configures the agent KPI aggregator.
+type KPIOptions struct {
+	// Buckets are the widths to roll up by. Defaults to DefaultKPIBuckets.
+	Buckets []time.Duration
+	// Grace keeps a bucket open after it ends so late events are still counted.
+	// Defaults to DefaultKPIGrace.
+	Grace time.Duration
+	// FlushInterval is how often closed buckets are emitted. Defaults to DefaultKPIFlushInterval.
+	FlushInterval time.Duration
+}
+
+// kpiKey identifies one agent's bucket.
+type kpiKey struct {
+	agent string
+	width time.Duration
+	start time.Time
+}
+
+// kpiBucket collects the agent calls that fall in one bucket, keyed by agent call SID
+// so a redelivered call replaces its earlier copy.
+type kpiBucket struct {
+	orgID string
+	calls map[int64]ports.ExileAgentCall
+}
+
+// KPIAggregator rolls ExileAgentCall durations up per partner agent and time
+// bucket. Calls are placed by their create time. A bucket is emitted once its
+// end plus the grace period has passed. Emitted buckets are remembered for one
+// bucket width (or the grace period, if longer) so that a late or redelivered
+// call is dropped and counted instead of emitting its bucket a second time.
+type KPIAggregator struct {
+	opts KPIOptions
+	emit func(ports.AgentKPI)
+	log  *zerolog.Logger
+	now  func() time.Time
+
+	mu      sync.Mutex
+	buckets map[kpiKey]*kpiBucket
+	emitted map[kpiKey]time.Time // when each emitted bucket may be forgotten
+	late    int
+	done    chan struct{}
+	wg      sync.WaitGroup
+	closed  bool
+}
+
+// NewKPIAggregator creates an aggregator that passes finished buckets to emit.
+// Call Start to emit buckets as they close.
+func NewKPIAggregator(opts KPIOptions, emit func(ports.AgentKPI), log *zerolog.Logger) (*KPIAggregator, error) {
+	if len(opts.Buckets) == 0 {
+		opts.Buckets = DefaultKPIBuckets
+	}
+
+	for _, width := range opts.Buckets {
+		if width < time.Minute || width%time.Minute != 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidKPIBucket, width)
+		}
+	}
+
+	if opts.Grace <= 0 {
+		opts.Grace = DefaultKPIGrace
+	}
+
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultKPIFlushInterval
+	}
+
+	return &KPIAggregator{
+		opts:    opts,
+		emit:    emit,
+		log:     log,
+		now:     time.Now,
+		buckets: make(map[kpiKey]*kpiBucket),
+		emitted: make(map[kpiKey]time.Time),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// Start emits buckets in the background as they close.
+func (a *KPIAggregator) Start() {
+	a.wg.Add(1)
+
+	go a.flushLoop()
+}
+
+func (a *KPIAggregator) flushLoop() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+			a.Expire()
+		}
+	}
+}
+
+// HandleMessage implements ports.Subscriber. Only agent call events are used.
+func (a *KPIAggregator) HandleMessage(_ context.Context, msg ports.Message) error {
+	if msg.Event != nil && msg.Event.AgentCall != nil {
+		a.Add(*msg.Event.AgentCall)
+	}
+
+	return nil
+}
+
+// Add counts an agent call in every bucket width. Calls without a partner agent
+// ID are ignored, and calls for a bucket that was already emitted are dropped.
+func (a *KPIAggregator) Add(call ports.ExileAgentCall) {
+	if call.PartnerAgentID == "" {
+		return
+	}
+
+	at, err := time.Parse(time.RFC3339, call.CreateTime)
+	if err != nil || at.Unix() == 0 {
+		at = a.now()
+	}
+
+	at = at.UTC()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, width := range a.opts.Buckets {
+		key := kpiKey{agent: call.PartnerAgentID, width: width, start: at.Truncate(width)}
+
+		if _, ok := a.emitted[key]; ok {
+			a.late++
+			a.log.Debug().
+				Str("partner_agent_id", key.agent).
+				Int64("agent_call_sid", call.AgentCallSid).
+				Str("bucket", FormatKPIBucket(width)).
+				Time("start", key.start).
+				Msg("Dropping agent call for an emitted KPI bucket")
+
+			continue
+		}
+
+		bucket, ok := a.buckets[key]
+		if !ok {
+			bucket = &kpiBucket{orgID: call.OrgID, calls: make(map[int64]ports.ExileAgentCall)}
+			a.buckets[key] = bucket
+		}
+
+		bucket.calls[call.AgentCallSid] = call
+	}
+}
+
+// Expire emits every bucket whose end plus the grace period has passed.
+func (a *KPIAggregator) Expire() int {
+	return a.drain(false)
+}
+
+// Flush emits every bucket. Buckets that have not closed are marked partial.
+func (a *KPIAggregator) Flush() int {
+	return a.drain(true)
+}
+
+// LateCalls returns how many times a call arrived for a bucket that had
+// already been emitted and was dropped.
+func (a *KPIAggregator) LateCalls() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.late
+}
+
+func (a *KPIAggregator) drain(all bool) int {
+	now := a.now()
+	cutoff := now.Add(-a.opts.Grace)
+
+	a.mu.Lock()
+
+	for key, forget := range a.emitted {
+		if !forget.After(now) {
+			delete(a.emitted, key)
+		}
+	}
+
+	var kpis []ports.AgentKPI
+
+	for key, bucket := range a.buckets {
+		final := !key.start.Add(key.width).After(cutoff)
+		if !final && !all {
+			continue
+		}
+
+		kpis = append(kpis, bucket.summarize(key, final))
+		delete(a.buckets, key)
+		a.emitted[key] = now.Add(max(key.width, a.opts.Grace))
+	}
+
+	a.mu.Unlock()
+
+	if len(kpis) > 0 {
+		a.log.Debug().Int("buckets", len(kpis)).Msg("Emitting agent KPIs")
+	}
+
+	SortAgentKPIs(kpis)
+
+	for _, kpi := range kpis {
+		a.emit(kpi)
+	}
+
+	return len(kpis)
+}
+
+// Close stops the background flush and emits every open bucket.
+func (a *KPIAggregator) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+
+		return nil
+	}
+
+	a.closed = true
+	close(a.done)
+	a.mu.Unlock()
+
+	a.wg.Wait()
+	a.Flush()
+
+	return nil
+}
+
+// AggregateKPIs rolls captured events up into one bucket width.
+func AggregateKPIs(events []ports.Event, width time.Duration) ([]ports.AgentKPI, error) {
+	var kpis []ports.AgentKPI
+
+	logger := zerolog.Nop()
+
+	a, err := NewKPIAggregator(KPIOptions{Buckets: []time.Duration{width}}, func(kpi ports.AgentKPI) {
+		kpis = append(kpis, kpi)
+	}, &logger)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		if event.AgentCall != nil {
+			a.Add(*event.AgentCall)
+		}
+	}
+
+	a.Flush()
+
+	return kpis, nil
+}
+
+// SortAgentKPIs orders KPIs by bucket start, then width, then agent.
+func SortAgentKPIs(kpis []ports.AgentKPI) {
+	sort.SliceStable(kpis, func(i, j int) bool {
+		switch {
+		case !kpis[i].Start.Equal(kpis[j].Start):
+			return kpis[i].Start.Before(kpis[j].Start)
+		case kpis[i].Bucket != kpis[j].Bucket:
+			return kpis[i].End.Before(kpis[j].End)
+		default:
+			return kpis[i].PartnerAgentID < kpis[j].PartnerAgentID
+		}
+	})
+}
+
+// FormatKPIBucket names a bucket width the way ParseKPIBucket reads it, e.g. "15m", "1h" or "1d".
+func FormatKPIBucket(width time.Duration) string {
+	const day = 24 * time.Hour
+
+	switch {
+	case width%day == 0:
+		return strconv.FormatInt(int64(width/day), 10) + "d"
+	case width%time.Hour == 0:
+		return strconv.FormatInt(int64(width/time.Hour), 10) + "h"
+	default:
+		return strconv.FormatInt(int64(width/time.Minute), 10) + "m"
+	}
+}
+
+// ParseKPIBucket reads a bucket width such as "15m", "1h" or "1d".
+func ParseKPIBucket(name string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(name, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("%w: %s", ErrInvalidKPIBucket, name)
+		}
+
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	width, err := time.ParseDuration(name)
+	if err != nil || width < time.Minute || width%time.Minute != 0 {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidKPIBucket, name)
+	}
+
+	return width, nil
+}
+
+// summarize computes totals, averages and handle-time percentiles for a bucket.
+func (b *kpiBucket) summarize(key kpiKey, final bool) ports.AgentKPI {
+	kpi := ports.AgentKPI{
+		PartnerAgentID: key.agent,
+		OrgID:          b.orgID,
+		Bucket:         FormatKPIBucket(key.width),
+		Start:          key.start,
+		End:            key.start.Add(key.width),
+		Calls:          len(b.calls),
+		Final:          final,
+	}
+
+	handleTimes := make([]float64, 0, len(b.calls))
+
+	for _, call := range b.calls {
+		handle := float64(call.TalkDuration + call.HoldDuration + call.WrapUpDuration)
+		handleTimes = append(handleTimes, handle)
+
+		kpi.Totals.Talk += float64(call.TalkDuration)
+		kpi.Totals.CallWait += float64(call.CallWaitDuration)
+		kpi.Totals.WrapUp += float64(call.WrapUpDuration)
+		kpi.Totals.Pause += float64(call.PauseDuration)
+		kpi.Totals.Hold += float64(call.HoldDuration)
+		kpi.Totals.Transfer += float64(call.TransferDuration)
+		kpi.Totals.Preview += float64(call.PreviewDuration)
+		kpi.Totals.Manual += float64(call.ManualDuration)
+		kpi.Totals.HandleTime += handle
+	}
+
+	if kpi.Calls > 0 {
+		n := float64(kpi.Calls)
+		kpi.Averages = ports.KPIDurations{
+			Talk:       kpi.Totals.Talk / n,
+			CallWait:   kpi.Totals.CallWait / n,
+			WrapUp:     kpi.Totals.WrapUp / n,
+			Pause:      kpi.Totals.Pause / n,
+			Hold:       kpi.Totals.Hold / n,
+			Transfer:   kpi.Totals.Transfer / n,
+			Preview:    kpi.Totals.Preview / n,
+			Manual:     kpi.Totals.Manual / n,
+			HandleTime: kpi.Totals.HandleTime / n,
+		}
+	}
+
+	slices.Sort(handleTimes)
+
+	kpi.HandleTimeP50 = percentile(handleTimes, 50)
+	kpi.HandleTimeP90 = percentile(handleTimes, 90)
+	kpi.HandleTimeP95 = percentile(handleTimes, 95)
+	kpi.HandleTimeP99 = percentile(handleTimes, 99)
+
+	return kpi
+}
+
+// percentile returns the nearest-rank percentile of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// Ensure KPIAggregator implements the ports.Subscriber interface.
+var _ ports.Subscriber = (*KPIAggregator)(nil)
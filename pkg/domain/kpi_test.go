@@ -0,0 +1,242 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"go.uber.org/fx"
+)
+
+func testAgentCall(sid int64, agent, created string, talk, hold, wrapUp int64) ports.ExileAgentCall {
+	return ports.ExileAgentCall{
+		AgentCallSid:   sid,
+		CallSid:        sid * 10,
+		CallType:       "INBOUND",
+		OrgID:          "org",
+		PartnerAgentID: agent,
+		CreateTime:     created,
+		TalkDuration:   talk,
+		HoldDuration:   hold,
+		WrapUpDuration: wrapUp,
+		PauseDuration:  1,
+	}
+}
+
+func TestAggregateKPIs(t *testing.T) {
+	events := []ports.Event{
+		{AgentCall: ptr(testAgentCall(1, "alice", "2025-01-01T10:01:00Z", 100, 0, 20))},
+		{AgentCall: ptr(testAgentCall(2, "alice", "2025-01-01T10:05:00Z", 200, 10, 10))},
+		{AgentCall: ptr(testAgentCall(3, "alice", "2025-01-01T10:20:00Z", 60, 0, 0))},
+		{AgentCall: ptr(testAgentCall(4, "bob", "2025-01-01T10:02:00Z", 50, 0, 0))},
+		// Redelivered copy of call 2 replaces the first.
+		{AgentCall: ptr(testAgentCall(2, "alice", "2025-01-01T10:05:00Z", 300, 10, 10))},
+		{Telephony: &ports.ExileTelephonyResult{CallSid: 10}},
+	}
+
+	kpis, err := AggregateKPIs(events, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("AggregateKPIs failed: %v", err)
+	}
+
+	if len(kpis) != 3 {
+		t.Fatalf("Expected 3 buckets, got %d", len(kpis))
+	}
+
+	alice := kpis[0]
+	if alice.PartnerAgentID != "alice" || alice.Bucket != "15m" || alice.Calls != 2 {
+		t.Fatalf("Expected alice's 10:00 bucket with 2 calls, got %+v", alice)
+	}
+
+	if !alice.Final {
+		t.Error("Expected a historical bucket to be final")
+	}
+
+	if alice.Totals.Talk != 400 || alice.Averages.Talk != 200 {
+		t.Errorf("Expected talk total 400 and average 200, got %v and %v", alice.Totals.Talk, alice.Averages.Talk)
+	}
+
+	if alice.Totals.HandleTime != 440 || alice.HandleTimeP50 != 120 || alice.HandleTimeP99 != 320 {
+		t.Errorf("Expected handle time 440 with p50 120 and p99 320, got %+v", alice)
+	}
+
+	if kpis[1].PartnerAgentID != "bob" || kpis[2].Start.Minute() != 15 {
+		t.Errorf("Expected bob's bucket then alice's 10:15 bucket, got %+v", kpis[1:])
+	}
+}
+
+func TestKPIAggregator_ExpiresClosedBuckets(t *testing.T) {
+	logger := zerolog.Nop()
+
+	var emitted []ports.AgentKPI
+
+	aggregator, err := NewKPIAggregator(KPIOptions{Grace: time.Minute}, func(kpi ports.AgentKPI) {
+		emitted = append(emitted, kpi)
+	}, &logger)
+	if err != nil {
+		t.Fatalf("NewKPIAggregator failed: %v", err)
+	}
+
+	now := time.Date(2025, 1, 1, 10, 16, 0, 0, time.UTC)
+	aggregator.now = func() time.Time { return now }
+
+	aggregator.Add(testAgentCall(1, "alice", "2025-01-01T10:01:00Z", 100, 0, 0))
+
+	if n := aggregator.Expire(); n != 1 || emitted[0].Bucket != "15m" {
+		t.Fatalf("Expected only the 15m bucket to close, got %d %+v", n, emitted)
+	}
+
+	if err := aggregator.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if len(emitted) != 3 || emitted[1].Final || emitted[2].Final {
+		t.Errorf("Expected Close to flush the hour and day buckets as partial, got %+v", emitted)
+	}
+}
+
+func TestKPIAggregator_DropsCallsForEmittedBuckets(t *testing.T) {
+	logger := zerolog.Nop()
+
+	var emitted []ports.AgentKPI
+
+	aggregator, err := NewKPIAggregator(KPIOptions{Buckets: []time.Duration{15 * time.Minute}, Grace: time.Minute}, func(kpi ports.AgentKPI) {
+		emitted = append(emitted, kpi)
+	}, &logger)
+	if err != nil {
+		t.Fatalf("NewKPIAggregator failed: %v", err)
+	}
+
+	now := time.Date(2025, 1, 1, 10, 16, 0, 0, time.UTC)
+	aggregator.now = func() time.Time { return now }
+
+	call := testAgentCall(1, "alice", "2025-01-01T10:01:00Z", 100, 0, 0)
+	aggregator.Add(call)
+
+	if n := aggregator.Expire(); n != 1 {
+		t.Fatalf("Expected the bucket to close, got %d", n)
+	}
+
+	// A redelivered copy and a late call for the emitted bucket.
+	aggregator.Add(call)
+	aggregator.Add(testAgentCall(2, "alice", "2025-01-01T10:05:00Z", 50, 0, 0))
+
+	if n := aggregator.Expire(); n != 0 {
+		t.Errorf("Expected the emitted bucket not to be emitted again, got %d more: %+v", n, emitted)
+	}
+
+	if late := aggregator.LateCalls(); late != 2 {
+		t.Errorf("Expected 2 late calls, got %d", late)
+	}
+
+	// The emitted bucket is forgotten once a bucket width has passed.
+	now = now.Add(15 * time.Minute)
+	aggregator.Expire()
+
+	aggregator.Add(testAgentCall(3, "alice", "2025-01-01T10:07:00Z", 50, 0, 0))
+
+	if n := aggregator.Expire(); n != 1 {
+		t.Errorf("Expected a call after the emitted bucket was forgotten to open a new one, got %d", n)
+	}
+}
+
+func TestParseKPIBucket(t *testing.T) {
+	tests := []struct {
+		name  string
+		width time.Duration
+		err   error
+	}{
+		{"15m", 15 * time.Minute, nil},
+		{"1h", time.Hour, nil},
+		{"1d", 24 * time.Hour, nil},
+		{"30s", 0, ErrInvalidKPIBucket},
+		{"0d", 0, ErrInvalidKPIBucket},
+		{"week", 0, ErrInvalidKPIBucket},
+	}
+
+	for _, tt := range tests {
+		width, err := ParseKPIBucket(tt.name)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseKPIBucket(%q): expected error %v, got %v", tt.name, tt.err, err)
+		}
+
+		if width != tt.width {
+			t.Errorf("ParseKPIBucket(%q): expected %s, got %s", tt.name, tt.width, width)
+		}
+
+		if err == nil && FormatKPIBucket(width) != tt.name {
+			t.Errorf("FormatKPIBucket(%s): expected %q, got %q", width, tt.name, FormatKPIBucket(width))
+		}
+	}
+}
+
+func TestModule_KPIAggregatorPublishesKPIs(t *testing.T) {
+	var bus *EventBus
+
+	app := fx.New(
+		Module,
+		fx.Provide(func() *zerolog.Logger {
+			logger := zerolog.Nop()
+			return &logger
+		}),
+		fx.Supply(&KPIOptions{Buckets: []time.Duration{time.Minute}, Grace: time.Millisecond, FlushInterval: 5 * time.Millisecond}),
+		fx.Populate(&bus),
+	)
+
+	if err := app.Err(); err != nil {
+		t.Fatalf("Module failed to initialize: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Failed to start app: %v", err)
+	}
+
+	defer func() {
+		if err := app.Stop(ctx); err != nil {
+			t.Errorf("Failed to stop app: %v", err)
+		}
+	}()
+
+	sub := newChannelSubscriber(16)
+	if err := bus.Subscribe("test", sub, SubscriptionOptions{}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	call := testAgentCall(1, "alice", "2025-01-01T10:01:00Z", 100, 0, 0)
+	if err := bus.Publish(ctx, ports.Message{Event: &ports.Event{AgentCall: &call}}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+
+	for {
+		select {
+		case msg := <-sub.received:
+			if msg.KPI == nil {
+				continue
+			}
+
+			if msg.KPI.PartnerAgentID != "alice" || msg.KPI.Calls != 1 || !msg.KPI.Final {
+				t.Errorf("Expected a final KPI for alice, got %+v", msg.KPI)
+			}
+
+			for _, stats := range bus.Stats() {
+				if stats.Name == KPISubscriberName && uint64(stats.Queued)+stats.Delivered != 1 {
+					t.Errorf("Expected the aggregator to receive only the agent call, got %+v", stats)
+				}
+			}
+
+			return
+		case <-deadline:
+			t.Fatal("Timed out waiting for an agent KPI")
+		}
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// recordingMetrics remembers the measurements reported to it.
+type recordingMetrics struct {
+	ports.NopMetrics
+
+	mu         sync.Mutex
+	polled     []string
+	duplicates []string
+	received   []string
+	handled    []string
+	handleErrs int
+	reloads    int
+}
+
+func (m *recordingMetrics) EventPolled(kind string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.polled = append(m.polled, kind)
+}
+
+func (m *recordingMetrics) EventDeduplicated(kind string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.duplicates = append(m.duplicates, kind)
+}
+
+func (m *recordingMetrics) JobReceived(jobType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.received = append(m.received, jobType)
+}
+
+func (m *recordingMetrics) JobHandled(subscriber, jobType string, _ time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.handled = append(m.handled, subscriber+"/"+jobType)
+	if err != nil {
+		m.handleErrs++
+	}
+}
+
+func (m *recordingMetrics) ConfigReloaded() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reloads++
+}
+
+func (m *recordingMetrics) handledJobs() ([]string, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]string(nil), m.handled...), m.handleErrs
+}
+
+func TestDomain_ReportsProcessMetrics(t *testing.T) {
+	domain, _, mockClient := setupTestDomain()
+	metrics := &recordingMetrics{}
+	domain.SetMetrics(metrics)
+
+	mockClient.pollEventsResult = ports.PollEventsResult{Events: []ports.Event{
+		{Telephony: &ports.ExileTelephonyResult{CallSid: 1}},
+		{AgentCall: &ports.ExileAgentCall{CallSid: 1}},
+	}}
+
+	if err := (&PollEventsProcess{domain: domain}).pollEvents(); err != nil {
+		t.Fatalf("pollEvents failed: %v", err)
+	}
+
+	jobs := make(chan ports.StreamJobsResult, 1)
+	jobs <- ports.StreamJobsResult{Job: &ports.Job{JobID: "job1", Type: "lookup"}}
+	close(jobs)
+	mockClient.streamJobsChan = jobs
+
+	if err := (&StreamJobsProcess{domain: domain}).streamJobs(); err != nil {
+		t.Fatalf("streamJobs failed: %v", err)
+	}
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	if len(metrics.polled) != 2 || metrics.polled[0] != ports.EventTypeTelephonyResult || metrics.polled[1] != ports.EventTypeAgentCall {
+		t.Errorf("Unexpected polled event kinds: %v", metrics.polled)
+	}
+
+	if len(metrics.received) != 1 || metrics.received[0] != "lookup" {
+		t.Errorf("Unexpected received job types: %v", metrics.received)
+	}
+}
+
+func TestDomain_ReportsConfigReloads(t *testing.T) {
+	logger := zerolog.Nop()
+	domain := NewDomain(&logger)
+	metrics := &recordingMetrics{}
+	domain.SetMetrics(metrics)
+
+	if err := domain.ClientConfigurationChanged(nil, &ports.GetClientConfigurationResult{}); err != nil {
+		t.Fatalf("ClientConfigurationChanged failed: %v", err)
+	}
+
+	if metrics.reloads != 1 {
+		t.Errorf("Expected 1 config reload, got %d", metrics.reloads)
+	}
+}
+
+func TestEventBus_ReportsJobHandling(t *testing.T) {
+	logger := zerolog.Nop()
+	bus := NewEventBus(&logger)
+	metrics := &recordingMetrics{}
+	bus.SetMetrics(metrics)
+
+	defer bus.Close()
+
+	failing := ports.SubscriberFunc(func(context.Context, ports.Message) error {
+		return errors.New("handler failed")
+	})
+
+	if err := bus.Subscribe("failing", failing, SubscriptionOptions{}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	bus.DispatchEvents([]ports.Event{{Type: "test"}})
+	bus.DispatchJob(&ports.Job{JobID: "job1", Type: "lookup"})
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for {
+		handled, errs := metrics.handledJobs()
+		if len(handled) == 1 {
+			if handled[0] != "failing/lookup" || errs != 1 {
+				t.Errorf("Unexpected job handling metrics: %v with %d errors", handled, errs)
+			}
+
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected one job handling measurement, got %v", handled)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -44,6 +44,15 @@ var Module = fx.Module("domain",
 		return domain
 	}),
 
+	// Provide the event bus so sinks and plugins can subscribe
+	fx.Provide(func(d *Domain) *EventBus {
+		return d.EventBus()
+	}),
+
+	fx.Provide(func(bus *EventBus) ports.EventBus {
+		return bus
+	}),
+
 	// Provide the domain service provider
 	fx.Provide(NewDomainServiceProvider),
 
@@ -62,31 +71,274 @@ var Module = fx.Module("domain",
 		return d.SetClient
 	}),
 
-	// Provide domain service methods
-	fx.Provide(func(d *Domain) func() error {
+	// Provide domain service methods. They share a signature, so each is named.
+	fx.Provide(fx.Annotate(func(d *Domain) func() error {
 		return d.StartExileClientConfiguration
-	}),
+	}, fx.ResultTags(`name:"start_exile_client_configuration"`))),
 
-	fx.Provide(func(d *Domain) func() error {
+	fx.Provide(fx.Annotate(func(d *Domain) func() error {
 		return d.StartPollEvents
-	}),
+	}, fx.ResultTags(`name:"start_poll_events"`))),
 
-	fx.Provide(func(d *Domain) func() error {
+	fx.Provide(fx.Annotate(func(d *Domain) func() error {
 		return d.StartStreamJobs
-	}),
+	}, fx.ResultTags(`name:"start_stream_jobs"`))),
 
-	fx.Provide(func(d *Domain) func() error {
+	fx.Provide(fx.Annotate(func(d *Domain) func() error {
 		return d.StartHostPlugin
-	}),
+	}, fx.ResultTags(`name:"start_host_plugin"`))),
 
-	fx.Provide(func(d *Domain) func() error {
+	fx.Provide(fx.Annotate(func(d *Domain) func() error {
 		return d.StopAllProcesses
-	}),
+	}, fx.ResultTags(`name:"stop_all_processes"`))),
 
 	fx.Provide(func(d *Domain) func() bool {
 		return d.IsRunning
 	}),
+
+	// Subscribe sinks and plugins contributed to the "event_subscribers" group
+	fx.Invoke(registerSubscribers),
+
+	// Spool polled events for durable subscribers, when spool options are supplied
+	fx.Invoke(installSpool),
+
+	// Drop repeated polled events, when deduplicator options are supplied
+	fx.Invoke(installDeduplicator),
+
+	// Route events with the supplied ports.Router, if any
+	fx.Invoke(installRouter),
+
+	// Join events into call records, when correlator options are supplied
+	fx.Invoke(installCorrelator),
+
+	// Roll agent calls up into KPIs, when KPI options are supplied
+	fx.Invoke(installKPIAggregator),
 )
 
+// SubscriberRegistration contributes an event bus subscriber through the
+// "event_subscribers" value group.
+//
+// Usage example:
+//
+//	fx.Provide(fx.Annotate(
+//	  func(sink *filesink.Sink) domain.SubscriberRegistration {
+//	    return domain.SubscriberRegistration{Name: "filesink", Subscriber: sink}
+//	  },
+//	  fx.ResultTags(`group:"event_subscribers"`),
+//	))
+type SubscriberRegistration struct {
+	Name       string
+	Subscriber ports.Subscriber
+	Options    SubscriptionOptions
+}
+
+type subscriberParams struct {
+	fx.In
+
+	Domain        *Domain
+	Registrations []SubscriberRegistration `group:"event_subscribers"`
+}
+
+func registerSubscribers(p subscriberParams) error {
+	for _, reg := range p.Registrations {
+		if err := p.Domain.Subscribe(reg.Name, reg.Subscriber, reg.Options); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type spoolParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Domain    *Domain
+	Options   *SpoolOptions `optional:"true"`
+}
+
+// installSpool opens an EventSpool so that durable subscribers, the host
+// plugin among them, receive every polled event at least once, across
+// restarts too. Enable it with fx.Supply(&domain.SpoolOptions{Dir: "/var/lib/sati/spool"}).
+func installSpool(p spoolParams) error {
+	if p.Options == nil {
+		return nil
+	}
+
+	spool, err := OpenEventSpool(*p.Options, p.Domain.log)
+	if err != nil {
+		return err
+	}
+
+	p.Domain.SetEventSpool(spool)
+
+	p.Lifecycle.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return spool.Close()
+		},
+	})
+
+	return nil
+}
+
+type dedupParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Domain    *Domain
+	Options   *DedupOptions `optional:"true"`
+}
+
+// installDeduplicator opens a Deduplicator that drops polled events already
+// seen within its window before they are spooled or published. Enable it with
+// fx.Supply(&domain.DedupOptions{Path: "/var/lib/sati/dedup.state"}).
+func installDeduplicator(p dedupParams) error {
+	if p.Options == nil {
+		return nil
+	}
+
+	dedup, err := OpenDeduplicator(*p.Options, p.Domain.log)
+	if err != nil {
+		return err
+	}
+
+	p.Domain.SetDeduplicator(dedup)
+
+	p.Lifecycle.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return dedup.Close()
+		},
+	})
+
+	return nil
+}
+
+type routerParams struct {
+	fx.In
+
+	Domain *Domain
+	Router ports.Router `optional:"true"`
+}
+
+// managedLister is implemented by routers that can list the subscribers they
+// manage, such as the rules adapter's RuleSet.
+type managedLister interface {
+	Managed() []string
+}
+
+// installRouter routes bus events with the supplied router. The rules adapter
+// (pkg/adapters/rules) provides one from a routing rules file.
+func installRouter(p routerParams) {
+	if p.Router == nil {
+		return
+	}
+
+	if lister, ok := p.Router.(managedLister); ok {
+		subscribed := make(map[string]bool)
+		for _, stats := range p.Domain.bus.Stats() {
+			subscribed[stats.Name] = true
+		}
+
+		for _, name := range lister.Managed() {
+			if !subscribed[name] {
+				p.Domain.log.Warn().Str("subscriber", name).Msg("Routing rules name a subscriber that is not registered")
+			}
+		}
+	}
+
+	p.Domain.bus.SetRouter(p.Router)
+}
+
+type correlatorParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Domain    *Domain
+	Options   *CorrelatorOptions `optional:"true"`
+}
+
+// installCorrelator subscribes a CallCorrelator that publishes each finished
+// call record back onto the bus as a ports.Message with Call set. The records
+// go to every subscriber but the correlator itself. Enable it with
+// fx.Supply(&domain.CorrelatorOptions{Window: time.Minute}).
+func installCorrelator(p correlatorParams) error {
+	if p.Options == nil {
+		return nil
+	}
+
+	bus := p.Domain.bus
+	log := p.Domain.log
+
+	correlator := NewCallCorrelator(*p.Options, func(rec ports.CallRecord) {
+		if err := bus.PublishFrom(context.Background(), CorrelatorSubscriberName, ports.Message{Call: &rec}); err != nil {
+			log.Error().Err(err).Int64("call_sid", rec.CallSid).Msg("Failed to publish call record")
+		}
+	}, log)
+
+	if err := bus.Subscribe(CorrelatorSubscriberName, correlator, SubscriptionOptions{}); err != nil {
+		return err
+	}
+
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			correlator.Start()
+
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			return correlator.Close()
+		},
+	})
+
+	return nil
+}
+
+type kpiParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Domain    *Domain
+	Options   *KPIOptions `optional:"true"`
+}
+
+// installKPIAggregator subscribes a KPIAggregator that publishes each closed
+// bucket back onto the bus as a ports.Message with KPI set. The KPIs go to
+// every subscriber but the aggregator itself. Enable it with
+// fx.Supply(&domain.KPIOptions{}).
+func installKPIAggregator(p kpiParams) error {
+	if p.Options == nil {
+		return nil
+	}
+
+	bus := p.Domain.bus
+	log := p.Domain.log
+
+	aggregator, err := NewKPIAggregator(*p.Options, func(kpi ports.AgentKPI) {
+		if err := bus.PublishFrom(context.Background(), KPISubscriberName, ports.Message{KPI: &kpi}); err != nil {
+			log.Error().Err(err).Str("partner_agent_id", kpi.PartnerAgentID).Msg("Failed to publish agent KPI")
+		}
+	}, log)
+	if err != nil {
+		return err
+	}
+
+	if err := bus.Subscribe(KPISubscriberName, aggregator, SubscriptionOptions{}); err != nil {
+		return err
+	}
+
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			aggregator.Start()
+
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			return aggregator.Close()
+		},
+	})
+
+	return nil
+}
+
 // Ensure Domain implements DomainService interface.
 var _ ports.DomainService = (*Domain)(nil)
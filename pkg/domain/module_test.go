@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"go.uber.org/fx"
+)
+
+func TestModule(t *testing.T) {
+	type processes struct {
+		fx.In
+
+		StartPollEvents  func() error `name:"start_poll_events"`
+		StopAllProcesses func() error `name:"stop_all_processes"`
+	}
+
+	app := fx.New(
+		Module,
+		fx.NopLogger,
+		fx.Provide(func() *zerolog.Logger {
+			logger := zerolog.Nop()
+			return &logger
+		}),
+		fx.Invoke(func(p processes) {
+			if p.StartPollEvents == nil || p.StopAllProcesses == nil {
+				t.Error("Expected the named process functions to be provided")
+			}
+		}),
+	)
+
+	if err := app.Err(); err != nil {
+		t.Fatalf("Module failed to initialize: %v", err)
+	}
+}
+
+func TestModule_InstallsDeduplicator(t *testing.T) {
+	var d *Domain
+
+	app := fx.New(
+		Module,
+		fx.NopLogger,
+		fx.Provide(func() *zerolog.Logger {
+			logger := zerolog.Nop()
+			return &logger
+		}),
+		fx.Supply(&DedupOptions{Path: filepath.Join(t.TempDir(), "dedup.state")}),
+		fx.Populate(&d),
+	)
+
+	if err := app.Err(); err != nil {
+		t.Fatalf("Module failed to initialize: %v", err)
+	}
+
+	if d.dedup == nil {
+		t.Fatal("Expected the deduplicator to be set on the domain")
+	}
+
+	if err := app.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if err := app.Stop(context.Background()); err != nil {
+		t.Errorf("Stop failed: %v", err)
+	}
+
+	if _, err := d.dedup.Filter(nil); !errors.Is(err, ErrDeduplicatorClosed) {
+		t.Errorf("Expected stopping the app to close the deduplicator, got %v", err)
+	}
+}
+
+func TestModule_InstallsSpool(t *testing.T) {
+	var d *Domain
+
+	app := fx.New(
+		Module,
+		fx.NopLogger,
+		fx.Provide(func() *zerolog.Logger {
+			logger := zerolog.Nop()
+			return &logger
+		}),
+		fx.Supply(&SpoolOptions{Dir: t.TempDir()}),
+		fx.Populate(&d),
+	)
+
+	if err := app.Err(); err != nil {
+		t.Fatalf("Module failed to initialize: %v", err)
+	}
+
+	if d.spool == nil {
+		t.Fatal("Expected the spool to be set on the domain")
+	}
+
+	if !slices.Contains(d.bus.DurableSubscribers(), HostPluginSubscriberName) {
+		t.Error("Expected the host plugin to be a durable subscriber")
+	}
+
+	if err := app.Stop(context.Background()); err != nil {
+		t.Errorf("Stop failed: %v", err)
+	}
+}
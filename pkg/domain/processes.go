@@ -50,7 +50,7 @@ func (p *ExileClientConfigurationProcess) run(ctx context.Context) {
 }
 
 func (p *ExileClientConfigurationProcess) checkConfiguration() error {
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), MaxCallTimeout)
 	defer cancel()
 
 	params := ports.GetClientConfigurationParams{}
@@ -67,6 +67,7 @@ func (p *ExileClientConfigurationProcess) checkConfiguration() error {
 
 	if configChanged {
 		p.domain.log.Info().Msg("Client configuration changed, restarting processes")
+		p.domain.currentMetrics().ConfigReloaded()
 		p.restartProcesses()
 	}
 
@@ -124,11 +125,17 @@ func (p *ExileClientConfigurationProcess) stop() {
 // PollEventsProcess methods
 
 func (p *PollEventsProcess) run(ctx context.Context) {
+	p.redeliver(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		default:
+			if !p.waitForCapacity(ctx) {
+				return
+			}
+
 			if err := p.pollEvents(); err != nil {
 				p.domain.log.Error().Err(err).Msg("Failed to poll events")
 				// Wait before retrying
@@ -138,23 +145,153 @@ func (p *PollEventsProcess) run(ctx context.Context) {
 	}
 }
 
+// pollEvents polls and publishes one batch of events under its own span.
 func (p *PollEventsProcess) pollEvents() error {
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
-	defer cancel()
+	ctx, span := p.domain.currentTracer().Start(context.Background(), pollEventsSpanName, ports.SpanOptions{})
 
+	err := p.pollBatch(ctx, span)
+	endSpan(span, err)
+
+	return err
+}
+
+func (p *PollEventsProcess) pollBatch(ctx context.Context, span ports.Span) error {
 	params := ports.PollEventsParams{}
 
-	result, err := p.domain.client.PollEvents(ctx, params)
+	callCtx, cancel := context.WithTimeout(ctx, MaxCallTimeout)
+	result, err := p.domain.client.PollEvents(callCtx, params)
+
+	cancel()
+
 	if err != nil {
 		return err
 	}
 
-	// Dispatch events to host plugin process
-	if p.domain.hostPluginProcess != nil {
-		p.domain.hostPluginProcess.DispatchEvents(result.Events)
+	p.domain.mu.RLock()
+	spool := p.domain.spool
+	dedup := p.domain.dedup
+	metrics := p.domain.metrics
+	p.domain.mu.RUnlock()
+
+	for _, event := range result.Events {
+		metrics.EventPolled(event.Kind())
 	}
 
-	return nil
+	span.SetAttribute("sati.events.polled", len(result.Events))
+
+	if dedup != nil {
+		events, err := dedup.Check(result.Events)
+		if err != nil {
+			// Publishing a repeat is better than losing the batch.
+			p.domain.log.Warn().Err(err).Int("count", len(result.Events)).Msg("Publishing events without deduplication")
+
+			events = result.Events
+			dedup = nil
+		}
+
+		reportDuplicates(metrics, result.Events, events)
+		span.SetAttribute("sati.events.duplicates", len(result.Events)-len(events))
+
+		if len(events) == 0 {
+			return nil
+		}
+
+		result.Events = events
+	}
+
+	if spool == nil {
+		// Publish events to every bus subscriber
+		p.domain.bus.DispatchEvents(result.Events)
+		p.commitKeys(dedup, result.Events)
+
+		return nil
+	}
+
+	// Persist the batch before anyone sees it so a crash cannot lose it
+	msgs, err := spool.Append(result.Events, p.domain.bus.DurableSubscribers())
+	if err != nil {
+		// The gate will not send the batch again, so it is published without
+		// the spool rather than lost.
+		p.domain.log.Error().Err(err).Int("count", len(result.Events)).Msg("Failed to spool events, publishing them unspooled")
+		p.domain.bus.DispatchEvents(result.Events)
+		p.commitKeys(dedup, result.Events)
+
+		return nil
+	}
+
+	// Remember the keys only once the batch is spooled, so a crash before
+	// then does not turn a redelivery into a dropped duplicate.
+	p.commitKeys(dedup, result.Events)
+
+	return p.domain.bus.Publish(context.Background(), msgs...)
+}
+
+// commitKeys remembers the keys of events that have been spooled or published.
+func (p *PollEventsProcess) commitKeys(dedup *Deduplicator, events []ports.Event) {
+	if dedup == nil {
+		return
+	}
+
+	if err := dedup.Commit(events); err != nil {
+		p.domain.log.Error().Err(err).Msg("Failed to persist deduplicator state")
+	}
+}
+
+// reportDuplicates records each event of polled that the deduplicator left
+// out of fresh. Fresh keeps the order of polled, so one pass finds them.
+func reportDuplicates(metrics ports.Metrics, polled, fresh []ports.Event) {
+	next := 0
+
+	for _, event := range polled {
+		if next < len(fresh) && fresh[next].Key() == event.Key() {
+			next++
+
+			continue
+		}
+
+		metrics.EventDeduplicated(event.Kind())
+	}
+}
+
+// redeliver hands spooled events that were not acknowledged before the last
+// shutdown back to the durable subscribers that still owe an ack.
+func (p *PollEventsProcess) redeliver(ctx context.Context) {
+	p.domain.mu.RLock()
+	spool := p.domain.spool
+	p.domain.mu.RUnlock()
+
+	if spool == nil {
+		return
+	}
+
+	for _, pending := range spool.Recover() {
+		if err := p.domain.bus.PublishTo(ctx, pending.Consumer, pending.Message); err != nil {
+			p.domain.log.Error().Err(err).
+				Str("subscriber", pending.Consumer).
+				Str("message_id", pending.Message.ID).
+				Msg("Failed to redeliver spooled event")
+		}
+	}
+}
+
+// waitForCapacity slows polling down while every subscriber's queue is full.
+// It returns false if the context is cancelled while waiting.
+func (p *PollEventsProcess) waitForCapacity(ctx context.Context) bool {
+	backoff := PollBackoffMin
+
+	for p.domain.bus.Saturated() {
+		p.domain.log.Debug().Dur("backoff", backoff).Msg("All event subscribers are saturated, delaying poll")
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, PollBackoffMax)
+	}
+
+	return true
 }
 
 func (p *PollEventsProcess) stop() {
@@ -166,11 +303,15 @@ func (p *PollEventsProcess) stop() {
 // StreamJobsProcess methods
 
 func (p *StreamJobsProcess) run(ctx context.Context) {
-	for {
+	for opened := false; ; opened = true {
 		select {
 		case <-ctx.Done():
 			return
 		default:
+			if opened {
+				p.domain.currentMetrics().StreamReconnected(StreamJobsName)
+			}
+
 			if err := p.streamJobs(); err != nil {
 				p.domain.log.Error().Err(err).Msg("Failed to stream jobs")
 				// Wait before retrying
@@ -180,22 +321,25 @@ func (p *StreamJobsProcess) run(ctx context.Context) {
 	}
 }
 
+// streamJobs reads one stream, which the client ends at the StreamJobs
+// deadline of the transport settings, or at MaxStreamDuration.
 func (p *StreamJobsProcess) streamJobs() error {
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), MaxStreamDuration)
 	defer cancel()
 
 	params := ports.StreamJobsParams{}
 	resultsChan := p.domain.client.StreamJobs(ctx, params)
+	metrics := p.domain.currentMetrics()
 
 	for result := range resultsChan {
 		if result.Error != nil {
 			return result.Error
 		}
 
-		// Dispatch job to host plugin process
-		if p.domain.hostPluginProcess != nil {
-			p.domain.hostPluginProcess.DispatchJob(result.Job)
-		}
+		metrics.JobReceived(result.Job.Type)
+
+		// Publish job to every bus subscriber
+		p.domain.bus.DispatchJob(result.Job)
 	}
 
 	return nil
@@ -243,3 +243,67 @@ func TestProcessStopMethods(t *testing.T) {
 
 	// HostPluginProcess stop test removed since it's now an interface
 }
+
+// deadlineClient records the time left on the context of each call.
+type deadlineClient struct {
+	*MockClientInterface
+	left map[string]time.Duration
+}
+
+func (c *deadlineClient) record(ctx context.Context, method string) {
+	if deadline, ok := ctx.Deadline(); ok {
+		c.left[method] = time.Until(deadline)
+	}
+}
+
+func (c *deadlineClient) GetClientConfiguration(ctx context.Context, params ports.GetClientConfigurationParams) (ports.GetClientConfigurationResult, error) {
+	c.record(ctx, "GetClientConfiguration")
+
+	return c.MockClientInterface.GetClientConfiguration(ctx, params)
+}
+
+func (c *deadlineClient) PollEvents(ctx context.Context, params ports.PollEventsParams) (ports.PollEventsResult, error) {
+	c.record(ctx, "PollEvents")
+
+	return c.MockClientInterface.PollEvents(ctx, params)
+}
+
+func (c *deadlineClient) StreamJobs(ctx context.Context, _ ports.StreamJobsParams) <-chan ports.StreamJobsResult {
+	c.record(ctx, "StreamJobs")
+
+	results := make(chan ports.StreamJobsResult)
+	close(results)
+
+	return results
+}
+
+func TestProcesses_BoundClientCalls(t *testing.T) {
+	domain, _, _ := setupTestDomain()
+	client := &deadlineClient{MockClientInterface: &MockClientInterface{}, left: map[string]time.Duration{}}
+	domain.SetClient(client)
+
+	// An unchanged configuration keeps the processes from being restarted
+	process := &ExileClientConfigurationProcess{domain: domain, lastConfig: &ports.GetClientConfigurationResult{}}
+	if err := process.checkConfiguration(); err != nil {
+		t.Fatalf("checkConfiguration failed: %v", err)
+	}
+
+	if err := (&PollEventsProcess{domain: domain}).pollEvents(); err != nil {
+		t.Fatalf("pollEvents failed: %v", err)
+	}
+
+	if err := (&StreamJobsProcess{domain: domain}).streamJobs(); err != nil {
+		t.Fatalf("streamJobs failed: %v", err)
+	}
+
+	for method, limit := range map[string]time.Duration{
+		"GetClientConfiguration": MaxCallTimeout,
+		"PollEvents":             MaxCallTimeout,
+		"StreamJobs":             MaxStreamDuration,
+	} {
+		left, ok := client.left[method]
+		if !ok || left <= 0 || left > limit {
+			t.Errorf("Expected %s to be bounded by %s, got %s (deadline set: %t)", method, limit, left, ok)
+		}
+	}
+}
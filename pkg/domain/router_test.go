@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// outboundRouter routes outbound calls to the "nats" subscriber only.
+type outboundRouter struct{}
+
+func (outboundRouter) Manages(subscriber string) bool {
+	return subscriber == "nats"
+}
+
+func (outboundRouter) Route(msg ports.Message) []string {
+	if msg.Event.CallType() == "OUTBOUND" {
+		return []string{"nats"}
+	}
+
+	return nil
+}
+
+// recordingAcker records acknowledgements as "consumer/id".
+type recordingAcker struct {
+	mu   sync.Mutex
+	acks []string
+}
+
+func (a *recordingAcker) Ack(consumer, messageID string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.acks = append(a.acks, consumer+"/"+messageID)
+
+	return nil
+}
+
+func (a *recordingAcker) acked() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return slices.Clone(a.acks)
+}
+
+func TestEventBus_RoutesWithRouter(t *testing.T) {
+	bus := newTestBus(t)
+	bus.SetRouter(outboundRouter{})
+
+	acker := &recordingAcker{}
+	bus.SetAcknowledger(acker)
+
+	nats := newChannelSubscriber(4)
+	plugin := newChannelSubscriber(4)
+
+	if err := bus.Subscribe("nats", nats, SubscriptionOptions{Durable: true}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	if err := bus.Subscribe("plugin", plugin, SubscriptionOptions{}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	err := bus.Publish(context.Background(),
+		ports.Message{ID: "in", Event: &ports.Event{Type: "inbound", AgentCall: &ports.ExileAgentCall{CallType: "INBOUND"}}},
+		ports.Message{ID: "out", Event: &ports.Event{Type: "outbound", AgentCall: &ports.ExileAgentCall{CallType: "OUTBOUND"}}},
+	)
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	plugin.expect(t, "inbound")
+	plugin.expect(t, "outbound")
+	nats.expect(t, "outbound")
+
+	waitFor(t, func() bool { return len(acker.acked()) == 2 })
+
+	if acked := acker.acked(); !slices.Contains(acked, "nats/in") || !slices.Contains(acked, "nats/out") {
+		t.Errorf("Expected skipped and delivered messages to be acknowledged, got %v", acked)
+	}
+}
@@ -0,0 +1,493 @@
+package domain
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// Error constants for event spool operations.
+var (
+	ErrSpoolDirRequired = errors.New("spool directory is required")
+	ErrSpoolClosed      = errors.New("event spool is closed")
+	ErrSpoolCorrupt     = errors.New("corrupt spool batch")
+)
+
+const (
+	spoolBatchExt = ".batch"
+	spoolAckExt   = ".ack"
+)
+
+// SpoolOptions configures the local event spool.
+type SpoolOptions struct {
+	// Dir holds one batch file per polled batch plus its acknowledgement log.
+	Dir string
+	// SyncAcks fsyncs the acknowledgement log after every ack. Batches are always
+	// fsynced before dispatch; syncing acks as well narrows the redelivery window
+	// after a crash at the cost of a disk flush per message.
+	SyncAcks bool
+}
+
+// PendingDelivery is a spooled message that a consumer has not acknowledged.
+type PendingDelivery struct {
+	Consumer string
+	Message  ports.Message
+}
+
+// spoolBatchHeader is the first line of every batch file.
+type spoolBatchHeader struct {
+	Seq       uint64    `json:"seq"`
+	Consumers []string  `json:"consumers"`
+	Count     int       `json:"count"`
+	Created   time.Time `json:"created"`
+}
+
+// spoolBatch tracks the acknowledgement state of one polled batch.
+type spoolBatch struct {
+	header   spoolBatchHeader
+	messages []ports.Message
+	acked    map[string]map[int]bool
+	ackFile  *os.File
+}
+
+func (b *spoolBatch) complete() bool {
+	for _, consumer := range b.header.Consumers {
+		if len(b.acked[consumer]) < b.header.Count {
+			return false
+		}
+	}
+
+	return true
+}
+
+// EventSpool writes every polled batch to disk before it is dispatched and keeps it
+// until each durable consumer has acknowledged every event in it. Batches that are
+// still incomplete when the connector restarts are handed back through Recover.
+type EventSpool struct {
+	opts SpoolOptions
+	log  *zerolog.Logger
+
+	mu        sync.Mutex
+	batches   map[uint64]*spoolBatch
+	recovered []PendingDelivery
+	seq       uint64
+	closed    bool
+}
+
+// OpenEventSpool opens or creates a spool directory and loads unfinished batches.
+func OpenEventSpool(opts SpoolOptions, log *zerolog.Logger) (*EventSpool, error) {
+	if opts.Dir == "" {
+		return nil, ErrSpoolDirRequired
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	s := &EventSpool{
+		opts:    opts,
+		log:     log,
+		batches: make(map[uint64]*spoolBatch),
+		seq:     uint64(time.Now().UnixNano()),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// load reads every batch file left in the spool directory.
+func (s *EventSpool) load() error {
+	paths, err := filepath.Glob(filepath.Join(s.opts.Dir, "*"+spoolBatchExt))
+	if err != nil {
+		return fmt.Errorf("failed to list spool batches: %w", err)
+	}
+
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		batch, err := s.loadBatch(path)
+		if err != nil {
+			s.log.Error().Err(err).Str("path", path).Msg("Skipping unreadable spool batch")
+
+			continue
+		}
+
+		if batch.complete() {
+			s.removeBatch(batch)
+
+			continue
+		}
+
+		s.batches[batch.header.Seq] = batch
+		s.seq = max(s.seq, batch.header.Seq)
+
+		for _, consumer := range batch.header.Consumers {
+			for i, msg := range batch.messages {
+				if !batch.acked[consumer][i] {
+					s.recovered = append(s.recovered, PendingDelivery{Consumer: consumer, Message: msg})
+				}
+			}
+		}
+	}
+
+	if len(s.recovered) > 0 {
+		s.log.Info().Int("batches", len(s.batches)).Int("deliveries", len(s.recovered)).Msg("Recovered unacknowledged events from spool")
+	}
+
+	return nil
+}
+
+func (s *EventSpool) loadBatch(path string) (*spoolBatch, error) {
+	//nolint:gosec // Spool path is built from operator configuration, not user input
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSpillRecordSize)
+
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("%w: missing header", ErrSpoolCorrupt)
+	}
+
+	batch := &spoolBatch{acked: make(map[string]map[int]bool)}
+	if err := json.Unmarshal(scanner.Bytes(), &batch.header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSpoolCorrupt, err)
+	}
+
+	for scanner.Scan() {
+		var msg ports.Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSpoolCorrupt, err)
+		}
+
+		batch.messages = append(batch.messages, msg)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSpoolCorrupt, err)
+	}
+
+	if len(batch.messages) != batch.header.Count {
+		return nil, fmt.Errorf("%w: expected %d events, found %d", ErrSpoolCorrupt, batch.header.Count, len(batch.messages))
+	}
+
+	for _, consumer := range batch.header.Consumers {
+		batch.acked[consumer] = make(map[int]bool)
+	}
+
+	if err := s.loadAcks(batch); err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// loadAcks replays the acknowledgement log of a batch. A torn final line from a
+// crash is ignored, which only causes that one event to be redelivered.
+func (s *EventSpool) loadAcks(batch *spoolBatch) error {
+	//nolint:gosec // Spool path is built from operator configuration, not user input
+	data, err := os.ReadFile(s.ackPath(batch.header.Seq))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to read spool acks: %w", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		consumer, id, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+
+		_, index, err := parseSpoolMessageID(id)
+		if err != nil {
+			continue
+		}
+
+		if acked, ok := batch.acked[consumer]; ok {
+			acked[index] = true
+		}
+	}
+
+	return nil
+}
+
+// Append durably writes a batch of events and returns the messages to publish.
+// consumers lists the durable subscribers that must acknowledge every event
+// before the batch is discarded.
+func (s *EventSpool) Append(events []ports.Event, consumers []string) ([]ports.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrSpoolClosed
+	}
+
+	s.seq++
+
+	batch := &spoolBatch{
+		header: spoolBatchHeader{
+			Seq:       s.seq,
+			Consumers: slices.Clone(consumers),
+			Count:     len(events),
+			Created:   time.Now().UTC(),
+		},
+		acked: make(map[string]map[int]bool, len(consumers)),
+	}
+
+	for _, consumer := range consumers {
+		batch.acked[consumer] = make(map[int]bool)
+	}
+
+	for i := range events {
+		batch.messages = append(batch.messages, ports.Message{
+			ID:    formatSpoolMessageID(batch.header.Seq, i),
+			Event: &events[i],
+		})
+	}
+
+	// Nothing to wait for: the messages still go out but are not kept.
+	if len(events) == 0 || len(consumers) == 0 {
+		return batch.messages, nil
+	}
+
+	if err := s.writeBatch(batch); err != nil {
+		return nil, err
+	}
+
+	s.batches[batch.header.Seq] = batch
+
+	return batch.messages, nil
+}
+
+// writeBatch writes a batch file through a temporary file so that a crash never
+// leaves a partially written batch behind.
+func (s *EventSpool) writeBatch(batch *spoolBatch) error {
+	path := s.batchPath(batch.header.Seq)
+	tmp := path + ".tmp"
+
+	//nolint:gosec // Spool path is built from operator configuration, not user input
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create spool batch: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+
+	err = encoder.Encode(batch.header)
+	for i := 0; err == nil && i < len(batch.messages); i++ {
+		err = encoder.Encode(batch.messages[i])
+	}
+
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to write spool batch: %w", err)
+	}
+
+	return syncDir(s.opts.Dir)
+}
+
+// Ack records that consumer has handled the message with the given ID. Messages
+// that did not come from the spool are ignored.
+func (s *EventSpool) Ack(consumer, messageID string) error {
+	seq, index, err := parseSpoolMessageID(messageID)
+	if err != nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSpoolClosed
+	}
+
+	batch, ok := s.batches[seq]
+	if !ok {
+		return nil
+	}
+
+	acked, ok := batch.acked[consumer]
+	if !ok || acked[index] {
+		return nil
+	}
+
+	acked[index] = true
+
+	if batch.complete() {
+		s.removeBatch(batch)
+		delete(s.batches, seq)
+
+		return nil
+	}
+
+	return s.appendAck(batch, consumer, messageID)
+}
+
+func (s *EventSpool) appendAck(batch *spoolBatch, consumer, messageID string) error {
+	if batch.ackFile == nil {
+		//nolint:gosec // Spool path is built from operator configuration, not user input
+		file, err := os.OpenFile(s.ackPath(batch.header.Seq), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to open spool ack log: %w", err)
+		}
+
+		batch.ackFile = file
+	}
+
+	if _, err := batch.ackFile.WriteString(consumer + "\t" + messageID + "\n"); err != nil {
+		return fmt.Errorf("failed to write spool ack: %w", err)
+	}
+
+	if s.opts.SyncAcks {
+		if err := batch.ackFile.Sync(); err != nil {
+			return fmt.Errorf("failed to sync spool ack log: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *EventSpool) removeBatch(batch *spoolBatch) {
+	if batch.ackFile != nil {
+		_ = batch.ackFile.Close()
+		batch.ackFile = nil
+	}
+
+	for _, path := range []string{s.batchPath(batch.header.Seq), s.ackPath(batch.header.Seq)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.log.Error().Err(err).Str("path", path).Msg("Failed to remove spool file")
+		}
+	}
+}
+
+// Recover returns the deliveries that were still unacknowledged when the spool was
+// opened. Each delivery is returned once; later calls return nothing.
+func (s *EventSpool) Recover() []PendingDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recovered := s.recovered
+	s.recovered = nil
+
+	return recovered
+}
+
+// PendingBatches returns the number of batches waiting for acknowledgements.
+func (s *EventSpool) PendingBatches() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.batches)
+}
+
+// Close releases open acknowledgement logs. Unfinished batches stay on disk.
+func (s *EventSpool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	s.closed = true
+
+	var errs []error
+
+	for _, batch := range s.batches {
+		if batch.ackFile != nil {
+			errs = append(errs, batch.ackFile.Close())
+			batch.ackFile = nil
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *EventSpool) batchPath(seq uint64) string {
+	return filepath.Join(s.opts.Dir, fmt.Sprintf("%020d%s", seq, spoolBatchExt))
+}
+
+func (s *EventSpool) ackPath(seq uint64) string {
+	return filepath.Join(s.opts.Dir, fmt.Sprintf("%020d%s", seq, spoolAckExt))
+}
+
+// formatSpoolMessageID builds the message ID "<batch seq>-<index>".
+func formatSpoolMessageID(seq uint64, index int) string {
+	return strconv.FormatUint(seq, 10) + "-" + strconv.Itoa(index)
+}
+
+func parseSpoolMessageID(id string) (uint64, int, error) {
+	seqPart, indexPart, ok := strings.Cut(id, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("not a spool message ID: %s", id)
+	}
+
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	index, err := strconv.Atoi(indexPart)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return seq, index, nil
+}
+
+// syncDir flushes directory entries so that renames survive a crash.
+func syncDir(dir string) error {
+	//nolint:gosec // Directory comes from operator configuration, not user input
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open directory for sync: %w", err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync directory: %w", err)
+	}
+
+	return nil
+}
+
+// Ensure EventSpool implements the ports.Acknowledger interface.
+var _ ports.Acknowledger = (*EventSpool)(nil)
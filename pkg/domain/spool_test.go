@@ -0,0 +1,270 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+func openTestSpool(t *testing.T, dir string) *EventSpool {
+	t.Helper()
+
+	logger := zerolog.Nop()
+
+	spool, err := OpenEventSpool(SpoolOptions{Dir: dir, SyncAcks: true}, &logger)
+	if err != nil {
+		t.Fatalf("OpenEventSpool failed: %v", err)
+	}
+
+	t.Cleanup(func() { _ = spool.Close() })
+
+	return spool
+}
+
+func spoolFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names
+}
+
+func TestOpenEventSpool_RequiresDir(t *testing.T) {
+	logger := zerolog.Nop()
+
+	if _, err := OpenEventSpool(SpoolOptions{}, &logger); !errors.Is(err, ErrSpoolDirRequired) {
+		t.Errorf("Expected ErrSpoolDirRequired, got %v", err)
+	}
+}
+
+func TestEventSpool_AckRemovesCompletedBatch(t *testing.T) {
+	dir := t.TempDir()
+	spool := openTestSpool(t, dir)
+
+	msgs, err := spool.Append([]ports.Event{{Type: "a"}, {Type: "b"}}, []string{"billing", "audit"})
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	if len(msgs) != 2 || msgs[0].ID == msgs[1].ID {
+		t.Fatalf("Expected two messages with distinct IDs, got %+v", msgs)
+	}
+
+	if files := spoolFiles(t, dir); len(files) != 1 {
+		t.Fatalf("Expected one batch file, got %v", files)
+	}
+
+	for _, consumer := range []string{"billing", "audit"} {
+		for _, msg := range msgs {
+			if err := spool.Ack(consumer, msg.ID); err != nil {
+				t.Fatalf("Ack failed: %v", err)
+			}
+		}
+	}
+
+	if spool.PendingBatches() != 0 {
+		t.Errorf("Expected no pending batches, got %d", spool.PendingBatches())
+	}
+
+	if files := spoolFiles(t, dir); len(files) != 0 {
+		t.Errorf("Expected spool directory to be empty, got %v", files)
+	}
+}
+
+func TestEventSpool_RecoverUnacknowledged(t *testing.T) {
+	dir := t.TempDir()
+	logger := zerolog.Nop()
+
+	spool, err := OpenEventSpool(SpoolOptions{Dir: dir}, &logger)
+	if err != nil {
+		t.Fatalf("OpenEventSpool failed: %v", err)
+	}
+
+	msgs, err := spool.Append([]ports.Event{{Type: "a"}, {Type: "b"}}, []string{"billing", "audit"})
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	_ = spool.Ack("billing", msgs[0].ID)
+	_ = spool.Ack("billing", msgs[1].ID)
+	_ = spool.Ack("audit", msgs[0].ID)
+
+	if err := spool.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened := openTestSpool(t, dir)
+
+	pending := reopened.Recover()
+	if len(pending) != 1 {
+		t.Fatalf("Expected one pending delivery, got %+v", pending)
+	}
+
+	if pending[0].Consumer != "audit" || pending[0].Message.ID != msgs[1].ID || pending[0].Message.Event.Type != "b" {
+		t.Errorf("Unexpected pending delivery: %+v", pending[0])
+	}
+
+	if again := reopened.Recover(); len(again) != 0 {
+		t.Errorf("Expected Recover to return deliveries once, got %+v", again)
+	}
+
+	if err := reopened.Ack("audit", msgs[1].ID); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+
+	if files := spoolFiles(t, dir); len(files) != 0 {
+		t.Errorf("Expected spool directory to be empty, got %v", files)
+	}
+}
+
+func TestEventSpool_IgnoresForeignAndTornAcks(t *testing.T) {
+	dir := t.TempDir()
+	spool := openTestSpool(t, dir)
+
+	msgs, err := spool.Append([]ports.Event{{Type: "a"}}, []string{"billing"})
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	if err := spool.Ack("billing", "42"); err != nil {
+		t.Errorf("Expected non-spool IDs to be ignored, got %v", err)
+	}
+
+	if err := spool.Ack("unknown", msgs[0].ID); err != nil {
+		t.Errorf("Expected unknown consumers to be ignored, got %v", err)
+	}
+
+	seq, _, err := parseSpoolMessageID(msgs[0].ID)
+	if err != nil {
+		t.Fatalf("parseSpoolMessageID failed: %v", err)
+	}
+
+	if err := os.WriteFile(spool.ackPath(seq), []byte("billing\t"+msgs[0].ID[:3]), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	_ = spool.Close()
+
+	reopened := openTestSpool(t, dir)
+	if pending := reopened.Recover(); len(pending) != 1 {
+		t.Errorf("Expected torn ack to leave the event pending, got %+v", pending)
+	}
+}
+
+func TestEventSpool_SkipsEmptyBatches(t *testing.T) {
+	dir := t.TempDir()
+	spool := openTestSpool(t, dir)
+
+	if _, err := spool.Append(nil, []string{"billing"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	msgs, err := spool.Append([]ports.Event{{Type: "a"}}, nil)
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	if len(msgs) != 1 {
+		t.Errorf("Expected the message to be returned without durable consumers, got %+v", msgs)
+	}
+
+	if files := spoolFiles(t, dir); len(files) != 0 {
+		t.Errorf("Expected nothing to be written, got %v", files)
+	}
+}
+
+func TestEventSpool_SkipsCorruptBatch(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000001"+spoolBatchExt), []byte("not json\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	spool := openTestSpool(t, dir)
+	if spool.PendingBatches() != 0 {
+		t.Errorf("Expected corrupt batch to be skipped, got %d pending", spool.PendingBatches())
+	}
+}
+
+func TestPollEventsProcess_SpoolsAndRedelivers(t *testing.T) {
+	dir := t.TempDir()
+
+	domain, _, mockClient := setupTestDomain()
+	spool := openTestSpool(t, dir)
+	domain.SetEventSpool(spool)
+
+	failing := ports.SubscriberFunc(func(context.Context, ports.Message) error {
+		return errors.New("billing database unavailable")
+	})
+
+	if err := domain.Subscribe("billing", failing, SubscriptionOptions{Durable: true}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	mockClient.pollEventsResult = ports.PollEventsResult{Events: []ports.Event{{Type: "billable"}}}
+
+	process := &PollEventsProcess{domain: domain}
+	if err := process.pollEvents(); err != nil {
+		t.Fatalf("pollEvents failed: %v", err)
+	}
+
+	if spool.PendingBatches() != 1 {
+		t.Fatalf("Expected the batch to stay spooled, got %d", spool.PendingBatches())
+	}
+
+	// Simulate a crash and restart with a healthy consumer.
+	_ = domain.EventBus().Close()
+	_ = spool.Close()
+
+	restarted, _, _ := setupTestDomain()
+	reopened := openTestSpool(t, dir)
+	restarted.SetEventSpool(reopened)
+
+	healthy := newChannelSubscriber(1)
+	if err := restarted.Subscribe("billing", healthy, SubscriptionOptions{Durable: true}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	(&PollEventsProcess{domain: restarted}).redeliver(context.Background())
+
+	healthy.expect(t, "billable")
+	waitFor(t, func() bool { return reopened.PendingBatches() == 0 })
+}
+
+func TestPollEventsProcess_PublishesWhenSpoolFails(t *testing.T) {
+	domain, _, mockClient := setupTestDomain()
+	sub := newChannelSubscriber(4)
+
+	if err := domain.Subscribe("sink", sub, SubscriptionOptions{Durable: true}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	spool := openTestSpool(t, t.TempDir())
+	domain.SetEventSpool(spool)
+
+	if err := spool.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	mockClient.pollEventsResult = ports.PollEventsResult{Events: []ports.Event{{Type: "a"}}}
+
+	process := &PollEventsProcess{domain: domain}
+	if err := process.pollEvents(); err != nil {
+		t.Fatalf("pollEvents failed: %v", err)
+	}
+
+	sub.expect(t, "a")
+}
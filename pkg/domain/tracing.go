@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"context"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+const (
+	pollEventsSpanName = "sati.poll_events"
+	handleJobSpanName  = "sati.handle_job"
+)
+
+type traceKey struct{}
+
+// ContextWithJob returns ctx carrying job's trace, as the remote parent of
+// spans started from it, and job's correlation ID.
+func ContextWithJob(ctx context.Context, job *ports.Job) context.Context {
+	if job == nil || job.Trace == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, traceKey{}, *job.Trace)
+}
+
+// contextWithSpan returns ctx carrying span as the current trace position,
+// keeping the correlation ID ctx carries. Spans without a trace leave ctx
+// unchanged, so logs keep the job's trace IDs.
+func contextWithSpan(ctx context.Context, span ports.Span) context.Context {
+	traceParent := span.TraceParent()
+	if traceParent == "" {
+		return ctx
+	}
+
+	tc, _ := ctx.Value(traceKey{}).(ports.TraceContext)
+	tc.TraceParent = traceParent
+
+	return context.WithValue(ctx, traceKey{}, tc)
+}
+
+// CorrelationID returns the correlation ID ctx carries, or "".
+func CorrelationID(ctx context.Context) string {
+	tc, _ := ctx.Value(traceKey{}).(ports.TraceContext)
+
+	return tc.CorrelationID
+}
+
+// LoggerWithTrace returns log with the trace_id, span_id and correlation_id
+// fields of ctx. Subscribers can use it on the context they are handed a job
+// with.
+func LoggerWithTrace(ctx context.Context, log *zerolog.Logger) *zerolog.Logger {
+	fields := log.With()
+
+	tc, _ := ctx.Value(traceKey{}).(ports.TraceContext)
+
+	if traceID, spanID, ok := parseTraceParent(tc.TraceParent); ok {
+		fields = fields.Str("trace_id", traceID).Str("span_id", spanID)
+	}
+
+	if tc.CorrelationID != "" {
+		fields = fields.Str("correlation_id", tc.CorrelationID)
+	}
+
+	logger := fields.Logger()
+
+	return &logger
+}
+
+// parseTraceParent returns the trace and parent span IDs of a W3C traceparent
+// header, "version-traceid-spanid-flags".
+func parseTraceParent(traceParent string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(traceParent, "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", false
+	}
+
+	return parts[1], parts[2], true
+}
+
+// startJobSpan starts the span of a delivery that contains jobs. It is a
+// child of the first job's trace and linked to the others. It returns a nil
+// span when msgs holds no job.
+func startJobSpan(ctx context.Context, tracer ports.Tracer, subscriber string, msgs []ports.Message) (context.Context, ports.Span) {
+	var jobs []*ports.Job
+
+	for _, msg := range msgs {
+		if msg.Job != nil {
+			jobs = append(jobs, msg.Job)
+		}
+	}
+
+	if len(jobs) == 0 {
+		return ctx, nil
+	}
+
+	links := make([]*ports.TraceContext, 0, len(jobs)-1)
+
+	for _, job := range jobs[1:] {
+		if job.Trace != nil {
+			links = append(links, job.Trace)
+		}
+	}
+
+	ctx, span := tracer.Start(ContextWithJob(ctx, jobs[0]), handleJobSpanName, ports.SpanOptions{
+		Parent:   jobs[0].Trace,
+		Links:    links,
+		Consumer: true,
+		Attributes: map[string]any{
+			"sati.subscriber": subscriber,
+			"sati.job.id":     jobs[0].JobID,
+			"sati.job.type":   jobs[0].Type,
+			"sati.jobs":       len(jobs),
+		},
+	})
+
+	return contextWithSpan(ctx, span), span
+}
+
+// endSpan records err on span and ends it. A nil span is ignored.
+func endSpan(span ports.Span, err error) {
+	if span == nil {
+		return
+	}
+
+	span.End(err)
+}
@@ -0,0 +1,202 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+const testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+func tracedJob(id string) *ports.Job {
+	return &ports.Job{JobID: id, Type: "lookup", Trace: &ports.TraceContext{
+		TraceParent:   "00-" + testTraceID + "-00f067aa0ba902b7-01",
+		CorrelationID: id,
+	}}
+}
+
+// recordedSpan is a span started by recordingTracer.
+type recordedSpan struct {
+	mu    *sync.Mutex
+	name  string
+	opts  ports.SpanOptions
+	id    string
+	attrs map[string]any
+	err   error
+	ended bool
+}
+
+func (s *recordedSpan) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attrs[key] = value
+}
+
+func (s *recordedSpan) TraceParent() string {
+	return "00-" + testTraceID + "-" + s.id + "-01"
+}
+
+func (s *recordedSpan) End(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.err, s.ended = err, true
+}
+
+// recordingTracer records the spans it starts. Each span gets the next span ID.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, opts ports.SpanOptions) (context.Context, ports.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	span := &recordedSpan{mu: &t.mu, name: name, opts: opts, id: fmt.Sprintf("%016x", len(t.spans)+1), attrs: map[string]any{}}
+	t.spans = append(t.spans, span)
+
+	return ctx, span
+}
+
+func (t *recordingTracer) ended() []*recordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var ended []*recordedSpan
+
+	for _, span := range t.spans {
+		if span.ended {
+			ended = append(ended, span)
+		}
+	}
+
+	return ended
+}
+
+func TestContextWithJob(t *testing.T) {
+	ctx := ContextWithJob(context.Background(), tracedJob("job1"))
+
+	if got := CorrelationID(ctx); got != "job1" {
+		t.Errorf("Expected correlation ID job1, got %q", got)
+	}
+
+	if got := ContextWithJob(context.Background(), &ports.Job{JobID: "job2"}); CorrelationID(got) != "" {
+		t.Error("Expected a job without trace context to leave ctx unchanged")
+	}
+}
+
+func TestLoggerWithTrace(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := zerolog.New(&buf)
+	LoggerWithTrace(ContextWithJob(context.Background(), tracedJob("job1")), &logger).Info().Msg("handled")
+
+	for _, want := range []string{`"trace_id":"` + testTraceID + `"`, `"span_id":"00f067aa0ba902b7"`, `"correlation_id":"job1"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected the log line to contain %s, got %s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	LoggerWithTrace(ContextWithJob(context.Background(), &ports.Job{JobID: "job2", Trace: &ports.TraceContext{
+		TraceParent: "garbage",
+	}}), &logger).Info().Msg("handled")
+
+	if strings.Contains(buf.String(), "trace_id") {
+		t.Errorf("Expected a malformed traceparent to be left out, got %s", buf.String())
+	}
+}
+
+func TestEventBus_TracesJobHandling(t *testing.T) {
+	tracer := &recordingTracer{}
+
+	logger := zerolog.Nop()
+	bus := NewEventBus(&logger)
+	bus.SetTracer(tracer)
+
+	defer bus.Close()
+
+	handled := make(chan context.Context, 1)
+
+	err := bus.Subscribe("plugin", ports.SubscriberFunc(func(ctx context.Context, _ ports.Message) error {
+		handled <- ctx
+
+		return errors.New("plugin failed")
+	}), SubscriptionOptions{})
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	job := tracedJob("job1")
+	bus.DispatchJob(job)
+
+	var ctx context.Context
+
+	select {
+	case ctx = <-handled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the job to be handled")
+	}
+
+	if got := CorrelationID(ctx); got != "job1" {
+		t.Errorf("Expected correlation ID job1, got %q", got)
+	}
+
+	var buf bytes.Buffer
+
+	log := zerolog.New(&buf)
+	LoggerWithTrace(ctx, &log).Info().Msg("handled")
+
+	if want := `"span_id":"0000000000000001"`; !strings.Contains(buf.String(), want) {
+		t.Errorf("Expected the handler's logs to carry the span's ID, got %s", buf.String())
+	}
+
+	waitFor(t, func() bool { return len(tracer.ended()) == 1 })
+
+	span := tracer.ended()[0]
+	if span.name != handleJobSpanName || span.opts.Parent != job.Trace || !span.opts.Consumer {
+		t.Errorf("Expected a consumer %s span under the job's trace, got %s under %v", handleJobSpanName, span.name, span.opts.Parent)
+	}
+
+	if span.opts.Attributes["sati.subscriber"] != "plugin" || span.opts.Attributes["sati.job.id"] != "job1" {
+		t.Errorf("Expected the subscriber and job on the span, got %v", span.opts.Attributes)
+	}
+
+	if span.err == nil || span.err.Error() != "plugin failed" {
+		t.Errorf("Expected the handler's error on the span, got %v", span.err)
+	}
+}
+
+func TestPollEvents_Traced(t *testing.T) {
+	domain, _, mockClient := setupTestDomain()
+
+	tracer := &recordingTracer{}
+	domain.SetTracer(tracer)
+
+	mockClient.pollEventsResult = ports.PollEventsResult{Events: []ports.Event{
+		{Telephony: &ports.ExileTelephonyResult{CallSid: 1}},
+	}}
+
+	if err := (&PollEventsProcess{domain: domain}).pollEvents(); err != nil {
+		t.Fatalf("pollEvents failed: %v", err)
+	}
+
+	spans := tracer.ended()
+	if len(spans) != 1 || spans[0].name != pollEventsSpanName {
+		t.Fatalf("Expected one %s span, got %v", pollEventsSpanName, spans)
+	}
+
+	if got := spans[0].attrs["sati.events.polled"]; got != 1 {
+		t.Errorf("Expected 1 polled event, got %v", got)
+	}
+}
@@ -6,6 +6,11 @@ import "context"
 // ClientInterface defines the interface for the TCN Exile Gate service client.
 // This interface abstracts the concrete client implementation and allows for
 // easier testing and dependency injection following clean architecture principles.
+//
+// Implementations must bound every call, including one made on a context
+// without a deadline, by a deadline of their own for the method; the domain
+// only adds a looser backstop (domain.MaxCallTimeout and
+// domain.MaxStreamDuration).
 type ClientInterface interface {
 	// Close closes the client connection.
 	Close() error
@@ -73,9 +73,11 @@ type HostPluginProcess interface {
 	// Stop stops the host plugin process.
 	Stop()
 
-	// DispatchEvents dispatches events to the plugin.
-	DispatchEvents(events []Event)
+	// DispatchEvents dispatches events to the plugin. An error means the plugin
+	// did not take them; they are dispatched again.
+	DispatchEvents(events []Event) error
 
-	// DispatchJob dispatches a job to the plugin.
-	DispatchJob(job *Job)
+	// DispatchJob dispatches a job to the plugin. An error means the plugin did
+	// not take it; it is dispatched again.
+	DispatchJob(job *Job) error
 }
@@ -0,0 +1,86 @@
+package ports
+
+import "context"
+
+// Message is the unit of delivery on the internal event bus.
+// Exactly one of Event, Job, Call or KPI is set. Event and Job come from the
+// gate; Call and KPI are derived in-process by the call correlator and the
+// agent KPI aggregator.
+type Message struct {
+	// ID identifies the message within the bus. It is assigned by the bus
+	// when the publisher leaves it empty.
+	ID    string
+	Event *Event
+	Job   *Job
+	Call  *CallRecord
+	KPI   *AgentKPI
+}
+
+// Subscriber consumes messages delivered by the event bus.
+type Subscriber interface {
+	// HandleMessage processes a single message. Returning an error marks the
+	// delivery as failed for this subscriber only.
+	HandleMessage(ctx context.Context, msg Message) error
+}
+
+// SubscriberFunc adapts an ordinary function to the Subscriber interface.
+type SubscriberFunc func(ctx context.Context, msg Message) error
+
+// HandleMessage calls f(ctx, msg).
+func (f SubscriberFunc) HandleMessage(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// BatchSubscriber is a Subscriber that prefers to receive queued messages in
+// batches. The bus hands it every message already waiting, up to the
+// subscription's batch size, in a single call.
+type BatchSubscriber interface {
+	Subscriber
+
+	// HandleBatch processes messages in order. Returning an error marks the
+	// whole batch as failed.
+	HandleBatch(ctx context.Context, msgs []Message) error
+}
+
+// Router decides which subscribers receive an event. Subscribers the router
+// does not manage receive every message.
+type Router interface {
+	// Manages reports whether the router decides delivery for the named subscriber.
+	Manages(subscriber string) bool
+
+	// Route returns the managed subscribers that should receive msg.
+	Route(msg Message) []string
+}
+
+// Acknowledger records that a consumer has finished with a message so that
+// it is not redelivered after a restart.
+type Acknowledger interface {
+	Ack(consumer, messageID string) error
+}
+
+// EventBus defines the interface for the in-process publish/subscribe bus
+// that fans polled events and streamed jobs out to independent consumers.
+type EventBus interface {
+	EventDispatcher
+
+	// Publish enqueues messages for every subscriber, honouring each
+	// subscriber's backpressure policy.
+	Publish(ctx context.Context, msgs ...Message) error
+
+	// Unsubscribe removes a subscriber by name and stops its delivery loop.
+	Unsubscribe(name string) error
+
+	// Saturated returns true if every subscriber that can hold up publishers has a full queue.
+	Saturated() bool
+
+	// Close stops all subscribers.
+	Close() error
+}
+
+// EventSink is a bus subscriber that persists or forwards events outside the process.
+type EventSink interface {
+	Subscriber
+
+	// Close flushes buffered data and releases resources.
+	Close() error
+}
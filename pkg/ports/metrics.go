@@ -0,0 +1,85 @@
+package ports
+
+import "time"
+
+// Metrics receives operational measurements from the gate client and the
+// domain processes. Implementations must be safe for concurrent use and
+// should return quickly, since they are called on the hot path.
+type Metrics interface {
+	// RPCCompleted records a finished GateService call. Method is the bare RPC
+	// name, e.g. "PollEvents", and code the gRPC status code name, e.g. "OK".
+	// For streaming calls duration covers the whole stream.
+	RPCCompleted(method, code string, duration time.Duration)
+
+	// RPCServed records which gate address answered a call attempt. Endpoint
+	// is the address the attempt was sent to, e.g. "10.0.0.7:443"; attempts
+	// that never reached an address are not recorded.
+	RPCServed(method, endpoint, code string)
+
+	// EventPolled records one event returned by PollEvents.
+	EventPolled(kind string)
+
+	// EventDeduplicated records one polled event dropped as a duplicate.
+	EventDeduplicated(kind string)
+
+	// JobReceived records one job read from the StreamJobs stream.
+	JobReceived(jobType string)
+
+	// JobHandled records a bus subscriber handling a job. Err is the error the
+	// subscriber returned, or nil.
+	JobHandled(subscriber, jobType string, duration time.Duration, err error)
+
+	// JobResultSubmitFailed records a SubmitJobResults call that returned an error.
+	JobResultSubmitFailed()
+
+	// StreamReconnected records a stream being opened again after it ended or failed.
+	StreamReconnected(stream string)
+
+	// ConfigReloaded records the domain restarting its processes for a new client configuration.
+	ConfigReloaded()
+
+	// CertificateExpiry reports when the client certificate stops being valid.
+	CertificateExpiry(notAfter time.Time)
+
+	// CertificateRotated records a certificate rotation attempt by outcome,
+	// "success" or "failure".
+	CertificateRotated(outcome string)
+}
+
+// NopMetrics is a Metrics that discards every measurement.
+type NopMetrics struct{}
+
+// RPCCompleted does nothing.
+func (NopMetrics) RPCCompleted(string, string, time.Duration) {}
+
+// RPCServed does nothing.
+func (NopMetrics) RPCServed(string, string, string) {}
+
+// EventPolled does nothing.
+func (NopMetrics) EventPolled(string) {}
+
+// EventDeduplicated does nothing.
+func (NopMetrics) EventDeduplicated(string) {}
+
+// JobReceived does nothing.
+func (NopMetrics) JobReceived(string) {}
+
+// JobHandled does nothing.
+func (NopMetrics) JobHandled(string, string, time.Duration, error) {}
+
+// JobResultSubmitFailed does nothing.
+func (NopMetrics) JobResultSubmitFailed() {}
+
+// StreamReconnected does nothing.
+func (NopMetrics) StreamReconnected(string) {}
+
+// ConfigReloaded does nothing.
+func (NopMetrics) ConfigReloaded() {}
+
+// CertificateExpiry does nothing.
+func (NopMetrics) CertificateExpiry(time.Time) {}
+
+// CertificateRotated does nothing.
+func (NopMetrics) CertificateRotated(string) {}
+
+var _ Metrics = NopMetrics{}
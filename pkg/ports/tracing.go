@@ -0,0 +1,63 @@
+package ports
+
+import "context"
+
+// TraceContext carries a job's trace from its receipt on StreamJobs to the
+// SubmitJobResults call for it, including through out-of-process plugins.
+type TraceContext struct {
+	// TraceParent and TraceState are W3C Trace Context headers. TraceParent is
+	// empty when tracing is off.
+	TraceParent string `json:"traceparent,omitempty"`
+	TraceState  string `json:"tracestate,omitempty"`
+	// CorrelationID ties together the log lines written for the job. It is
+	// set even when tracing is off.
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// Tracer starts the spans of the domain processes' work. The tracing adapter
+// implements it with OpenTelemetry. Implementations must be safe for
+// concurrent use.
+type Tracer interface {
+	// Start starts a span named name and returns ctx carrying it. The span is
+	// a child of opts.Parent when it is set, and otherwise of the span ctx
+	// carries.
+	Start(ctx context.Context, name string, opts SpanOptions) (context.Context, Span)
+}
+
+// SpanOptions describes a span to start.
+type SpanOptions struct {
+	// Parent is a remote trace the span continues, e.g. a job's.
+	Parent *TraceContext
+	// Links are the traces of the other work the span covers.
+	Links []*TraceContext
+	// Consumer marks a span that handles work sent by another service.
+	Consumer bool
+	// Attributes are set on the span as it starts. Values are strings, bools,
+	// ints, int64s or float64s.
+	Attributes map[string]any
+}
+
+// Span is a started span.
+type Span interface {
+	// SetAttribute sets an attribute on the span. Value is a string, bool,
+	// int, int64 or float64.
+	SetAttribute(key string, value any)
+	// TraceParent returns the span as a W3C traceparent header, or "" when it
+	// has no trace.
+	TraceParent() string
+	// End records err, unless it is nil, as the span's error and ends it.
+	End(err error)
+}
+
+// NopTracer starts spans that record nothing.
+type NopTracer struct{}
+
+func (NopTracer) Start(ctx context.Context, _ string, _ SpanOptions) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttribute(string, any) {}
+func (nopSpan) TraceParent() string      { return "" }
+func (nopSpan) End(error)                {}
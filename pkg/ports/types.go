@@ -1,6 +1,9 @@
 package ports
 
 import (
+	"fmt"
+	"time"
+
 	gatev2pb "github.com/tcncloud/sati-go/internal/genproto/tcnapi/exile/gate/v2"
 )
 
@@ -62,9 +65,19 @@ type ListAgentsResult struct {
 
 // --- UpdateAgentStatus ---
 
+// AgentState is an agent's state as the gate names it, e.g. AGENT_STATE_PAUSED.
+type AgentState = gatev2pb.AgentState
+
+// ParseAgentState returns the AgentState with the given name.
+func ParseAgentState(name string) (AgentState, bool) {
+	state, ok := gatev2pb.AgentState_value[name]
+
+	return AgentState(state), ok
+}
+
 type UpdateAgentStatusParams struct {
-	PartnerAgentID string              // Required
-	NewState       gatev2pb.AgentState // Required (Using proto enum directly is often okay)
+	PartnerAgentID string     // Required
+	NewState       AgentState // Required (Using proto enum directly is often okay)
 	Reason         *string
 }
 
@@ -216,11 +229,88 @@ type PollEventsResult struct {
 	Events []Event
 }
 
+// Event types reported in Event.Type.
+const (
+	EventTypeTelephonyResult  = "telephony_result"
+	EventTypeAgentCall        = "agent_call"
+	EventTypeAgentResponse    = "agent_response"
+	EventTypeTransferInstance = "transfer_instance"
+)
+
 type Event struct {
-	Type          string
-	Telephony     *ExileTelephonyResult
-	AgentCall     *ExileAgentCall
-	AgentResponse *ExileAgentResponse
+	Type             string
+	Telephony        *ExileTelephonyResult
+	AgentCall        *ExileAgentCall
+	AgentResponse    *ExileAgentResponse
+	TransferInstance *ExileTransferInstance
+}
+
+// Kind returns the event type, deriving it from the populated entity when Type is empty.
+func (e Event) Kind() string {
+	switch {
+	case e.Type != "":
+		return e.Type
+	case e.Telephony != nil:
+		return EventTypeTelephonyResult
+	case e.AgentCall != nil:
+		return EventTypeAgentCall
+	case e.AgentResponse != nil:
+		return EventTypeAgentResponse
+	case e.TransferInstance != nil:
+		return EventTypeTransferInstance
+	default:
+		return ""
+	}
+}
+
+// Key returns a stable identity for the event built from its SIDs. Redelivered
+// or re-polled copies of the same entity share a key, so it is suitable for
+// idempotency and deduplication.
+func (e Event) Key() string {
+	switch {
+	case e.Telephony != nil:
+		return fmt.Sprintf("%s/%s/%d", EventTypeTelephonyResult, e.Telephony.CallType, e.Telephony.CallSid)
+	case e.AgentCall != nil:
+		return fmt.Sprintf("%s/%d", EventTypeAgentCall, e.AgentCall.AgentCallSid)
+	case e.AgentResponse != nil:
+		return fmt.Sprintf("%s/%d", EventTypeAgentResponse, e.AgentResponse.AgentCallResponseSid)
+	case e.TransferInstance != nil:
+		return fmt.Sprintf("%s/%s", EventTypeTransferInstance, e.TransferInstance.TransferInstanceID)
+	default:
+		return e.Kind()
+	}
+}
+
+// OrgID returns the organization of the populated entity.
+func (e Event) OrgID() string {
+	switch {
+	case e.Telephony != nil:
+		return e.Telephony.OrgID
+	case e.AgentCall != nil:
+		return e.AgentCall.OrgID
+	case e.AgentResponse != nil:
+		return e.AgentResponse.OrgID
+	case e.TransferInstance != nil:
+		return e.TransferInstance.OrgID
+	default:
+		return ""
+	}
+}
+
+// CallType returns the call type of the populated entity. Transfers report the source leg.
+func (e Event) CallType() string {
+	switch {
+	case e.Telephony != nil:
+		return e.Telephony.CallType
+	case e.AgentCall != nil:
+		return e.AgentCall.CallType
+	case e.AgentResponse != nil:
+		return e.AgentResponse.CallType
+	case e.TransferInstance != nil:
+		return e.TransferInstance.SourceCallType
+	default:
+		return ""
+	}
 }
 
 type ExileTelephonyResult struct {
@@ -282,6 +372,124 @@ type ExileAgentResponse struct {
 	PartnerAgentID       string
 }
 
+type ExileTransferInstance struct {
+	ClientSid                    int64
+	OrgID                        string
+	TransferInstanceID           string
+	SourceCallSid                int64
+	SourceCallType               string
+	SourcePartnerAgentID         string
+	SourceUserID                 string
+	SourceConversationID         int64
+	SourceSessionSid             int64
+	SourceAgentCallSid           int64
+	DestinationType              string // "call", "agent", "phone" or "skills"
+	DestinationCallSid           int64
+	DestinationCallType          string
+	DestinationConversationID    int64
+	DestinationSessionSid        int64
+	DestinationPartnerAgentID    string
+	DestinationUserID            string
+	DestinationPhoneNumber       string
+	DestinationSkills            []string
+	CreateTime                   string
+	UpdateTime                   string
+	TransferPendingStartTime     string
+	TransferStartTime            string
+	TransferEndTime              string
+	TransferExternalEndTime      string
+	TransferResult               string
+	TransferType                 string
+	StartAsPending               bool
+	StartedAsConference          bool
+	DurationMicroseconds         int64
+	ExternalDurationMicroseconds int64
+	PendingDurationMicroseconds  int64
+}
+
+// --- Call correlation ---
+
+// CallRecord joins every event received for one call.
+type CallRecord struct {
+	CallSid  int64
+	CallType string
+	OrgID    string
+	// Telephony is the latest telephony result, if one was received.
+	Telephony *ExileTelephonyResult
+	// AgentCalls, Responses and Transfers hold the latest version of each entity.
+	AgentCalls []ExileAgentCall
+	Responses  []ExileAgentResponse
+	Transfers  []ExileTransferInstance
+	// Agents lists the partner agent IDs involved, in order of first appearance.
+	Agents []string
+	// Dispositions are the agent responses as key/value pairs.
+	Dispositions []CallDisposition
+	// Timeline lists what happened on the call, oldest first.
+	Timeline []TimelineEntry
+	// FirstSeen and LastSeen are when the correlator received the first and last event.
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// Events is the number of events joined into the record.
+	Events int
+}
+
+// CallDisposition is one response recorded by an agent.
+type CallDisposition struct {
+	PartnerAgentID string
+	Key            string
+	Value          string
+	Time           string
+}
+
+// Timeline entry kinds reported in TimelineEntry.Kind, in addition to the event types.
+const (
+	TimelineCallStarted = "call_started"
+	TimelineCallEnded   = "call_ended"
+)
+
+// TimelineEntry is one point in a call timeline.
+type TimelineEntry struct {
+	Time        string
+	Kind        string
+	Description string
+}
+
+// --- Agent KPIs ---
+
+// AgentKPI rolls up one partner agent's calls over a time bucket. Durations are
+// in the units of ExileAgentCall (seconds). Handle time is talk + hold + wrap-up.
+type AgentKPI struct {
+	PartnerAgentID string
+	OrgID          string
+	// Bucket is the bucket width, e.g. "15m", "1h" or "24h".
+	Bucket string
+	Start  time.Time
+	End    time.Time
+	Calls  int
+	// Final is false for a bucket emitted before it closed, e.g. at shutdown.
+	Final    bool
+	Totals   KPIDurations
+	Averages KPIDurations
+	// HandleTime percentiles use the nearest-rank method.
+	HandleTimeP50 float64
+	HandleTimeP90 float64
+	HandleTimeP95 float64
+	HandleTimeP99 float64
+}
+
+// KPIDurations holds one value per ExileAgentCall duration.
+type KPIDurations struct {
+	Talk       float64
+	CallWait   float64
+	WrapUp     float64
+	Pause      float64
+	Hold       float64
+	Transfer   float64
+	Preview    float64
+	Manual     float64
+	HandleTime float64
+}
+
 // --- StreamJobs ---
 type StreamJobsParams struct{}
 
@@ -294,6 +502,8 @@ type Job struct {
 	JobID string
 	Type  string
 	Data  map[string]interface{}
+	// Trace is set by the gate client when the job is received.
+	Trace *TraceContext `json:",omitempty"`
 }
 
 // --- SubmitJobResults ---
@@ -447,7 +657,10 @@ type LogParams struct {
 type LogResult struct{}
 
 // --- RotateCertificate ---
-type RotateCertificateParams struct{}
+type RotateCertificateParams struct {
+	// CertificateHash identifies the certificate being rotated.
+	CertificateHash string
+}
 
 type RotateCertificateResult struct {
 	Certificate   string
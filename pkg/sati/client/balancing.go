@@ -0,0 +1,85 @@
+package client
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	saticonfig "github.com/tcncloud/sati-go/pkg/sati/config"
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/health" // Registers client-side health checking.
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// endpointsScheme names the resolver that hands a connection its list of
+// endpoints.
+const endpointsScheme = "sati-endpoints"
+
+// route is where a connection sends calls: the gate's endpoints and the
+// service config that balances calls over them.
+type route struct {
+	endpoints     []string
+	serviceConfig string
+}
+
+// newRoute returns the route of cfg's endpoints and load_balancing section.
+func newRoute(cfg *saticonfig.Config) route {
+	endpoints := cfg.Endpoints()
+	for i, endpoint := range endpoints {
+		endpoints[i] = parseAPIEndpoint(endpoint)
+	}
+
+	return route{endpoints: endpoints, serviceConfig: serviceConfig(cfg.LoadBalancing)}
+}
+
+func (r route) String() string {
+	return strings.Join(r.endpoints, ",")
+}
+
+func (r route) equal(other route) bool {
+	return slices.Equal(r.endpoints, other.endpoints) && r.serviceConfig == other.serviceConfig
+}
+
+// target returns the gRPC target of the route and the dial options it needs.
+// A single endpoint is resolved by DNS, so the policy balances over its
+// addresses. Several endpoints are handed to the connection by a resolver of
+// its own, and each keeps its host name for the gate's certificate check.
+func (r route) target() (string, []grpc.DialOption) {
+	if len(r.endpoints) == 1 {
+		return r.endpoints[0], nil
+	}
+
+	state := resolver.State{Endpoints: make([]resolver.Endpoint, len(r.endpoints))}
+	for i, endpoint := range r.endpoints {
+		state.Endpoints[i] = resolver.Endpoint{
+			Addresses: []resolver.Address{{Addr: endpoint, ServerName: endpoint}},
+		}
+	}
+
+	builder := manual.NewBuilderWithScheme(endpointsScheme)
+	builder.InitialState(state)
+
+	return endpointsScheme + ":///gate", []grpc.DialOption{grpc.WithResolvers(builder)}
+}
+
+// serviceConfig returns the gRPC service config of a load_balancing section.
+// The gate does not send one, so it applies to every connection.
+func serviceConfig(lb *saticonfig.LoadBalancing) string {
+	policy := saticonfig.PolicyPickFirst
+	if lb != nil && lb.Policy != "" {
+		policy = lb.Policy
+	}
+
+	config := map[string]any{
+		"loadBalancingConfig": []map[string]any{{policy: map[string]any{}}},
+	}
+
+	if lb != nil && lb.HealthCheck {
+		config["healthCheckConfig"] = map[string]string{"serviceName": lb.HealthCheckService}
+	}
+
+	data, _ := json.Marshal(config)
+
+	return string(data)
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tcncloud/sati-go/pkg/ports"
+	saticonfig "github.com/tcncloud/sati-go/pkg/sati/config"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// endpointMetrics remembers which gate address answered each call.
+type endpointMetrics struct {
+	ports.NopMetrics
+
+	mu        sync.Mutex
+	endpoints []string
+}
+
+func (m *endpointMetrics) RPCServed(_, endpoint, _ string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.endpoints = append(m.endpoints, endpoint)
+}
+
+func (m *endpointMetrics) last() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.endpoints) == 0 {
+		return ""
+	}
+
+	return m.endpoints[len(m.endpoints)-1]
+}
+
+// servedBy makes calls until endpoint answers one, or fails after a few seconds.
+func servedBy(t *testing.T, c *Client, m *endpointMetrics, endpoint string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for m.last() != endpoint {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected a call to be served by %s, last was %s", endpoint, m.last())
+		}
+
+		orgID(t, c)
+	}
+}
+
+func TestClient_FailsOverToNextEndpoint(t *testing.T) {
+	pki := newTestPKI(t)
+	firstAddr, first := serveTestGate(t, pki)
+	secondAddr, _ := serveTestGate(t, pki)
+
+	cfg := pki.config(firstAddr, "client-1")
+	cfg.APIEndpoints = []string{secondAddr}
+
+	metrics := &endpointMetrics{}
+
+	c, err := NewClient(cfg, WithMetrics(metrics))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+
+	orgID(t, c)
+
+	if got := metrics.last(); got != firstAddr {
+		t.Fatalf("Expected pick_first to use the first endpoint %s, got %s", firstAddr, got)
+	}
+
+	first.server.Stop()
+
+	orgID(t, c)
+
+	if got := metrics.last(); got != secondAddr {
+		t.Errorf("Expected the call to fail over to %s, got %s", secondAddr, got)
+	}
+}
+
+func TestClient_RoundRobinSkipsUnhealthyEndpoints(t *testing.T) {
+	pki := newTestPKI(t)
+	firstAddr, first := serveTestGate(t, pki)
+	secondAddr, _ := serveTestGate(t, pki)
+	thirdAddr, _ := serveTestGate(t, pki)
+
+	first.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	cfg := pki.config(firstAddr, "client-1")
+	cfg.APIEndpoints = []string{secondAddr, thirdAddr}
+	cfg.LoadBalancing = &saticonfig.LoadBalancing{Policy: saticonfig.PolicyRoundRobin, HealthCheck: true}
+
+	metrics := &endpointMetrics{}
+
+	c, err := NewClient(cfg, WithMetrics(metrics))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+
+	servedBy(t, c, metrics, secondAddr)
+	servedBy(t, c, metrics, thirdAddr)
+
+	for range 10 {
+		orgID(t, c)
+	}
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	for _, endpoint := range metrics.endpoints {
+		if endpoint == firstAddr {
+			t.Fatalf("Expected no call to the endpoint that is not serving, got %v", metrics.endpoints)
+		}
+	}
+}
+
+func TestRoute(t *testing.T) {
+	single := newRoute(&saticonfig.Config{APIEndpoint: "https://gate.example.com"})
+
+	if target, opts := single.target(); target != "gate.example.com:443" || len(opts) != 0 {
+		t.Errorf("Expected a single endpoint to be dialed directly, got %q with %d options", target, len(opts))
+	}
+
+	if !strings.Contains(single.serviceConfig, saticonfig.PolicyPickFirst) {
+		t.Errorf("Expected pick_first by default, got %s", single.serviceConfig)
+	}
+
+	several := newRoute(&saticonfig.Config{
+		APIEndpoint:   "https://gate.example.com",
+		APIEndpoints:  []string{"gate-b.example.com:443", "https://gate.example.com"},
+		LoadBalancing: &saticonfig.LoadBalancing{Policy: saticonfig.PolicyRoundRobin, HealthCheck: true},
+	})
+
+	if got := several.String(); got != "gate.example.com:443,gate-b.example.com:443" {
+		t.Errorf("Unexpected endpoints %q", got)
+	}
+
+	if target, opts := several.target(); !strings.HasPrefix(target, endpointsScheme+":") || len(opts) != 1 {
+		t.Errorf("Expected several endpoints to use their own resolver, got %q with %d options", target, len(opts))
+	}
+
+	want := `{"healthCheckConfig":{"serviceName":""},"loadBalancingConfig":[{"round_robin":{}}]}`
+	if several.serviceConfig != want {
+		t.Errorf("Expected service config %s, got %s", want, several.serviceConfig)
+	}
+
+	if single.equal(several) || !several.equal(several) {
+		t.Error("Expected routes to be equal only to themselves")
+	}
+}
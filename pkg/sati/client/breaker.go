@@ -0,0 +1,373 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/sati/gateerr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrCircuitOpen is returned without calling the gate while a method's circuit is open.
+// It is a gateerr.ErrUnavailable.
+var ErrCircuitOpen = gateerr.New(gateerr.ErrUnavailable, "circuit breaker open")
+
+// Circuit breaker defaults.
+const (
+	// DefaultCircuitFailureThreshold is how many consecutive failures open a circuit.
+	DefaultCircuitFailureThreshold = 5
+	// DefaultCircuitOpenTimeout is how long a circuit stays open before it lets a trial call through.
+	DefaultCircuitOpenTimeout = 30 * time.Second
+	// DefaultCircuitHalfOpenCalls is how many trial calls must succeed to close a circuit.
+	DefaultCircuitHalfOpenCalls = 1
+)
+
+// DefaultCircuitFailureCodes are the status codes that show the gate is degraded.
+var DefaultCircuitFailureCodes = []codes.Code{
+	codes.Unavailable,
+	codes.DeadlineExceeded,
+	codes.ResourceExhausted,
+	codes.Internal,
+	codes.Unknown,
+}
+
+// CircuitState is the state of one circuit.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every call through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails every call with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of trial calls through.
+	CircuitHalfOpen
+)
+
+// String returns the state's name.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// CircuitBreakerConfig configures the circuit breaker. Each method has its own
+// circuit unless Groups puts it in a shared one. A circuit opens after
+// FailureThreshold consecutive failures, stays open for OpenTimeout, then
+// half-opens and lets HalfOpenCalls trial calls through. It closes once they
+// all succeed and opens again if any fails.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is how many consecutive failures open a circuit. Zero
+	// disables the breaker.
+	FailureThreshold int
+	OpenTimeout      time.Duration
+	HalfOpenCalls    int
+	// FailureCodes are the status codes counted as failures. Other status
+	// codes show the gate is answering and count as successes; canceled calls
+	// and errors that are not statuses count neither way.
+	FailureCodes []codes.Code
+	// Groups maps a bare method name, e.g. "ListAgents", to the name of a
+	// circuit it shares with other methods.
+	Groups map[string]string
+}
+
+// DefaultCircuitBreakerConfig returns a breaker with a circuit per method.
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureThreshold: DefaultCircuitFailureThreshold,
+		OpenTimeout:      DefaultCircuitOpenTimeout,
+		HalfOpenCalls:    DefaultCircuitHalfOpenCalls,
+		FailureCodes:     DefaultCircuitFailureCodes,
+	}
+}
+
+// WithCircuitBreaker replaces DefaultCircuitBreakerConfig.
+// WithCircuitBreaker(CircuitBreakerConfig{}) disables the breaker.
+func WithCircuitBreaker(config CircuitBreakerConfig) Option {
+	return func(o *options) {
+		o.breaker = config
+	}
+}
+
+func (c CircuitBreakerConfig) enabled() bool {
+	return c.FailureThreshold > 0
+}
+
+// breaker holds the circuits of one client.
+type breaker struct {
+	config CircuitBreakerConfig
+	log    *zerolog.Logger
+	now    func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+func newBreaker(config CircuitBreakerConfig, log *zerolog.Logger) *breaker {
+	if config.HalfOpenCalls <= 0 {
+		config.HalfOpenCalls = DefaultCircuitHalfOpenCalls
+	}
+
+	return &breaker{
+		config:   config,
+		log:      log,
+		now:      time.Now,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+// circuit returns the circuit for a bare method name, creating it on first use.
+func (b *breaker) circuit(method string) *circuit {
+	name := method
+	if group, ok := b.config.Groups[method]; ok {
+		name = group
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[name]
+	if !ok {
+		c = &circuit{name: name, breaker: b}
+		b.circuits[name] = c
+	}
+
+	return c
+}
+
+// outcome is how a call's result counts for its circuit.
+type outcome int
+
+const (
+	// outcomeSuccess shows the gate answering.
+	outcomeSuccess outcome = iota
+	// outcomeFailure is an answer with one of the FailureCodes.
+	outcomeFailure
+	// outcomeNotAttempted is an error raised before the gate was called, such
+	// as a context canceled while waiting. It counts neither way and frees a
+	// half-open trial for another call.
+	outcomeNotAttempted
+)
+
+// outcome classifies err. Errors that are not gRPC statuses were not returned
+// by the gate, and a call the caller canceled says nothing about it.
+func (b *breaker) outcome(err error) outcome {
+	if err == nil {
+		return outcomeSuccess
+	}
+
+	st, ok := status.FromError(err)
+	if !ok || st.Code() == codes.Canceled {
+		return outcomeNotAttempted
+	}
+
+	if slices.Contains(b.config.FailureCodes, st.Code()) {
+		return outcomeFailure
+	}
+
+	return outcomeSuccess
+}
+
+// circuit tracks the state of one method or group.
+type circuit struct {
+	name    string
+	breaker *breaker
+
+	mu         sync.Mutex
+	state      CircuitState
+	generation uint64
+	failures   int
+	openedAt   time.Time
+	trials     int
+	successes  int
+}
+
+// allow reports whether a call may proceed, returning the generation its
+// outcome must be recorded against.
+func (c *circuit) allow() (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state == CircuitOpen {
+		remaining := c.breaker.config.OpenTimeout - c.breaker.now().Sub(c.openedAt)
+		if remaining > 0 {
+			return 0, fmt.Errorf("%w: %s, retry in %s", ErrCircuitOpen, c.name, remaining.Round(time.Millisecond))
+		}
+
+		c.transition(CircuitHalfOpen)
+	}
+
+	if c.state == CircuitHalfOpen {
+		if c.trials >= c.breaker.config.HalfOpenCalls {
+			return 0, fmt.Errorf("%w: %s, trial calls in progress", ErrCircuitOpen, c.name)
+		}
+
+		c.trials++
+	}
+
+	return c.generation, nil
+}
+
+// record updates the circuit with the outcome of a call. Outcomes of calls
+// let through before the last state change are ignored.
+func (c *circuit) record(generation uint64, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	result := c.breaker.outcome(err)
+
+	switch c.state {
+	case CircuitClosed:
+		switch result {
+		case outcomeSuccess:
+			c.failures = 0
+		case outcomeFailure:
+			c.failures++
+			if c.failures >= c.breaker.config.FailureThreshold {
+				c.transition(CircuitOpen)
+			}
+		case outcomeNotAttempted:
+		}
+	case CircuitHalfOpen:
+		switch result {
+		case outcomeSuccess:
+			c.successes++
+			if c.successes >= c.breaker.config.HalfOpenCalls {
+				c.transition(CircuitClosed)
+			}
+		case outcomeFailure:
+			c.transition(CircuitOpen)
+		case outcomeNotAttempted:
+			c.trials--
+		}
+	case CircuitOpen:
+	}
+}
+
+// transition moves the circuit to state and logs the change. The caller holds c.mu.
+func (c *circuit) transition(state CircuitState) {
+	from := c.state
+
+	c.state = state
+	c.generation++
+	c.trials = 0
+	c.successes = 0
+
+	event := c.breaker.log.Info()
+
+	switch state {
+	case CircuitOpen:
+		c.openedAt = c.breaker.now()
+		event = c.breaker.log.Warn().Int("failures", c.failures).Dur("open_timeout", c.breaker.config.OpenTimeout)
+	case CircuitClosed:
+		c.failures = 0
+	case CircuitHalfOpen:
+	}
+
+	event.Str("circuit", c.name).Str("from", from.String()).Str("to", state.String()).Msg("Circuit breaker state changed")
+}
+
+func unaryBreakerInterceptor(b *breaker) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		c := b.circuit(rpcName(method))
+
+		generation, err := c.allow()
+		if err != nil {
+			return err
+		}
+
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		c.record(generation, err)
+
+		return err
+	}
+}
+
+// streamBreakerInterceptor judges a stream by how it starts: the first
+// message or a clean end is a success, a failure to open or a first receive
+// error is a failure. A stream whose context ends before its first receive,
+// e.g. because the caller only sends or gives up, is not attempted.
+func streamBreakerInterceptor(b *breaker) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		c := b.circuit(rpcName(method))
+
+		generation, err := c.allow()
+		if err != nil {
+			return nil, err
+		}
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			c.record(generation, err)
+
+			return nil, err
+		}
+
+		s := &breakerStream{ClientStream: stream, circuit: c, generation: generation}
+
+		go func() {
+			<-stream.Context().Done()
+			s.record(stream.Context().Err())
+		}()
+
+		return s, nil
+	}
+}
+
+type breakerStream struct {
+	grpc.ClientStream
+
+	circuit    *circuit
+	generation uint64
+	once       sync.Once
+}
+
+func (s *breakerStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if errors.Is(err, io.EOF) {
+		s.record(nil)
+	} else {
+		s.record(err)
+	}
+
+	return err
+}
+
+// record reports the stream's outcome once.
+func (s *breakerStream) record(err error) {
+	s.once.Do(func() {
+		s.circuit.record(s.generation, err)
+	})
+}
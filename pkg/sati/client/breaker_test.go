@@ -0,0 +1,260 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestBreaker(config CircuitBreakerConfig) (*breaker, *time.Time, *bytes.Buffer) {
+	var buf bytes.Buffer
+
+	logger := zerolog.New(&buf)
+	b := newBreaker(config, &logger)
+
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	return b, &now, &buf
+}
+
+func TestBreaker_OpensAndRecovers(t *testing.T) {
+	config := DefaultCircuitBreakerConfig()
+	config.FailureThreshold = 2
+	config.OpenTimeout = time.Minute
+
+	b, now, logs := newTestBreaker(config)
+	interceptor := unaryBreakerInterceptor(b)
+
+	invoker := &fakeInvoker{errs: []error{
+		status.Error(codes.Unavailable, "down"),
+		status.Error(codes.NotFound, "no agent"), // the gate answered, so the count resets
+		status.Error(codes.Unavailable, "down"),
+		status.Error(codes.Unavailable, "down"),
+	}}
+
+	call := func() error {
+		return interceptor(context.Background(), gateMethod("ListAgents"), nil, nil, nil, invoker.invoke)
+	}
+
+	for range 4 {
+		_ = call()
+	}
+
+	if err := call(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+
+	if invoker.calls != 4 {
+		t.Errorf("Expected the open circuit not to call the gate, got %d calls", invoker.calls)
+	}
+
+	// Other methods have their own circuit
+	if err := interceptor(context.Background(), gateMethod("ListSkills"), nil, nil, nil, invoker.invoke); err != nil {
+		t.Errorf("Expected ListSkills to be unaffected, got %v", err)
+	}
+
+	*now = now.Add(time.Minute)
+
+	if err := call(); err != nil {
+		t.Fatalf("Expected the half-open trial call to succeed, got %v", err)
+	}
+
+	if err := call(); err != nil {
+		t.Fatalf("Expected the circuit to close, got %v", err)
+	}
+
+	for _, want := range []string{`"from":"closed","to":"open"`, `"from":"open","to":"half-open"`, `"from":"half-open","to":"closed"`} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("Expected a state change log with %s, got %s", want, logs.String())
+		}
+	}
+}
+
+func TestBreaker_HalfOpenFailureReopens(t *testing.T) {
+	config := DefaultCircuitBreakerConfig()
+	config.FailureThreshold = 1
+	config.HalfOpenCalls = 2
+
+	b, now, _ := newTestBreaker(config)
+	c := b.circuit("Dial")
+
+	generation, _ := c.allow()
+	c.record(generation, status.Error(codes.Unavailable, "down"))
+
+	*now = now.Add(config.OpenTimeout)
+
+	first, err := c.allow()
+	if err != nil {
+		t.Fatalf("Expected a trial call, got %v", err)
+	}
+
+	if _, err := c.allow(); err != nil {
+		t.Fatalf("Expected a second trial call, got %v", err)
+	}
+
+	if _, err := c.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected trial calls to be limited, got %v", err)
+	}
+
+	c.record(first, status.Error(codes.DeadlineExceeded, "slow"))
+
+	if c.state != CircuitOpen {
+		t.Errorf("Expected a failed trial to reopen the circuit, got %s", c.state)
+	}
+}
+
+func TestBreaker_NotAttempted(t *testing.T) {
+	config := DefaultCircuitBreakerConfig()
+	config.FailureThreshold = 2
+
+	b, now, _ := newTestBreaker(config)
+	c := b.circuit("UpsertAgent")
+
+	for _, err := range []error{
+		status.Error(codes.Unavailable, "down"),
+		context.Canceled,
+		status.Error(codes.Canceled, "canceled"),
+	} {
+		generation, _ := c.allow()
+		c.record(generation, err)
+	}
+
+	if c.state != CircuitClosed || c.failures != 1 {
+		t.Fatalf("Expected calls that were not attempted to keep the failure count, got %s with %d failures", c.state, c.failures)
+	}
+
+	generation, _ := c.allow()
+	c.record(generation, status.Error(codes.Unavailable, "down"))
+
+	*now = now.Add(config.OpenTimeout)
+
+	trial, err := c.allow()
+	if err != nil {
+		t.Fatalf("Expected a trial call, got %v", err)
+	}
+
+	c.record(trial, context.DeadlineExceeded)
+
+	if c.state != CircuitHalfOpen {
+		t.Fatalf("Expected a trial that was not attempted to leave the circuit half-open, got %s", c.state)
+	}
+
+	if _, err := c.allow(); err != nil {
+		t.Errorf("Expected the trial slot to be free again, got %v", err)
+	}
+}
+
+func TestBreaker_Groups(t *testing.T) {
+	config := DefaultCircuitBreakerConfig()
+	config.FailureThreshold = 1
+	config.Groups = map[string]string{"ListAgents": "agents", "GetAgentById": "agents"}
+
+	b, _, _ := newTestBreaker(config)
+
+	c := b.circuit("ListAgents")
+	generation, _ := c.allow()
+	c.record(generation, status.Error(codes.Unavailable, "down"))
+
+	if _, err := b.circuit("GetAgentById").allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected the shared circuit to be open, got %v", err)
+	}
+}
+
+func TestStreamBreakerInterceptor(t *testing.T) {
+	config := DefaultCircuitBreakerConfig()
+	config.FailureThreshold = 1
+
+	b, _, _ := newTestBreaker(config)
+	interceptor := streamBreakerInterceptor(b)
+
+	streamer := func(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &contextStream{fakeClientStream: fakeClientStream{errs: []error{status.Error(codes.Unavailable, "down"), io.EOF}}, ctx: ctx}, nil
+	}
+
+	stream, err := interceptor(context.Background(), &grpc.StreamDesc{}, nil, gateMethod("StreamJobs"), streamer)
+	if err != nil {
+		t.Fatalf("Expected the stream to open, got %v", err)
+	}
+
+	_ = stream.RecvMsg(nil)
+
+	if _, err := interceptor(context.Background(), &grpc.StreamDesc{}, nil, gateMethod("StreamJobs"), streamer); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected a failed first receive to open the circuit, got %v", err)
+	}
+}
+
+// contextStream is a fakeClientStream with a context.
+type contextStream struct {
+	fakeClientStream
+
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamBreakerInterceptor_ContextEndFreesTrial(t *testing.T) {
+	config := DefaultCircuitBreakerConfig()
+	config.FailureThreshold = 1
+
+	b, now, _ := newTestBreaker(config)
+	interceptor := streamBreakerInterceptor(b)
+
+	c := b.circuit("StreamJobs")
+	generation, _ := c.allow()
+	c.record(generation, status.Error(codes.Unavailable, "down"))
+
+	*now = now.Add(config.OpenTimeout)
+
+	streamer := func(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &contextStream{ctx: ctx}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if _, err := interceptor(ctx, &grpc.StreamDesc{}, nil, gateMethod("StreamJobs"), streamer); err != nil {
+		t.Fatalf("Expected the trial stream to open, got %v", err)
+	}
+
+	if _, err := c.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected the trial stream to hold the slot, got %v", err)
+	}
+
+	// The caller gives up without receiving.
+	cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := c.allow(); err == nil {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the ended stream to free its trial slot")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if c.state != CircuitHalfOpen {
+		t.Errorf("Expected the circuit to stay half-open, got %s", c.state)
+	}
+}
+
+func TestBreaker_Disabled(t *testing.T) {
+	o := newOptions([]Option{WithCircuitBreaker(CircuitBreakerConfig{})})
+	if o.breaker.enabled() {
+		t.Error("Expected WithCircuitBreaker(CircuitBreakerConfig{}) to disable the breaker")
+	}
+}
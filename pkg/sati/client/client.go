@@ -2,42 +2,54 @@ package client
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/rs/zerolog"
 	gatev2pb "github.com/tcncloud/sati-go/internal/genproto/tcnapi/exile/gate/v2" // Keep for internal mapping
 	"github.com/tcncloud/sati-go/pkg/ports"
 	saticonfig "github.com/tcncloud/sati-go/pkg/sati/config"
+	"github.com/tcncloud/sati-go/pkg/sati/gateerr"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"google.golang.org/protobuf/types/known/wrapperspb" // Needed for optional fields
 )
 
-// Common error constants for client operations.
+// Common error constants for client operations. Errors from the gate itself
+// are classified by gateerr.FromError.
 var (
-	ErrScrubListIDRequired     = errors.New("ScrubListID and at least one Entry are required")
-	ErrEntryContentEmpty       = errors.New("entry content cannot be empty")
-	ErrDialParamsRequired      = errors.New("PartnerAgentID and PhoneNumber are required")
+	ErrScrubListIDRequired     = gateerr.New(gateerr.ErrInvalidArgument, "ScrubListID and at least one Entry are required")
+	ErrEntryContentEmpty       = gateerr.New(gateerr.ErrInvalidArgument, "entry content cannot be empty")
+	ErrDialParamsRequired      = gateerr.New(gateerr.ErrInvalidArgument, "PartnerAgentID and PhoneNumber are required")
 	ErrDialResponseNil         = errors.New("received nil response from gRPC Dial")
-	ErrUserIDRequired          = errors.New("UserID is required")
-	ErrAgentNotFound           = errors.New("agent not found or nil response received")
+	ErrUserIDRequired          = gateerr.New(gateerr.ErrInvalidArgument, "UserID is required")
+	ErrAgentNotFound           = gateerr.New(gateerr.ErrNotFound, "agent not found or nil response received")
 	ErrClientConfigResponseNil = errors.New("received nil response from gRPC GetClientConfiguration")
 	ErrListAgentsStreamNil     = errors.New("received nil agent in ListAgents stream")
-	ErrPartnerAgentIDRequired  = errors.New("PartnerAgentID is required")
+	ErrPartnerAgentIDRequired  = gateerr.New(gateerr.ErrInvalidArgument, "PartnerAgentID is required")
 	ErrCAAppendFailed          = errors.New("failed to append CA cert")
 )
 
 // Client provides methods for interacting with the GateService API.
 type Client struct {
-	conn *grpc.ClientConn
-	gate gatev2pb.GateServiceClient
+	mu       sync.RWMutex
+	conn     *grpc.ClientConn
+	gate     gatev2pb.GateServiceClient
+	current  *connection
+	draining map[*connection]struct{}
+	reloaded chan struct{}
+	creds    *reloadableCredentials
+	dialOpts []grpc.DialOption
+	metrics  ports.Metrics
+	jobs     *jobSpans
+	log      *zerolog.Logger
 }
 
 // Ensure Client implements the ClientInterface interface
@@ -45,20 +57,57 @@ var _ ports.ClientInterface = (*Client)(nil)
 
 // NewClient creates a new Sati API client.
 // It takes the configuration and sets up the gRPC connection and client stub.
-func NewClient(cfg *saticonfig.Config) (*Client, error) {
-	conn, err := setupConnection(cfg)
+// Transient failures are retried with DefaultRetryConfig unless WithRetry says otherwise,
+// calls are throttled to the configuration's rate_limits unless WithRateLimits replaces them,
+// and the connection is tuned by its transport section unless WithTransport replaces it.
+// Reload switches a running client to a renewed certificate or a new endpoint.
+func NewClient(cfg *saticonfig.Config, opts ...Option) (*Client, error) {
+	o := newOptions(opts)
+	if o.rateLimits == nil {
+		o.rateLimits = cfg.RateLimits
+	}
+	if o.transport == nil {
+		o.transport = cfg.Transport
+	}
+
+	material, err := loadTLSMaterial(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Client{
-		conn: conn,
-		gate: gatev2pb.NewGateServiceClient(conn),
-	}, nil
+	reportCertificateExpiry(o.metrics, material.cert.Certificate)
+
+	c := &Client{
+		draining: make(map[*connection]struct{}),
+		reloaded: make(chan struct{}),
+		creds:    newReloadableCredentials(material),
+		dialOpts: o.dialOptions(),
+		metrics:  o.metrics,
+		jobs:     newJobSpans(o.tracerProvider),
+		log:      o.log,
+	}
+
+	c.current, err = dial(newRoute(cfg), material.serverName, c.creds, c.dialOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	c.conn = c.current.conn
+	c.gate = c.current.gate
+
+	return c, nil
 }
 
-// Close terminates the underlying gRPC connection.
+// Close terminates the gRPC connection, and any connection still draining
+// after a reload.
 func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for old := range c.draining {
+		_ = old.conn.Close()
+	}
+
 	if c.conn != nil {
 		return c.conn.Close()
 	}
@@ -79,9 +128,9 @@ func (c *Client) AddAgentCallResponse(ctx context.Context, params ports.AddAgent
 		Value:            params.ResponseValue,
 	}
 
-	_, err := c.gate.AddAgentCallResponse(ctx, req)
+	_, err := c.currentGate().AddAgentCallResponse(ctx, req)
 	if err != nil {
-		return ports.AddAgentCallResponseResult{}, err
+		return ports.AddAgentCallResponseResult{}, gateerr.FromError("AddAgentCallResponse", err)
 	}
 
 	return ports.AddAgentCallResponseResult{}, nil
@@ -117,9 +166,9 @@ func (c *Client) AddScrubListEntries(ctx context.Context, params ports.AddScrubL
 		req.CountryCode = *params.CountryCode // Assuming proto field is string, not wrapper
 	}
 
-	_, err := c.gate.AddScrubListEntries(ctx, req)
+	_, err := c.currentGate().AddScrubListEntries(ctx, req)
 	if err != nil {
-		return ports.AddScrubListEntriesResult{}, err
+		return ports.AddScrubListEntriesResult{}, gateerr.FromError("AddScrubListEntries", err)
 	}
 
 	return ports.AddScrubListEntriesResult{}, nil // Return empty struct for success
@@ -147,9 +196,9 @@ func (c *Client) Dial(ctx context.Context, params ports.DialParams) (ports.DialR
 		req.RecordId = wrapperspb.String(*params.RecordID)
 	}
 
-	resp, err := c.gate.Dial(ctx, req)
+	resp, err := c.currentGate().Dial(ctx, req)
 	if err != nil {
-		return ports.DialResult{}, err
+		return ports.DialResult{}, gateerr.FromError("Dial", err)
 	}
 
 	if resp == nil {
@@ -171,9 +220,9 @@ func (c *Client) GetAgentByID(ctx context.Context, params ports.GetAgentByIDPara
 
 	req := &gatev2pb.GetAgentByIdRequest{UserId: params.UserID}
 
-	resp, err := c.gate.GetAgentById(ctx, req)
+	resp, err := c.currentGate().GetAgentById(ctx, req)
 	if err != nil {
-		return ports.GetAgentByIDResult{}, err
+		return ports.GetAgentByIDResult{}, gateerr.FromError("GetAgentById", err)
 	}
 
 	if resp == nil || resp.GetAgent() == nil {
@@ -198,9 +247,9 @@ func (c *Client) GetAgentByPartnerID(ctx context.Context, params ports.GetAgentB
 		PartnerAgentId: params.PartnerAgentID,
 	}
 
-	resp, err := c.gate.GetAgentByPartnerId(ctx, req)
+	resp, err := c.currentGate().GetAgentByPartnerId(ctx, req)
 	if err != nil {
-		return ports.GetAgentByPartnerIDResult{}, err
+		return ports.GetAgentByPartnerIDResult{}, gateerr.FromError("GetAgentByPartnerId", err)
 	}
 
 	if resp == nil || resp.GetAgent() == nil {
@@ -224,9 +273,9 @@ func (c *Client) GetAgentStatus(ctx context.Context, params ports.GetAgentStatus
 		PartnerAgentId: params.PartnerAgentID,
 	}
 
-	resp, err := c.gate.GetAgentStatus(ctx, req)
+	resp, err := c.currentGate().GetAgentStatus(ctx, req)
 	if err != nil {
-		return ports.GetAgentStatusResult{}, err
+		return ports.GetAgentStatusResult{}, gateerr.FromError("GetAgentStatus", err)
 	}
 
 	if resp == nil {
@@ -249,9 +298,9 @@ func (c *Client) GetAgentStatus(ctx context.Context, params ports.GetAgentStatus
 func (c *Client) GetClientConfiguration(ctx context.Context, params ports.GetClientConfigurationParams) (ports.GetClientConfigurationResult, error) {
 	req := &gatev2pb.GetClientConfigurationRequest{}
 
-	resp, err := c.gate.GetClientConfiguration(ctx, req)
+	resp, err := c.currentGate().GetClientConfiguration(ctx, req)
 	if err != nil {
-		return ports.GetClientConfigurationResult{}, err
+		return ports.GetClientConfigurationResult{}, gateerr.FromError("GetClientConfiguration", err)
 	}
 
 	if resp == nil {
@@ -272,9 +321,9 @@ func (c *Client) GetClientConfiguration(ctx context.Context, params ports.GetCli
 func (c *Client) GetOrganizationInfo(ctx context.Context, params ports.GetOrganizationInfoParams) (ports.GetOrganizationInfoResult, error) {
 	req := &gatev2pb.GetOrganizationInfoRequest{}
 
-	resp, err := c.gate.GetOrganizationInfo(ctx, req)
+	resp, err := c.currentGate().GetOrganizationInfo(ctx, req)
 	if err != nil {
-		return ports.GetOrganizationInfoResult{}, err
+		return ports.GetOrganizationInfoResult{}, gateerr.FromError("GetOrganizationInfo", err)
 	}
 
 	if resp == nil {
@@ -295,9 +344,9 @@ func (c *Client) GetRecordingStatus(ctx context.Context, params ports.GetRecordi
 		PartnerAgentId: params.PartnerAgentID,
 	}
 
-	resp, err := c.gate.GetRecordingStatus(ctx, req)
+	resp, err := c.currentGate().GetRecordingStatus(ctx, req)
 	if err != nil {
-		return ports.GetRecordingStatusResult{}, err
+		return ports.GetRecordingStatusResult{}, gateerr.FromError("GetRecordingStatus", err)
 	}
 
 	if resp == nil {
@@ -319,9 +368,9 @@ func (c *Client) ListAgents(ctx context.Context, params ports.ListAgentsParams)
 	go func() {
 		defer close(resultsChan)
 
-		stream, err := c.gate.ListAgents(ctx, req)
+		stream, err := c.currentGate().ListAgents(ctx, req)
 		if err != nil {
-			resultsChan <- ports.ListAgentsResult{Error: fmt.Errorf("failed to start ListAgents stream: %w", err)}
+			resultsChan <- ports.ListAgentsResult{Error: fmt.Errorf("failed to start ListAgents stream: %w", gateerr.FromError("ListAgents", err))}
 
 			return
 		}
@@ -330,7 +379,7 @@ func (c *Client) ListAgents(ctx context.Context, params ports.ListAgentsParams)
 			resp, err := stream.Recv()
 			if err != nil {
 				if !IsStreamEnd(err) { // Don't send EOF as error
-					resultsChan <- ports.ListAgentsResult{Error: fmt.Errorf("error receiving from ListAgents stream: %w", err)}
+					resultsChan <- ports.ListAgentsResult{Error: fmt.Errorf("error receiving from ListAgents stream: %w", gateerr.FromError("ListAgents", err))}
 				}
 
 				return // End goroutine on EOF or error
@@ -356,9 +405,9 @@ func (c *Client) ListHuntGroupPauseCodes(ctx context.Context, params ports.ListH
 		PartnerAgentId: params.PartnerAgentID,
 	}
 
-	resp, err := c.gate.ListHuntGroupPauseCodes(ctx, req)
+	resp, err := c.currentGate().ListHuntGroupPauseCodes(ctx, req)
 	if err != nil {
-		return ports.ListHuntGroupPauseCodesResult{}, err
+		return ports.ListHuntGroupPauseCodesResult{}, gateerr.FromError("ListHuntGroupPauseCodes", err)
 	}
 
 	if resp == nil {
@@ -385,9 +434,9 @@ func (c *Client) ListHuntGroupPauseCodes(ctx context.Context, params ports.ListH
 func (c *Client) ListScrubLists(ctx context.Context, params ports.ListScrubListsParams) (ports.ListScrubListsResult, error) {
 	req := &gatev2pb.ListScrubListsRequest{}
 
-	resp, err := c.gate.ListScrubLists(ctx, req)
+	resp, err := c.currentGate().ListScrubLists(ctx, req)
 	if err != nil {
-		return ports.ListScrubListsResult{}, err
+		return ports.ListScrubListsResult{}, gateerr.FromError("ListScrubLists", err)
 	}
 
 	if resp == nil {
@@ -419,9 +468,9 @@ func (c *Client) Log(ctx context.Context, params ports.LogParams) (ports.LogResu
 		Payload: logMessage,
 	}
 
-	_, err := c.gate.Log(ctx, req)
+	_, err := c.currentGate().Log(ctx, req)
 	if err != nil {
-		return ports.LogResult{}, err
+		return ports.LogResult{}, gateerr.FromError("Log", err)
 	}
 
 	return ports.LogResult{}, nil
@@ -431,9 +480,9 @@ func (c *Client) Log(ctx context.Context, params ports.LogParams) (ports.LogResu
 func (c *Client) PollEvents(ctx context.Context, params ports.PollEventsParams) (ports.PollEventsResult, error) {
 	req := &gatev2pb.PollEventsRequest{}
 
-	resp, err := c.gate.PollEvents(ctx, req)
+	resp, err := c.currentGate().PollEvents(ctx, req)
 	if err != nil {
-		return ports.PollEventsResult{}, err
+		return ports.PollEventsResult{}, gateerr.FromError("PollEvents", err)
 	}
 
 	if resp == nil {
@@ -445,6 +494,7 @@ func (c *Client) PollEvents(ctx context.Context, params ports.PollEventsParams)
 		e := ports.Event{}
 
 		if telephony := event.GetTelephonyResult(); telephony != nil {
+			e.Type = ports.EventTypeTelephonyResult
 			e.Telephony = &ports.ExileTelephonyResult{
 				CallSid:        telephony.GetCallSid(),
 				CallType:       telephony.GetCallType(),
@@ -467,6 +517,7 @@ func (c *Client) PollEvents(ctx context.Context, params ports.PollEventsParams)
 		}
 
 		if agentCall := event.GetAgentCall(); agentCall != nil {
+			e.Type = ports.EventTypeAgentCall
 			e.AgentCall = &ports.ExileAgentCall{
 				AgentCallSid:             agentCall.GetAgentCallSid(),
 				CallSid:                  agentCall.GetCallSid(),
@@ -492,6 +543,7 @@ func (c *Client) PollEvents(ctx context.Context, params ports.PollEventsParams)
 		}
 
 		if agentResponse := event.GetAgentResponse(); agentResponse != nil {
+			e.Type = ports.EventTypeAgentResponse
 			e.AgentResponse = &ports.ExileAgentResponse{
 				AgentCallResponseSid: agentResponse.GetAgentCallResponseSid(),
 				CallSid:              agentResponse.GetCallSid(),
@@ -509,6 +561,11 @@ func (c *Client) PollEvents(ctx context.Context, params ports.PollEventsParams)
 			}
 		}
 
+		if transfer := event.GetTransferInstance(); transfer != nil {
+			e.Type = ports.EventTypeTransferInstance
+			e.TransferInstance = mapProtoTransferInstance(transfer)
+		}
+
 		events = append(events, e)
 	}
 
@@ -525,9 +582,9 @@ func (c *Client) PutCallOnSimpleHold(ctx context.Context, params ports.PutCallOn
 		PartnerAgentId: params.PartnerAgentID,
 	}
 
-	_, err := c.gate.PutCallOnSimpleHold(ctx, req)
+	_, err := c.currentGate().PutCallOnSimpleHold(ctx, req)
 	if err != nil {
-		return ports.PutCallOnSimpleHoldResult{}, err
+		return ports.PutCallOnSimpleHoldResult{}, gateerr.FromError("PutCallOnSimpleHold", err)
 	}
 
 	return ports.PutCallOnSimpleHoldResult{}, nil
@@ -540,9 +597,9 @@ func (c *Client) RemoveScrubListEntries(ctx context.Context, params ports.Remove
 		Entries:     params.EntryIDs,
 	}
 
-	_, err := c.gate.RemoveScrubListEntries(ctx, req)
+	_, err := c.currentGate().RemoveScrubListEntries(ctx, req)
 	if err != nil {
-		return ports.RemoveScrubListEntriesResult{}, err
+		return ports.RemoveScrubListEntriesResult{}, gateerr.FromError("RemoveScrubListEntries", err)
 	}
 
 	return ports.RemoveScrubListEntriesResult{}, nil
@@ -550,11 +607,13 @@ func (c *Client) RemoveScrubListEntries(ctx context.Context, params ports.Remove
 
 // RotateCertificate rotates the client certificate.
 func (c *Client) RotateCertificate(ctx context.Context, params ports.RotateCertificateParams) (ports.RotateCertificateResult, error) {
-	req := &gatev2pb.RotateCertificateRequest{}
+	req := &gatev2pb.RotateCertificateRequest{
+		CertificateHash: params.CertificateHash,
+	}
 
-	resp, err := c.gate.RotateCertificate(ctx, req)
+	resp, err := c.currentGate().RotateCertificate(ctx, req)
 	if err != nil {
-		return ports.RotateCertificateResult{}, err
+		return ports.RotateCertificateResult{}, gateerr.FromError("RotateCertificate", err)
 	}
 
 	if resp == nil {
@@ -576,9 +635,9 @@ func (c *Client) StartCallRecording(ctx context.Context, params ports.StartCallR
 		PartnerAgentId: params.PartnerAgentID,
 	}
 
-	_, err := c.gate.StartCallRecording(ctx, req)
+	_, err := c.currentGate().StartCallRecording(ctx, req)
 	if err != nil {
-		return ports.StartCallRecordingResult{}, err
+		return ports.StartCallRecordingResult{}, gateerr.FromError("StartCallRecording", err)
 	}
 
 	return ports.StartCallRecordingResult{}, nil
@@ -590,57 +649,156 @@ func (c *Client) StopCallRecording(ctx context.Context, params ports.StopCallRec
 		PartnerAgentId: params.PartnerAgentID,
 	}
 
-	_, err := c.gate.StopCallRecording(ctx, req)
+	_, err := c.currentGate().StopCallRecording(ctx, req)
 	if err != nil {
-		return ports.StopCallRecordingResult{}, err
+		return ports.StopCallRecordingResult{}, gateerr.FromError("StopCallRecording", err)
 	}
 
 	return ports.StopCallRecordingResult{}, nil
 }
 
 // StreamJobs returns a channel that emits jobs from the Operator platform.
+// Every job carries a correlation ID, and its trace when tracing is on. The
+// stream follows Reload onto the new connection: jobs keep arriving on the old
+// stream until the gate has accepted the new one, and only then is the old
+// stream canceled.
 func (c *Client) StreamJobs(ctx context.Context, params ports.StreamJobsParams) <-chan ports.StreamJobsResult {
 	resultChan := make(chan ports.StreamJobsResult, 1)
 
 	go func() {
 		defer close(resultChan)
 
-		req := &gatev2pb.StreamJobsRequest{}
+		c.streamJobs(ctx, resultChan)
+	}()
 
-		stream, err := c.gate.StreamJobs(ctx, req)
-		if err != nil {
-			resultChan <- ports.StreamJobsResult{Error: err}
-			return
+	return resultChan
+}
+
+// jobStream is one StreamJobs stream, whose jobs a reader goroutine forwards.
+type jobStream struct {
+	cancel context.CancelFunc
+	// ready is closed once the gate has sent the stream's headers, that is,
+	// accepted it.
+	ready chan struct{}
+	// ended receives the reader's error, or nil when the gate ended the stream.
+	ended chan error
+}
+
+// stop cancels the stream and waits for its reader, so that it no longer
+// sends on the results channel.
+func (s *jobStream) stop() {
+	s.cancel()
+	<-s.ended
+}
+
+// streamJobs forwards the jobs of the current connection's stream to results
+// until it ends, opening a new stream after every reload.
+func (c *Client) streamJobs(ctx context.Context, results chan<- ports.StreamJobsResult) {
+	current, reloaded, err := c.openJobStream(ctx, results)
+	if err != nil {
+		results <- ports.StreamJobsResult{Error: gateerr.FromError("StreamJobs", err)}
+		return
+	}
+
+	// Streams on replaced connections, kept until the current one is ready.
+	var replaced []*jobStream
+
+	for {
+		var ready <-chan struct{}
+		if len(replaced) > 0 {
+			ready = current.ready
 		}
 
-		for {
-			resp, err := stream.Recv()
-			if errors.Is(err, io.EOF) {
-				break
+		select {
+		case err := <-current.ended:
+			for _, s := range replaced {
+				s.stop()
 			}
 
+			current.cancel()
+
 			if err != nil {
-				resultChan <- ports.StreamJobsResult{Error: err}
-				return
+				results <- ports.StreamJobsResult{Error: gateerr.FromError("StreamJobs", err)}
 			}
 
-			// Process the job in the response
-			jobData := make(map[string]interface{})
-			// Convert job data to map - this would need to be implemented based on the actual job structure
-			jobData["job_id"] = resp.GetJobId()
-			jobData["type"] = "" // Type not available in this response
-
-			resultChan <- ports.StreamJobsResult{
-				Job: &ports.Job{
-					JobID: resp.GetJobId(),
-					Type:  "", // Type not available in this response
-					Data:  jobData,
-				},
+			return
+		case <-ready:
+			for _, s := range replaced {
+				s.stop()
 			}
+
+			replaced = nil
+		case <-reloaded:
+			next, nextReloaded, err := c.openJobStream(ctx, results)
+			reloaded = nextReloaded
+
+			if err != nil {
+				c.log.Warn().Err(err).Msg("Failed to reopen StreamJobs after a reload, keeping the stream on the replaced connection")
+				continue
+			}
+
+			replaced = append(replaced, current)
+			current = next
+		}
+	}
+}
+
+// openJobStream opens a StreamJobs stream on the current connection and
+// starts its reader. It also returns the channel the next reload closes.
+func (c *Client) openJobStream(ctx context.Context, results chan<- ports.StreamJobsResult) (*jobStream, <-chan struct{}, error) {
+	gate, reloaded := c.streamGate()
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	stream, err := gate.StreamJobs(ctx, &gatev2pb.StreamJobsRequest{})
+	if err != nil {
+		cancel()
+
+		return nil, reloaded, err
+	}
+
+	s := &jobStream{cancel: cancel, ready: make(chan struct{}), ended: make(chan error, 1)}
+
+	go func() {
+		if _, err := stream.Header(); err == nil {
+			close(s.ready)
 		}
 	}()
 
-	return resultChan
+	go func() {
+		s.ended <- c.readJobs(stream, results)
+	}()
+
+	return s, reloaded, nil
+}
+
+// readJobs forwards the jobs of stream to results until it ends.
+func (c *Client) readJobs(stream grpc.ServerStreamingClient[gatev2pb.StreamJobsResponse], results chan<- ports.StreamJobsResult) error {
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// Process the job in the response
+		jobData := make(map[string]interface{})
+		// Convert job data to map - this would need to be implemented based on the actual job structure
+		jobData["job_id"] = resp.GetJobId()
+		jobData["type"] = "" // Type not available in this response
+
+		job := &ports.Job{
+			JobID: resp.GetJobId(),
+			Type:  "", // Type not available in this response
+			Data:  jobData,
+		}
+		c.jobs.start(job)
+
+		results <- ports.StreamJobsResult{Job: job}
+	}
 }
 
 // SubmitJobResults submits results for jobs received via StreamJobs.
@@ -654,9 +812,15 @@ func (c *Client) SubmitJobResults(ctx context.Context, params ports.SubmitJobRes
 	// This would need to be implemented based on the actual job result structure
 	// For now, we'll leave it empty
 
-	_, err := c.gate.SubmitJobResults(ctx, req)
+	_, err := c.currentGate().SubmitJobResults(c.jobs.context(ctx, params.JobID), req)
+	c.jobs.submitted(params.JobID, params.EndOfTransmission, err)
+
 	if err != nil {
-		return ports.SubmitJobResultsResult{}, err
+		if c.metrics != nil {
+			c.metrics.JobResultSubmitFailed()
+		}
+
+		return ports.SubmitJobResultsResult{}, gateerr.FromError("SubmitJobResults", err)
 	}
 
 	return ports.SubmitJobResultsResult{}, nil
@@ -668,9 +832,9 @@ func (c *Client) TakeCallOffSimpleHold(ctx context.Context, params ports.TakeCal
 		PartnerAgentId: params.PartnerAgentID,
 	}
 
-	_, err := c.gate.TakeCallOffSimpleHold(ctx, req)
+	_, err := c.currentGate().TakeCallOffSimpleHold(ctx, req)
 	if err != nil {
-		return ports.TakeCallOffSimpleHoldResult{}, err
+		return ports.TakeCallOffSimpleHoldResult{}, gateerr.FromError("TakeCallOffSimpleHold", err)
 	}
 
 	return ports.TakeCallOffSimpleHoldResult{}, nil
@@ -691,9 +855,9 @@ func (c *Client) UpdateAgentStatus(ctx context.Context, params ports.UpdateAgent
 		req.Reason = *params.Reason
 	}
 
-	_, err := c.gate.UpdateAgentStatus(ctx, req)
+	_, err := c.currentGate().UpdateAgentStatus(ctx, req)
 	if err != nil {
-		return ports.UpdateAgentStatusResult{}, err
+		return ports.UpdateAgentStatusResult{}, gateerr.FromError("UpdateAgentStatus", err)
 	}
 
 	return ports.UpdateAgentStatusResult{}, nil
@@ -710,9 +874,9 @@ func (c *Client) UpdateScrubListEntry(ctx context.Context, params ports.UpdateSc
 		req.Notes = wrapperspb.String(*params.Notes)
 	}
 
-	_, err := c.gate.UpdateScrubListEntry(ctx, req)
+	_, err := c.currentGate().UpdateScrubListEntry(ctx, req)
 	if err != nil {
-		return ports.UpdateScrubListEntryResult{}, err
+		return ports.UpdateScrubListEntryResult{}, gateerr.FromError("UpdateScrubListEntry", err)
 	}
 
 	return ports.UpdateScrubListEntryResult{}, nil
@@ -728,9 +892,9 @@ func (c *Client) UpsertAgent(ctx context.Context, params ports.UpsertAgentParams
 		Password:       "", // Password not available in params
 	}
 
-	_, err := c.gate.UpsertAgent(ctx, req)
+	_, err := c.currentGate().UpsertAgent(ctx, req)
 	if err != nil {
-		return ports.UpsertAgentResult{}, err
+		return ports.UpsertAgentResult{}, gateerr.FromError("UpsertAgent", err)
 	}
 
 	return ports.UpsertAgentResult{}, nil
@@ -742,9 +906,9 @@ func (c *Client) UpsertAgent(ctx context.Context, params ports.UpsertAgentParams
 func (c *Client) ListNCLRulesetNames(ctx context.Context, params ports.ListNCLRulesetNamesParams) (ports.ListNCLRulesetNamesResult, error) {
 	req := &gatev2pb.ListNCLRulesetNamesRequest{}
 
-	resp, err := c.gate.ListNCLRulesetNames(ctx, req)
+	resp, err := c.currentGate().ListNCLRulesetNames(ctx, req)
 	if err != nil {
-		return ports.ListNCLRulesetNamesResult{}, err
+		return ports.ListNCLRulesetNamesResult{}, gateerr.FromError("ListNCLRulesetNames", err)
 	}
 
 	return ports.ListNCLRulesetNamesResult{
@@ -756,9 +920,9 @@ func (c *Client) ListNCLRulesetNames(ctx context.Context, params ports.ListNCLRu
 func (c *Client) ListSkills(ctx context.Context, params ports.ListSkillsParams) (ports.ListSkillsResult, error) {
 	req := &gatev2pb.ListSkillsRequest{}
 
-	resp, err := c.gate.ListSkills(ctx, req)
+	resp, err := c.currentGate().ListSkills(ctx, req)
 	if err != nil {
-		return ports.ListSkillsResult{}, err
+		return ports.ListSkillsResult{}, gateerr.FromError("ListSkills", err)
 	}
 
 	skills := make([]ports.Skill, 0, len(resp.GetSkills()))
@@ -781,9 +945,9 @@ func (c *Client) ListAgentSkills(ctx context.Context, params ports.ListAgentSkil
 		PartnerAgentId: params.PartnerAgentID,
 	}
 
-	resp, err := c.gate.ListAgentSkills(ctx, req)
+	resp, err := c.currentGate().ListAgentSkills(ctx, req)
 	if err != nil {
-		return ports.ListAgentSkillsResult{}, err
+		return ports.ListAgentSkillsResult{}, gateerr.FromError("ListAgentSkills", err)
 	}
 
 	skills := make([]ports.Skill, 0, len(resp.GetSkills()))
@@ -807,9 +971,9 @@ func (c *Client) AssignAgentSkill(ctx context.Context, params ports.AssignAgentS
 		SkillId:        params.SkillID,
 	}
 
-	_, err := c.gate.AssignAgentSkill(ctx, req)
+	_, err := c.currentGate().AssignAgentSkill(ctx, req)
 	if err != nil {
-		return ports.AssignAgentSkillResult{}, err
+		return ports.AssignAgentSkillResult{}, gateerr.FromError("AssignAgentSkill", err)
 	}
 
 	return ports.AssignAgentSkillResult{}, nil
@@ -822,9 +986,9 @@ func (c *Client) UnassignAgentSkill(ctx context.Context, params ports.UnassignAg
 		SkillId:        params.SkillID,
 	}
 
-	_, err := c.gate.UnassignAgentSkill(ctx, req)
+	_, err := c.currentGate().UnassignAgentSkill(ctx, req)
 	if err != nil {
-		return ports.UnassignAgentSkillResult{}, err
+		return ports.UnassignAgentSkillResult{}, gateerr.FromError("UnassignAgentSkill", err)
 	}
 
 	return ports.UnassignAgentSkillResult{}, nil
@@ -876,9 +1040,9 @@ func (c *Client) SearchVoiceRecordings(ctx context.Context, params ports.SearchV
 
 		req.SearchOptions = searchOptions
 
-		stream, err := c.gate.SearchVoiceRecordings(ctx, req)
+		stream, err := c.currentGate().SearchVoiceRecordings(ctx, req)
 		if err != nil {
-			resultChan <- ports.SearchVoiceRecordingsResult{Error: err}
+			resultChan <- ports.SearchVoiceRecordingsResult{Error: gateerr.FromError("SearchVoiceRecordings", err)}
 
 			return
 		}
@@ -890,7 +1054,7 @@ func (c *Client) SearchVoiceRecordings(ctx context.Context, params ports.SearchV
 			}
 
 			if err != nil {
-				resultChan <- ports.SearchVoiceRecordingsResult{Error: err}
+				resultChan <- ports.SearchVoiceRecordingsResult{Error: gateerr.FromError("SearchVoiceRecordings", err)}
 
 				return
 			}
@@ -925,9 +1089,9 @@ func (c *Client) GetVoiceRecordingDownloadLink(ctx context.Context, params ports
 		RecordingId: params.RecordingSid,
 	}
 
-	resp, err := c.gate.GetVoiceRecordingDownloadLink(ctx, req)
+	resp, err := c.currentGate().GetVoiceRecordingDownloadLink(ctx, req)
 	if err != nil {
-		return ports.GetVoiceRecordingDownloadLinkResult{}, err
+		return ports.GetVoiceRecordingDownloadLinkResult{}, gateerr.FromError("GetVoiceRecordingDownloadLink", err)
 	}
 
 	return ports.GetVoiceRecordingDownloadLinkResult{
@@ -940,9 +1104,9 @@ func (c *Client) GetVoiceRecordingDownloadLink(ctx context.Context, params ports
 func (c *Client) ListSearchableRecordingFields(ctx context.Context, params ports.ListSearchableRecordingFieldsParams) (ports.ListSearchableRecordingFieldsResult, error) {
 	req := &gatev2pb.ListSearchableRecordingFieldsRequest{}
 
-	resp, err := c.gate.ListSearchableRecordingFields(ctx, req)
+	resp, err := c.currentGate().ListSearchableRecordingFields(ctx, req)
 	if err != nil {
-		return ports.ListSearchableRecordingFieldsResult{}, err
+		return ports.ListSearchableRecordingFieldsResult{}, gateerr.FromError("ListSearchableRecordingFields", err)
 	}
 
 	fields := make([]ports.SearchableField, 0, len(resp.GetFields()))
@@ -990,9 +1154,9 @@ func (c *Client) Transfer(ctx context.Context, params ports.TransferParams) (por
 		}
 	}
 
-	_, err := c.gate.Transfer(ctx, req)
+	_, err := c.currentGate().Transfer(ctx, req)
 	if err != nil {
-		return ports.TransferResult{}, err
+		return ports.TransferResult{}, gateerr.FromError("Transfer", err)
 	}
 
 	return ports.TransferResult{}, nil
@@ -1016,32 +1180,75 @@ func mapProtoAgentToAgent(pbAgent *gatev2pb.Agent) *ports.Agent {
 	}
 }
 
-// setupConnection configures and establishes the gRPC connection.
-func setupConnection(cfg *saticonfig.Config) (*grpc.ClientConn, error) {
-	cert, err := tls.X509KeyPair([]byte(cfg.Certificate), []byte(cfg.PrivateKey))
-	if err != nil {
-		return nil, fmt.Errorf("failed to load client cert: %w", err)
-	}
+// mapProtoTransferInstance flattens a proto transfer instance into the ports type.
+func mapProtoTransferInstance(pb *gatev2pb.ExileTransferInstance) *ports.ExileTransferInstance {
+	transfer := &ports.ExileTransferInstance{
+		ClientSid:                    pb.GetClientSid(),
+		OrgID:                        pb.GetOrgId(),
+		TransferInstanceID:           pb.GetTransferInstanceId(),
+		CreateTime:                   formatOptionalTimestamp(pb.GetCreateTime()),
+		UpdateTime:                   formatOptionalTimestamp(pb.GetUpdateTime()),
+		TransferPendingStartTime:     formatOptionalTimestamp(pb.GetTransferPendingStartTime()),
+		TransferStartTime:            formatOptionalTimestamp(pb.GetTransferStartTime()),
+		TransferEndTime:              formatOptionalTimestamp(pb.GetTransferEndTime()),
+		TransferExternalEndTime:      formatOptionalTimestamp(pb.GetTransferExternalEndTime()),
+		TransferResult:               pb.GetTransferResult().String(),
+		TransferType:                 pb.GetTransferType().String(),
+		StartAsPending:               pb.GetStartAsPending(),
+		StartedAsConference:          pb.GetStartedAsConference(),
+		DurationMicroseconds:         pb.GetDurationMicroseconds(),
+		ExternalDurationMicroseconds: pb.GetExternalDurationMicroseconds(),
+		PendingDurationMicroseconds:  pb.GetPendingDurationMicroseconds(),
+	}
+
+	if source := pb.GetSource().GetCall(); source != nil {
+		transfer.SourceCallSid = source.GetCallSid()
+		transfer.SourceCallType = source.GetCallType()
+		transfer.SourcePartnerAgentID = source.GetPartnerAgentId()
+		transfer.SourceUserID = source.GetUserId()
+		transfer.SourceConversationID = source.GetConversationId()
+		transfer.SourceSessionSid = source.GetSessionSid()
+		transfer.SourceAgentCallSid = source.GetAgentCallSid()
+	}
+
+	destination := pb.GetDestination()
 
-	caCertPool := x509.NewCertPool()
-	if ok := caCertPool.AppendCertsFromPEM([]byte(cfg.CACertificate)); !ok {
-		return nil, ErrCAAppendFailed
+	switch {
+	case destination.GetCall() != nil:
+		transfer.DestinationType = "call"
+		transfer.DestinationCallSid = destination.GetCall().GetCallSid()
+		transfer.DestinationCallType = destination.GetCall().GetCallType()
+		transfer.DestinationConversationID = destination.GetCall().GetConversationId()
+	case destination.GetAgent() != nil:
+		transfer.DestinationType = "agent"
+		transfer.DestinationSessionSid = destination.GetAgent().GetSessionSid()
+		transfer.DestinationPartnerAgentID = destination.GetAgent().GetPartnerAgentId()
+		transfer.DestinationUserID = destination.GetAgent().GetUserId()
+	case destination.GetPhone() != nil:
+		transfer.DestinationType = "phone"
+		transfer.DestinationPhoneNumber = destination.GetPhone().GetPhoneNumber()
+	case len(destination.GetSkills()) > 0:
+		transfer.DestinationType = "skills"
+	}
+
+	for skill, enabled := range destination.GetSkills() {
+		if enabled {
+			transfer.DestinationSkills = append(transfer.DestinationSkills, skill)
+		}
 	}
 
-	creds := credentials.NewTLS(&tls.Config{
-		Certificates: []tls.Certificate{cert},
-		RootCAs:      caCertPool,
-		MinVersion:   tls.VersionTLS12, // Set minimum TLS version to 1.2
-	})
+	slices.Sort(transfer.DestinationSkills)
 
-	endpoint := parseAPIEndpoint(cfg.APIEndpoint)
+	return transfer
+}
 
-	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(creds))
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to API: %w", err)
+// formatOptionalTimestamp formats a timestamp as RFC3339, or returns an empty string when it is unset.
+func formatOptionalTimestamp(ts *timestamppb.Timestamp) string {
+	if ts == nil {
+		return ""
 	}
 
-	return conn, nil
+	return ts.AsTime().Format(time.RFC3339)
 }
 
 // parseAPIEndpoint ensures the endpoint is in a format grpc.NewClient understands.
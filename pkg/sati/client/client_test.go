@@ -9,8 +9,11 @@ import (
 
 	gatev2 "github.com/tcncloud/sati-go/internal/genproto/tcnapi/exile/gate/v2"
 	"github.com/tcncloud/sati-go/pkg/ports"
+	"github.com/tcncloud/sati-go/pkg/sati/gateerr"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // --- Mocks ---
@@ -748,6 +751,40 @@ func TestClient_ConfigurationMethods(t *testing.T) {
 		// Just verify the method was called successfully
 	})
 
+	t.Run("PollEventsMapsTransferInstance", func(t *testing.T) {
+		mockService.pollEventsResp = &gatev2.PollEventsResponse{Events: []*gatev2.Event{{
+			Entity: &gatev2.Event_TransferInstance{TransferInstance: &gatev2.ExileTransferInstance{
+				TransferInstanceId: "ti-1",
+				Source: &gatev2.ExileTransferInstance_Source{
+					Call: &gatev2.ExileTransferInstance_Source_SourceCall{CallSid: 42, PartnerAgentId: "agent-a"},
+				},
+				Destination: &gatev2.ExileTransferInstance_Destination{
+					Entity: &gatev2.ExileTransferInstance_Destination_Agent{
+						Agent: &gatev2.ExileTransferInstance_DestinationAgent{PartnerAgentId: "agent-b"},
+					},
+				},
+				TransferType: gatev2.ExileTransferInstance_WARM_AGENT,
+			}},
+		}}}
+		mockService.pollEventsErr = nil
+
+		resp, err := client.PollEvents(ctx, ports.PollEventsParams{})
+		if err != nil {
+			t.Fatalf("PollEvents returned error: %v", err)
+		}
+
+		if len(resp.Events) != 1 || resp.Events[0].Type != ports.EventTypeTransferInstance {
+			t.Fatalf("Expected one transfer instance event, got %+v", resp.Events)
+		}
+
+		transfer := resp.Events[0].TransferInstance
+		if transfer.TransferInstanceID != "ti-1" || transfer.SourceCallSid != 42 ||
+			transfer.DestinationType != "agent" || transfer.DestinationPartnerAgentID != "agent-b" ||
+			transfer.TransferType != "WARM_AGENT" || transfer.TransferStartTime != "" {
+			t.Errorf("Unexpected transfer instance mapping: %+v", transfer)
+		}
+	})
+
 	// --- Test GetOrganizationInfo ---
 	t.Run("GetOrganizationInfoSuccess", func(t *testing.T) {
 		mockService.getOrganizationInfoCalled = false // Reset
@@ -1816,6 +1853,53 @@ func TestClient_StreamJobs(t *testing.T) {
 	// Add more tests for other methods...
 }
 
+func TestClient_ClassifiesGateErrors(t *testing.T) {
+	client, mockService := setupTestClient()
+	ctx := context.Background()
+
+	t.Run("GetAgentById", func(t *testing.T) {
+		mockService.getAgentByIDResp = nil
+		mockService.getAgentByIDErr = status.Error(codes.NotFound, "no such agent")
+
+		_, err := client.GetAgentByID(ctx, ports.GetAgentByIDParams{UserID: "unknown"})
+		if !errors.Is(err, gateerr.ErrNotFound) {
+			t.Errorf("Expected gateerr.ErrNotFound, got %v", err)
+		}
+
+		var gateErr *gateerr.Error
+		if !errors.As(err, &gateErr) || gateErr.Method != "GetAgentById" || gateErr.Message != "no such agent" {
+			t.Errorf("Expected the gate's message for GetAgentById, got %#v", err)
+		}
+	})
+
+	t.Run("NilAgent", func(t *testing.T) {
+		mockService.getAgentByIDResp = &gatev2.GetAgentByIdResponse{}
+		mockService.getAgentByIDErr = nil
+
+		_, err := client.GetAgentByID(ctx, ports.GetAgentByIDParams{UserID: "unknown"})
+		if !errors.Is(err, ErrAgentNotFound) || !errors.Is(err, gateerr.ErrNotFound) {
+			t.Errorf("Expected ErrAgentNotFound to be a gateerr.ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("ArgumentCheck", func(t *testing.T) {
+		_, err := client.Dial(ctx, ports.DialParams{})
+		if !errors.Is(err, ErrDialParamsRequired) || !errors.Is(err, gateerr.ErrInvalidArgument) {
+			t.Errorf("Expected ErrDialParamsRequired to be a gateerr.ErrInvalidArgument, got %v", err)
+		}
+	})
+
+	t.Run("StreamJobs", func(t *testing.T) {
+		mockService.streamJobsStream = nil
+		mockService.streamJobsErr = status.Error(codes.Unauthenticated, "tls: certificate revoked")
+
+		result := <-client.StreamJobs(ctx, ports.StreamJobsParams{})
+		if !errors.Is(result.Error, gateerr.ErrCertificateRejected) {
+			t.Errorf("Expected gateerr.ErrCertificateRejected, got %v", result.Error)
+		}
+	})
+}
+
 func TestIsStreamEnd(t *testing.T) {
 	tests := []struct {
 		name string
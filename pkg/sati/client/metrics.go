@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// rpcName returns the bare method name of a full gRPC method such as
+// "/tcnapi.exile.gate.v2.GateService/PollEvents".
+func rpcName(fullMethod string) string {
+	return path.Base(fullMethod)
+}
+
+func unaryMetricsInterceptor(metrics ports.Metrics) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		var p peer.Peer
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(&p))...)
+		code := status.Code(err).String()
+		metrics.RPCCompleted(rpcName(method), code, time.Since(start))
+		reportEndpoint(metrics, rpcName(method), &p, code)
+
+		return err
+	}
+}
+
+func streamMetricsInterceptor(metrics ports.Metrics) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		p := &peer.Peer{}
+		start := time.Now()
+
+		stream, err := streamer(ctx, desc, cc, method, append(opts, grpc.Peer(p))...)
+		if err != nil {
+			code := status.Code(err).String()
+			metrics.RPCCompleted(rpcName(method), code, time.Since(start))
+			reportEndpoint(metrics, rpcName(method), p, code)
+
+			return nil, err
+		}
+
+		return &measuredStream{ClientStream: stream, metrics: metrics, method: rpcName(method), peer: p, start: start}, nil
+	}
+}
+
+// measuredStream reports a streaming call once it ends, which is when RecvMsg
+// first returns an error; io.EOF is a successful end. gRPC fills in peer by
+// then.
+type measuredStream struct {
+	grpc.ClientStream
+
+	metrics ports.Metrics
+	method  string
+	peer    *peer.Peer
+	start   time.Time
+	once    sync.Once
+}
+
+func (s *measuredStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.once.Do(func() {
+			code := status.Code(err)
+			if errors.Is(err, io.EOF) {
+				code = codes.OK
+			}
+
+			s.metrics.RPCCompleted(s.method, code.String(), time.Since(s.start))
+			reportEndpoint(s.metrics, s.method, s.peer, code.String())
+		})
+	}
+
+	return err
+}
+
+// reportEndpoint reports the gate address p that answered a call, when the
+// call reached one.
+func reportEndpoint(metrics ports.Metrics, method string, p *peer.Peer, code string) {
+	if p == nil || p.Addr == nil {
+		return
+	}
+
+	metrics.RPCServed(method, p.Addr.String(), code)
+}
+
+// reportCertificateExpiry reports when the leaf client certificate expires.
+func reportCertificateExpiry(metrics ports.Metrics, chain [][]byte) {
+	if len(chain) == 0 {
+		return
+	}
+
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return
+	}
+
+	metrics.CertificateExpiry(leaf.NotAfter)
+}
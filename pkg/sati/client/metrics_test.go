@@ -0,0 +1,213 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/tcncloud/sati-go/pkg/ports"
+	saticonfig "github.com/tcncloud/sati-go/pkg/sati/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recordingMetrics remembers the measurements reported to it.
+type recordingMetrics struct {
+	ports.NopMetrics
+
+	rpcs         []string
+	submitFailed int
+	certNotAfter time.Time
+}
+
+func (m *recordingMetrics) RPCCompleted(method, code string, _ time.Duration) {
+	m.rpcs = append(m.rpcs, method+" "+code)
+}
+
+func (m *recordingMetrics) JobResultSubmitFailed() {
+	m.submitFailed++
+}
+
+func (m *recordingMetrics) CertificateExpiry(notAfter time.Time) {
+	m.certNotAfter = notAfter
+}
+
+// fakeClientStream returns the queued errors from RecvMsg.
+type fakeClientStream struct {
+	grpc.ClientStream
+
+	errs []error
+}
+
+func (s *fakeClientStream) RecvMsg(any) error {
+	err := s.errs[0]
+	s.errs = s.errs[1:]
+
+	return err
+}
+
+const testMethod = "/tcnapi.exile.gate.v2.GateService/PollEvents"
+
+func TestUnaryMetricsInterceptor(t *testing.T) {
+	metrics := &recordingMetrics{}
+	interceptor := unaryMetricsInterceptor(metrics)
+
+	ok := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error { return nil }
+	denied := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		return status.Error(codes.PermissionDenied, "denied")
+	}
+
+	_ = interceptor(context.Background(), testMethod, nil, nil, nil, ok)
+
+	if err := interceptor(context.Background(), testMethod, nil, nil, nil, denied); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected the invoker's error to be returned, got %v", err)
+	}
+
+	want := []string{"PollEvents OK", "PollEvents PermissionDenied"}
+	if len(metrics.rpcs) != len(want) || metrics.rpcs[0] != want[0] || metrics.rpcs[1] != want[1] {
+		t.Errorf("Expected %v, got %v", want, metrics.rpcs)
+	}
+}
+
+func TestStreamMetricsInterceptor(t *testing.T) {
+	tests := []struct {
+		name string
+		errs []error
+		want string
+	}{
+		{"end of stream", []error{nil, io.EOF, io.EOF}, "StreamJobs OK"},
+		{"failure", []error{status.Error(codes.Unavailable, "gone")}, "StreamJobs Unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &recordingMetrics{}
+			interceptor := streamMetricsInterceptor(metrics)
+
+			streamer := func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+				return &fakeClientStream{errs: tt.errs}, nil
+			}
+
+			stream, err := interceptor(context.Background(), &grpc.StreamDesc{}, nil, "/tcnapi.exile.gate.v2.GateService/StreamJobs", streamer)
+			if err != nil {
+				t.Fatalf("Interceptor failed: %v", err)
+			}
+
+			for range tt.errs {
+				_ = stream.RecvMsg(nil)
+			}
+
+			if len(metrics.rpcs) != 1 || metrics.rpcs[0] != tt.want {
+				t.Errorf("Expected [%s], got %v", tt.want, metrics.rpcs)
+			}
+		})
+	}
+}
+
+func TestClient_SubmitJobResultsFailureMetric(t *testing.T) {
+	metrics := &recordingMetrics{}
+	mockService := &mockGateServiceClient{submitJobResultsErr: errors.New("unavailable")}
+	client := &Client{gate: mockService, metrics: metrics}
+
+	if _, err := client.SubmitJobResults(context.Background(), ports.SubmitJobResultsParams{JobID: "job1"}); err == nil {
+		t.Fatal("Expected SubmitJobResults to fail")
+	}
+
+	if metrics.submitFailed != 1 {
+		t.Errorf("Expected 1 submit failure, got %d", metrics.submitFailed)
+	}
+}
+
+func TestNewClient_WithMetrics(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sati-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	// Nothing listens on port 1, so the call fails without reaching a gate.
+	cfg := &saticonfig.Config{
+		APIEndpoint:   "127.0.0.1:1",
+		CACertificate: certPEM,
+		Certificate:   certPEM,
+		PrivateKey:    string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+	}
+
+	metrics := &recordingMetrics{}
+
+	client, err := NewClient(cfg, WithMetrics(metrics), WithRetry(RetryConfig{}))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.GetClientConfiguration(ctx, ports.GetClientConfigurationParams{}); err == nil {
+		t.Fatal("Expected the call to fail")
+	}
+
+	if len(metrics.rpcs) != 1 || metrics.rpcs[0] != "GetClientConfiguration Unavailable" {
+		t.Errorf("Expected [GetClientConfiguration Unavailable], got %v", metrics.rpcs)
+	}
+
+	if metrics.certNotAfter.IsZero() {
+		t.Error("Expected the certificate expiry to be reported")
+	}
+}
+
+func TestReportCertificateExpiry(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	notAfter := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second).UTC()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sati-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	metrics := &recordingMetrics{}
+	reportCertificateExpiry(metrics, [][]byte{der})
+
+	if !metrics.certNotAfter.Equal(notAfter) {
+		t.Errorf("Expected expiry %v, got %v", notAfter, metrics.certNotAfter)
+	}
+}
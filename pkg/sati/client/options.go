@@ -0,0 +1,104 @@
+package client
+
+import (
+	"os"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	saticonfig "github.com/tcncloud/sati-go/pkg/sati/config"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// Option configures optional Client behavior.
+type Option func(*options)
+
+type options struct {
+	metrics        ports.Metrics
+	retry          RetryConfig
+	breaker        CircuitBreakerConfig
+	rateLimits     *saticonfig.RateLimits
+	transport      *saticonfig.Transport
+	tracerProvider trace.TracerProvider
+	log            *zerolog.Logger
+}
+
+// WithLogger sets where the client logs, e.g. circuit breaker state changes.
+// The default logger writes to stderr.
+func WithLogger(log *zerolog.Logger) Option {
+	return func(o *options) {
+		o.log = log
+	}
+}
+
+// WithMetrics reports every GateService call, failed SubmitJobResults call and
+// the client certificate's expiry to metrics.
+func WithMetrics(metrics ports.Metrics) Option {
+	return func(o *options) {
+		o.metrics = metrics
+	}
+}
+
+func newOptions(opts []Option) options {
+	logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+	o := options{
+		metrics: ports.NopMetrics{},
+		retry:   DefaultRetryConfig(),
+		breaker: DefaultCircuitBreakerConfig(),
+		log:     &logger,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// dialOptions returns the transport settings and the interceptors for the
+// configured options. Each call's deadline is set first, so it bounds all of
+// its retries. Retries wrap the circuit breaker, so an open circuit ends them. Each attempt the
+// breaker lets through waits for the rate limiter, and every attempt that
+// reaches the gate is measured and, with a tracer provider, traced.
+func (o options) dialOptions() []grpc.DialOption {
+	var (
+		unary  []grpc.UnaryClientInterceptor
+		stream []grpc.StreamClientInterceptor
+	)
+
+	unary = append(unary, unaryTransportInterceptor(o.transport))
+	stream = append(stream, streamTransportInterceptor(o.transport))
+
+	if o.retry.enabled() {
+		unary = append(unary, unaryRetryInterceptor(o.retry))
+		stream = append(stream, streamRetryInterceptor(o.retry))
+	}
+
+	if o.breaker.enabled() {
+		b := newBreaker(o.breaker, o.log)
+		unary = append(unary, unaryBreakerInterceptor(b))
+		stream = append(stream, streamBreakerInterceptor(b))
+	}
+
+	if limiter := newRateLimiter(o.rateLimits, o.log); limiter != nil {
+		unary = append(unary, unaryRateLimitInterceptor(limiter))
+		stream = append(stream, streamRateLimitInterceptor(limiter))
+	}
+
+	if _, ok := o.metrics.(ports.NopMetrics); !ok {
+		unary = append(unary, unaryMetricsInterceptor(o.metrics))
+		stream = append(stream, streamMetricsInterceptor(o.metrics))
+	}
+
+	dialOpts := append(transportDialOptions(o.transport),
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	)
+
+	if o.tracerProvider != nil {
+		dialOpts = append(dialOpts, tracingDialOptions(o.tracerProvider)...)
+	}
+
+	return dialOpts
+}
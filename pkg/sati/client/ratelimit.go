@@ -0,0 +1,271 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	saticonfig "github.com/tcncloud/sati-go/pkg/sati/config"
+	"github.com/tcncloud/sati-go/pkg/sati/gateerr"
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrRateLimited is returned when a call would have to wait for the rate
+// limiter past its context's deadline. It is a gateerr.ErrDeadlineExceeded.
+var ErrRateLimited = gateerr.New(gateerr.ErrDeadlineExceeded, "rate limit wait exceeds the call deadline")
+
+// Adaptive rate limiting.
+const (
+	// RateLimitBackoffFactor scales a bucket's rate down each time the gate
+	// throttles a call with retry info.
+	RateLimitBackoffFactor = 0.5
+	// RateLimitMinFraction is the lowest fraction of its configured rate a bucket is lowered to.
+	RateLimitMinFraction = 0.1
+	// RateLimitRecoveryInterval is how long a lowered bucket must go unthrottled
+	// before its rate is raised again.
+	RateLimitRecoveryInterval = 10 * time.Second
+	// RateLimitRecoveryStep is the fraction of its configured rate a bucket
+	// regains after each quiet RateLimitRecoveryInterval.
+	RateLimitRecoveryStep = 0.1
+)
+
+// WithRateLimits replaces the rate limits read from the configuration's
+// rate_limits section.
+func WithRateLimits(limits saticonfig.RateLimits) Option {
+	return func(o *options) {
+		o.rateLimits = &limits
+	}
+}
+
+// rateLimiter holds the global bucket and the per-method buckets of one client.
+type rateLimiter struct {
+	global  *bucket
+	methods map[string]*bucket
+}
+
+// newRateLimiter returns nil when limits sets no limit.
+func newRateLimiter(limits *saticonfig.RateLimits, log *zerolog.Logger) *rateLimiter {
+	if limits == nil || (limits.Global == nil && len(limits.Methods) == 0) {
+		return nil
+	}
+
+	r := &rateLimiter{methods: make(map[string]*bucket, len(limits.Methods))}
+
+	if limits.Global != nil {
+		r.global = newBucket("global", *limits.Global, log)
+	}
+
+	for method, limit := range limits.Methods {
+		r.methods[method] = newBucket(method, limit, log)
+	}
+
+	return r
+}
+
+// buckets returns the buckets a call to a bare method name draws from.
+func (r *rateLimiter) buckets(method string) []*bucket {
+	buckets := make([]*bucket, 0, 2)
+	if r.global != nil {
+		buckets = append(buckets, r.global)
+	}
+
+	if b, ok := r.methods[method]; ok {
+		buckets = append(buckets, b)
+	}
+
+	return buckets
+}
+
+// wait blocks until every bucket for method has a token.
+func (r *rateLimiter) wait(ctx context.Context, method string) error {
+	for _, b := range r.buckets(method) {
+		if err := b.wait(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			return fmt.Errorf("%w: %s", ErrRateLimited, method)
+		}
+	}
+
+	return nil
+}
+
+// observe lowers the rate of method's buckets when err is a RESOURCE_EXHAUSTED
+// status carrying retry info.
+func (r *rateLimiter) observe(method string, err error) {
+	delay, ok := throttleDelay(err)
+	if !ok {
+		return
+	}
+
+	for _, b := range r.buckets(method) {
+		b.throttle(delay)
+	}
+}
+
+// throttleDelay returns the retry delay of a RESOURCE_EXHAUSTED status.
+func throttleDelay(err error) (time.Duration, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.ResourceExhausted {
+		return 0, false
+	}
+
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return info.GetRetryDelay().AsDuration(), true
+		}
+	}
+
+	return 0, false
+}
+
+// bucket is a token bucket whose rate the gate can push down.
+type bucket struct {
+	name       string
+	configured rate.Limit
+	limiter    *rate.Limiter
+	log        *zerolog.Logger
+	now        func() time.Time
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+	adjusted    time.Time
+}
+
+func newBucket(name string, limit saticonfig.RateLimit, log *zerolog.Logger) *bucket {
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = max(1, int(math.Ceil(limit.RPS)))
+	}
+
+	return &bucket{
+		name:       name,
+		configured: rate.Limit(limit.RPS),
+		limiter:    rate.NewLimiter(rate.Limit(limit.RPS), burst),
+		log:        log,
+		now:        time.Now,
+	}
+}
+
+// wait blocks until the gate's retry delay has passed and a token is available.
+func (b *bucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	b.recover()
+	pause := b.pausedUntil.Sub(b.now())
+	b.mu.Unlock()
+
+	if pause > 0 {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < pause {
+			return ErrRateLimited
+		}
+
+		timer := time.NewTimer(pause)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return b.limiter.Wait(ctx)
+}
+
+// throttle pauses the bucket for delay and lowers its rate.
+func (b *bucket) throttle(delay time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if until := now.Add(delay); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+
+	lowered := max(b.limiter.Limit()*RateLimitBackoffFactor, b.configured*RateLimitMinFraction)
+	b.limiter.SetLimit(lowered)
+	b.adjusted = now
+
+	b.log.Warn().
+		Str("bucket", b.name).
+		Float64("rps", float64(lowered)).
+		Dur("retry_delay", delay).
+		Msg("Gate throttled calls, lowering client rate limit")
+}
+
+// recover raises a lowered rate one step for each quiet recovery interval. The
+// caller holds b.mu.
+func (b *bucket) recover() {
+	current := b.limiter.Limit()
+	if current >= b.configured {
+		return
+	}
+
+	now := b.now()
+
+	steps := int(now.Sub(b.adjusted) / RateLimitRecoveryInterval)
+	if steps == 0 {
+		return
+	}
+
+	raised := min(b.configured, current+b.configured*RateLimitRecoveryStep*rate.Limit(steps))
+	b.limiter.SetLimit(raised)
+	b.adjusted = now
+
+	if raised == b.configured {
+		b.log.Info().Str("bucket", b.name).Float64("rps", float64(raised)).Msg("Client rate limit restored")
+	}
+}
+
+func unaryRateLimitInterceptor(r *rateLimiter) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		name := rpcName(method)
+
+		if err := r.wait(ctx, name); err != nil {
+			return err
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		r.observe(name, err)
+
+		return err
+	}
+}
+
+// streamRateLimitInterceptor limits how often streams are opened.
+func streamRateLimitInterceptor(r *rateLimiter) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		name := rpcName(method)
+
+		if err := r.wait(ctx, name); err != nil {
+			return nil, err
+		}
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		r.observe(name, err)
+
+		return stream, err
+	}
+}
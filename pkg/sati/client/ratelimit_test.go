@@ -0,0 +1,227 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	saticonfig "github.com/tcncloud/sati-go/pkg/sati/config"
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func newTestRateLimiter(t *testing.T, limits saticonfig.RateLimits) (*rateLimiter, *bytes.Buffer) {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	logger := zerolog.New(&buf)
+
+	r := newRateLimiter(&limits, &logger)
+	if r == nil {
+		t.Fatal("Expected a rate limiter")
+	}
+
+	return r, &buf
+}
+
+func throttled(t *testing.T, delay time.Duration) error {
+	t.Helper()
+
+	st, err := status.New(codes.ResourceExhausted, "slow down").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	if err != nil {
+		t.Fatalf("Failed to build status: %v", err)
+	}
+
+	return st.Err()
+}
+
+func TestNewRateLimiter_NoLimits(t *testing.T) {
+	logger := zerolog.Nop()
+
+	if r := newRateLimiter(nil, &logger); r != nil {
+		t.Error("Expected no rate limiter without limits")
+	}
+
+	if r := newRateLimiter(&saticonfig.RateLimits{}, &logger); r != nil {
+		t.Error("Expected no rate limiter with an empty rate_limits section")
+	}
+}
+
+func TestRateLimiter_Buckets(t *testing.T) {
+	r, _ := newTestRateLimiter(t, saticonfig.RateLimits{
+		Global:  &saticonfig.RateLimit{RPS: 100},
+		Methods: map[string]saticonfig.RateLimit{"UpsertAgent": {RPS: 1, Burst: 1}},
+	})
+
+	if got := len(r.buckets("UpsertAgent")); got != 2 {
+		t.Errorf("Expected UpsertAgent to draw from 2 buckets, got %d", got)
+	}
+
+	if got := len(r.buckets("ListAgents")); got != 1 {
+		t.Errorf("Expected ListAgents to draw from the global bucket only, got %d", got)
+	}
+
+	if got := r.global.limiter.Burst(); got != 100 {
+		t.Errorf("Expected the burst to default to one second's worth, got %d", got)
+	}
+}
+
+func TestUnaryRateLimitInterceptor_WaitsOnDeadline(t *testing.T) {
+	r, _ := newTestRateLimiter(t, saticonfig.RateLimits{
+		Methods: map[string]saticonfig.RateLimit{"UpsertAgent": {RPS: 0.1, Burst: 1}},
+	})
+
+	interceptor := unaryRateLimitInterceptor(r)
+	invoker := &fakeInvoker{}
+
+	if err := interceptor(context.Background(), gateMethod("UpsertAgent"), nil, nil, nil, invoker.invoke); err != nil {
+		t.Fatalf("Expected the first call to use the burst, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := interceptor(ctx, gateMethod("UpsertAgent"), nil, nil, nil, invoker.invoke)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected ErrRateLimited, got %v", err)
+	}
+
+	if invoker.calls != 1 {
+		t.Errorf("Expected the limited call not to reach the gate, got %d calls", invoker.calls)
+	}
+
+	// Other methods are not limited
+	if err := interceptor(ctx, gateMethod("ListAgents"), nil, nil, nil, invoker.invoke); err != nil {
+		t.Errorf("Expected ListAgents to be unaffected, got %v", err)
+	}
+}
+
+func TestRateLimitedTrialKeepsCircuitHalfOpen(t *testing.T) {
+	r, _ := newTestRateLimiter(t, saticonfig.RateLimits{
+		Methods: map[string]saticonfig.RateLimit{"UpsertAgent": {RPS: 0.1, Burst: 1}},
+	})
+
+	config := DefaultCircuitBreakerConfig()
+	config.FailureThreshold = 1
+
+	b, now, _ := newTestBreaker(config)
+	c := b.circuit("UpsertAgent")
+
+	generation, _ := c.allow()
+	c.record(generation, status.Error(codes.Unavailable, "down"))
+
+	*now = now.Add(config.OpenTimeout)
+
+	gate := &fakeInvoker{}
+	limited := unaryRateLimitInterceptor(r)
+
+	// Use up the burst, so the trial call has to wait.
+	if err := limited(context.Background(), gateMethod("UpsertAgent"), nil, nil, nil, gate.invoke); err != nil {
+		t.Fatalf("Expected the first call to use the burst, got %v", err)
+	}
+
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return limited(ctx, method, req, reply, cc, gate.invoke, opts...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := unaryBreakerInterceptor(b)(ctx, gateMethod("UpsertAgent"), nil, nil, nil, invoker); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+
+	if c.state != CircuitHalfOpen {
+		t.Fatalf("Expected a rate limited trial to leave the circuit half-open, got %s", c.state)
+	}
+
+	if _, err := c.allow(); err != nil {
+		t.Errorf("Expected the rate limited trial to free its slot, got %v", err)
+	}
+}
+
+func TestThrottleDelay(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		want   time.Duration
+		wantOk bool
+	}{
+		{"RetryInfo", throttled(t, 2*time.Second), 2 * time.Second, true},
+		{"NoRetryInfo", status.Error(codes.ResourceExhausted, "quota"), 0, false},
+		{"OtherCode", status.Error(codes.Unavailable, "down"), 0, false},
+		{"Nil", nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := throttleDelay(tt.err)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("Expected (%v, %v), got (%v, %v)", tt.want, tt.wantOk, got, ok)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_AdaptsToThrottling(t *testing.T) {
+	r, logs := newTestRateLimiter(t, saticonfig.RateLimits{
+		Methods: map[string]saticonfig.RateLimit{"ListAgents": {RPS: 10}},
+	})
+
+	b := r.methods["ListAgents"]
+
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	for range 5 {
+		r.observe("ListAgents", throttled(t, time.Second))
+	}
+
+	if got := b.limiter.Limit(); got != 1 {
+		t.Errorf("Expected the rate to bottom out at 1 rps, got %v", got)
+	}
+
+	if !strings.Contains(logs.String(), "lowering client rate limit") {
+		t.Errorf("Expected a throttling log, got %s", logs.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := r.wait(ctx, "ListAgents"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected the retry delay to exceed the deadline, got %v", err)
+	}
+
+	now = now.Add(5 * RateLimitRecoveryInterval)
+
+	b.mu.Lock()
+	b.recover()
+	b.mu.Unlock()
+
+	if got, want := b.limiter.Limit(), rate.Limit(6); got < want-0.001 || got > want+0.001 {
+		t.Errorf("Expected the rate to recover to %v rps, got %v", want, got)
+	}
+
+	now = now.Add(10 * RateLimitRecoveryInterval)
+
+	b.mu.Lock()
+	b.recover()
+	b.mu.Unlock()
+
+	if got := b.limiter.Limit(); got != 10 {
+		t.Errorf("Expected the configured rate to be restored, got %v", got)
+	}
+
+	if !strings.Contains(logs.String(), "Client rate limit restored") {
+		t.Errorf("Expected a restored log, got %s", logs.String())
+	}
+}
@@ -0,0 +1,383 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"sync/atomic"
+	"time"
+
+	gatev2pb "github.com/tcncloud/sati-go/internal/genproto/tcnapi/exile/gate/v2"
+	saticonfig "github.com/tcncloud/sati-go/pkg/sati/config"
+	"github.com/tcncloud/sati-go/pkg/sati/gateerr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Connection draining after a reload.
+const (
+	// ReloadDrainTimeout is how long a replaced connection may keep serving
+	// the calls and streams started on it before it is closed under them.
+	// StreamJobs streams move to the new connection without waiting for it.
+	ReloadDrainTimeout = 5 * time.Minute
+	// reloadDrainPollInterval is how often a replaced connection is checked
+	// for calls still in flight.
+	reloadDrainPollInterval = 100 * time.Millisecond
+)
+
+// errClientOnly is returned for a server handshake on the gate credentials.
+var errClientOnly = errors.New("gate credentials only support client handshakes")
+
+// tlsMaterial is the certificate, key, CA pool and server checks of one
+// configuration.
+type tlsMaterial struct {
+	cert       tls.Certificate
+	roots      *x509.CertPool
+	serverName string
+	pins       map[string]bool
+
+	certificate, privateKey, caCertificate string
+}
+
+// loadTLSMaterial checks the certificate against the configured fingerprint
+// and parses the certificate, key, CA certificate and server pins.
+func loadTLSMaterial(cfg *saticonfig.Config) (*tlsMaterial, error) {
+	if err := cfg.VerifyFingerprint(); err != nil {
+		return nil, err
+	}
+
+	cert, err := tls.X509KeyPair([]byte(cfg.Certificate), []byte(cfg.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client cert: %w", err)
+	}
+
+	roots := x509.NewCertPool()
+	if ok := roots.AppendCertsFromPEM([]byte(cfg.CACertificate)); !ok {
+		return nil, ErrCAAppendFailed
+	}
+
+	pins := make(map[string]bool, len(cfg.ServerPins))
+	for _, pin := range cfg.ServerPins {
+		hash, err := saticonfig.ParseServerPin(pin)
+		if err != nil {
+			return nil, err
+		}
+
+		pins[hash] = true
+	}
+
+	return &tlsMaterial{
+		cert:          cert,
+		roots:         roots,
+		serverName:    cfg.ServerName,
+		pins:          pins,
+		certificate:   cfg.Certificate,
+		privateKey:    cfg.PrivateKey,
+		caCertificate: cfg.CACertificate,
+	}, nil
+}
+
+// same reports whether m was loaded from the same configuration as other.
+func (m *tlsMaterial) same(other *tlsMaterial) bool {
+	return m.certificate == other.certificate &&
+		m.privateKey == other.privateKey &&
+		m.caCertificate == other.caCertificate &&
+		m.serverName == other.serverName &&
+		maps.Equal(m.pins, other.pins)
+}
+
+// reloadableCredentials hands the current certificate and CA pool to every
+// TLS handshake, so reconnects pick up a reload without a new tls.Config.
+type reloadableCredentials struct {
+	current atomic.Pointer[tlsMaterial]
+}
+
+func newReloadableCredentials(material *tlsMaterial) *reloadableCredentials {
+	r := &reloadableCredentials{}
+	r.current.Store(material)
+
+	return r
+}
+
+// transportCredentials checks the gate's certificate against serverName, or
+// the dialed endpoint's host when it is empty.
+func (r *reloadableCredentials) transportCredentials(serverName string) credentials.TransportCredentials {
+	return &handshakeCredentials{creds: r, serverName: serverName}
+}
+
+// handshakeCredentials builds a tls.Config for every handshake, since RootCAs
+// is fixed once a tls.Config is in use. The gate's certificate is verified by
+// crypto/tls against the current pool and the server name, which is the
+// dialed host unless overridden; that also covers IP endpoints, which send no
+// SNI.
+type handshakeCredentials struct {
+	creds      *reloadableCredentials
+	serverName string
+}
+
+// current returns TLS credentials with the current certificate and CA pool.
+func (h *handshakeCredentials) current() credentials.TransportCredentials {
+	material := h.creds.current.Load()
+
+	return credentials.NewTLS(&tls.Config{
+		ServerName: h.serverName,
+		RootCAs:    material.roots,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return &material.cert, nil
+		},
+		VerifyConnection: material.verifyPins,
+		MinVersion:       tls.VersionTLS12, // Set minimum TLS version to 1.2
+	})
+}
+
+// ClientHandshake marks certificate failures with their gateerr kind, since
+// gRPC passes only the message of a handshake error on to the failed call.
+func (h *handshakeCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, info, err := h.current().ClientHandshake(ctx, authority, rawConn)
+	if err != nil {
+		return nil, nil, gateerr.WrapTLSError(err)
+	}
+
+	return &alertConn{Conn: conn}, info, nil
+}
+
+// alertConn marks certificate alerts read after the handshake. A TLS 1.3 gate
+// checks the client certificate once the client has finished its side of the
+// handshake, so a rejection arrives with the first read.
+type alertConn struct {
+	net.Conn
+}
+
+func (c *alertConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+
+	return n, gateerr.WrapTLSError(err)
+}
+
+func (h *handshakeCredentials) ServerHandshake(net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errClientOnly
+}
+
+func (h *handshakeCredentials) Info() credentials.ProtocolInfo {
+	return h.current().Info()
+}
+
+func (h *handshakeCredentials) Clone() credentials.TransportCredentials {
+	return &handshakeCredentials{creds: h.creds, serverName: h.serverName}
+}
+
+// OverrideServerName is part of credentials.TransportCredentials.
+func (h *handshakeCredentials) OverrideServerName(serverName string) error {
+	h.serverName = serverName
+
+	return nil
+}
+
+// verifyPins checks that the gate's verified chain contains a server pin when
+// any are configured.
+func (m *tlsMaterial) verifyPins(cs tls.ConnectionState) error {
+	if len(m.pins) == 0 {
+		return nil
+	}
+
+	for _, chain := range cs.VerifiedChains {
+		for _, cert := range chain {
+			if m.pins[saticonfig.SPKIHash(cert)] {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("%w: the gate's key hashes to %s%s", gateerr.ErrServerPinMismatch,
+		saticonfig.ServerPinPrefix, saticonfig.SPKIHash(cs.PeerCertificates[0]))
+}
+
+// connection is one gRPC connection to the gate and the calls in flight on it.
+type connection struct {
+	conn   *grpc.ClientConn
+	gate   gatev2pb.GateServiceClient
+	route  route
+	active atomic.Int64
+}
+
+// dial connects to the route's endpoints with creds and the client's
+// interceptors. The connection counts its calls first, so a retried call
+// stays in flight between attempts.
+func dial(r route, serverName string, creds *reloadableCredentials, dialOpts []grpc.DialOption) (*connection, error) {
+	c := &connection{route: r}
+
+	target, targetOpts := r.target()
+
+	opts := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(creds.transportCredentials(serverName)),
+		grpc.WithDefaultServiceConfig(r.serviceConfig),
+		grpc.WithChainUnaryInterceptor(c.unaryInterceptor),
+		grpc.WithChainStreamInterceptor(c.streamInterceptor),
+	}, targetOpts...)
+	opts = append(opts, dialOpts...)
+
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to API: %w", err)
+	}
+
+	c.conn = conn
+	c.gate = gatev2pb.NewGateServiceClient(conn)
+
+	return c, nil
+}
+
+func (c *connection) unaryInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	c.active.Add(1)
+	defer c.active.Add(-1)
+
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// streamInterceptor counts a stream until it ends, however it ends.
+func (c *connection) streamInterceptor(
+	ctx context.Context,
+	desc *grpc.StreamDesc,
+	cc *grpc.ClientConn,
+	method string,
+	streamer grpc.Streamer,
+	opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	c.active.Add(1)
+
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		c.active.Add(-1)
+
+		return nil, err
+	}
+
+	go func() {
+		<-stream.Context().Done()
+		c.active.Add(-1)
+	}()
+
+	return stream, nil
+}
+
+// drain closes the connection once no call is in flight on it, or after
+// timeout. The first check waits one poll interval, so that calls which
+// picked the connection just before it was replaced have started.
+func (c *connection) drain(timeout time.Duration) {
+	ticker := time.NewTicker(reloadDrainPollInterval)
+	defer ticker.Stop()
+
+	deadline := time.Now().Add(timeout)
+
+	for range ticker.C {
+		if c.active.Load() == 0 || time.Now().After(deadline) {
+			break
+		}
+	}
+
+	_ = c.conn.Close()
+}
+
+// Reload switches the client to cfg's endpoints, load balancing, certificate,
+// key, CA certificate, server name and server pins. New calls use a new
+// connection at once; calls and streams already in flight finish on the old
+// one, which is closed when they are done or after ReloadDrainTimeout. A
+// StreamJobs stream opens a stream on the new connection and leaves the old one
+// once the gate has accepted it. Reloading an unchanged configuration does
+// nothing. Rate limits and transport settings keep the values the client was
+// created with.
+func (c *Client) Reload(cfg *saticonfig.Config) error {
+	material, err := loadTLSMaterial(cfg)
+	if err != nil {
+		return err
+	}
+
+	r := newRoute(cfg)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	old := c.current
+	if old.route.equal(r) && c.creds.current.Load().same(material) {
+		return nil
+	}
+
+	next, err := dial(r, material.serverName, c.creds, c.dialOpts)
+	if err != nil {
+		return err
+	}
+
+	// Handshakes of the old connection, e.g. reconnects while it drains, use
+	// the new certificate from here on.
+	c.creds.current.Store(material)
+
+	next.conn.Connect()
+
+	c.current = next
+	c.conn = next.conn
+	c.gate = next.gate
+	c.draining[old] = struct{}{}
+
+	close(c.reloaded)
+	c.reloaded = make(chan struct{})
+
+	reportCertificateExpiry(c.metrics, material.cert.Certificate)
+
+	c.log.Info().
+		Stringer("endpoint", r).
+		Stringer("previous_endpoint", old.route).
+		Msg("Reloaded gate connection")
+
+	go func() {
+		old.drain(ReloadDrainTimeout)
+
+		c.mu.Lock()
+		delete(c.draining, old)
+		c.mu.Unlock()
+
+		c.log.Info().Stringer("endpoint", old.route).Msg("Closed replaced gate connection")
+	}()
+
+	return nil
+}
+
+// ReloadFromFile loads, validates and reloads the configuration at path. It is
+// a saticonfig.ConfigLoaderFunc, so a daemon can follow its config file with
+//
+//	saticonfig.NewConfigWatcher([]string{path}, client.ReloadFromFile)
+func (c *Client) ReloadFromFile(path string) error {
+	cfg, err := saticonfig.LoadAndValidateConfig(path)
+	if err != nil {
+		return err
+	}
+
+	return c.Reload(cfg)
+}
+
+// streamGate returns the stub of the connection new streams should use and a
+// channel that the next reload closes.
+func (c *Client) streamGate() (gatev2pb.GateServiceClient, <-chan struct{}) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.gate, c.reloaded
+}
+
+// currentGate returns the stub of the connection new calls should use.
+func (c *Client) currentGate() gatev2pb.GateServiceClient {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.gate
+}
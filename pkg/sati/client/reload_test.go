@@ -0,0 +1,458 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	gatev2pb "github.com/tcncloud/sati-go/internal/genproto/tcnapi/exile/gate/v2"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	saticonfig "github.com/tcncloud/sati-go/pkg/sati/config"
+	"github.com/tcncloud/sati-go/pkg/sati/gateerr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+)
+
+// testPKI issues certificates from a throwaway CA.
+type testPKI struct {
+	t      *testing.T
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	caPEM  string
+	serial int64
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+
+	p := &testPKI{t: t}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate CA key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "sati-test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %v", err)
+	}
+
+	p.ca, _ = x509.ParseCertificate(der)
+	p.caKey = key
+	p.caPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	p.serial = 1
+
+	return p
+}
+
+// issue returns the PEM certificate and key of a leaf named commonName.
+func (p *testPKI) issue(commonName string, usage x509.ExtKeyUsage) (string, string) {
+	p.t.Helper()
+
+	return p.issueFor(commonName, usage, "127.0.0.1", "gate.test")
+}
+
+// issueFor issues a certificate valid for hosts, which are IPs or DNS names.
+func (p *testPKI) issueFor(commonName string, usage x509.ExtKeyUsage, hosts ...string) (string, string) {
+	p.t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		p.t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	p.serial++
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(p.serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, p.ca, &key.PublicKey, p.caKey)
+	if err != nil {
+		p.t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		p.t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+// config returns a client configuration for endpoint with a new certificate
+// named commonName.
+func (p *testPKI) config(endpoint, commonName string) *saticonfig.Config {
+	cert, key := p.issue(commonName, x509.ExtKeyUsageClientAuth)
+
+	return &saticonfig.Config{
+		APIEndpoint:   endpoint,
+		CACertificate: p.caPEM,
+		Certificate:   cert,
+		PrivateKey:    key,
+	}
+}
+
+// testGate answers GetClientConfiguration with the caller's certificate name
+// and streams the jobs sent to it on jobs. Its health reports SERVING until
+// changed.
+type testGate struct {
+	gatev2pb.UnimplementedGateServiceServer
+
+	jobs   chan string
+	health *health.Server
+	server *grpc.Server
+}
+
+func (g *testGate) GetClientConfiguration(ctx context.Context, _ *gatev2pb.GetClientConfigurationRequest) (*gatev2pb.GetClientConfigurationResponse, error) {
+	p, _ := peer.FromContext(ctx)
+	info, _ := p.AuthInfo.(credentials.TLSInfo)
+
+	return &gatev2pb.GetClientConfigurationResponse{OrgId: info.State.PeerCertificates[0].Subject.CommonName}, nil
+}
+
+func (g *testGate) StreamJobs(_ *gatev2pb.StreamJobsRequest, stream grpc.ServerStreamingServer[gatev2pb.StreamJobsResponse]) error {
+	for id := range g.jobs {
+		if err := stream.Send(&gatev2pb.StreamJobsResponse{JobId: id}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// serveTestGate starts a gate that requires client certificates from p.
+func serveTestGate(t *testing.T, p *testPKI) (string, *testGate) {
+	t.Helper()
+
+	return serveTestGateFor(t, p, "127.0.0.1", "gate.test")
+}
+
+// serveTestGateFor starts a gate whose certificate is valid for hosts only.
+func serveTestGateFor(t *testing.T, p *testPKI, hosts ...string) (string, *testGate) {
+	t.Helper()
+
+	certPEM, keyPEM := p.issueFor("gate", x509.ExtKeyUsageServerAuth, hosts...)
+
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		t.Fatalf("Failed to load gate certificate: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(p.ca)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})))
+
+	gate := &testGate{jobs: make(chan string), health: health.NewServer(), server: server}
+	gatev2pb.RegisterGateServiceServer(server, gate)
+	healthpb.RegisterHealthServer(server, gate.health)
+
+	go func() { _ = server.Serve(lis) }()
+
+	t.Cleanup(server.Stop)
+
+	return lis.Addr().String(), gate
+}
+
+func orgID(t *testing.T, c *Client) string {
+	t.Helper()
+
+	result, err := c.GetClientConfiguration(context.Background(), ports.GetClientConfigurationParams{})
+	if err != nil {
+		t.Fatalf("GetClientConfiguration failed: %v", err)
+	}
+
+	return result.OrgID
+}
+
+func TestClient_ReloadMovesNewCallsAndJobStreams(t *testing.T) {
+	pki := newTestPKI(t)
+	oldAddr, oldGate := serveTestGate(t, pki)
+	newAddr, newGate := serveTestGate(t, pki)
+
+	c, err := NewClient(pki.config(oldAddr, "client-1"))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+
+	if got := orgID(t, c); got != "client-1" {
+		t.Fatalf("Expected the first certificate, got %q", got)
+	}
+
+	jobs := c.StreamJobs(context.Background(), ports.StreamJobsParams{})
+
+	oldGate.jobs <- "job-1"
+	if result := <-jobs; result.Error != nil || result.Job.JobID != "job-1" {
+		t.Fatalf("Unexpected first job: %+v", result)
+	}
+
+	renewed := pki.config(newAddr, "client-2")
+	if err := c.Reload(renewed); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	if got := orgID(t, c); got != "client-2" {
+		t.Errorf("Expected the renewed certificate, got %q", got)
+	}
+
+	// The old stream keeps delivering jobs until the gate has accepted the
+	// new one, which the test gate does with its first job.
+	oldGate.jobs <- "job-2"
+	if result := <-jobs; result.Error != nil || result.Job.JobID != "job-2" {
+		t.Fatalf("Unexpected job on the old stream after reload: %+v", result)
+	}
+
+	newGate.jobs <- "job-3"
+	if result := <-jobs; result.Error != nil || result.Job.JobID != "job-3" {
+		t.Fatalf("Unexpected job on the new stream after reload: %+v", result)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.mu.RLock()
+		draining := len(c.draining)
+		c.mu.RUnlock()
+
+		if draining == 0 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the replaced connection to be closed once the job stream moved")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	newGate.jobs <- "job-4"
+	if result := <-jobs; result.Error != nil || result.Job.JobID != "job-4" {
+		t.Fatalf("Unexpected job after the old connection closed: %+v", result)
+	}
+
+	close(newGate.jobs)
+
+	if result, ok := <-jobs; ok {
+		t.Fatalf("Expected the stream to end, got %+v", result)
+	}
+
+	current := c.current
+	if err := c.Reload(renewed); err != nil {
+		t.Fatalf("Reload of an unchanged configuration failed: %v", err)
+	}
+
+	if c.current != current {
+		t.Error("Expected reloading an unchanged configuration to keep the connection")
+	}
+}
+
+func TestClient_ReloadRejectsBadCertificate(t *testing.T) {
+	pki := newTestPKI(t)
+	addr, _ := serveTestGate(t, pki)
+
+	c, err := NewClient(pki.config(addr, "client-1"))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+
+	broken := pki.config(addr, "client-2")
+	broken.PrivateKey = "not a key"
+
+	if err := c.Reload(broken); err == nil {
+		t.Fatal("Expected Reload to fail for an unreadable key")
+	}
+
+	if got := orgID(t, c); got != "client-1" {
+		t.Errorf("Expected the client to keep its certificate, got %q", got)
+	}
+}
+
+func TestReloadableCredentials_RejectsUnknownCA(t *testing.T) {
+	pki := newTestPKI(t)
+	addr, _ := serveTestGate(t, pki)
+
+	cfg := pki.config(addr, "client-1")
+	cfg.CACertificate = newTestPKI(t).caPEM
+
+	c, err := NewClient(cfg, WithRetry(RetryConfig{}))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+
+	_, err = c.GetClientConfiguration(context.Background(), ports.GetClientConfigurationParams{})
+	if !errors.Is(err, gateerr.ErrServerCertificateUntrusted) {
+		t.Fatalf("Expected ErrServerCertificateUntrusted, got %v", err)
+	}
+}
+
+func TestClient_GateRejectsClientCertificate(t *testing.T) {
+	pki := newTestPKI(t)
+	addr, _ := serveTestGate(t, pki)
+
+	cfg := newTestPKI(t).config(addr, "client-1")
+	cfg.CACertificate = pki.caPEM
+
+	c, err := NewClient(cfg, WithRetry(RetryConfig{}))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+
+	_, err = c.GetClientConfiguration(context.Background(), ports.GetClientConfigurationParams{})
+	if !errors.Is(err, gateerr.ErrCertificateRejected) || errors.Is(err, gateerr.ErrServerCertificateUntrusted) {
+		t.Fatalf("Expected ErrCertificateRejected, got %v", err)
+	}
+}
+
+func TestClient_ServerPins(t *testing.T) {
+	pki := newTestPKI(t)
+	addr, _ := serveTestGate(t, pki)
+
+	pinned := pki.config(addr, "client-1")
+	pinned.ServerPins = []string{saticonfig.ServerPinPrefix + saticonfig.SPKIHash(pki.ca)}
+
+	c, err := NewClient(pinned)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+
+	if got := orgID(t, c); got != "client-1" {
+		t.Errorf("Expected a chain with the pinned CA key to be accepted, got %q", got)
+	}
+
+	mispinned := pki.config(addr, "client-1")
+	mispinned.ServerPins = []string{saticonfig.SPKIHash(newTestPKI(t).ca)}
+
+	other, err := NewClient(mispinned, WithRetry(RetryConfig{}))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer other.Close()
+
+	_, err = other.GetClientConfiguration(context.Background(), ports.GetClientConfigurationParams{})
+	if !errors.Is(err, gateerr.ErrServerPinMismatch) {
+		t.Errorf("Expected ErrServerPinMismatch, got %v", err)
+	}
+}
+
+func TestClient_ServerName(t *testing.T) {
+	pki := newTestPKI(t)
+	addr, _ := serveTestGate(t, pki)
+
+	cfg := pki.config(addr, "client-1")
+	cfg.ServerName = "gate.test"
+
+	c, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+
+	if got := orgID(t, c); got != "client-1" {
+		t.Errorf("Expected the gate to be checked against gate.test, got %q", got)
+	}
+
+	cfg = pki.config(addr, "client-1")
+	cfg.ServerName = "other.test"
+
+	other, err := NewClient(cfg, WithRetry(RetryConfig{}))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer other.Close()
+
+	if _, err := other.GetClientConfiguration(context.Background(), ports.GetClientConfigurationParams{}); err == nil {
+		t.Error("Expected a gate certificate without other.test to be rejected")
+	}
+}
+
+func TestClient_ChecksIPEndpointName(t *testing.T) {
+	pki := newTestPKI(t)
+	addr, _ := serveTestGateFor(t, pki, "gate.test")
+
+	c, err := NewClient(pki.config(addr, "client-1"), WithRetry(RetryConfig{}))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.GetClientConfiguration(context.Background(), ports.GetClientConfigurationParams{}); err == nil {
+		t.Error("Expected a gate certificate without the endpoint's IP to be rejected")
+	}
+
+	cfg := pki.config(addr, "client-1")
+	cfg.ServerName = "gate.test"
+
+	named, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer named.Close()
+
+	if got := orgID(t, named); got != "client-1" {
+		t.Errorf("Expected the gate to be checked against server_name, got %q", got)
+	}
+}
+
+func TestNewClient_RejectsFingerprintMismatch(t *testing.T) {
+	pki := newTestPKI(t)
+
+	cfg := pki.config("127.0.0.1:1", "client-1")
+	cfg.FingerprintSHA256 = strings.Repeat("ab", 32)
+
+	if _, err := NewClient(cfg); !errors.Is(err, saticonfig.ErrFingerprintMismatch) {
+		t.Errorf("Expected ErrFingerprintMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Retry defaults.
+const (
+	// DefaultRetryAttempts is how many times a call is tried, including the first attempt.
+	DefaultRetryAttempts = 4
+	// DefaultRetryInitialBackoff is the longest wait before the first retry.
+	DefaultRetryInitialBackoff = 100 * time.Millisecond
+	// DefaultRetryMaxBackoff caps the wait between retries.
+	DefaultRetryMaxBackoff = 5 * time.Second
+	// DefaultRetryMultiplier grows the backoff after each retry.
+	DefaultRetryMultiplier = 2.0
+)
+
+// DefaultRetryableCodes are the status codes of transient failures.
+var DefaultRetryableCodes = []codes.Code{codes.Unavailable, codes.ResourceExhausted, codes.Aborted}
+
+// SafeMethods are the GateService reads that can be repeated without side effects.
+var SafeMethods = []string{
+	"GetAgentById",
+	"GetAgentByPartnerId",
+	"GetAgentStatus",
+	"GetClientConfiguration",
+	"GetOrganizationInfo",
+	"GetRecordingStatus",
+	"GetVoiceRecordingDownloadLink",
+	"ListAgentSkills",
+	"ListAgents",
+	"ListHuntGroupPauseCodes",
+	"ListNCLRulesetNames",
+	"ListScrubLists",
+	"ListSearchableRecordingFields",
+	"ListSkills",
+	"SearchVoiceRecordings",
+}
+
+// RetryPolicy controls how a failed call is retried. Each wait is chosen at
+// random up to the current backoff, which starts at InitialBackoff and grows
+// by Multiplier up to MaxBackoff. No retry is made once the caller's context
+// is done or its deadline would pass during the wait.
+type RetryPolicy struct {
+	// MaxAttempts is how many times the call is tried, including the first
+	// attempt. Values below 2 disable retries.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// RetryableCodes are the status codes worth retrying.
+	RetryableCodes []codes.Code
+	// Idempotent calls are retried after any retryable failure. Other calls are
+	// only retried when the request never reached a connection, so the server
+	// cannot have acted on it.
+	Idempotent bool
+}
+
+// RetryConfig selects a RetryPolicy per GateService method.
+type RetryConfig struct {
+	// Default applies to methods without an entry in Methods.
+	Default RetryPolicy
+	// Methods maps a bare method name, e.g. "GetAgentById", to its policy.
+	Methods map[string]RetryPolicy
+}
+
+// DefaultRetryPolicy returns the policy for calls that must not be repeated
+// once sent. Set Idempotent for reads.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    DefaultRetryAttempts,
+		InitialBackoff: DefaultRetryInitialBackoff,
+		MaxBackoff:     DefaultRetryMaxBackoff,
+		Multiplier:     DefaultRetryMultiplier,
+		RetryableCodes: DefaultRetryableCodes,
+	}
+}
+
+// DefaultRetryConfig retries SafeMethods after any transient failure and every
+// other method only when the request was not sent.
+func DefaultRetryConfig() RetryConfig {
+	safe := DefaultRetryPolicy()
+	safe.Idempotent = true
+
+	methods := make(map[string]RetryPolicy, len(SafeMethods))
+	for _, method := range SafeMethods {
+		methods[method] = safe
+	}
+
+	return RetryConfig{Default: DefaultRetryPolicy(), Methods: methods}
+}
+
+// WithRetry replaces DefaultRetryConfig. WithRetry(RetryConfig{}) makes every
+// call exactly once.
+func WithRetry(config RetryConfig) Option {
+	return func(o *options) {
+		o.retry = config
+	}
+}
+
+// policy returns the policy for a bare method name.
+func (c RetryConfig) policy(method string) RetryPolicy {
+	if p, ok := c.Methods[method]; ok {
+		return p
+	}
+
+	return c.Default
+}
+
+// enabled reports whether any method is retried.
+func (c RetryConfig) enabled() bool {
+	if c.Default.MaxAttempts > 1 {
+		return true
+	}
+
+	for _, p := range c.Methods {
+		if p.MaxAttempts > 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// shouldRetry reports whether a failed attempt may be retried. Sent is false
+// when the attempt never got a connection.
+func (p RetryPolicy) shouldRetry(err error, sent bool) bool {
+	if !slices.Contains(p.RetryableCodes, status.Code(err)) {
+		return false
+	}
+
+	return p.Idempotent || !sent
+}
+
+// backoff returns the jittered wait before retry number n, counting from 1.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	limit := float64(p.InitialBackoff)
+	for range n - 1 {
+		limit *= p.Multiplier
+	}
+
+	if p.MaxBackoff > 0 {
+		limit = min(limit, float64(p.MaxBackoff))
+	}
+
+	if limit <= 0 {
+		return 0
+	}
+
+	//nolint:gosec // Jitter does not need a secure source
+	return time.Duration(rand.Int64N(int64(limit) + 1))
+}
+
+// wait sleeps before retry number n. It returns false, without waiting, when
+// the context is done or its deadline would pass first.
+func (p RetryPolicy) wait(ctx context.Context, n int) bool {
+	delay := p.backoff(n)
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+		return false
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func unaryRetryInterceptor(config RetryConfig) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		policy := config.policy(rpcName(method))
+
+		for attempt := 1; ; attempt++ {
+			var p peer.Peer
+
+			err := invoker(ctx, method, req, reply, cc, append(opts[:len(opts):len(opts)], grpc.Peer(&p))...)
+			if err == nil || attempt >= policy.MaxAttempts || !policy.shouldRetry(err, p.Addr != nil) {
+				return err
+			}
+
+			if !policy.wait(ctx, attempt) {
+				return err
+			}
+		}
+	}
+}
+
+// streamRetryInterceptor retries opening a stream. Failures after the stream
+// is established are returned to the caller, which knows what it has consumed.
+func streamRetryInterceptor(config RetryConfig) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		policy := config.policy(rpcName(method))
+
+		for attempt := 1; ; attempt++ {
+			var p peer.Peer
+
+			stream, err := streamer(ctx, desc, cc, method, append(opts[:len(opts):len(opts)], grpc.Peer(&p))...)
+			if err == nil || attempt >= policy.MaxAttempts || !policy.shouldRetry(err, p.Addr != nil) {
+				return stream, err
+			}
+
+			if !policy.wait(ctx, attempt) {
+				return nil, err
+			}
+		}
+	}
+}
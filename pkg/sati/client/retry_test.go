@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// fakeInvoker fails with the queued errors, then succeeds. Sent marks the
+// attempts as having reached a connection.
+type fakeInvoker struct {
+	errs  []error
+	sent  bool
+	calls int
+}
+
+func (f *fakeInvoker) invoke(_ context.Context, _ string, _, _ any, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+	f.calls++
+
+	if f.sent {
+		for _, opt := range opts {
+			if p, ok := opt.(grpc.PeerCallOption); ok {
+				*p.PeerAddr = peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 443}}
+			}
+		}
+	}
+
+	if len(f.errs) == 0 {
+		return nil
+	}
+
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+
+	return err
+}
+
+func testRetryConfig() RetryConfig {
+	config := DefaultRetryConfig()
+	config.Default.InitialBackoff = time.Millisecond
+	config.Default.MaxBackoff = time.Millisecond
+
+	for method, policy := range config.Methods {
+		policy.InitialBackoff = time.Millisecond
+		policy.MaxBackoff = time.Millisecond
+		config.Methods[method] = policy
+	}
+
+	return config
+}
+
+func gateMethod(name string) string {
+	return "/tcnapi.exile.gate.v2.GateService/" + name
+}
+
+func TestUnaryRetryInterceptor(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "deploying")
+
+	tests := []struct {
+		name      string
+		method    string
+		errs      []error
+		sent      bool
+		wantCalls int
+		wantCode  codes.Code
+	}{
+		{"safe read retries after send", "GetAgentById", []error{unavailable, unavailable}, true, 3, codes.OK},
+		{"safe read gives up", "ListSkills", []error{unavailable, unavailable, unavailable, unavailable}, true, DefaultRetryAttempts, codes.Unavailable},
+		{"non-retryable code", "GetRecordingStatus", []error{status.Error(codes.InvalidArgument, "bad")}, true, 1, codes.InvalidArgument},
+		{"dial is not repeated once sent", "Dial", []error{unavailable}, true, 1, codes.Unavailable},
+		{"transfer retries when unsent", "Transfer", []error{unavailable}, false, 2, codes.OK},
+		{"scrub list retries when unsent", "AddScrubListEntries", []error{unavailable, unavailable}, false, 3, codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoker := &fakeInvoker{errs: tt.errs, sent: tt.sent}
+			interceptor := unaryRetryInterceptor(testRetryConfig())
+
+			err := interceptor(context.Background(), gateMethod(tt.method), nil, nil, nil, invoker.invoke)
+			if status.Code(err) != tt.wantCode {
+				t.Errorf("Expected code %v, got %v", tt.wantCode, err)
+			}
+
+			if invoker.calls != tt.wantCalls {
+				t.Errorf("Expected %d attempts, got %d", tt.wantCalls, invoker.calls)
+			}
+		})
+	}
+}
+
+func TestUnaryRetryInterceptor_RespectsDeadline(t *testing.T) {
+	config := DefaultRetryConfig()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	config.Methods["GetAgentById"] = RetryPolicy{
+		MaxAttempts:    DefaultRetryAttempts,
+		InitialBackoff: time.Hour,
+		MaxBackoff:     time.Hour,
+		Multiplier:     DefaultRetryMultiplier,
+		RetryableCodes: DefaultRetryableCodes,
+		Idempotent:     true,
+	}
+
+	invoker := &fakeInvoker{errs: []error{status.Error(codes.Unavailable, "down")}, sent: true}
+
+	start := time.Now()
+	err := unaryRetryInterceptor(config)(ctx, gateMethod("GetAgentById"), nil, nil, nil, invoker.invoke)
+
+	if status.Code(err) != codes.Unavailable || invoker.calls != 1 {
+		t.Errorf("Expected one failed attempt, got %d attempts and %v", invoker.calls, err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("Expected no wait past the deadline, waited %v", elapsed)
+	}
+}
+
+func TestUnaryRetryInterceptor_Disabled(t *testing.T) {
+	o := newOptions([]Option{WithRetry(RetryConfig{})})
+	if o.retry.enabled() {
+		t.Fatal("Expected WithRetry(RetryConfig{}) to disable retries")
+	}
+
+	invoker := &fakeInvoker{errs: []error{status.Error(codes.Unavailable, "down")}, sent: true}
+
+	_ = unaryRetryInterceptor(o.retry)(context.Background(), gateMethod("ListSkills"), nil, nil, nil, invoker.invoke)
+
+	if invoker.calls != 1 {
+		t.Errorf("Expected a single attempt, got %d", invoker.calls)
+	}
+}
+
+func TestStreamRetryInterceptor(t *testing.T) {
+	invoker := &fakeInvoker{errs: []error{status.Error(codes.Unavailable, "down")}}
+
+	streamer := func(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if err := invoker.invoke(ctx, method, nil, nil, nil, opts...); err != nil {
+			return nil, err
+		}
+
+		return &fakeClientStream{}, nil
+	}
+
+	stream, err := streamRetryInterceptor(testRetryConfig())(context.Background(), &grpc.StreamDesc{}, nil, gateMethod("StreamJobs"), streamer)
+	if err != nil || stream == nil {
+		t.Fatalf("Expected the stream to open on retry, got %v", err)
+	}
+
+	if invoker.calls != 2 {
+		t.Errorf("Expected 2 attempts, got %d", invoker.calls)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := DefaultRetryPolicy()
+
+	for n := 1; n <= 10; n++ {
+		limit := min(DefaultRetryInitialBackoff<<(n-1), DefaultRetryMaxBackoff)
+
+		if d := policy.backoff(n); d < 0 || d > limit {
+			t.Errorf("Backoff %d = %v, want within [0, %v]", n, d, limit)
+		}
+	}
+}
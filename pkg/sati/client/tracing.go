@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tcncloud/sati-go/pkg/ports"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// JobSpanTimeout is how long a job's span waits for its final SubmitJobResults
+// call before it is ended as abandoned.
+const JobSpanTimeout = time.Hour
+
+const (
+	tracerName  = "github.com/tcncloud/sati-go/pkg/sati/client"
+	jobSpanName = "sati.job"
+)
+
+// WithTracerProvider traces every GateService call with otelgrpc and starts a
+// span for each streamed job that ends with the SubmitJobResults call that
+// sets EndOfTransmission. The job's span travels with it in ports.Job.Trace.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = tp
+	}
+}
+
+// tracingDialOptions returns the otelgrpc stats handler for tp.
+func tracingDialOptions(tp trace.TracerProvider) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler(
+			otelgrpc.WithTracerProvider(tp),
+			otelgrpc.WithPropagators(propagation.TraceContext{}),
+		)),
+	}
+}
+
+// jobSpans holds the spans of jobs that have been received but whose results
+// have not all been submitted. A nil *jobSpans only sets correlation IDs.
+type jobSpans struct {
+	tracer trace.Tracer
+	now    func() time.Time
+
+	mu    sync.Mutex
+	spans map[string]*jobSpan
+}
+
+type jobSpan struct {
+	span    trace.Span
+	started time.Time
+}
+
+func newJobSpans(tp trace.TracerProvider) *jobSpans {
+	if tp == nil {
+		return nil
+	}
+
+	return &jobSpans{
+		tracer: tp.Tracer(tracerName),
+		now:    time.Now,
+		spans:  make(map[string]*jobSpan),
+	}
+}
+
+// start begins job's span and records it in job.Trace.
+func (j *jobSpans) start(job *ports.Job) {
+	job.Trace = &ports.TraceContext{CorrelationID: job.JobID}
+
+	if j == nil {
+		return
+	}
+
+	j.sweep()
+
+	ctx, span := j.tracer.Start(context.Background(), jobSpanName,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("sati.job.id", job.JobID),
+			attribute.String("sati.job.type", job.Type),
+		),
+	)
+
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+
+	job.Trace.TraceParent = carrier.Get("traceparent")
+	job.Trace.TraceState = carrier.Get("tracestate")
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if previous, ok := j.spans[job.JobID]; ok {
+		previous.span.End()
+	}
+
+	j.spans[job.JobID] = &jobSpan{span: span, started: j.now()}
+}
+
+// context parents a SubmitJobResults call on the job's span unless ctx
+// already carries a span, e.g. one a plugin continued from job.Trace.
+func (j *jobSpans) context(ctx context.Context, jobID string) context.Context {
+	if j == nil || trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if s, ok := j.spans[jobID]; ok {
+		return trace.ContextWithSpan(ctx, s.span)
+	}
+
+	return ctx
+}
+
+// submitted records a SubmitJobResults call for jobID and ends the job's span
+// once the final results are in.
+func (j *jobSpans) submitted(jobID string, final bool, err error) {
+	if j == nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	s, ok := j.spans[jobID]
+	if !ok {
+		return
+	}
+
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(otelcodes.Error, err.Error())
+	}
+
+	if final {
+		s.span.End()
+		delete(j.spans, jobID)
+	}
+}
+
+// sweep ends the spans of jobs whose results were never submitted.
+func (j *jobSpans) sweep() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := j.now()
+
+	for id, s := range j.spans {
+		if now.Sub(s.started) >= JobSpanTimeout {
+			s.span.SetStatus(otelcodes.Error, "no results submitted within JobSpanTimeout")
+			s.span.End()
+			delete(j.spans, id)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	gatev2 "github.com/tcncloud/sati-go/internal/genproto/tcnapi/exile/gate/v2"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTracedTestClient() (*Client, *mockGateServiceClient, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	mockService := &mockGateServiceClient{}
+
+	return &Client{gate: mockService, jobs: newJobSpans(tp)}, mockService, recorder
+}
+
+func TestClient_JobSpan(t *testing.T) {
+	client, mockService, recorder := newTracedTestClient()
+	mockService.streamJobsStream = &mockStreamJobsClient{
+		respQueue: []*gatev2.StreamJobsResponse{{JobId: "job1"}},
+		err:       io.EOF,
+	}
+
+	result := <-client.StreamJobs(context.Background(), ports.StreamJobsParams{})
+	if result.Job == nil || result.Job.Trace == nil {
+		t.Fatalf("Expected a job with trace context, got %+v", result)
+	}
+
+	if result.Job.Trace.TraceParent == "" || result.Job.Trace.CorrelationID != "job1" {
+		t.Errorf("Expected a traceparent and correlation ID job1, got %+v", result.Job.Trace)
+	}
+
+	// A partial result leaves the span open
+	if _, err := client.SubmitJobResults(context.Background(), ports.SubmitJobResultsParams{JobID: "job1"}); err != nil {
+		t.Fatalf("SubmitJobResults failed: %v", err)
+	}
+
+	if n := len(recorder.Ended()); n != 0 {
+		t.Fatalf("Expected the job span to stay open, got %d ended spans", n)
+	}
+
+	ctx := client.jobs.context(context.Background(), "job1")
+	if got := trace.SpanContextFromContext(ctx).TraceID(); got.String() != result.Job.Trace.TraceParent[3:35] {
+		t.Errorf("Expected the submission to run in the job's trace, got %s", got)
+	}
+
+	mockService.submitJobResultsErr = errors.New("rejected")
+
+	_, _ = client.SubmitJobResults(context.Background(), ports.SubmitJobResultsParams{JobID: "job1", EndOfTransmission: true})
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != jobSpanName {
+		t.Fatalf("Expected the final submission to end the job span, got %v", spans)
+	}
+
+	if spans[0].Status().Description != "rejected" {
+		t.Errorf("Expected the submission error on the span, got %q", spans[0].Status().Description)
+	}
+}
+
+func TestJobSpans_Sweep(t *testing.T) {
+	client, _, recorder := newTracedTestClient()
+
+	now := time.Now()
+	client.jobs.now = func() time.Time { return now }
+
+	client.jobs.start(&ports.Job{JobID: "abandoned"})
+
+	now = now.Add(JobSpanTimeout)
+	client.jobs.start(&ports.Job{JobID: "next"})
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Status().Description == "" {
+		t.Fatalf("Expected the abandoned job's span to end with an error, got %v", spans)
+	}
+}
+
+func TestJobSpans_Untraced(t *testing.T) {
+	var jobs *jobSpans
+
+	job := &ports.Job{JobID: "job1"}
+	jobs.start(job)
+
+	if job.Trace == nil || job.Trace.CorrelationID != "job1" || job.Trace.TraceParent != "" {
+		t.Errorf("Expected only a correlation ID without tracing, got %+v", job.Trace)
+	}
+
+	jobs.submitted("job1", true, nil)
+}
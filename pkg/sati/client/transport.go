@@ -0,0 +1,166 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	saticonfig "github.com/tcncloud/sati-go/pkg/sati/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+)
+
+// Version is the connector version sent to the gate in the user agent and the
+// VersionHeader of every call. Release builds set it with
+// -ldflags "-X github.com/tcncloud/sati-go/pkg/sati/client.Version=v1.2.3".
+var Version = "dev"
+
+// VersionHeader is the metadata header that carries Version.
+const VersionHeader = "x-sati-version"
+
+// WithTransport replaces the transport settings read from the configuration's
+// transport section.
+func WithTransport(transport saticonfig.Transport) Option {
+	return func(o *options) {
+		o.transport = &transport
+	}
+}
+
+// userAgent returns the connector's user agent, after the configured one.
+func userAgent(t *saticonfig.Transport) string {
+	agent := "sati-go/" + Version
+	if t != nil && t.UserAgent != "" {
+		agent = t.UserAgent + " " + agent
+	}
+
+	return agent
+}
+
+// transportDialOptions applies the keepalive, compression, message size and
+// user agent settings of t.
+func transportDialOptions(t *saticonfig.Transport) []grpc.DialOption {
+	dialOpts := []grpc.DialOption{grpc.WithUserAgent(userAgent(t))}
+
+	if t == nil {
+		return dialOpts
+	}
+
+	if k := t.Keepalive; k != nil {
+		dialOpts = append(dialOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                time.Duration(k.Time),
+			Timeout:             time.Duration(k.Timeout),
+			PermitWithoutStream: k.PermitWithoutStream,
+		}))
+	}
+
+	var callOpts []grpc.CallOption
+
+	if t.Compression == saticonfig.CompressionGzip {
+		callOpts = append(callOpts, grpc.UseCompressor(gzip.Name))
+	}
+
+	if t.MaxSendMessageBytes > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(t.MaxSendMessageBytes))
+	}
+
+	if t.MaxRecvMessageBytes > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(t.MaxRecvMessageBytes))
+	}
+
+	if len(callOpts) > 0 {
+		dialOpts = append(dialOpts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+
+	return dialOpts
+}
+
+// outgoingContext bounds a call to method by its deadline, which only ever
+// shortens the caller's own, and attaches the version and configured headers.
+func outgoingContext(ctx context.Context, t *saticonfig.Transport, method string) (context.Context, context.CancelFunc) {
+	pairs := []string{VersionHeader, Version}
+	if t != nil {
+		for key, value := range t.Metadata {
+			pairs = append(pairs, key, value)
+		}
+	}
+
+	ctx = metadata.AppendToOutgoingContext(ctx, pairs...)
+
+	return context.WithTimeout(ctx, t.Deadline(rpcName(method)))
+}
+
+func unaryTransportInterceptor(t *saticonfig.Transport) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		ctx, cancel := outgoingContext(ctx, t, method)
+		defer cancel()
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// streamTransportInterceptor bounds the whole stream by its deadline.
+func streamTransportInterceptor(t *saticonfig.Transport) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		ctx, cancel := outgoingContext(ctx, t, method)
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			cancel()
+
+			return nil, err
+		}
+
+		return &deadlineStream{ClientStream: stream, cancel: cancel, serverStreams: desc.ServerStreams}, nil
+	}
+}
+
+// deadlineStream releases its deadline's timer once the stream has ended: when
+// RecvMsg fails, when the only reply of a stream without server streaming has
+// arrived, or when CloseSend fails. The caller's context ending releases it
+// too, as the deadline is derived from it. A successful CloseSend leaves it
+// running, since a server stream's replies still arrive after it.
+type deadlineStream struct {
+	grpc.ClientStream
+
+	cancel        context.CancelFunc
+	serverStreams bool
+	once          sync.Once
+}
+
+func (s *deadlineStream) release() {
+	s.once.Do(s.cancel)
+}
+
+func (s *deadlineStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil || !s.serverStreams {
+		s.release()
+	}
+
+	return err
+}
+
+func (s *deadlineStream) CloseSend() error {
+	err := s.ClientStream.CloseSend()
+	if err != nil {
+		s.release()
+	}
+
+	return err
+}
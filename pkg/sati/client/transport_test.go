@@ -0,0 +1,182 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	saticonfig "github.com/tcncloud/sati-go/pkg/sati/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const listAgentsMethod = "/tcnapi.exile.gate.v2.GateService/ListAgents"
+
+func TestUnaryTransportInterceptor_SetsDeadlineAndHeaders(t *testing.T) {
+	transport := &saticonfig.Transport{
+		Deadlines: &saticonfig.Deadlines{Methods: map[string]saticonfig.Duration{"ListAgents": saticonfig.Duration(time.Minute)}},
+		Metadata:  map[string]string{"x-site": "east"},
+	}
+
+	var (
+		deadline time.Time
+		md       metadata.MD
+	)
+
+	invoker := func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		deadline, _ = ctx.Deadline()
+		md, _ = metadata.FromOutgoingContext(ctx)
+
+		return nil
+	}
+
+	start := time.Now()
+
+	err := unaryTransportInterceptor(transport)(context.Background(), listAgentsMethod, nil, nil, nil, invoker)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if remaining := deadline.Sub(start); remaining < 59*time.Second || remaining > 61*time.Second {
+		t.Errorf("Expected a one minute deadline, got %v", remaining)
+	}
+
+	if got := md.Get(VersionHeader); len(got) != 1 || got[0] != Version {
+		t.Errorf("Expected the version header, got %v", got)
+	}
+
+	if got := md.Get("x-site"); len(got) != 1 || got[0] != "east" {
+		t.Errorf("Expected the configured header, got %v", got)
+	}
+}
+
+func TestUnaryTransportInterceptor_KeepsShorterDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	want, _ := ctx.Deadline()
+
+	invoker := func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		if got, _ := ctx.Deadline(); !got.Equal(want) {
+			t.Errorf("Expected the caller's deadline %v, got %v", want, got)
+		}
+
+		return nil
+	}
+
+	if err := unaryTransportInterceptor(nil)(ctx, listAgentsMethod, nil, nil, nil, invoker); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+type endedStream struct {
+	grpc.ClientStream
+}
+
+func (endedStream) RecvMsg(any) error {
+	return io.EOF
+}
+
+func TestStreamTransportInterceptor_CancelsWhenStreamEnds(t *testing.T) {
+	var streamCtx context.Context
+
+	streamer := func(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+		streamCtx = ctx
+
+		return endedStream{}, nil
+	}
+
+	stream, err := streamTransportInterceptor(nil)(context.Background(), &grpc.StreamDesc{}, nil, "/tcnapi.exile.gate.v2.GateService/StreamJobs", streamer)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, ok := streamCtx.Deadline(); !ok {
+		t.Fatal("Expected the stream to have a deadline")
+	}
+
+	if err := stream.RecvMsg(nil); !errors.Is(err, io.EOF) {
+		t.Fatalf("Expected io.EOF, got %v", err)
+	}
+
+	if !errors.Is(streamCtx.Err(), context.Canceled) {
+		t.Errorf("Expected the stream's context to be canceled, got %v", streamCtx.Err())
+	}
+}
+
+// stubStream answers RecvMsg with a reply and CloseSend with closeErr.
+type stubStream struct {
+	grpc.ClientStream
+
+	closeErr error
+}
+
+func (stubStream) RecvMsg(any) error { return nil }
+
+func (s stubStream) CloseSend() error { return s.closeErr }
+
+func TestStreamTransportInterceptor_ReleasesDeadline(t *testing.T) {
+	errClosed := errors.New("closed")
+
+	tests := []struct {
+		name     string
+		desc     grpc.StreamDesc
+		closeErr error
+		use      func(stream grpc.ClientStream, cancel context.CancelFunc)
+		released bool
+	}{
+		{"CloseSendOfServerStream", grpc.StreamDesc{ServerStreams: true}, nil, func(s grpc.ClientStream, _ context.CancelFunc) { _ = s.CloseSend() }, false},
+		{"FailedCloseSend", grpc.StreamDesc{ServerStreams: true}, errClosed, func(s grpc.ClientStream, _ context.CancelFunc) { _ = s.CloseSend() }, true},
+		{"ReplyOfServerStream", grpc.StreamDesc{ServerStreams: true}, nil, func(s grpc.ClientStream, _ context.CancelFunc) { _ = s.RecvMsg(nil) }, false},
+		{"OnlyReply", grpc.StreamDesc{ClientStreams: true}, nil, func(s grpc.ClientStream, _ context.CancelFunc) { _ = s.RecvMsg(nil) }, true},
+		{"CallerCanceled", grpc.StreamDesc{ServerStreams: true}, nil, func(_ grpc.ClientStream, cancel context.CancelFunc) { cancel() }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var streamCtx context.Context
+
+			streamer := func(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+				streamCtx = ctx
+
+				return stubStream{closeErr: tt.closeErr}, nil
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			stream, err := streamTransportInterceptor(nil)(ctx, &tt.desc, nil, "/tcnapi.exile.gate.v2.GateService/StreamJobs", streamer)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			tt.use(stream, cancel)
+
+			if released := errors.Is(streamCtx.Err(), context.Canceled); released != tt.released {
+				t.Errorf("Expected the deadline released to be %v, got %v", tt.released, released)
+			}
+		})
+	}
+}
+
+func TestTransportDialOptions(t *testing.T) {
+	if got := len(transportDialOptions(nil)); got != 1 {
+		t.Errorf("Expected only the user agent without transport settings, got %d options", got)
+	}
+
+	transport := &saticonfig.Transport{
+		Keepalive:           &saticonfig.Keepalive{Time: saticonfig.Duration(30 * time.Second)},
+		Compression:         saticonfig.CompressionGzip,
+		MaxRecvMessageBytes: 16 << 20,
+	}
+
+	if got := len(transportDialOptions(transport)); got != 3 {
+		t.Errorf("Expected user agent, keepalive and call options, got %d options", got)
+	}
+
+	if got := userAgent(&saticonfig.Transport{UserAgent: "acme/1.0"}); got != "acme/1.0 sati-go/"+Version {
+		t.Errorf("Unexpected user agent %q", got)
+	}
+}
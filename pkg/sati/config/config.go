@@ -24,7 +24,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/rs/zerolog/log"
@@ -45,8 +47,37 @@ var (
 	ErrInvalidJSON         = errors.New("invalid JSON format")
 	ErrEmptyConfig         = errors.New("empty configuration")
 	ErrRequiredField       = errors.New("required field is missing")
+	ErrInvalidRateLimit    = errors.New("rate limit must have a positive rps and a non-negative burst")
+	ErrInvalidTransport    = errors.New("invalid transport setting")
+	ErrInvalidDuration     = errors.New("duration must be a string such as \"30s\"")
 )
 
+// Config file replacement.
+const (
+	// ReplaceAttempts is how many times the watcher looks for a config file
+	// that was removed or renamed before it stops watching it.
+	ReplaceAttempts = 20
+	// ReplaceRetryInterval is the wait between those attempts.
+	ReplaceRetryInterval = 50 * time.Millisecond
+)
+
+// Transport defaults.
+const (
+	// CompressionGzip compresses every request and asks for compressed responses.
+	CompressionGzip = "gzip"
+	// DefaultDeadline bounds each gate call whose method has no deadline of its own.
+	DefaultDeadline = 10 * time.Second
+)
+
+// DefaultMethodDeadlines are the deadlines of the gate methods that return
+// large results or hold a stream open.
+var DefaultMethodDeadlines = map[string]time.Duration{
+	"ListAgents":            30 * time.Second,
+	"PollEvents":            30 * time.Second,
+	"SearchVoiceRecordings": 30 * time.Second,
+	"StreamJobs":            30 * time.Second,
+}
+
 // Config represents the application configuration structure.
 type Config struct {
 	CACertificate           string `json:"ca_certificate"`
@@ -57,11 +88,186 @@ type Config struct {
 	APIEndpoint             string `json:"api_endpoint"`
 	CertificateName         string `json:"certificate_name"`
 	CertificateDescription  string `json:"certificate_description"`
+
+	// APIEndpoints are further gate endpoints. Calls are balanced over, or
+	// fail over between, APIEndpoint and these as LoadBalancing says.
+	APIEndpoints []string `json:"api_endpoints,omitempty"`
+	// LoadBalancing chooses how calls are spread over the endpoints. Like
+	// RateLimits it is added by hand when needed.
+	LoadBalancing *LoadBalancing `json:"load_balancing,omitempty"`
+
+	// ServerName overrides the name the gate's certificate is checked
+	// against, which is otherwise the host of each endpoint.
+	ServerName string `json:"server_name,omitempty"`
+	// ServerPins are base64 SHA-256 hashes of public keys, optionally
+	// prefixed with ServerPinPrefix. When set, the gate's certificate chain
+	// must contain one of them.
+	ServerPins []string `json:"server_pins,omitempty"`
+
+	// RateLimits throttles calls to the gate. It is not part of the issued
+	// configuration and is added by hand when needed.
+	RateLimits *RateLimits `json:"rate_limits,omitempty"`
+
+	// Transport tunes the connection to the gate. Like RateLimits it is added
+	// by hand when needed.
+	Transport *Transport `json:"transport,omitempty"`
+}
+
+// RateLimits caps the rate of calls to the gate, across all methods and per
+// bare GateService method name, e.g. "UpsertAgent".
+type RateLimits struct {
+	Global  *RateLimit           `json:"global,omitempty"`
+	Methods map[string]RateLimit `json:"methods,omitempty"`
+}
+
+// RateLimit is a token bucket refilled at RPS calls per second that holds up
+// to Burst calls. A zero Burst allows bursts of one second's worth of calls.
+type RateLimit struct {
+	RPS   float64 `json:"rps"`
+	Burst int     `json:"burst,omitempty"`
+}
+
+// Validate checks that the limit can be enforced.
+func (l RateLimit) Validate() error {
+	if l.RPS <= 0 || l.Burst < 0 {
+		return ErrInvalidRateLimit
+	}
+
+	return nil
+}
+
+// Validate checks every limit.
+func (l *RateLimits) Validate() error {
+	if l.Global != nil {
+		if err := l.Global.Validate(); err != nil {
+			return fmt.Errorf("global: %w", err)
+		}
+	}
+
+	for method, limit := range l.Methods {
+		if err := limit.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", method, err)
+		}
+	}
+
+	return nil
+}
+
+// Duration is a time.Duration written in JSON as a string such as "30s".
+type Duration time.Duration
+
+// MarshalJSON writes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON reads a duration string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return ErrInvalidDuration
+	}
+
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidDuration, raw)
+	}
+
+	*d = Duration(parsed)
+
+	return nil
+}
+
+// Transport tunes the gRPC connection to the gate.
+type Transport struct {
+	Keepalive *Keepalive `json:"keepalive,omitempty"`
+	// Compression is CompressionGzip or empty for none.
+	Compression string `json:"compression,omitempty"`
+	// MaxSendMessageBytes and MaxRecvMessageBytes raise or lower gRPC's
+	// message size limits. Zero keeps gRPC's defaults, 4 MiB for received
+	// messages, which large record results can exceed.
+	MaxSendMessageBytes int        `json:"max_send_message_bytes,omitempty"`
+	MaxRecvMessageBytes int        `json:"max_recv_message_bytes,omitempty"`
+	Deadlines           *Deadlines `json:"deadlines,omitempty"`
+	// UserAgent is put in front of the connector's own user agent.
+	UserAgent string `json:"user_agent,omitempty"`
+	// Metadata is sent as headers with every call.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Keepalive pings the gate after Time without activity and closes the
+// connection if the ping is not answered within Timeout.
+type Keepalive struct {
+	Time                Duration `json:"time"`
+	Timeout             Duration `json:"timeout,omitempty"`
+	PermitWithoutStream bool     `json:"permit_without_stream,omitempty"`
+}
+
+// Deadlines bound each gate call. Methods is keyed by bare GateService method
+// name, e.g. "ListAgents"; Default applies to every other method.
+type Deadlines struct {
+	Default Duration            `json:"default,omitempty"`
+	Methods map[string]Duration `json:"methods,omitempty"`
+}
+
+// Deadline returns the deadline of a call to method: its own deadline, then
+// the configured default, then DefaultMethodDeadlines, then DefaultDeadline.
+func (t *Transport) Deadline(method string) time.Duration {
+	if t != nil && t.Deadlines != nil {
+		if d, ok := t.Deadlines.Methods[method]; ok {
+			return time.Duration(d)
+		}
+
+		if t.Deadlines.Default > 0 {
+			return time.Duration(t.Deadlines.Default)
+		}
+	}
+
+	if d, ok := DefaultMethodDeadlines[method]; ok {
+		return d
+	}
+
+	return DefaultDeadline
+}
+
+// Validate checks that every setting can be applied.
+func (t *Transport) Validate() error {
+	if k := t.Keepalive; k != nil && (k.Time <= 0 || k.Timeout < 0) {
+		return fmt.Errorf("%w: keepalive needs a positive time and a non-negative timeout", ErrInvalidTransport)
+	}
+
+	if t.Compression != "" && t.Compression != CompressionGzip {
+		return fmt.Errorf("%w: unknown compression %q", ErrInvalidTransport, t.Compression)
+	}
+
+	if t.MaxSendMessageBytes < 0 || t.MaxRecvMessageBytes < 0 {
+		return fmt.Errorf("%w: message sizes must not be negative", ErrInvalidTransport)
+	}
+
+	if t.Deadlines != nil {
+		if t.Deadlines.Default < 0 {
+			return fmt.Errorf("%w: deadlines.default must not be negative", ErrInvalidTransport)
+		}
+
+		for method, d := range t.Deadlines.Methods {
+			if d <= 0 {
+				return fmt.Errorf("%w: deadline of %s must be positive", ErrInvalidTransport, method)
+			}
+		}
+	}
+
+	for key := range t.Metadata {
+		if key == "" || strings.HasPrefix(strings.ToLower(key), "grpc-") {
+			return fmt.Errorf("%w: metadata key %q", ErrInvalidTransport, key)
+		}
+	}
+
+	return nil
 }
 
 // Validate checks if the configuration has all required fields.
 func (c *Config) Validate() error {
-	if c.APIEndpoint == "" {
+	if len(c.Endpoints()) == 0 {
 		return fmt.Errorf("%w: api_endpoint", ErrRequiredField)
 	}
 	if c.CACertificate == "" {
@@ -73,6 +279,29 @@ func (c *Config) Validate() error {
 	if c.PrivateKey == "" {
 		return fmt.Errorf("%w: private_key", ErrRequiredField)
 	}
+	if err := c.VerifyFingerprint(); err != nil {
+		return err
+	}
+	for _, pin := range c.ServerPins {
+		if _, err := ParseServerPin(pin); err != nil {
+			return fmt.Errorf("server_pins: %w", err)
+		}
+	}
+	if c.LoadBalancing != nil {
+		if err := c.LoadBalancing.Validate(); err != nil {
+			return fmt.Errorf("load_balancing: %w", err)
+		}
+	}
+	if c.RateLimits != nil {
+		if err := c.RateLimits.Validate(); err != nil {
+			return fmt.Errorf("rate_limits: %w", err)
+		}
+	}
+	if c.Transport != nil {
+		if err := c.Transport.Validate(); err != nil {
+			return fmt.Errorf("transport: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -212,10 +441,13 @@ func (cw *ConfigWatcher) watchLoop() {
 			if !ok {
 				return
 			}
-			if event.Op&fsnotify.Write == fsnotify.Write {
+			switch {
+			case event.Op&fsnotify.Write == fsnotify.Write:
 				if err := cw.loader(event.Name); err != nil {
 					log.Error().Err(err).Str("path", event.Name).Msg("Error in config loader")
 				}
+			case event.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+				cw.rewatch(event.Name)
 			}
 		case err, ok := <-cw.watcher.Errors:
 			if !ok {
@@ -226,6 +458,29 @@ func (cw *ConfigWatcher) watchLoop() {
 	}
 }
 
+// rewatch follows a config file that was replaced rather than written, as
+// editors, atomic writers and mounted secrets do, which ends the watch on it.
+// It watches the new file and loads it.
+func (cw *ConfigWatcher) rewatch(path string) {
+	for range ReplaceAttempts {
+		if err := cw.watcher.Add(path); err == nil {
+			if err := cw.loader(path); err != nil {
+				log.Error().Err(err).Str("path", path).Msg("Error in config loader")
+			}
+
+			return
+		}
+
+		select {
+		case <-cw.ctx.Done():
+			return
+		case <-time.After(ReplaceRetryInterval):
+		}
+	}
+
+	log.Error().Str("path", path).Msg("Config file was removed and not replaced, no longer watching it")
+}
+
 // Stop stops the watcher and cleans up resources.
 func (cw *ConfigWatcher) Stop() error {
 	cw.mu.Lock()
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -507,6 +510,54 @@ func TestWatchConfig(t *testing.T) {
 	StopWatching()
 }
 
+func TestConfigWatcher_FollowsReplacedFile(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "replaced.cfg")
+
+	if err := os.WriteFile(configPath, []byte(validBase64JSON), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	loaded := make(chan string, 10)
+
+	watcher, err := NewConfigWatcher([]string{configPath}, func(path string) error {
+		loaded <- path
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("NewConfigWatcher failed: %v", err)
+	}
+	defer watcher.Stop()
+
+	if err := watcher.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	<-loaded // startup load
+
+	// Replace the file the way atomic writers do, twice, to check that the
+	// watch follows the new file each time.
+	for i := range 2 {
+		staged := filepath.Join(tempDir, fmt.Sprintf("staged-%d.cfg", i))
+		if err := os.WriteFile(staged, []byte(validBase64JSON), 0644); err != nil {
+			t.Fatalf("Failed to stage config file: %v", err)
+		}
+
+		if err := os.Rename(staged, configPath); err != nil {
+			t.Fatalf("Failed to replace config file: %v", err)
+		}
+
+		select {
+		case path := <-loaded:
+			if path != configPath {
+				t.Errorf("Expected %s to be loaded, got %s", configPath, path)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected loader to be called after replacement %d", i+1)
+		}
+	}
+}
+
 func TestConfigValidation(t *testing.T) {
 	t.Run("ValidConfig", func(t *testing.T) {
 		config := &Config{
@@ -547,6 +598,118 @@ func TestConfigValidation(t *testing.T) {
 			t.Error("Expected validation error for missing CA certificate")
 		}
 	})
+
+	t.Run("InvalidRateLimit", func(t *testing.T) {
+		config := &Config{
+			APIEndpoint:   "test.com",
+			CACertificate: "test_ca",
+			Certificate:   "test_cert",
+			PrivateKey:    "test_key",
+			RateLimits:    &RateLimits{Methods: map[string]RateLimit{"UpsertAgent": {RPS: 0}}},
+		}
+
+		err := config.Validate()
+		if !errors.Is(err, ErrInvalidRateLimit) {
+			t.Errorf("Expected ErrInvalidRateLimit, got: %v", err)
+		}
+	})
+}
+
+func TestNewConfigFromString_RateLimits(t *testing.T) {
+	raw := `{"api_endpoint":"test.com","rate_limits":{"global":{"rps":50,"burst":100},"methods":{"UpsertAgent":{"rps":5}}}}`
+
+	config, err := NewConfigFromString(base64.StdEncoding.EncodeToString([]byte(raw)))
+	if err != nil {
+		t.Fatalf("NewConfigFromString failed: %v", err)
+	}
+
+	if config.RateLimits == nil || config.RateLimits.Global.RPS != 50 || config.RateLimits.Global.Burst != 100 {
+		t.Fatalf("Unexpected global rate limit: %+v", config.RateLimits)
+	}
+
+	if limit := config.RateLimits.Methods["UpsertAgent"]; limit.RPS != 5 || limit.Burst != 0 {
+		t.Errorf("Unexpected UpsertAgent rate limit: %+v", limit)
+	}
+}
+
+func TestNewConfigFromString_Transport(t *testing.T) {
+	raw := `{"api_endpoint":"test.com","transport":{"keepalive":{"time":"30s","timeout":"5s"},"compression":"gzip",` +
+		`"max_recv_message_bytes":16777216,"deadlines":{"default":"15s","methods":{"ListAgents":"2m"}},` +
+		`"user_agent":"acme-connector/1.0","metadata":{"x-site":"east"}}}`
+
+	config, err := NewConfigFromString(base64.StdEncoding.EncodeToString([]byte(raw)))
+	if err != nil {
+		t.Fatalf("NewConfigFromString failed: %v", err)
+	}
+
+	transport := config.Transport
+	if transport == nil || transport.Keepalive == nil || time.Duration(transport.Keepalive.Time) != 30*time.Second {
+		t.Fatalf("Unexpected transport: %+v", transport)
+	}
+
+	if transport.Compression != CompressionGzip || transport.MaxRecvMessageBytes != 16<<20 {
+		t.Errorf("Unexpected compression or message size: %+v", transport)
+	}
+
+	if transport.Metadata["x-site"] != "east" || transport.UserAgent != "acme-connector/1.0" {
+		t.Errorf("Unexpected headers: %+v", transport)
+	}
+
+	if err := transport.Validate(); err != nil {
+		t.Errorf("Expected a valid transport, got: %v", err)
+	}
+}
+
+func TestNewConfigFromString_InvalidDuration(t *testing.T) {
+	raw := `{"api_endpoint":"test.com","transport":{"deadlines":{"default":30}}}`
+
+	_, err := NewConfigFromString(base64.StdEncoding.EncodeToString([]byte(raw)))
+	if !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("Expected ErrInvalidJSON, got: %v", err)
+	}
+}
+
+func TestTransport_Deadline(t *testing.T) {
+	var unset *Transport
+
+	if d := unset.Deadline("GetAgentById"); d != DefaultDeadline {
+		t.Errorf("Expected DefaultDeadline, got %v", d)
+	}
+
+	if d := unset.Deadline("StreamJobs"); d != DefaultMethodDeadlines["StreamJobs"] {
+		t.Errorf("Expected the built-in StreamJobs deadline, got %v", d)
+	}
+
+	transport := &Transport{Deadlines: &Deadlines{
+		Default: Duration(5 * time.Second),
+		Methods: map[string]Duration{"ListAgents": Duration(time.Minute)},
+	}}
+
+	if d := transport.Deadline("ListAgents"); d != time.Minute {
+		t.Errorf("Expected the configured ListAgents deadline, got %v", d)
+	}
+
+	if d := transport.Deadline("StreamJobs"); d != 5*time.Second {
+		t.Errorf("Expected the configured default, got %v", d)
+	}
+}
+
+func TestTransport_Validate(t *testing.T) {
+	tests := map[string]Transport{
+		"keepalive":    {Keepalive: &Keepalive{}},
+		"compression":  {Compression: "snappy"},
+		"message size": {MaxSendMessageBytes: -1},
+		"deadline":     {Deadlines: &Deadlines{Methods: map[string]Duration{"ListAgents": 0}}},
+		"metadata":     {Metadata: map[string]string{"grpc-timeout": "1S"}},
+	}
+
+	for name, transport := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := transport.Validate(); !errors.Is(err, ErrInvalidTransport) {
+				t.Errorf("Expected ErrInvalidTransport, got: %v", err)
+			}
+		})
+	}
 }
 
 func TestLoadAndValidateConfig(t *testing.T) {
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidLoadBalancing is returned for a load_balancing section the client cannot apply.
+var ErrInvalidLoadBalancing = errors.New("invalid load balancing setting")
+
+// Load balancing policies.
+const (
+	// PolicyPickFirst sends every call to the first endpoint address that
+	// connects and moves to the next one when it fails. It is the default.
+	PolicyPickFirst = "pick_first"
+	// PolicyRoundRobin spreads calls over every endpoint address that is up.
+	PolicyRoundRobin = "round_robin"
+)
+
+// LoadBalancing chooses how calls are spread over the gate's endpoints, and
+// the addresses a DNS name resolves to.
+type LoadBalancing struct {
+	// Policy is PolicyPickFirst or PolicyRoundRobin. Empty means PolicyPickFirst.
+	Policy string `json:"policy,omitempty"`
+	// HealthCheck asks every address for its gRPC health status and sends no
+	// calls to the ones that are not serving. It needs PolicyRoundRobin.
+	HealthCheck bool `json:"health_check,omitempty"`
+	// HealthCheckService is the service name sent in health checks. Empty asks
+	// for the health of the gate as a whole.
+	HealthCheckService string `json:"health_check_service,omitempty"`
+}
+
+// Validate checks that the policy is known and supports health checks when
+// they are enabled.
+func (l *LoadBalancing) Validate() error {
+	switch l.Policy {
+	case "", PolicyPickFirst:
+		if l.HealthCheck {
+			return fmt.Errorf("%w: health_check needs the %s policy", ErrInvalidLoadBalancing, PolicyRoundRobin)
+		}
+	case PolicyRoundRobin:
+	default:
+		return fmt.Errorf("%w: unknown policy %q", ErrInvalidLoadBalancing, l.Policy)
+	}
+
+	return nil
+}
+
+// Endpoints returns APIEndpoint followed by APIEndpoints, without empty or
+// repeated entries.
+func (c *Config) Endpoints() []string {
+	endpoints := make([]string, 0, 1+len(c.APIEndpoints))
+	seen := make(map[string]bool, cap(endpoints))
+
+	for _, endpoint := range append([]string{c.APIEndpoint}, c.APIEndpoints...) {
+		if endpoint == "" || seen[endpoint] {
+			continue
+		}
+
+		seen[endpoint] = true
+		endpoints = append(endpoints, endpoint)
+	}
+
+	return endpoints
+}
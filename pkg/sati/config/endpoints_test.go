@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestConfig_Endpoints(t *testing.T) {
+	config := &Config{
+		APIEndpoint:  "gate-a.example.com:443",
+		APIEndpoints: []string{"gate-b.example.com:443", "", "gate-a.example.com:443"},
+	}
+
+	want := []string{"gate-a.example.com:443", "gate-b.example.com:443"}
+	if got := config.Endpoints(); !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestConfigValidation_Endpoints(t *testing.T) {
+	config := &Config{
+		APIEndpoints:  []string{"gate-b.example.com:443"},
+		CACertificate: "test_ca",
+		Certificate:   "test_cert",
+		PrivateKey:    "test_key",
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected api_endpoints to stand in for api_endpoint, got: %v", err)
+	}
+
+	config.APIEndpoints = nil
+	if err := config.Validate(); !errors.Is(err, ErrRequiredField) {
+		t.Errorf("Expected ErrRequiredField without any endpoint, got: %v", err)
+	}
+}
+
+func TestLoadBalancing_Validate(t *testing.T) {
+	valid := []LoadBalancing{
+		{},
+		{Policy: PolicyPickFirst},
+		{Policy: PolicyRoundRobin, HealthCheck: true, HealthCheckService: "gate"},
+	}
+
+	for _, lb := range valid {
+		if err := lb.Validate(); err != nil {
+			t.Errorf("Expected %+v to be valid, got: %v", lb, err)
+		}
+	}
+
+	invalid := []LoadBalancing{
+		{Policy: "least_request"},
+		{HealthCheck: true},
+	}
+
+	for _, lb := range invalid {
+		if err := lb.Validate(); !errors.Is(err, ErrInvalidLoadBalancing) {
+			t.Errorf("Expected ErrInvalidLoadBalancing for %+v, got: %v", lb, err)
+		}
+	}
+}
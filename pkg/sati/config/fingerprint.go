@@ -0,0 +1,102 @@
+package config
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Error constants for certificate fingerprints and pins.
+var (
+	ErrFingerprintMismatch = errors.New("client certificate does not match the configured fingerprint")
+	ErrInvalidServerPin    = errors.New("server pin must be a base64 SHA-256 hash of a public key")
+)
+
+// ServerPinPrefix may precede a server pin, as in "sha256/<base64 hash>".
+const ServerPinPrefix = "sha256/"
+
+// Fingerprints returns the SHA-256 fingerprint of a DER certificate as
+// lowercase hex, the fingerprint_sha256 form, and as colon separated
+// uppercase hex pairs, the fingerprint_sha256_string form.
+func Fingerprints(der []byte) (string, string) {
+	sum := sha256.Sum256(der)
+
+	pairs := make([]string, len(sum))
+	for i, b := range sum {
+		pairs[i] = fmt.Sprintf("%02X", b)
+	}
+
+	return hex.EncodeToString(sum[:]), strings.Join(pairs, ":")
+}
+
+// VerifyFingerprint checks that the client certificate matches
+// fingerprint_sha256 and fingerprint_sha256_string, when they are set. Either
+// may be written as hex, with or without colons, or as base64.
+func (c *Config) VerifyFingerprint() error {
+	if c.FingerprintSHA256 == "" && c.FingerprintSHA256String == "" {
+		return nil
+	}
+
+	block, _ := pem.Decode([]byte(c.Certificate))
+	if block == nil {
+		return fmt.Errorf("%w: certificate is not PEM", ErrFingerprintMismatch)
+	}
+
+	actual, _ := Fingerprints(block.Bytes)
+
+	for field, stated := range map[string]string{
+		"fingerprint_sha256":        c.FingerprintSHA256,
+		"fingerprint_sha256_string": c.FingerprintSHA256String,
+	} {
+		if stated == "" {
+			continue
+		}
+
+		if normalizeFingerprint(stated) != actual {
+			return fmt.Errorf("%w: %s is %s, certificate is %s", ErrFingerprintMismatch, field, stated, actual)
+		}
+	}
+
+	return nil
+}
+
+// normalizeFingerprint returns a SHA-256 fingerprint as lowercase hex, or the
+// input unchanged when it is not a fingerprint.
+func normalizeFingerprint(fingerprint string) string {
+	trimmed := strings.NewReplacer(":", "", " ", "").Replace(strings.TrimSpace(fingerprint))
+
+	if decoded, err := hex.DecodeString(trimmed); err == nil && len(decoded) == sha256.Size {
+		return strings.ToLower(trimmed)
+	}
+
+	if decoded, err := base64.StdEncoding.DecodeString(trimmed); err == nil && len(decoded) == sha256.Size {
+		return hex.EncodeToString(decoded)
+	}
+
+	return fingerprint
+}
+
+// SPKIHash returns the server pin of a certificate: the base64 SHA-256 hash of
+// its public key.
+func SPKIHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ParseServerPin returns the base64 hash of a server pin, without ServerPinPrefix.
+func ParseServerPin(pin string) (string, error) {
+	hash := strings.TrimPrefix(strings.TrimSpace(pin), ServerPinPrefix)
+
+	decoded, err := base64.StdEncoding.DecodeString(hash)
+	if err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("%w: %q", ErrInvalidServerPin, pin)
+	}
+
+	return hash, nil
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// selfSignedCertificate returns a throwaway PEM certificate and its parsed form.
+func selfSignedCertificate(t *testing.T) (string, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sati-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	cert, _ := x509.ParseCertificate(der)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), cert
+}
+
+func TestConfig_VerifyFingerprint(t *testing.T) {
+	certificate, cert := selfSignedCertificate(t)
+	hexFingerprint, colonFingerprint := Fingerprints(cert.Raw)
+	sum := sha256.Sum256(cert.Raw)
+
+	matching := map[string]*Config{
+		"none":       {},
+		"hex":        {FingerprintSHA256: hexFingerprint},
+		"upper hex":  {FingerprintSHA256: strings.ToUpper(hexFingerprint)},
+		"colon hex":  {FingerprintSHA256String: colonFingerprint},
+		"both":       {FingerprintSHA256: hexFingerprint, FingerprintSHA256String: colonFingerprint},
+		"lower pair": {FingerprintSHA256String: strings.ToLower(colonFingerprint)},
+		"base64":     {FingerprintSHA256: base64.StdEncoding.EncodeToString(sum[:])},
+	}
+
+	for name, cfg := range matching {
+		t.Run(name, func(t *testing.T) {
+			cfg.Certificate = certificate
+			if err := cfg.VerifyFingerprint(); err != nil {
+				t.Errorf("Expected the fingerprint to match, got: %v", err)
+			}
+		})
+	}
+
+	mismatched := map[string]*Config{
+		"other hex":    {FingerprintSHA256: strings.Repeat("ab", sha256.Size)},
+		"other string": {FingerprintSHA256: hexFingerprint, FingerprintSHA256String: strings.Repeat("AB:", sha256.Size-1) + "AB"},
+		"garbage":      {FingerprintSHA256: "not a fingerprint"},
+	}
+
+	for name, cfg := range mismatched {
+		t.Run(name, func(t *testing.T) {
+			cfg.Certificate = certificate
+			if err := cfg.VerifyFingerprint(); !errors.Is(err, ErrFingerprintMismatch) {
+				t.Errorf("Expected ErrFingerprintMismatch, got: %v", err)
+			}
+		})
+	}
+
+	t.Run("not PEM", func(t *testing.T) {
+		cfg := &Config{Certificate: "test_cert", FingerprintSHA256: hexFingerprint}
+		if err := cfg.VerifyFingerprint(); !errors.Is(err, ErrFingerprintMismatch) {
+			t.Errorf("Expected ErrFingerprintMismatch, got: %v", err)
+		}
+	})
+}
+
+func TestParseServerPin(t *testing.T) {
+	_, cert := selfSignedCertificate(t)
+	hash := SPKIHash(cert)
+
+	for _, pin := range []string{hash, ServerPinPrefix + hash, " " + hash + " "} {
+		got, err := ParseServerPin(pin)
+		if err != nil || got != hash {
+			t.Errorf("ParseServerPin(%q) = %q, %v, want %q", pin, got, err, hash)
+		}
+	}
+
+	for _, pin := range []string{"", "sha256/", "not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := ParseServerPin(pin); !errors.Is(err, ErrInvalidServerPin) {
+			t.Errorf("ParseServerPin(%q): expected ErrInvalidServerPin, got %v", pin, err)
+		}
+	}
+}
+
+func TestConfigValidation_ServerPins(t *testing.T) {
+	config := &Config{
+		APIEndpoint:   "test.com",
+		CACertificate: "test_ca",
+		Certificate:   "test_cert",
+		PrivateKey:    "test_key",
+		ServerPins:    []string{"sha256/nope"},
+	}
+
+	if err := config.Validate(); !errors.Is(err, ErrInvalidServerPin) {
+		t.Errorf("Expected ErrInvalidServerPin, got: %v", err)
+	}
+}
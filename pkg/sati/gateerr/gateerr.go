@@ -0,0 +1,268 @@
+// Package gateerr classifies the errors returned by the gate client. Every
+// error a gate call fails with is an *Error whose Kind is one of the sentinels
+// below, so callers can branch with errors.Is and read details with errors.As.
+package gateerr
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error kinds.
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrAlreadyExists      = errors.New("already exists")
+	ErrPermissionDenied   = errors.New("permission denied")
+	ErrInvalidArgument    = errors.New("invalid argument")
+	ErrFailedPrecondition = errors.New("failed precondition")
+	ErrResourceExhausted  = errors.New("resource exhausted")
+	ErrUnavailable        = errors.New("unavailable")
+	ErrDeadlineExceeded   = errors.New("deadline exceeded")
+	ErrUnauthenticated    = errors.New("unauthenticated")
+	// ErrCertificateRejected is the kind of errors caused by the gate refusing
+	// the client certificate. It wraps ErrUnauthenticated.
+	ErrCertificateRejected = fmt.Errorf("%w: certificate rejected", ErrUnauthenticated)
+	// ErrServerCertificateUntrusted is the kind of errors caused by the client
+	// failing to verify the gate's certificate: it is not issued by a trusted
+	// CA, has expired, or does not name the gate's host.
+	ErrServerCertificateUntrusted = errors.New("gate certificate not trusted")
+	// ErrServerPinMismatch is the kind of errors caused by the client refusing
+	// a gate certificate chain that contains none of the configured server
+	// pins, as an interception proxy's would.
+	ErrServerPinMismatch = errors.New("gate certificate matches no server pin")
+	// ErrInternal is the kind of every other gate failure.
+	ErrInternal = errors.New("gate error")
+)
+
+// FieldViolation describes one invalid field of a request.
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// Error is a classified gate error.
+type Error struct {
+	// Kind is one of the Err* sentinels, or context.Canceled.
+	Kind error
+	// Code is the gRPC status code the gate answered with, or codes.Unknown
+	// for errors raised by the client.
+	Code codes.Code
+	// Method is the bare gate method name, e.g. "GetAgentById".
+	Method     string
+	Message    string
+	Violations []FieldViolation
+
+	cause error
+}
+
+// New returns an error of kind raised by the client itself.
+func New(kind error, message string) *Error {
+	return &Error{Kind: kind, Code: codes.Unknown, Message: message}
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+
+	if e.Method != "" {
+		b.WriteString(e.Method)
+		b.WriteString(": ")
+	}
+
+	b.WriteString(e.Kind.Error())
+
+	if e.Message != "" {
+		b.WriteString(": ")
+		b.WriteString(e.Message)
+	}
+
+	for _, v := range e.Violations {
+		fmt.Fprintf(&b, "; %s: %s", v.Field, v.Description)
+	}
+
+	return b.String()
+}
+
+// Unwrap returns the kind and the original error, so that errors.Is matches
+// the kind and status.FromError still finds the gate's status.
+func (e *Error) Unwrap() []error {
+	if e.cause == nil {
+		return []error{e.Kind}
+	}
+
+	return []error{e.Kind, e.cause}
+}
+
+// FromError classifies err, returned by a call to method. Errors that are
+// already classified, and errors that are neither gRPC statuses nor context
+// errors, such as io.EOF or argument checks, are returned unchanged.
+func FromError(method string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var classified *Error
+	if errors.As(err, &classified) {
+		return err
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return &Error{Kind: ErrDeadlineExceeded, Code: codes.DeadlineExceeded, Method: method, cause: err}
+		case errors.Is(err, context.Canceled):
+			return &Error{Kind: context.Canceled, Code: codes.Canceled, Method: method, cause: err}
+		default:
+			return err
+		}
+	}
+
+	return &Error{
+		Kind:       kindOf(st),
+		Code:       st.Code(),
+		Method:     method,
+		Message:    st.Message(),
+		Violations: violations(st),
+		cause:      err,
+	}
+}
+
+func kindOf(st *status.Status) error {
+	// A failed handshake reaches us as a status message only, so the client
+	// names its kind in the message with WrapTLSError.
+	for _, kind := range []error{ErrServerPinMismatch, ErrServerCertificateUntrusted, ErrCertificateRejected} {
+		if strings.Contains(st.Message(), kind.Error()) {
+			return kind
+		}
+	}
+
+	switch st.Code() {
+	case codes.NotFound:
+		return ErrNotFound
+	case codes.AlreadyExists:
+		return ErrAlreadyExists
+	case codes.PermissionDenied:
+		return ErrPermissionDenied
+	case codes.InvalidArgument, codes.OutOfRange:
+		return ErrInvalidArgument
+	case codes.FailedPrecondition:
+		return ErrFailedPrecondition
+	case codes.ResourceExhausted:
+		return ErrResourceExhausted
+	case codes.Unavailable:
+		return ErrUnavailable
+	case codes.DeadlineExceeded:
+		return ErrDeadlineExceeded
+	case codes.Unauthenticated:
+		if certificateProblem(st.Message()) {
+			return ErrCertificateRejected
+		}
+
+		return ErrUnauthenticated
+	case codes.Canceled:
+		return context.Canceled
+	default:
+		return ErrInternal
+	}
+}
+
+// certificateProblem reports whether an Unauthenticated status message from
+// the gate is about the client certificate.
+func certificateProblem(message string) bool {
+	message = strings.ToLower(message)
+
+	for _, marker := range []string{"tls:", "x509:", "certificate"} {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// certificateAlerts are the TLS alerts a server sends when it refuses the
+// client certificate.
+var certificateAlerts = map[tls.AlertError]bool{
+	42:  true, // bad_certificate
+	43:  true, // unsupported_certificate
+	44:  true, // certificate_revoked
+	45:  true, // certificate_expired
+	46:  true, // certificate_unknown
+	48:  true, // unknown_ca
+	116: true, // certificate_required
+}
+
+// WrapTLSError wraps err, returned by a TLS handshake with the gate or a read
+// from the connection, in ErrServerCertificateUntrusted when the client could
+// not verify the gate's certificate, or in ErrCertificateRejected when the
+// gate sent an alert refusing the client certificate. gRPC keeps only the
+// message of a connection error, which FromError then classifies. Other
+// errors are returned unchanged.
+func WrapTLSError(err error) error {
+	if err == nil || errors.Is(err, ErrServerPinMismatch) ||
+		errors.Is(err, ErrServerCertificateUntrusted) || errors.Is(err, ErrCertificateRejected) {
+		return err
+	}
+
+	var (
+		verification *tls.CertificateVerificationError
+		authority    x509.UnknownAuthorityError
+		hostname     x509.HostnameError
+		invalid      x509.CertificateInvalidError
+	)
+
+	switch {
+	case errors.As(err, &verification), errors.As(err, &authority), errors.As(err, &hostname), errors.As(err, &invalid):
+		return fmt.Errorf("%w: %w", ErrServerCertificateUntrusted, err)
+	case certificateAlert(err):
+		return fmt.Errorf("%w: %w", ErrCertificateRejected, err)
+	default:
+		return err
+	}
+}
+
+// certificateAlert reports whether err carries one of certificateAlerts.
+// crypto/tls reports an alert received from the peer as a "remote error"
+// *net.OpError around an unexported alert type, which shares AlertError's text.
+func certificateAlert(err error) bool {
+	var alert tls.AlertError
+	if errors.As(err, &alert) {
+		return certificateAlerts[alert]
+	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) || opErr.Op != "remote error" || opErr.Err == nil {
+		return false
+	}
+
+	for alert := range certificateAlerts {
+		if opErr.Err.Error() == alert.Error() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func violations(st *status.Status) []FieldViolation {
+	var out []FieldViolation
+
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range badRequest.GetFieldViolations() {
+				out = append(out, FieldViolation{Field: v.GetField(), Description: v.GetDescription()})
+			}
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,144 @@
+package gateerr
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFromError_Kinds(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"NotFound", status.Error(codes.NotFound, "no agent"), ErrNotFound},
+		{"PermissionDenied", status.Error(codes.PermissionDenied, "nope"), ErrPermissionDenied},
+		{"InvalidArgument", status.Error(codes.InvalidArgument, "bad"), ErrInvalidArgument},
+		{"Unavailable", status.Error(codes.Unavailable, "connection refused"), ErrUnavailable},
+		{"DeadlineExceeded", status.Error(codes.DeadlineExceeded, "slow"), ErrDeadlineExceeded},
+		{"Unauthenticated", status.Error(codes.Unauthenticated, "missing token"), ErrUnauthenticated},
+		{"CertificateRevoked", status.Error(codes.Unauthenticated, "certificate revoked"), ErrCertificateRejected},
+		{"HandshakeFailed", status.Error(codes.Unavailable, `connection error: desc = "transport: authentication handshake failed: unauthenticated: certificate rejected: remote error: tls: bad certificate"`), ErrCertificateRejected},
+		{"ServerCertificateUntrusted", status.Error(codes.Unavailable, `connection error: desc = "transport: authentication handshake failed: gate certificate not trusted: tls: failed to verify certificate: x509: certificate signed by unknown authority"`), ErrServerCertificateUntrusted},
+		{"UnmarkedHandshake", status.Error(codes.Unavailable, `connection error: desc = "transport: authentication handshake failed: x509: certificate signed by unknown authority"`), ErrUnavailable},
+		{"ServerPinMismatch", status.Error(codes.Unavailable, `connection error: desc = "transport: authentication handshake failed: gate certificate matches no server pin: the gate's key hashes to sha256/abc="`), ErrServerPinMismatch},
+		{"Internal", status.Error(codes.Internal, "boom"), ErrInternal},
+		{"Canceled", status.Error(codes.Canceled, "canceled"), context.Canceled},
+		{"ContextDeadline", context.DeadlineExceeded, ErrDeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := FromError("GetAgentById", tt.err)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+
+			var gateErr *Error
+			if !errors.As(err, &gateErr) || gateErr.Method != "GetAgentById" {
+				t.Errorf("Expected an *Error for GetAgentById, got %#v", err)
+			}
+		})
+	}
+}
+
+func TestFromError_CertificateRejectedIsUnauthenticated(t *testing.T) {
+	err := FromError("Dial", status.Error(codes.Unauthenticated, "x509: certificate has expired"))
+	if !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("Expected a rejected certificate to be unauthenticated, got %v", err)
+	}
+}
+
+func TestWrapTLSError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"UnknownAuthority", &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}, ErrServerCertificateUntrusted},
+		{"Hostname", x509.HostnameError{Host: "gate.test", Certificate: &x509.Certificate{}}, ErrServerCertificateUntrusted},
+		{"RemoteAlert", &net.OpError{Op: "remote error", Err: tls.AlertError(42)}, ErrCertificateRejected},
+		{"CertificateRequired", tls.AlertError(116), ErrCertificateRejected},
+		{"OtherAlert", tls.AlertError(40), nil},
+		{"EOF", io.EOF, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := WrapTLSError(tt.err)
+
+			if tt.want == nil {
+				if err != tt.err { //nolint:errorlint // identity is the point
+					t.Errorf("Expected %v to be returned unchanged, got %v", tt.err, err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, tt.want) || !errors.Is(err, tt.err) {
+				t.Errorf("Expected %v wrapped in %v, got %v", tt.err, tt.want, err)
+			}
+
+			if got := FromError("Dial", status.Error(codes.Unavailable, err.Error())); !errors.Is(got, tt.want) {
+				t.Errorf("Expected the message of %v to classify as %v, got %v", err, tt.want, got)
+			}
+		})
+	}
+
+	if err := WrapTLSError(nil); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+}
+
+func TestFromError_KeepsStatus(t *testing.T) {
+	err := FromError("Dial", status.Error(codes.NotFound, "no agent"))
+
+	if got := status.Code(err); got != codes.NotFound {
+		t.Errorf("Expected status.Code to still report NotFound, got %v", got)
+	}
+
+	if got, want := err.Error(), "Dial: not found: no agent"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestFromError_FieldViolations(t *testing.T) {
+	st, err := status.New(codes.InvalidArgument, "invalid agent").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "username", Description: "must not be empty"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to build status: %v", err)
+	}
+
+	var gateErr *Error
+	if !errors.As(FromError("UpsertAgent", st.Err()), &gateErr) {
+		t.Fatal("Expected an *Error")
+	}
+
+	want := FieldViolation{Field: "username", Description: "must not be empty"}
+	if len(gateErr.Violations) != 1 || gateErr.Violations[0] != want {
+		t.Errorf("Expected %v, got %v", want, gateErr.Violations)
+	}
+}
+
+func TestFromError_Unchanged(t *testing.T) {
+	local := New(ErrInvalidArgument, "UserID is required")
+
+	for _, err := range []error{nil, io.EOF, errors.New("local"), local, fmt.Errorf("wrapped: %w", local)} {
+		if got := FromError("GetAgentById", err); got != err { //nolint:errorlint // identity is the point
+			t.Errorf("Expected %v to be returned unchanged, got %v", err, got)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/domain"
 	"github.com/tcncloud/sati-go/pkg/ports"
 )
 
@@ -37,19 +38,46 @@ func (p *HostPluginProcess) Stop() {
 }
 
 // DispatchEvents dispatches events to the plugin.
-func (p *HostPluginProcess) DispatchEvents(events []ports.Event) {
+func (p *HostPluginProcess) DispatchEvents(events []ports.Event) error {
 	// Dispatch events to the plugin
 	p.log.Debug().Int("count", len(events)).Msg("Dispatching events to plugin")
 	// Plugin dispatch logic would go here
+	return nil
 }
 
 // DispatchJob dispatches a job to the plugin.
-func (p *HostPluginProcess) DispatchJob(job *ports.Job) {
+func (p *HostPluginProcess) DispatchJob(job *ports.Job) error {
 	// Dispatch job to the plugin
-	p.log.Debug().Str("job_id", job.JobID).Str("type", job.Type).Msg("Dispatching job to plugin")
+	log := domain.LoggerWithTrace(domain.ContextWithJob(context.Background(), job), p.log)
+	log.Debug().Str("job_id", job.JobID).Str("type", job.Type).Msg("Dispatching job to plugin")
 	// Plugin dispatch logic would go here
+	return nil
+}
+
+// PluginEnv returns the environment variables that hand job's trace to an
+// out-of-process plugin: TRACEPARENT and TRACESTATE, which OpenTelemetry SDKs
+// read as the parent context, and SATI_CORRELATION_ID.
+func PluginEnv(job *ports.Job) []string {
+	if job == nil || job.Trace == nil {
+		return nil
+	}
+
+	var env []string
+
+	if job.Trace.TraceParent != "" {
+		env = append(env, "TRACEPARENT="+job.Trace.TraceParent)
+	}
+
+	if job.Trace.TraceState != "" {
+		env = append(env, "TRACESTATE="+job.Trace.TraceState)
+	}
+
+	if job.Trace.CorrelationID != "" {
+		env = append(env, "SATI_CORRELATION_ID="+job.Trace.CorrelationID)
+	}
+
+	return env
 }
 
 // Ensure HostPluginProcess implements ports.HostPluginProcess interface.
 var _ ports.HostPluginProcess = (*HostPluginProcess)(nil)
-
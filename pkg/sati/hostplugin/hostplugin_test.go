@@ -0,0 +1,35 @@
+package hostplugin
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+func TestPluginEnv(t *testing.T) {
+	job := &ports.Job{JobID: "job-1", Trace: &ports.TraceContext{
+		TraceParent:   "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		TraceState:    "vendor=value",
+		CorrelationID: "corr-1",
+	}}
+
+	want := []string{
+		"TRACEPARENT=00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"TRACESTATE=vendor=value",
+		"SATI_CORRELATION_ID=corr-1",
+	}
+
+	if got := PluginEnv(job); !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	job.Trace.TraceState = ""
+	if got := PluginEnv(job); len(got) != 2 || got[1] != "SATI_CORRELATION_ID=corr-1" {
+		t.Errorf("Expected an empty tracestate to be left out, got %v", got)
+	}
+
+	if got := PluginEnv(&ports.Job{JobID: "job-2"}); got != nil {
+		t.Errorf("Expected no variables for a job without a trace, got %v", got)
+	}
+}
@@ -0,0 +1,504 @@
+// Package rotation renews the client certificate before it expires. A Manager
+// watches the certificate in the config file, asks the gate for a new one
+// through RotateCertificate, checks it and rewrites the config file.
+package rotation
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	saticonfig "github.com/tcncloud/sati-go/pkg/sati/config"
+)
+
+// Manager defaults.
+const (
+	// DefaultLeadTime is how long before the certificate expires it is rotated.
+	DefaultLeadTime = 14 * 24 * time.Hour
+	// DefaultWarnBefore is how long before the certificate expires the
+	// manager starts logging warnings.
+	DefaultWarnBefore = 30 * 24 * time.Hour
+	// DefaultCheckInterval is how often the certificate is checked.
+	DefaultCheckInterval = time.Hour
+	// BackupSuffix is appended to the config file path for the copy of the
+	// configuration a rotation replaced.
+	BackupSuffix = ".bak"
+)
+
+// Rotation outcomes reported to ports.Metrics.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Error constants for certificate rotation.
+var (
+	ErrConfigPathRequired   = errors.New("config path is required")
+	ErrClientRequired       = errors.New("client is required")
+	ErrInvalidOptions       = errors.New("lead time, warning window and check interval must not be negative")
+	ErrNoCertificate        = errors.New("no PEM certificate found")
+	ErrEmptyRotation        = errors.New("gate returned no certificate")
+	ErrUnreadableRotation   = errors.New("rotated certificate is neither a configuration nor a PEM certificate")
+	ErrKeyMismatch          = errors.New("rotated certificate does not match the private key")
+	ErrUntrustedCertificate = errors.New("rotated certificate does not chain to the CA certificate")
+	ErrConfigNotObject      = errors.New("config file is not a JSON object")
+)
+
+// Client is the part of saticlient.Client the manager uses.
+type Client interface {
+	RotateCertificate(ctx context.Context, params ports.RotateCertificateParams) (ports.RotateCertificateResult, error)
+	// Reload switches the client to the rotated configuration.
+	Reload(cfg *saticonfig.Config) error
+}
+
+// Options configures a Manager.
+type Options struct {
+	// ConfigPath is the base64 config file holding the certificate.
+	ConfigPath string
+	// LeadTime is how long before expiry the certificate is rotated.
+	// Defaults to DefaultLeadTime.
+	LeadTime time.Duration
+	// WarnBefore is how long before expiry each check logs a warning.
+	// Defaults to DefaultWarnBefore.
+	WarnBefore time.Duration
+	// CheckInterval is how often the certificate is checked. Defaults to
+	// DefaultCheckInterval.
+	CheckInterval time.Duration
+}
+
+// Manager rotates the client certificate before it expires.
+type Manager struct {
+	client  Client
+	opts    Options
+	metrics ports.Metrics
+	log     *zerolog.Logger
+	now     func() time.Time
+
+	// rotating serializes rotations, so a check and a forced Rotate never race.
+	rotating sync.Mutex
+
+	mu     sync.Mutex
+	done   chan struct{}
+	wg     sync.WaitGroup
+	closed bool
+}
+
+// NewManager creates a rotation manager. Start begins the periodic checks.
+func NewManager(client Client, opts Options, metrics ports.Metrics, log *zerolog.Logger) (*Manager, error) {
+	if client == nil {
+		return nil, ErrClientRequired
+	}
+
+	if opts.ConfigPath == "" {
+		return nil, ErrConfigPathRequired
+	}
+
+	if opts.LeadTime < 0 || opts.WarnBefore < 0 || opts.CheckInterval < 0 {
+		return nil, ErrInvalidOptions
+	}
+
+	if opts.LeadTime == 0 {
+		opts.LeadTime = DefaultLeadTime
+	}
+
+	if opts.WarnBefore == 0 {
+		opts.WarnBefore = DefaultWarnBefore
+	}
+
+	if opts.CheckInterval == 0 {
+		opts.CheckInterval = DefaultCheckInterval
+	}
+
+	if metrics == nil {
+		metrics = ports.NopMetrics{}
+	}
+
+	return &Manager{
+		client:  client,
+		opts:    opts,
+		metrics: metrics,
+		log:     log,
+		now:     time.Now,
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// Start checks the certificate now and then every CheckInterval.
+func (m *Manager) Start() {
+	m.wg.Add(1)
+
+	go m.checkLoop()
+}
+
+func (m *Manager) checkLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.opts.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Check(context.Background()); err != nil {
+			m.log.Error().Err(err).Str("path", m.opts.ConfigPath).Msg("Client certificate check failed")
+		}
+
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close stops the periodic checks.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+
+		return nil
+	}
+
+	m.closed = true
+	close(m.done)
+	m.mu.Unlock()
+
+	m.wg.Wait()
+
+	return nil
+}
+
+// Check reports the certificate's expiry, warns when it is close and rotates
+// it once it is within the lead time.
+func (m *Manager) Check(ctx context.Context) error {
+	cfg, err := saticonfig.LoadConfig(m.opts.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	leaf, err := leafCertificate(cfg.Certificate)
+	if err != nil {
+		return err
+	}
+
+	m.metrics.CertificateExpiry(leaf.NotAfter)
+
+	remaining := leaf.NotAfter.Sub(m.now())
+
+	switch {
+	case remaining <= 0:
+		m.log.Error().Time("expires_at", leaf.NotAfter).Msg("Client certificate has expired")
+	case remaining <= m.opts.WarnBefore:
+		m.log.Warn().Time("expires_at", leaf.NotAfter).Dur("remaining", remaining).Msg("Client certificate expires soon")
+	}
+
+	if remaining > m.opts.LeadTime {
+		return nil
+	}
+
+	_, err = m.Rotate(ctx)
+
+	return err
+}
+
+// Rotate asks the gate for a new certificate, checks that it matches the
+// private key and chains to the CA certificate, rewrites the config file and
+// reloads the client. The previous config file is kept with BackupSuffix.
+func (m *Manager) Rotate(ctx context.Context) (*saticonfig.Config, error) {
+	m.rotating.Lock()
+	defer m.rotating.Unlock()
+
+	cfg, err := m.rotate(ctx)
+	if err != nil {
+		m.metrics.CertificateRotated(OutcomeFailure)
+		m.log.Error().Err(err).Str("path", m.opts.ConfigPath).Msg("Client certificate rotation failed")
+
+		return nil, err
+	}
+
+	m.metrics.CertificateRotated(OutcomeSuccess)
+
+	return cfg, nil
+}
+
+func (m *Manager) rotate(ctx context.Context) (*saticonfig.Config, error) {
+	raw, err := os.ReadFile(m.opts.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := decodeFields(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := saticonfig.NewConfigFromString(string(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := CertificateHash(current)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := m.client.RotateCertificate(ctx, ports.RotateCertificateParams{CertificateHash: hash})
+	if err != nil {
+		return nil, fmt.Errorf("rotate certificate: %w", err)
+	}
+
+	updates, err := rotatedFields(current, result)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := Validate(updates["certificate"], updates["private_key"], updates["ca_certificate"])
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range updates {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		fields[key] = encoded
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := []byte(base64.StdEncoding.EncodeToString(data))
+
+	next, err := saticonfig.NewConfigFromString(string(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeConfig(m.opts.ConfigPath, raw, encoded); err != nil {
+		return nil, err
+	}
+
+	m.metrics.CertificateExpiry(leaf.NotAfter)
+	m.log.Info().
+		Str("path", m.opts.ConfigPath).
+		Time("expires_at", leaf.NotAfter).
+		Msg("Client certificate rotated")
+
+	if err := m.client.Reload(next); err != nil {
+		return nil, fmt.Errorf("reload rotated configuration: %w", err)
+	}
+
+	return next, nil
+}
+
+// CertificateHash returns the hash the gate identifies cfg's certificate by:
+// its fingerprint_sha256, or the SHA-256 of the certificate when the
+// configuration has none.
+func CertificateHash(cfg *saticonfig.Config) (string, error) {
+	if cfg.FingerprintSHA256 != "" {
+		return cfg.FingerprintSHA256, nil
+	}
+
+	leaf, err := leafCertificate(cfg.Certificate)
+	if err != nil {
+		return "", err
+	}
+
+	fingerprint, _ := saticonfig.Fingerprints(leaf.Raw)
+
+	return fingerprint, nil
+}
+
+// Validate checks that certificate matches privateKey, chains to
+// caCertificate and is valid now, and returns its leaf.
+func Validate(certificate, privateKey, caCertificate string) (*x509.Certificate, error) {
+	pair, err := tls.X509KeyPair([]byte(certificate), []byte(privateKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyMismatch, err)
+	}
+
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(caCertificate)) {
+		return nil, fmt.Errorf("%w: no CA certificate", ErrUntrustedCertificate)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, der := range pair.Certificate[1:] {
+		if cert, err := x509.ParseCertificate(der); err == nil {
+			intermediates.AddCert(cert)
+		}
+	}
+
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUntrustedCertificate, err)
+	}
+
+	return leaf, nil
+}
+
+// rotatedFields returns the config file fields a rotation changes. The gate
+// answers with either a whole base64 configuration or a certificate for the
+// current key; anything it leaves out is kept from current.
+func rotatedFields(current *saticonfig.Config, result ports.RotateCertificateResult) (map[string]string, error) {
+	encoded := strings.TrimSpace(result.Certificate)
+	if encoded == "" {
+		return nil, ErrEmptyRotation
+	}
+
+	updates := map[string]string{
+		"private_key":    first(result.PrivateKey, current.PrivateKey),
+		"ca_certificate": first(result.CACertificate, current.CACertificate),
+	}
+
+	if rotated, err := saticonfig.NewConfigFromString(encoded); err == nil && rotated.Certificate != "" {
+		updates["certificate"] = rotated.Certificate
+		updates["private_key"] = first(rotated.PrivateKey, updates["private_key"])
+		updates["ca_certificate"] = first(rotated.CACertificate, updates["ca_certificate"])
+
+		for key, value := range map[string]string{
+			"certificate_name":        rotated.CertificateName,
+			"certificate_description": rotated.CertificateDescription,
+		} {
+			if value != "" {
+				updates[key] = value
+			}
+		}
+	} else {
+		certificate := encoded
+		if !strings.Contains(certificate, "-----BEGIN") {
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil || !strings.Contains(string(decoded), "-----BEGIN") {
+				return nil, ErrUnreadableRotation
+			}
+
+			certificate = string(decoded)
+		}
+
+		updates["certificate"] = certificate
+	}
+
+	// The fingerprints are always recomputed, so that they match the new
+	// certificate when the client verifies them on reload.
+	leaf, err := leafCertificate(updates["certificate"])
+	if err != nil {
+		return nil, err
+	}
+
+	updates["fingerprint_sha256"], updates["fingerprint_sha256_string"] = saticonfig.Fingerprints(leaf.Raw)
+
+	return updates, nil
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// leafCertificate parses the first certificate of a PEM chain.
+func leafCertificate(certificate string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(certificate))
+	if block == nil {
+		return nil, ErrNoCertificate
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// decodeFields decodes a base64 config file into its raw JSON fields, so the
+// rewrite keeps fields the Config type does not know.
+func decodeFields(raw []byte) (map[string]json.RawMessage, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", saticonfig.ErrInvalidBase64, err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return nil, fmt.Errorf("%w: %w", saticonfig.ErrInvalidJSON, ErrConfigNotObject)
+	}
+
+	return fields, nil
+}
+
+// writeConfig saves previous with BackupSuffix and then replaces path with
+// data by renaming a synced temporary file over it, so readers see either the
+// old or the new configuration.
+func writeConfig(path string, previous, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	mode := info.Mode().Perm()
+
+	if err := writeFileAtomic(path+BackupSuffix, previous, mode); err != nil {
+		return fmt.Errorf("failed to back up config: %w", err)
+	}
+
+	if err := writeFileAtomic(path, data, mode); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return nil
+}
+
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	tmp := file.Name()
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Chmod(mode)
+	}
+
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+
+	return err
+}
@@ -0,0 +1,380 @@
+package rotation
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tcncloud/sati-go/pkg/ports"
+	saticonfig "github.com/tcncloud/sati-go/pkg/sati/config"
+)
+
+// testCA issues client certificates.
+type testCA struct {
+	t     *testing.T
+	cert  *x509.Certificate
+	key   *ecdsa.PrivateKey
+	pem   string
+	count int64
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key := newKey(t)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "sati-test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %v", err)
+	}
+
+	cert, _ := x509.ParseCertificate(der)
+
+	return &testCA{t: t, cert: cert, key: key, pem: encodeCert(der), count: 1}
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	return key
+}
+
+func encodeCert(der []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func encodeKey(t *testing.T, key *ecdsa.PrivateKey) string {
+	t.Helper()
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+}
+
+// issue returns a PEM client certificate for key that expires after validFor.
+func (ca *testCA) issue(key *ecdsa.PrivateKey, validFor time.Duration) string {
+	ca.t.Helper()
+
+	ca.count++
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(ca.count),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validFor),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		ca.t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	return encodeCert(der)
+}
+
+// writeTestConfig writes a base64 config file with an extra field the Config
+// type does not know.
+func writeTestConfig(t *testing.T, fields map[string]string) string {
+	t.Helper()
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "sati.cfg")
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(data)), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	return path
+}
+
+func readFields(t *testing.T, path string) map[string]string {
+	t.Helper()
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(string(raw))
+	if err != nil {
+		t.Fatalf("Config is not base64: %v", err)
+	}
+
+	var fields map[string]string
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Config is not JSON: %v", err)
+	}
+
+	return fields
+}
+
+type fakeClient struct {
+	result   ports.RotateCertificateResult
+	err      error
+	params   []ports.RotateCertificateParams
+	reloaded []*saticonfig.Config
+}
+
+func (f *fakeClient) RotateCertificate(_ context.Context, params ports.RotateCertificateParams) (ports.RotateCertificateResult, error) {
+	f.params = append(f.params, params)
+
+	return f.result, f.err
+}
+
+func (f *fakeClient) Reload(cfg *saticonfig.Config) error {
+	f.reloaded = append(f.reloaded, cfg)
+
+	return nil
+}
+
+type rotationMetrics struct {
+	ports.NopMetrics
+
+	outcomes []string
+	expiry   time.Time
+}
+
+func (m *rotationMetrics) CertificateRotated(outcome string) {
+	m.outcomes = append(m.outcomes, outcome)
+}
+
+func (m *rotationMetrics) CertificateExpiry(notAfter time.Time) {
+	m.expiry = notAfter
+}
+
+// fixture is a config file with a certificate that expires after validFor.
+type fixture struct {
+	ca      *testCA
+	key     *ecdsa.PrivateKey
+	path    string
+	client  *fakeClient
+	metrics *rotationMetrics
+	logs    *bytes.Buffer
+	manager *Manager
+}
+
+func newFixture(t *testing.T, validFor time.Duration) *fixture {
+	t.Helper()
+
+	ca := newTestCA(t)
+	key := newKey(t)
+
+	f := &fixture{
+		ca:  ca,
+		key: key,
+		path: writeTestConfig(t, map[string]string{
+			"api_endpoint":   "https://gate.example.com",
+			"ca_certificate": ca.pem,
+			"certificate":    ca.issue(key, validFor),
+			"private_key":    encodeKey(t, key),
+			"extra":          "kept",
+		}),
+		client:  &fakeClient{},
+		metrics: &rotationMetrics{},
+		logs:    &bytes.Buffer{},
+	}
+
+	logger := zerolog.New(f.logs)
+
+	manager, err := NewManager(f.client, Options{ConfigPath: f.path}, f.metrics, &logger)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	f.manager = manager
+
+	return f
+}
+
+func TestManager_RotatesCertificateForCurrentKey(t *testing.T) {
+	f := newFixture(t, 24*time.Hour)
+	before, _ := os.ReadFile(f.path)
+
+	renewed := f.ca.issue(f.key, 90*24*time.Hour)
+	f.client.result = ports.RotateCertificateResult{Certificate: base64.StdEncoding.EncodeToString([]byte(renewed))}
+
+	cfg, err := f.manager.Rotate(context.Background())
+	if err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+
+	if len(f.client.params) != 1 || len(f.client.params[0].CertificateHash) != 64 {
+		t.Errorf("Expected the certificate's SHA-256 as hash, got %+v", f.client.params)
+	}
+
+	fields := readFields(t, f.path)
+	if fields["certificate"] != renewed || fields["extra"] != "kept" || fields["api_endpoint"] != "https://gate.example.com" {
+		t.Errorf("Unexpected rewritten config: %v", fields)
+	}
+
+	if !strings.Contains(fields["fingerprint_sha256_string"], ":") {
+		t.Errorf("Expected a recomputed fingerprint, got %q", fields["fingerprint_sha256_string"])
+	}
+
+	if backup, _ := os.ReadFile(f.path + BackupSuffix); !bytes.Equal(backup, before) {
+		t.Error("Expected the previous config file in the backup")
+	}
+
+	if len(f.client.reloaded) != 1 || f.client.reloaded[0].Certificate != cfg.Certificate {
+		t.Errorf("Expected the client to be reloaded with the rotated config")
+	}
+
+	if len(f.metrics.outcomes) != 1 || f.metrics.outcomes[0] != OutcomeSuccess {
+		t.Errorf("Expected a successful rotation metric, got %v", f.metrics.outcomes)
+	}
+
+	if time.Until(f.metrics.expiry) < 89*24*time.Hour {
+		t.Errorf("Expected the rotated certificate's expiry, got %v", f.metrics.expiry)
+	}
+}
+
+func TestManager_RotatesToReturnedConfiguration(t *testing.T) {
+	f := newFixture(t, 24*time.Hour)
+
+	key := newKey(t)
+	returned, _ := json.Marshal(map[string]string{
+		"certificate": f.ca.issue(key, 90*24*time.Hour),
+		"private_key": encodeKey(t, key),
+	})
+	f.client.result = ports.RotateCertificateResult{Certificate: base64.StdEncoding.EncodeToString(returned)}
+
+	if _, err := f.manager.Rotate(context.Background()); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+
+	fields := readFields(t, f.path)
+	if fields["private_key"] != encodeKey(t, key) || fields["ca_certificate"] != f.ca.pem {
+		t.Errorf("Expected the returned key and the current CA certificate, got %v", fields)
+	}
+}
+
+func TestManager_RejectsUnusableCertificates(t *testing.T) {
+	tests := map[string]struct {
+		certificate func(f *fixture) string
+		want        error
+	}{
+		"other key": {
+			certificate: func(f *fixture) string { return f.ca.issue(newKey(t), 90*24*time.Hour) },
+			want:        ErrKeyMismatch,
+		},
+		"other CA": {
+			certificate: func(f *fixture) string { return newTestCA(t).issue(f.key, 90*24*time.Hour) },
+			want:        ErrUntrustedCertificate,
+		},
+		"garbage": {
+			certificate: func(*fixture) string { return "bm90IGEgY2VydGlmaWNhdGU=" },
+			want:        ErrUnreadableRotation,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := newFixture(t, 24*time.Hour)
+			before, _ := os.ReadFile(f.path)
+
+			f.client.result = ports.RotateCertificateResult{Certificate: tt.certificate(f)}
+
+			if _, err := f.manager.Rotate(context.Background()); !errors.Is(err, tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, err)
+			}
+
+			if after, _ := os.ReadFile(f.path); !bytes.Equal(after, before) {
+				t.Error("Expected the config file to be left alone")
+			}
+
+			if _, err := os.Stat(f.path + BackupSuffix); !os.IsNotExist(err) {
+				t.Error("Expected no backup to be written")
+			}
+
+			if len(f.client.reloaded) != 0 {
+				t.Error("Expected the client not to be reloaded")
+			}
+
+			if len(f.metrics.outcomes) != 1 || f.metrics.outcomes[0] != OutcomeFailure {
+				t.Errorf("Expected a failed rotation metric, got %v", f.metrics.outcomes)
+			}
+		})
+	}
+}
+
+func TestManager_CheckRotatesWithinLeadTime(t *testing.T) {
+	f := newFixture(t, 20*24*time.Hour)
+	f.client.err = errors.New("unexpected rotation")
+
+	if err := f.manager.Check(context.Background()); err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+
+	if len(f.client.params) != 0 {
+		t.Fatal("Expected no rotation outside the lead time")
+	}
+
+	if !strings.Contains(f.logs.String(), "Client certificate expires soon") {
+		t.Errorf("Expected an expiry warning, got %s", f.logs.String())
+	}
+
+	f.manager.now = func() time.Time { return time.Now().Add(7 * 24 * time.Hour) }
+	f.client.err = nil
+	f.client.result = ports.RotateCertificateResult{Certificate: f.ca.issue(f.key, 90*24*time.Hour)}
+
+	if err := f.manager.Check(context.Background()); err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+
+	if len(f.client.params) != 1 {
+		t.Error("Expected a rotation within the lead time")
+	}
+}
+
+func TestNewManager_Validates(t *testing.T) {
+	logger := zerolog.Nop()
+
+	if _, err := NewManager(nil, Options{ConfigPath: "sati.cfg"}, nil, &logger); !errors.Is(err, ErrClientRequired) {
+		t.Errorf("Expected ErrClientRequired, got %v", err)
+	}
+
+	if _, err := NewManager(&fakeClient{}, Options{}, nil, &logger); !errors.Is(err, ErrConfigPathRequired) {
+		t.Errorf("Expected ErrConfigPathRequired, got %v", err)
+	}
+
+	if _, err := NewManager(&fakeClient{}, Options{ConfigPath: "sati.cfg", LeadTime: -time.Hour}, nil, &logger); !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("Expected ErrInvalidOptions, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// AvroNamespace is the namespace of every generated Avro record.
+const AvroNamespace = "com.tcncloud.sati"
+
+// avroJSONValueDoc documents fields whose Go type holds arbitrary JSON, which
+// Avro cannot describe.
+const avroJSONValueDoc = "Arbitrary JSON values are carried as JSON-encoded strings."
+
+// avroRecord is an Avro record schema. Fields keep the order of the Go struct.
+type avroRecord struct {
+	Type      string      `json:"type"`
+	Name      string      `json:"name"`
+	Namespace string      `json:"namespace,omitempty"`
+	Doc       string      `json:"doc,omitempty"`
+	Version   string      `json:"sati.schema.version,omitempty"`
+	Fields    []avroField `json:"fields"`
+}
+
+type avroField struct {
+	Name    string          `json:"name"`
+	Type    any             `json:"type"`
+	Doc     string          `json:"doc,omitempty"`
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+// avroGen builds an Avro schema. Each nested struct is defined inline the
+// first time it is used and referenced by name afterwards.
+type avroGen struct {
+	defined map[string]bool
+}
+
+func avroSchemaOf(t Type) (*avroRecord, error) {
+	g := &avroGen{defined: map[string]bool{t.goType.Name(): true}}
+
+	root, err := g.record(t.goType)
+	if err != nil {
+		return nil, err
+	}
+
+	root.Namespace = AvroNamespace
+	root.Doc = t.Doc
+	root.Version = Version
+
+	return root, nil
+}
+
+func (g *avroGen) record(t reflect.Type) (*avroRecord, error) {
+	fields := fieldsOf(t)
+	rec := &avroRecord{Type: "record", Name: t.Name(), Fields: make([]avroField, 0, len(fields))}
+
+	for _, f := range fields {
+		typ, err := g.typeOf(f.goType)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.Name(), f.name, err)
+		}
+
+		af := avroField{Name: f.name, Type: typ}
+
+		// Unions list null first so null is a valid default, which keeps
+		// readers compatible when a nullable field is added.
+		if union, ok := typ.([]any); ok && union[0] == "null" {
+			af.Default = json.RawMessage("null")
+		}
+
+		if holdsJSONValue(f.goType) {
+			af.Doc = avroJSONValueDoc
+		}
+
+		rec.Fields = append(rec.Fields, af)
+	}
+
+	return rec, nil
+}
+
+func (g *avroGen) typeOf(t reflect.Type) (any, error) {
+	if t == timeType {
+		return map[string]any{"type": "long", "logicalType": "timestamp-micros"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Interface:
+		return "string", nil
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return "int", nil
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return "long", nil
+	case reflect.Float32:
+		return "float", nil
+	case reflect.Float64:
+		return "double", nil
+	case reflect.Struct:
+		if g.defined[t.Name()] {
+			return t.Name(), nil
+		}
+
+		g.defined[t.Name()] = true
+
+		return g.record(t)
+	case reflect.Pointer:
+		elem, err := g.typeOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return []any{"null", elem}, nil
+	case reflect.Slice:
+		items, err := g.typeOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return []any{"null", map[string]any{"type": "array", "items": items}}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+		}
+
+		values, err := g.typeOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return []any{"null", map[string]any{"type": "map", "values": values}}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+	}
+}
+
+// holdsJSONValue reports whether t is or contains an interface type.
+func holdsJSONValue(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		return holdsJSONValue(t.Elem())
+	default:
+		return false
+	}
+}
@@ -0,0 +1,137 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+)
+
+const (
+	jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+	jsonSchemaBaseURL = "https://raw.githubusercontent.com/tcncloud/sati-go/main/schemas/"
+)
+
+// jsonSchemaGen builds a JSON Schema document. Nested structs are emitted
+// once under $defs and referenced by their Go type name.
+type jsonSchemaGen struct {
+	defs map[string]any
+}
+
+func jsonSchemaOf(t Type) (map[string]any, error) {
+	g := &jsonSchemaGen{defs: make(map[string]any)}
+
+	root, err := g.object(t.goType)
+	if err != nil {
+		return nil, err
+	}
+
+	root["$schema"] = jsonSchemaDialect
+	root["$id"] = jsonSchemaBaseURL + t.Name + ".schema.json"
+	root["title"] = t.goType.Name()
+	root["description"] = t.Doc
+	root["x-schema-version"] = Version
+
+	if len(g.defs) > 0 {
+		root["$defs"] = g.defs
+	}
+
+	return root, nil
+}
+
+// object describes a struct as encoding/json writes it: every field without
+// omitempty is always present, possibly as null.
+func (g *jsonSchemaGen) object(t reflect.Type) (map[string]any, error) {
+	properties := make(map[string]any)
+	required := []string{}
+
+	for _, f := range fieldsOf(t) {
+		s, err := g.schemaOf(f.goType)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.Name(), f.name, err)
+		}
+
+		properties[f.name] = s
+
+		if !f.optional {
+			required = append(required, f.name)
+		}
+	}
+
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}, nil
+}
+
+func (g *jsonSchemaGen) schemaOf(t reflect.Type) (map[string]any, error) {
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Struct:
+		name := t.Name()
+		if _, ok := g.defs[name]; !ok {
+			// Reserve the name first so recursive types terminate.
+			g.defs[name] = nil
+
+			def, err := g.object(t)
+			if err != nil {
+				return nil, err
+			}
+
+			g.defs[name] = def
+		}
+
+		return map[string]any{"$ref": "#/$defs/" + name}, nil
+	case reflect.Pointer:
+		elem, err := g.schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return nullable(elem), nil
+	case reflect.Slice:
+		items, err := g.schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]any{"type": []string{"array", "null"}, "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+		}
+
+		values, err := g.schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": values}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+	}
+}
+
+// nullable also accepts null, as encoding/json writes for nil pointers.
+func nullable(s map[string]any) map[string]any {
+	if typ, ok := s["type"].(string); ok {
+		s["type"] = []string{typ, "null"}
+
+		return s
+	}
+
+	return map[string]any{"anyOf": []any{s, map[string]any{"type": "null"}}}
+}
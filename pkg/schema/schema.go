@@ -0,0 +1,178 @@
+// Package schema describes the public event and job types of pkg/ports as
+// JSON Schema and Avro for downstream consumers.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// Version is stamped on every record written by the file and webhook sinks.
+// Bump it whenever a change to the published types is not backward
+// compatible, i.e. anything other than adding a field.
+const Version = "1"
+
+// Error constants for schema generation.
+var (
+	ErrUnknownType     = errors.New("unknown schema type")
+	ErrUnknownFormat   = errors.New("unknown schema format")
+	ErrUnsupportedType = errors.New("unsupported Go type")
+)
+
+// Schema formats accepted by Generate.
+const (
+	FormatJSONSchema = "jsonschema"
+	FormatAvro       = "avro"
+)
+
+// Formats lists the schema formats in export order.
+var Formats = []string{FormatJSONSchema, FormatAvro}
+
+// Type is a published event or job type.
+type Type struct {
+	// Name identifies the type on the command line and in file names.
+	Name string
+	// Doc describes the type in the generated schemas.
+	Doc string
+
+	goType reflect.Type
+}
+
+var types = []Type{
+	{
+		Name:   "event",
+		Doc:    "An event polled from the gate. Exactly one of Telephony, AgentCall, AgentResponse and TransferInstance is set.",
+		goType: reflect.TypeFor[ports.Event](),
+	},
+	{
+		Name:   "call_record",
+		Doc:    "Every event received for one call, joined by the call correlator.",
+		goType: reflect.TypeFor[ports.CallRecord](),
+	},
+	{
+		Name:   "agent_kpi",
+		Doc:    "One partner agent's call durations rolled up over a time bucket.",
+		goType: reflect.TypeFor[ports.AgentKPI](),
+	},
+	{
+		Name:   "job",
+		Doc:    "A job streamed from the gate.",
+		goType: reflect.TypeFor[ports.Job](),
+	},
+	{
+		Name:   "job_result",
+		Doc:    "Results submitted for a job.",
+		goType: reflect.TypeFor[ports.SubmitJobResultsParams](),
+	},
+}
+
+// Types returns the published types in export order.
+func Types() []Type {
+	return append([]Type(nil), types...)
+}
+
+// Lookup returns the published type with the given name.
+func Lookup(name string) (Type, error) {
+	for _, t := range types {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+
+	return Type{}, fmt.Errorf("%w: %s", ErrUnknownType, name)
+}
+
+// FileName returns the file a type's schema is published as, e.g.
+// "event.schema.json" or "event.avsc".
+func FileName(typeName, format string) (string, error) {
+	switch format {
+	case FormatJSONSchema:
+		return typeName + ".schema.json", nil
+	case FormatAvro:
+		return typeName + ".avsc", nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// Generate returns the schema of a published type in the given format as
+// indented JSON. The output is deterministic.
+func Generate(typeName, format string) ([]byte, error) {
+	t, err := Lookup(typeName)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc any
+
+	switch format {
+	case FormatJSONSchema:
+		doc, err = jsonSchemaOf(t)
+	case FormatAvro:
+		doc, err = avroSchemaOf(t)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// field is one struct field as encoding/json writes it.
+type field struct {
+	name     string
+	goType   reflect.Type
+	optional bool
+}
+
+// fieldsOf lists the fields encoding/json writes for a struct type, honoring
+// json tags. Fields tagged omitempty may be absent.
+func fieldsOf(t reflect.Type) []field {
+	fields := make([]field, 0, t.NumField())
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		fields = append(fields, field{
+			name:     name,
+			goType:   f.Type,
+			optional: strings.Contains(","+opts+",", ",omitempty,"),
+		})
+	}
+
+	return fields
+}
+
+var timeType = reflect.TypeFor[time.Time]()
@@ -0,0 +1,153 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/tcncloud/sati-go/pkg/ports"
+)
+
+// publishedDir holds the schemas consumers download, relative to this package.
+const publishedDir = "../../schemas"
+
+var update = flag.Bool("update", false, "rewrite the published schemas from the Go types")
+
+func TestPublishedSchemasMatchTypes(t *testing.T) {
+	for _, typ := range Types() {
+		for _, format := range Formats {
+			name, err := FileName(typ.Name, format)
+			if err != nil {
+				t.Fatalf("FileName failed: %v", err)
+			}
+
+			generated, err := Generate(typ.Name, format)
+			if err != nil {
+				t.Fatalf("Generate(%s, %s) failed: %v", typ.Name, format, err)
+			}
+
+			path := filepath.Join(publishedDir, name)
+
+			if *update {
+				if err := os.WriteFile(path, generated, 0o644); err != nil { //nolint:gosec // Published schemas are world-readable
+					t.Fatalf("WriteFile failed: %v", err)
+				}
+
+				continue
+			}
+
+			published, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read published schema: %v", err)
+			}
+
+			if !bytes.Equal(published, generated) {
+				t.Errorf("schemas/%s no longer matches pkg/ports: run `go test ./pkg/schema -update`, "+
+					"and bump schema.Version unless the change only adds fields", name)
+			}
+		}
+	}
+}
+
+func TestJSONSchema_PropertiesMatchEncoding(t *testing.T) {
+	data, err := json.Marshal(ports.Event{Type: ports.EventTypeAgentCall, AgentCall: &ports.ExileAgentCall{}})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var encoded map[string]any
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	generated, err := Generate("event", FormatJSONSchema)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	var doc struct {
+		Properties map[string]any            `json:"properties"`
+		Required   []string                  `json:"required"`
+		Defs       map[string]map[string]any `json:"$defs"`
+	}
+	if err := json.Unmarshal(generated, &doc); err != nil {
+		t.Fatalf("Failed to parse generated schema: %v", err)
+	}
+
+	keys := make([]string, 0, len(encoded))
+	for key := range encoded {
+		keys = append(keys, key)
+	}
+
+	required := append([]string(nil), doc.Required...)
+
+	sort.Strings(keys)
+	sort.Strings(required)
+
+	if len(keys) != len(required) {
+		t.Fatalf("Expected required properties %v, got %v", keys, required)
+	}
+
+	for i := range keys {
+		if keys[i] != required[i] {
+			t.Fatalf("Expected required properties %v, got %v", keys, required)
+		}
+	}
+
+	if _, ok := doc.Defs["ExileTransferInstance"]; !ok {
+		t.Errorf("Expected ExileTransferInstance in $defs, got %v", doc.Defs)
+	}
+}
+
+func TestAvro_NullableFieldsDefaultToNull(t *testing.T) {
+	generated, err := Generate("event", FormatAvro)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	var rec struct {
+		Name    string `json:"name"`
+		Version string `json:"sati.schema.version"`
+		Fields  []struct {
+			Name    string          `json:"name"`
+			Type    json.RawMessage `json:"type"`
+			Default json.RawMessage `json:"default"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(generated, &rec); err != nil {
+		t.Fatalf("Failed to parse generated schema: %v", err)
+	}
+
+	if rec.Name != "Event" || rec.Version != Version {
+		t.Errorf("Expected record Event at version %s, got %s at %s", Version, rec.Name, rec.Version)
+	}
+
+	if len(rec.Fields) != 5 {
+		t.Fatalf("Expected 5 fields, got %d", len(rec.Fields))
+	}
+
+	if rec.Fields[0].Name != "Type" || rec.Fields[0].Default != nil {
+		t.Errorf("Expected Type without a default, got %+v", rec.Fields[0])
+	}
+
+	for _, f := range rec.Fields[1:] {
+		if string(f.Default) != "null" {
+			t.Errorf("Expected %s to default to null, got %s", f.Name, f.Default)
+		}
+	}
+}
+
+func TestGenerate_UnknownTypeAndFormat(t *testing.T) {
+	if _, err := Generate("nope", FormatAvro); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("Expected ErrUnknownType, got %v", err)
+	}
+
+	if _, err := Generate("event", "protobuf"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}